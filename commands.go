@@ -0,0 +1,162 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/gcamlicali/tradeshopExample/internal/auth"
+	"github.com/gcamlicali/tradeshopExample/internal/cart"
+	"github.com/gcamlicali/tradeshopExample/pkg/config"
+	db "github.com/gcamlicali/tradeshopExample/pkg/database"
+	logger "github.com/gcamlicali/tradeshopExample/pkg/logging"
+	"github.com/gcamlicali/tradeshopExample/pkg/migration"
+	"github.com/gcamlicali/tradeshopExample/pkg/prompt"
+	"gorm.io/gorm"
+)
+
+const usage = `usage:
+  shop                      start the server
+  shop admin create --email <mail> [--first-name <name>] [--last-name <name>]
+                            create a super-admin or rotate the password of an existing one,
+                            the password is read from SHOP_ADMIN_PASSWORD or asked on the terminal
+  shop migrate up           apply pending schema migrations
+  shop migrate down [n]     revert the last n migrations, 1 by default
+  shop migrate status       print the schema version`
+
+// runCommand runs a maintenance command instead of the server
+func runCommand(args []string) error {
+	if len(args) >= 2 && args[0] == "admin" && args[1] == "create" {
+		return adminCreate(args[2:])
+	}
+	if len(args) >= 2 && args[0] == "migrate" {
+		return migrateCommand(args[1], args[2:])
+	}
+	return fmt.Errorf("unknown command %q\n%s", args, usage)
+}
+
+func adminCreate(args []string) error {
+	flags := flag.NewFlagSet("admin create", flag.ContinueOnError)
+	email := flags.String("email", os.Getenv("SHOP_ADMIN_EMAIL"), "admin email, defaults to SHOP_ADMIN_EMAIL")
+	firstName := flags.String("first-name", envOr("SHOP_ADMIN_FIRST_NAME", "Admin"), "first name of a new admin")
+	lastName := flags.String("last-name", envOr("SHOP_ADMIN_LAST_NAME", "Admin"), "last name of a new admin")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *email == "" {
+		return errors.New("--email or SHOP_ADMIN_EMAIL is required")
+	}
+
+	password := os.Getenv("SHOP_ADMIN_PASSWORD")
+	if password == "" {
+		var err error
+		if password, err = prompt.NewPassword("Admin password"); err != nil {
+			return err
+		}
+	}
+
+	cfg, err := config.LoadConfig("./pkg/config/config-local")
+	if err != nil {
+		return err
+	}
+	logger.NewLogger(cfg)
+	defer logger.Close()
+	DB := db.Connect(cfg)
+	if err := checkSchema(DB, cfg); err != nil {
+		return err
+	}
+
+	authRepo := auth.NewAuthRepository(DB)
+	refreshTokenRepo := auth.NewRefreshTokenRepository(DB)
+	cartRepo := cart.NewCartRepository(DB)
+
+	created, err := auth.NewAdminProvisioner(authRepo, refreshTokenRepo, cartRepo).Provision(auth.AdminAccount{
+		Email:     *email,
+		FirstName: *firstName,
+		LastName:  *lastName,
+		Password:  password,
+	})
+	if err != nil {
+		return err
+	}
+
+	if created {
+		fmt.Printf("Admin %s created\n", *email)
+	} else {
+		fmt.Printf("Admin %s password rotated, the account is signed out everywhere\n", *email)
+	}
+	return nil
+}
+
+func migrateCommand(action string, args []string) error {
+	steps := 1
+	switch action {
+	case "up", "status":
+		if len(args) > 0 {
+			return fmt.Errorf("migrate %s takes no arguments\n%s", action, usage)
+		}
+	case "down":
+		if len(args) > 1 {
+			return fmt.Errorf("migrate down takes at most one argument\n%s", usage)
+		}
+		if len(args) == 1 {
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n < 1 {
+				return fmt.Errorf("migrate down: %q is not a positive number", args[0])
+			}
+			steps = n
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", action, usage)
+	}
+
+	cfg, err := config.LoadConfig("./pkg/config/config-local")
+	if err != nil {
+		return err
+	}
+	logger.NewLogger(cfg)
+	defer logger.Close()
+	DB := db.Connect(cfg)
+
+	migrator, err := migration.New(DB, cfg.DBConfig.MigrationFolder)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	switch action {
+	case "up":
+		err = migrator.Up()
+	case "down":
+		err = migrator.Down(steps)
+	}
+	if err != nil {
+		return err
+	}
+
+	status, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Schema %s\n", status)
+	return nil
+}
+
+// checkSchema fails unless the database is migrated to the version this binary expects
+func checkSchema(DB *gorm.DB, cfg *config.Config) error {
+	migrator, err := migration.New(DB, cfg.DBConfig.MigrationFolder)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+	return migrator.Check()
+}
+
+func envOr(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,121 @@
+package address
+
+import (
+	"net/http"
+
+	"github.com/gcamlicali/tradeshopExample/internal/api"
+	httpErr "github.com/gcamlicali/tradeshopExample/internal/httpErrors"
+	"github.com/gin-gonic/gin"
+	"github.com/go-openapi/strfmt"
+	"github.com/google/uuid"
+)
+
+type addressHandler struct {
+	service Service
+}
+
+func NewAddressHandler(r *gin.RouterGroup, service Service, authMW gin.HandlerFunc) {
+	h := &addressHandler{service: service}
+
+	addressRoute := r.Group("/addresses")
+	addressRoute.Use(authMW)
+	addressRoute.GET("", h.list)
+	addressRoute.POST("", h.create)
+	addressRoute.GET("/:id", h.get)
+	addressRoute.PUT("/:id", h.update)
+	addressRoute.DELETE("/:id", h.delete)
+}
+
+func (h *addressHandler) list(c *gin.Context) {
+	userID := c.MustGet("userId").(uuid.UUID)
+
+	addresses, err := h.service.List(userID)
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, addressesToResponse(*addresses))
+}
+
+func (h *addressHandler) get(c *gin.Context) {
+	userID := c.MustGet("userId").(uuid.UUID)
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "Address ID is not valid", err.Error())))
+		return
+	}
+
+	address, err := h.service.Get(userID, id)
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, addressToResponse(address))
+}
+
+func (h *addressHandler) create(c *gin.Context) {
+	userID := c.MustGet("userId").(uuid.UUID)
+
+	req := api.Address{}
+	if err := c.Bind(&req); err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "check your request body", err.Error())))
+		return
+	}
+	if err := req.Validate(strfmt.NewFormats()); err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	address, err := h.service.Create(userID, req)
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, addressToResponse(address))
+}
+
+func (h *addressHandler) update(c *gin.Context) {
+	userID := c.MustGet("userId").(uuid.UUID)
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "Address ID is not valid", err.Error())))
+		return
+	}
+
+	req := api.Address{}
+	if err := c.Bind(&req); err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "check your request body", err.Error())))
+		return
+	}
+	if err := req.Validate(strfmt.NewFormats()); err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	address, err := h.service.Update(userID, id, req)
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, addressToResponse(address))
+}
+
+func (h *addressHandler) delete(c *gin.Context) {
+	userID := c.MustGet("userId").(uuid.UUID)
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "Address ID is not valid", err.Error())))
+		return
+	}
+
+	if err := h.service.Delete(userID, id); err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, "Address deleted")
+}
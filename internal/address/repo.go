@@ -0,0 +1,98 @@
+package address
+
+import (
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type AddressRepositoy struct {
+	db *gorm.DB
+}
+
+type IAddressRepository interface {
+	Create(a *models.Address) (*models.Address, error)
+	GetByID(userID uuid.UUID, id uuid.UUID) (*models.Address, error)
+	GetByUserID(userID uuid.UUID) (*[]models.Address, error)
+	GetDefault(userID uuid.UUID) (*models.Address, error)
+	Update(a *models.Address) (*models.Address, error)
+	Delete(userID uuid.UUID, id uuid.UUID) error
+	ClearDefault(userID uuid.UUID, exceptID uuid.UUID) error
+}
+
+func NewAddressRepository(db *gorm.DB) *AddressRepositoy {
+	return &AddressRepositoy{db: db}
+}
+
+func (r *AddressRepositoy) Create(a *models.Address) (*models.Address, error) {
+	zap.L().Debug("address.repo.create", zap.Reflect("address", a))
+	if err := r.db.Create(a).Error; err != nil {
+		zap.L().Error("address.repo.Create failed to create address", zap.Error(err))
+		return nil, err
+	}
+	return a, nil
+}
+
+// GetByID returns the address only when it belongs to the user
+func (r *AddressRepositoy) GetByID(userID uuid.UUID, id uuid.UUID) (*models.Address, error) {
+	zap.L().Debug("address.repo.getByID", zap.Reflect("userID", userID), zap.Reflect("id", id))
+
+	var address models.Address
+	if err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&address).Error; err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+// GetByUserID returns the address book of the user, the default address first
+func (r *AddressRepositoy) GetByUserID(userID uuid.UUID) (*[]models.Address, error) {
+	zap.L().Debug("address.repo.getByUserID", zap.Reflect("userID", userID))
+
+	var addresses = []models.Address{}
+	if err := r.db.Where("user_id = ?", userID).Order("is_default DESC, created_at").Find(&addresses).Error; err != nil {
+		return nil, err
+	}
+	return &addresses, nil
+}
+
+func (r *AddressRepositoy) GetDefault(userID uuid.UUID) (*models.Address, error) {
+	zap.L().Debug("address.repo.getDefault", zap.Reflect("userID", userID))
+
+	var address models.Address
+	if err := r.db.Where("user_id = ? AND is_default", userID).First(&address).Error; err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+func (r *AddressRepositoy) Update(a *models.Address) (*models.Address, error) {
+	zap.L().Debug("address.repo.update", zap.Reflect("address", a))
+
+	if err := r.db.Save(a).Error; err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (r *AddressRepositoy) Delete(userID uuid.UUID, id uuid.UUID) error {
+	zap.L().Debug("address.repo.delete", zap.Reflect("userID", userID), zap.Reflect("id", id))
+
+	result := r.db.Delete(&models.Address{}, "id = ? AND user_id = ?", id, userID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ClearDefault unsets the default flag of every address of the user but exceptID
+func (r *AddressRepositoy) ClearDefault(userID uuid.UUID, exceptID uuid.UUID) error {
+	zap.L().Debug("address.repo.clearDefault", zap.Reflect("userID", userID), zap.Reflect("exceptID", exceptID))
+
+	return r.db.Model(&models.Address{}).
+		Where("user_id = ? AND id <> ? AND is_default", userID, exceptID).
+		Update("is_default", false).Error
+}
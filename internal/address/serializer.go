@@ -0,0 +1,36 @@
+package address
+
+import (
+	"github.com/gcamlicali/tradeshopExample/internal/api"
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+)
+
+func addressToResponse(m *models.Address) *api.Address {
+	address := PostalAddressToResponse(m.PostalAddress)
+	address.ID = m.ID.String()
+	address.Title = m.Title
+	address.IsDefault = m.IsDefault
+	return address
+}
+
+func addressesToResponse(ms []models.Address) []*api.Address {
+	addresses := make([]*api.Address, 0, len(ms))
+	for i := range ms {
+		addresses = append(addresses, addressToResponse(&ms[i]))
+	}
+	return addresses
+}
+
+// PostalAddressToResponse is shared by order responses, the copy on an order has no ID
+func PostalAddressToResponse(m models.PostalAddress) *api.Address {
+	return &api.Address{
+		FullName:   &m.FullName,
+		Phone:      &m.Phone,
+		Line1:      &m.Line1,
+		Line2:      m.Line2,
+		District:   m.District,
+		City:       &m.City,
+		PostalCode: m.PostalCode,
+		Country:    m.Country,
+	}
+}
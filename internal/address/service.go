@@ -0,0 +1,164 @@
+package address
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gcamlicali/tradeshopExample/internal/api"
+	httpErr "github.com/gcamlicali/tradeshopExample/internal/httpErrors"
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DefaultCountry is used when an address comes without a country
+const DefaultCountry = "TR"
+
+type addressService struct {
+	repo IAddressRepository
+}
+
+type Service interface {
+	List(userID uuid.UUID) (*[]models.Address, error)
+	Get(userID uuid.UUID, id uuid.UUID) (*models.Address, error)
+	Create(userID uuid.UUID, req api.Address) (*models.Address, error)
+	Update(userID uuid.UUID, id uuid.UUID, req api.Address) (*models.Address, error)
+	Delete(userID uuid.UUID, id uuid.UUID) error
+}
+
+func NewAddressService(repo IAddressRepository) Service {
+	return &addressService{repo: repo}
+}
+
+func (s *addressService) List(userID uuid.UUID) (*[]models.Address, error) {
+	addresses, err := s.repo.GetByUserID(userID)
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Get addresses error", err.Error())
+	}
+	return addresses, nil
+}
+
+func (s *addressService) Get(userID uuid.UUID, id uuid.UUID) (*models.Address, error) {
+	address, err := s.repo.GetByID(userID, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, httpErr.NewRestError(http.StatusNotFound, "Address not found", id.String())
+	}
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Get address error", err.Error())
+	}
+	return address, nil
+}
+
+// Create adds an address to the book, the first address of a user becomes the default one
+func (s *addressService) Create(userID uuid.UUID, req api.Address) (*models.Address, error) {
+	address := fromRequest(req)
+	address.UserID = userID
+
+	if !address.IsDefault {
+		_, err := s.repo.GetDefault(userID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			address.IsDefault = true
+		} else if err != nil {
+			return nil, httpErr.NewRestError(http.StatusInternalServerError, "Get default address error", err.Error())
+		}
+	}
+
+	address, err := s.repo.Create(address)
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Address create error", err.Error())
+	}
+	if err := s.keepSingleDefault(address); err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+// Update replaces every field of the address, orders keep the copy they were shipped to.
+// The default address stays default until another address is made the default.
+func (s *addressService) Update(userID uuid.UUID, id uuid.UUID, req api.Address) (*models.Address, error) {
+	current, err := s.Get(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	address := fromRequest(req)
+	address.ID = current.ID
+	address.CreatedAt = current.CreatedAt
+	address.UserID = current.UserID
+	address.IsDefault = address.IsDefault || current.IsDefault
+
+	address, err = s.repo.Update(address)
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Address update error", err.Error())
+	}
+	if err := s.keepSingleDefault(address); err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+// Delete removes an address, the oldest one left becomes the default when the default is deleted
+func (s *addressService) Delete(userID uuid.UUID, id uuid.UUID) error {
+	address, err := s.Get(userID, id)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.Delete(userID, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return httpErr.NewRestError(http.StatusNotFound, "Address not found", id.String())
+	}
+	if err != nil {
+		return httpErr.NewRestError(http.StatusInternalServerError, "Address delete error", err.Error())
+	}
+	if !address.IsDefault {
+		return nil
+	}
+
+	left, err := s.repo.GetByUserID(userID)
+	if err != nil {
+		return httpErr.NewRestError(http.StatusInternalServerError, "Get addresses error", err.Error())
+	}
+	if len(*left) == 0 {
+		return nil
+	}
+	next := (*left)[0]
+	next.IsDefault = true
+	if _, err := s.repo.Update(&next); err != nil {
+		return httpErr.NewRestError(http.StatusInternalServerError, "Address update error", err.Error())
+	}
+	return nil
+}
+
+// keepSingleDefault takes the default flag from the other addresses when address is the default
+func (s *addressService) keepSingleDefault(address *models.Address) error {
+	if !address.IsDefault {
+		return nil
+	}
+	if err := s.repo.ClearDefault(address.UserID, address.ID); err != nil {
+		return httpErr.NewRestError(http.StatusInternalServerError, "Default address update error", err.Error())
+	}
+	return nil
+}
+
+// fromRequest trims the fields of a validated request
+func fromRequest(req api.Address) *models.Address {
+	address := &models.Address{
+		Title: strings.TrimSpace(req.Title),
+		PostalAddress: models.PostalAddress{
+			FullName:   strings.TrimSpace(*req.FullName),
+			Phone:      strings.TrimSpace(*req.Phone),
+			Line1:      strings.TrimSpace(*req.Line1),
+			Line2:      strings.TrimSpace(req.Line2),
+			District:   strings.TrimSpace(req.District),
+			City:       strings.TrimSpace(*req.City),
+			PostalCode: strings.TrimSpace(req.PostalCode),
+			Country:    strings.ToUpper(strings.TrimSpace(req.Country)),
+		},
+		IsDefault: req.IsDefault,
+	}
+	if address.Country == "" {
+		address.Country = DefaultCountry
+	}
+	return address
+}
@@ -0,0 +1,210 @@
+package address
+
+import (
+	"github.com/gcamlicali/tradeshopExample/internal/api"
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"testing"
+	"time"
+)
+
+var (
+	userID      = uuid.New()
+	otherUserID = uuid.New()
+	currentTime = time.Now()
+
+	home = models.Address{
+		ID:            uuid.New(),
+		CreatedAt:     currentTime.Add(-time.Hour),
+		UserID:        userID,
+		Title:         "Home",
+		PostalAddress: models.PostalAddress{FullName: "Ayse Yilmaz", Phone: "+905551112233", Line1: "Ataturk Cad. No:1", City: "Istanbul", Country: "TR"},
+		IsDefault:     true,
+	}
+	work = models.Address{
+		ID:            uuid.New(),
+		CreatedAt:     currentTime,
+		UserID:        userID,
+		Title:         "Work",
+		PostalAddress: models.PostalAddress{FullName: "Ayse Yilmaz", Phone: "+905551112233", Line1: "Kizilay Mah. No:5", City: "Ankara", Country: "TR"},
+	}
+)
+
+func addressRequest(title string, isDefault bool) api.Address {
+	fullName, phone, line1, city := " Ayse Yilmaz ", "+905551112233", "Cumhuriyet Cad. No:10", "Izmir"
+	return api.Address{Title: title, FullName: &fullName, Phone: &phone, Line1: &line1, City: &city, IsDefault: isDefault}
+}
+
+func Test_addressService_Create(t *testing.T) {
+	tests := []struct {
+		name        string
+		items       []models.Address
+		isDefault   bool
+		wantDefault []string
+	}{
+		{name: "addressService_Create_FirstAddressIsDefault_ShouldSuccess", items: nil, wantDefault: []string{"Summer"}},
+		{name: "addressService_Create_KeepsDefault_ShouldSuccess", items: []models.Address{home, work}, wantDefault: []string{"Home"}},
+		{name: "addressService_Create_NewDefault_ShouldSuccess", items: []models.Address{home, work}, isDefault: true, wantDefault: []string{"Summer"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &addressMockRepo{Items: append([]models.Address{}, tt.items...)}
+			s := &addressService{repo: repo}
+
+			got, err := s.Create(userID, addressRequest("Summer", tt.isDefault))
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			if got.FullName != "Ayse Yilmaz" || got.Country != DefaultCountry {
+				t.Errorf("Create() address = %+v", got.PostalAddress)
+			}
+			if defaults := repo.defaults(userID); !equal(defaults, tt.wantDefault) {
+				t.Errorf("Create() default addresses = %v, want %v", defaults, tt.wantDefault)
+			}
+		})
+	}
+}
+
+func Test_addressService_Update(t *testing.T) {
+	tests := []struct {
+		name        string
+		userID      uuid.UUID
+		id          uuid.UUID
+		isDefault   bool
+		wantDefault []string
+		wantErr     bool
+	}{
+		{name: "addressService_Update_DefaultStaysDefault_ShouldSuccess", userID: userID, id: home.ID, wantDefault: []string{"Summer"}},
+		{name: "addressService_Update_NewDefault_ShouldSuccess", userID: userID, id: work.ID, isDefault: true, wantDefault: []string{"Summer"}},
+		{name: "addressService_Update_ErrorOtherUser_ShouldFail", userID: otherUserID, id: home.ID, wantDefault: []string{"Home"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &addressMockRepo{Items: []models.Address{home, work}}
+			s := &addressService{repo: repo}
+
+			_, err := s.Update(tt.userID, tt.id, addressRequest("Summer", tt.isDefault))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Update() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if defaults := repo.defaults(userID); !equal(defaults, tt.wantDefault) {
+				t.Errorf("Update() default addresses = %v, want %v", defaults, tt.wantDefault)
+			}
+		})
+	}
+}
+
+func Test_addressService_Delete(t *testing.T) {
+	tests := []struct {
+		name        string
+		userID      uuid.UUID
+		id          uuid.UUID
+		wantDefault []string
+		wantErr     bool
+	}{
+		{name: "addressService_Delete_Default_ShouldSuccess", userID: userID, id: home.ID, wantDefault: []string{"Work"}},
+		{name: "addressService_Delete_Other_ShouldSuccess", userID: userID, id: work.ID, wantDefault: []string{"Home"}},
+		{name: "addressService_Delete_ErrorOtherUser_ShouldFail", userID: otherUserID, id: home.ID, wantDefault: []string{"Home"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &addressMockRepo{Items: []models.Address{home, work}}
+			s := &addressService{repo: repo}
+
+			if err := s.Delete(tt.userID, tt.id); (err != nil) != tt.wantErr {
+				t.Fatalf("Delete() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if defaults := repo.defaults(userID); !equal(defaults, tt.wantDefault) {
+				t.Errorf("Delete() default addresses = %v, want %v", defaults, tt.wantDefault)
+			}
+		})
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+type addressMockRepo struct {
+	Items []models.Address
+}
+
+// defaults returns the titles of the default addresses of the user
+func (r *addressMockRepo) defaults(userID uuid.UUID) []string {
+	titles := []string{}
+	for _, item := range r.Items {
+		if item.UserID == userID && item.IsDefault {
+			titles = append(titles, item.Title)
+		}
+	}
+	return titles
+}
+
+func (r *addressMockRepo) Create(a *models.Address) (*models.Address, error) {
+	a.ID = uuid.New()
+	a.CreatedAt = time.Now()
+	r.Items = append(r.Items, *a)
+	return a, nil
+}
+func (r *addressMockRepo) GetByID(userID uuid.UUID, id uuid.UUID) (*models.Address, error) {
+	for _, item := range r.Items {
+		if item.ID == id && item.UserID == userID {
+			address := item
+			return &address, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (r *addressMockRepo) GetByUserID(userID uuid.UUID) (*[]models.Address, error) {
+	addresses := []models.Address{}
+	for _, item := range r.Items {
+		if item.UserID == userID {
+			addresses = append(addresses, item)
+		}
+	}
+	return &addresses, nil
+}
+func (r *addressMockRepo) GetDefault(userID uuid.UUID) (*models.Address, error) {
+	for _, item := range r.Items {
+		if item.UserID == userID && item.IsDefault {
+			address := item
+			return &address, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (r *addressMockRepo) Update(a *models.Address) (*models.Address, error) {
+	for i, item := range r.Items {
+		if item.ID == a.ID {
+			r.Items[i] = *a
+			return a, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (r *addressMockRepo) Delete(userID uuid.UUID, id uuid.UUID) error {
+	for i, item := range r.Items {
+		if item.ID == id && item.UserID == userID {
+			r.Items = append(r.Items[:i], r.Items[i+1:]...)
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+func (r *addressMockRepo) ClearDefault(userID uuid.UUID, exceptID uuid.UUID) error {
+	for i, item := range r.Items {
+		if item.UserID == userID && item.ID != exceptID {
+			r.Items[i].IsDefault = false
+		}
+	}
+	return nil
+}
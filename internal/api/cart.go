@@ -22,11 +22,26 @@ type Cart struct {
 	// cart items
 	CartItems []*CartItem `json:"cart_items"`
 
+	// discount total
+	DiscountTotal *Money `json:"discount_total,omitempty"`
+
+	// discounts
+	Discounts []*Discount `json:"discounts"`
+
 	// id
 	ID string `json:"id,omitempty"`
 
+	// subtotal
+	Subtotal *Money `json:"subtotal,omitempty"`
+
+	// tax lines
+	TaxLines []*TaxLine `json:"tax_lines"`
+
+	// tax total
+	TaxTotal *Money `json:"tax_total,omitempty"`
+
 	// total price
-	TotalPrice int32 `json:"totalPrice,omitempty"`
+	TotalPrice *Money `json:"totalPrice,omitempty"`
 }
 
 // Validate validates this cart
@@ -37,6 +52,30 @@ func (m *Cart) Validate(formats strfmt.Registry) error {
 		res = append(res, err)
 	}
 
+	if err := m.validateDiscountTotal(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateDiscounts(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateSubtotal(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateTaxLines(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateTaxTotal(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateTotalPrice(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if len(res) > 0 {
 		return errors.CompositeValidationError(res...)
 	}
@@ -69,6 +108,134 @@ func (m *Cart) validateCartItems(formats strfmt.Registry) error {
 	return nil
 }
 
+func (m *Cart) validateDiscountTotal(formats strfmt.Registry) error {
+	if swag.IsZero(m.DiscountTotal) { // not required
+		return nil
+	}
+
+	if m.DiscountTotal != nil {
+		if err := m.DiscountTotal.Validate(formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("discount_total")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("discount_total")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Cart) validateDiscounts(formats strfmt.Registry) error {
+	if swag.IsZero(m.Discounts) { // not required
+		return nil
+	}
+
+	for i := 0; i < len(m.Discounts); i++ {
+		if swag.IsZero(m.Discounts[i]) { // not required
+			continue
+		}
+
+		if m.Discounts[i] != nil {
+			if err := m.Discounts[i].Validate(formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("discounts" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("discounts" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+func (m *Cart) validateSubtotal(formats strfmt.Registry) error {
+	if swag.IsZero(m.Subtotal) { // not required
+		return nil
+	}
+
+	if m.Subtotal != nil {
+		if err := m.Subtotal.Validate(formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("subtotal")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("subtotal")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Cart) validateTaxLines(formats strfmt.Registry) error {
+	if swag.IsZero(m.TaxLines) { // not required
+		return nil
+	}
+
+	for i := 0; i < len(m.TaxLines); i++ {
+		if swag.IsZero(m.TaxLines[i]) { // not required
+			continue
+		}
+
+		if m.TaxLines[i] != nil {
+			if err := m.TaxLines[i].Validate(formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("tax_lines" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("tax_lines" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+func (m *Cart) validateTaxTotal(formats strfmt.Registry) error {
+	if swag.IsZero(m.TaxTotal) { // not required
+		return nil
+	}
+
+	if m.TaxTotal != nil {
+		if err := m.TaxTotal.Validate(formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("tax_total")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("tax_total")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Cart) validateTotalPrice(formats strfmt.Registry) error {
+	if swag.IsZero(m.TotalPrice) { // not required
+		return nil
+	}
+
+	if m.TotalPrice != nil {
+		if err := m.TotalPrice.Validate(formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("totalPrice")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("totalPrice")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
 // ContextValidate validate this cart based on the context it is used
 func (m *Cart) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
 	var res []error
@@ -77,6 +244,30 @@ func (m *Cart) ContextValidate(ctx context.Context, formats strfmt.Registry) err
 		res = append(res, err)
 	}
 
+	if err := m.contextValidateDiscountTotal(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.contextValidateDiscounts(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.contextValidateSubtotal(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.contextValidateTaxLines(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.contextValidateTaxTotal(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.contextValidateTotalPrice(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
 	if len(res) > 0 {
 		return errors.CompositeValidationError(res...)
 	}
@@ -103,6 +294,110 @@ func (m *Cart) contextValidateCartItems(ctx context.Context, formats strfmt.Regi
 	return nil
 }
 
+func (m *Cart) contextValidateDiscountTotal(ctx context.Context, formats strfmt.Registry) error {
+
+	if m.DiscountTotal != nil {
+		if err := m.DiscountTotal.ContextValidate(ctx, formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("discount_total")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("discount_total")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Cart) contextValidateDiscounts(ctx context.Context, formats strfmt.Registry) error {
+
+	for i := 0; i < len(m.Discounts); i++ {
+
+		if m.Discounts[i] != nil {
+			if err := m.Discounts[i].ContextValidate(ctx, formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("discounts" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("discounts" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+func (m *Cart) contextValidateSubtotal(ctx context.Context, formats strfmt.Registry) error {
+
+	if m.Subtotal != nil {
+		if err := m.Subtotal.ContextValidate(ctx, formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("subtotal")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("subtotal")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Cart) contextValidateTaxLines(ctx context.Context, formats strfmt.Registry) error {
+
+	for i := 0; i < len(m.TaxLines); i++ {
+
+		if m.TaxLines[i] != nil {
+			if err := m.TaxLines[i].ContextValidate(ctx, formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("tax_lines" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("tax_lines" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+func (m *Cart) contextValidateTaxTotal(ctx context.Context, formats strfmt.Registry) error {
+
+	if m.TaxTotal != nil {
+		if err := m.TaxTotal.ContextValidate(ctx, formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("tax_total")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("tax_total")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Cart) contextValidateTotalPrice(ctx context.Context, formats strfmt.Registry) error {
+
+	if m.TotalPrice != nil {
+		if err := m.TotalPrice.ContextValidate(ctx, formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("totalPrice")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("totalPrice")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
 // MarshalBinary interface implementation
 func (m *Cart) MarshalBinary() ([]byte, error) {
 	if m == nil {
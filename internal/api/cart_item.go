@@ -18,30 +18,115 @@ import (
 // swagger:model Cart_Item
 type CartItem struct {
 
+	// discount
+	Discount *Money `json:"discount,omitempty"`
+
+	// net
+	Net *Money `json:"net,omitempty"`
+
 	// price
-	Price int32 `json:"price,omitempty"`
+	Price *Money `json:"price,omitempty"`
 
 	// product
 	Product *Product `json:"product,omitempty"`
 
 	// quantity
 	Quantity int32 `json:"quantity,omitempty"`
+
+	// tax
+	Tax *Money `json:"tax,omitempty"`
+
+	// tax rate
+	TaxRate int64 `json:"tax_rate,omitempty"`
 }
 
 // Validate validates this cart item
 func (m *CartItem) Validate(formats strfmt.Registry) error {
 	var res []error
 
+	if err := m.validateDiscount(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateNet(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validatePrice(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if err := m.validateProduct(formats); err != nil {
 		res = append(res, err)
 	}
 
+	if err := m.validateTax(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if len(res) > 0 {
 		return errors.CompositeValidationError(res...)
 	}
 	return nil
 }
 
+func (m *CartItem) validateDiscount(formats strfmt.Registry) error {
+	if swag.IsZero(m.Discount) { // not required
+		return nil
+	}
+
+	if m.Discount != nil {
+		if err := m.Discount.Validate(formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("discount")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("discount")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *CartItem) validateNet(formats strfmt.Registry) error {
+	if swag.IsZero(m.Net) { // not required
+		return nil
+	}
+
+	if m.Net != nil {
+		if err := m.Net.Validate(formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("net")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("net")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *CartItem) validatePrice(formats strfmt.Registry) error {
+	if swag.IsZero(m.Price) { // not required
+		return nil
+	}
+
+	if m.Price != nil {
+		if err := m.Price.Validate(formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("price")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("price")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (m *CartItem) validateProduct(formats strfmt.Registry) error {
 	if swag.IsZero(m.Product) { // not required
 		return nil
@@ -61,20 +146,103 @@ func (m *CartItem) validateProduct(formats strfmt.Registry) error {
 	return nil
 }
 
+func (m *CartItem) validateTax(formats strfmt.Registry) error {
+	if swag.IsZero(m.Tax) { // not required
+		return nil
+	}
+
+	if m.Tax != nil {
+		if err := m.Tax.Validate(formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("tax")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("tax")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
 // ContextValidate validate this cart item based on the context it is used
 func (m *CartItem) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
 	var res []error
 
+	if err := m.contextValidateDiscount(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.contextValidateNet(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.contextValidatePrice(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
 	if err := m.contextValidateProduct(ctx, formats); err != nil {
 		res = append(res, err)
 	}
 
+	if err := m.contextValidateTax(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
 	if len(res) > 0 {
 		return errors.CompositeValidationError(res...)
 	}
 	return nil
 }
 
+func (m *CartItem) contextValidateDiscount(ctx context.Context, formats strfmt.Registry) error {
+
+	if m.Discount != nil {
+		if err := m.Discount.ContextValidate(ctx, formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("discount")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("discount")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *CartItem) contextValidateNet(ctx context.Context, formats strfmt.Registry) error {
+
+	if m.Net != nil {
+		if err := m.Net.ContextValidate(ctx, formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("net")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("net")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *CartItem) contextValidatePrice(ctx context.Context, formats strfmt.Registry) error {
+
+	if m.Price != nil {
+		if err := m.Price.ContextValidate(ctx, formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("price")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("price")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (m *CartItem) contextValidateProduct(ctx context.Context, formats strfmt.Registry) error {
 
 	if m.Product != nil {
@@ -91,6 +259,22 @@ func (m *CartItem) contextValidateProduct(ctx context.Context, formats strfmt.Re
 	return nil
 }
 
+func (m *CartItem) contextValidateTax(ctx context.Context, formats strfmt.Registry) error {
+
+	if m.Tax != nil {
+		if err := m.Tax.ContextValidate(ctx, formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("tax")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("tax")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
 // MarshalBinary interface implementation
 func (m *CartItem) MarshalBinary() ([]byte, error) {
 	if m == nil {
@@ -19,9 +19,24 @@ import (
 // swagger:model Category
 type Category struct {
 
+	// set by the server
+	ID string `json:"id,omitempty"`
+
 	// name
 	// Required: true
 	Name *string `json:"name"`
+
+	// category this one is nested under, empty for a top level category
+	ParentID string `json:"parent_id,omitempty"`
+
+	// order among the categories under the same parent, ties are ordered by name
+	Position int32 `json:"position,omitempty"`
+
+	// days a delivered product of the category can be returned, the shop default when 0
+	ReturnDays int32 `json:"return_days,omitempty"`
+
+	// name folded to ASCII words joined by dashes when empty
+	Slug string `json:"slug,omitempty"`
 }
 
 // Validate validates this category
@@ -21,6 +21,7 @@ type ItemQuantity struct {
 
 	// quantity
 	// Required: true
+	// Minimum: 1
 	Quantity *int32 `json:"quantity"`
 }
 
@@ -44,6 +45,10 @@ func (m *ItemQuantity) validateQuantity(formats strfmt.Registry) error {
 		return err
 	}
 
+	if err := validate.MinimumInt("quantity", "body", int64(*m.Quantity), 1, false); err != nil {
+		return err
+	}
+
 	return nil
 }
 
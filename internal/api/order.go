@@ -7,9 +7,12 @@ package api
 
 import (
 	"context"
+	"strconv"
 
+	"github.com/go-openapi/errors"
 	"github.com/go-openapi/strfmt"
 	"github.com/go-openapi/swag"
+	"github.com/go-openapi/validate"
 )
 
 // Order order
@@ -20,14 +23,55 @@ type Order struct {
 	// cart id
 	CartID string `json:"cart_id,omitempty"`
 
+	// created at
+	// Format: date-time
+	CreatedAt strfmt.DateTime `json:"created_at,omitempty"`
+
+	// discount total
+	DiscountTotal *Money `json:"discount_total,omitempty"`
+
+	// discounts
+	Discounts []*Discount `json:"discounts"`
+
 	// id
 	ID string `json:"id,omitempty"`
 
+	// lines
+	Lines []*OrderLine `json:"lines"`
+
+	// payments
+	Payments []*Payment `json:"payments"`
+
+	// refunds
+	Refunds []*Refund `json:"refunds"`
+
+	// copy of the address the order is shipped to
+	ShippingAddress *Address `json:"shipping_address,omitempty"`
+
+	// shipping cost
+	ShippingCost *Money `json:"shipping_cost,omitempty"`
+
 	// status
 	Status string `json:"status,omitempty"`
 
+	// status history
+	StatusHistory []*OrderStatusChange `json:"status_history"`
+
+	// subtotal
+	Subtotal *Money `json:"subtotal,omitempty"`
+
+	// tax lines
+	TaxLines []*TaxLine `json:"tax_lines"`
+
+	// tax total
+	TaxTotal *Money `json:"tax_total,omitempty"`
+
 	// total price
-	TotalPrice int32 `json:"total_price,omitempty"`
+	TotalPrice *Money `json:"total_price,omitempty"`
+
+	// updated at
+	// Format: date-time
+	UpdatedAt strfmt.DateTime `json:"updated_at,omitempty"`
 
 	// user id
 	UserID string `json:"user_id,omitempty"`
@@ -35,11 +79,635 @@ type Order struct {
 
 // Validate validates this order
 func (m *Order) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.validateCreatedAt(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateDiscountTotal(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateDiscounts(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateLines(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validatePayments(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateRefunds(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateShippingAddress(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateShippingCost(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateStatusHistory(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateSubtotal(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateTaxLines(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateTaxTotal(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateTotalPrice(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateUpdatedAt(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *Order) validateCreatedAt(formats strfmt.Registry) error {
+	if swag.IsZero(m.CreatedAt) { // not required
+		return nil
+	}
+
+	if err := validate.FormatOf("created_at", "body", "date-time", m.CreatedAt.String(), formats); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *Order) validateDiscountTotal(formats strfmt.Registry) error {
+	if swag.IsZero(m.DiscountTotal) { // not required
+		return nil
+	}
+
+	if m.DiscountTotal != nil {
+		if err := m.DiscountTotal.Validate(formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("discount_total")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("discount_total")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Order) validateDiscounts(formats strfmt.Registry) error {
+	if swag.IsZero(m.Discounts) { // not required
+		return nil
+	}
+
+	for i := 0; i < len(m.Discounts); i++ {
+		if swag.IsZero(m.Discounts[i]) { // not required
+			continue
+		}
+
+		if m.Discounts[i] != nil {
+			if err := m.Discounts[i].Validate(formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("discounts" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("discounts" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+func (m *Order) validateLines(formats strfmt.Registry) error {
+	if swag.IsZero(m.Lines) { // not required
+		return nil
+	}
+
+	for i := 0; i < len(m.Lines); i++ {
+		if swag.IsZero(m.Lines[i]) { // not required
+			continue
+		}
+
+		if m.Lines[i] != nil {
+			if err := m.Lines[i].Validate(formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("lines" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("lines" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+func (m *Order) validatePayments(formats strfmt.Registry) error {
+	if swag.IsZero(m.Payments) { // not required
+		return nil
+	}
+
+	for i := 0; i < len(m.Payments); i++ {
+		if swag.IsZero(m.Payments[i]) { // not required
+			continue
+		}
+
+		if m.Payments[i] != nil {
+			if err := m.Payments[i].Validate(formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("payments" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("payments" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+func (m *Order) validateRefunds(formats strfmt.Registry) error {
+	if swag.IsZero(m.Refunds) { // not required
+		return nil
+	}
+
+	for i := 0; i < len(m.Refunds); i++ {
+		if swag.IsZero(m.Refunds[i]) { // not required
+			continue
+		}
+
+		if m.Refunds[i] != nil {
+			if err := m.Refunds[i].Validate(formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("refunds" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("refunds" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+func (m *Order) validateShippingAddress(formats strfmt.Registry) error {
+	if swag.IsZero(m.ShippingAddress) { // not required
+		return nil
+	}
+
+	if m.ShippingAddress != nil {
+		if err := m.ShippingAddress.Validate(formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("shipping_address")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("shipping_address")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Order) validateShippingCost(formats strfmt.Registry) error {
+	if swag.IsZero(m.ShippingCost) { // not required
+		return nil
+	}
+
+	if m.ShippingCost != nil {
+		if err := m.ShippingCost.Validate(formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("shipping_cost")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("shipping_cost")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Order) validateStatusHistory(formats strfmt.Registry) error {
+	if swag.IsZero(m.StatusHistory) { // not required
+		return nil
+	}
+
+	for i := 0; i < len(m.StatusHistory); i++ {
+		if swag.IsZero(m.StatusHistory[i]) { // not required
+			continue
+		}
+
+		if m.StatusHistory[i] != nil {
+			if err := m.StatusHistory[i].Validate(formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("status_history" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("status_history" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+func (m *Order) validateSubtotal(formats strfmt.Registry) error {
+	if swag.IsZero(m.Subtotal) { // not required
+		return nil
+	}
+
+	if m.Subtotal != nil {
+		if err := m.Subtotal.Validate(formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("subtotal")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("subtotal")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Order) validateTaxLines(formats strfmt.Registry) error {
+	if swag.IsZero(m.TaxLines) { // not required
+		return nil
+	}
+
+	for i := 0; i < len(m.TaxLines); i++ {
+		if swag.IsZero(m.TaxLines[i]) { // not required
+			continue
+		}
+
+		if m.TaxLines[i] != nil {
+			if err := m.TaxLines[i].Validate(formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("tax_lines" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("tax_lines" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
 	return nil
 }
 
-// ContextValidate validates this order based on context it is used
+func (m *Order) validateTaxTotal(formats strfmt.Registry) error {
+	if swag.IsZero(m.TaxTotal) { // not required
+		return nil
+	}
+
+	if m.TaxTotal != nil {
+		if err := m.TaxTotal.Validate(formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("tax_total")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("tax_total")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Order) validateTotalPrice(formats strfmt.Registry) error {
+	if swag.IsZero(m.TotalPrice) { // not required
+		return nil
+	}
+
+	if m.TotalPrice != nil {
+		if err := m.TotalPrice.Validate(formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("total_price")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("total_price")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Order) validateUpdatedAt(formats strfmt.Registry) error {
+	if swag.IsZero(m.UpdatedAt) { // not required
+		return nil
+	}
+
+	if err := validate.FormatOf("updated_at", "body", "date-time", m.UpdatedAt.String(), formats); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ContextValidate validate this order based on the context it is used
 func (m *Order) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.contextValidateDiscountTotal(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.contextValidateDiscounts(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.contextValidateLines(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.contextValidatePayments(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.contextValidateRefunds(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.contextValidateShippingAddress(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.contextValidateShippingCost(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.contextValidateStatusHistory(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.contextValidateSubtotal(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.contextValidateTaxLines(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.contextValidateTaxTotal(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.contextValidateTotalPrice(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *Order) contextValidateDiscountTotal(ctx context.Context, formats strfmt.Registry) error {
+
+	if m.DiscountTotal != nil {
+		if err := m.DiscountTotal.ContextValidate(ctx, formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("discount_total")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("discount_total")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Order) contextValidateDiscounts(ctx context.Context, formats strfmt.Registry) error {
+
+	for i := 0; i < len(m.Discounts); i++ {
+
+		if m.Discounts[i] != nil {
+			if err := m.Discounts[i].ContextValidate(ctx, formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("discounts" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("discounts" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+func (m *Order) contextValidateLines(ctx context.Context, formats strfmt.Registry) error {
+
+	for i := 0; i < len(m.Lines); i++ {
+
+		if m.Lines[i] != nil {
+			if err := m.Lines[i].ContextValidate(ctx, formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("lines" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("lines" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+func (m *Order) contextValidatePayments(ctx context.Context, formats strfmt.Registry) error {
+
+	for i := 0; i < len(m.Payments); i++ {
+
+		if m.Payments[i] != nil {
+			if err := m.Payments[i].ContextValidate(ctx, formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("payments" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("payments" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+func (m *Order) contextValidateRefunds(ctx context.Context, formats strfmt.Registry) error {
+
+	for i := 0; i < len(m.Refunds); i++ {
+
+		if m.Refunds[i] != nil {
+			if err := m.Refunds[i].ContextValidate(ctx, formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("refunds" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("refunds" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+func (m *Order) contextValidateShippingAddress(ctx context.Context, formats strfmt.Registry) error {
+
+	if m.ShippingAddress != nil {
+		if err := m.ShippingAddress.ContextValidate(ctx, formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("shipping_address")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("shipping_address")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Order) contextValidateShippingCost(ctx context.Context, formats strfmt.Registry) error {
+
+	if m.ShippingCost != nil {
+		if err := m.ShippingCost.ContextValidate(ctx, formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("shipping_cost")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("shipping_cost")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Order) contextValidateStatusHistory(ctx context.Context, formats strfmt.Registry) error {
+
+	for i := 0; i < len(m.StatusHistory); i++ {
+
+		if m.StatusHistory[i] != nil {
+			if err := m.StatusHistory[i].ContextValidate(ctx, formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("status_history" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("status_history" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+func (m *Order) contextValidateSubtotal(ctx context.Context, formats strfmt.Registry) error {
+
+	if m.Subtotal != nil {
+		if err := m.Subtotal.ContextValidate(ctx, formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("subtotal")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("subtotal")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Order) contextValidateTaxLines(ctx context.Context, formats strfmt.Registry) error {
+
+	for i := 0; i < len(m.TaxLines); i++ {
+
+		if m.TaxLines[i] != nil {
+			if err := m.TaxLines[i].ContextValidate(ctx, formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("tax_lines" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("tax_lines" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+func (m *Order) contextValidateTaxTotal(ctx context.Context, formats strfmt.Registry) error {
+
+	if m.TaxTotal != nil {
+		if err := m.TaxTotal.ContextValidate(ctx, formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("tax_total")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("tax_total")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Order) contextValidateTotalPrice(ctx context.Context, formats strfmt.Registry) error {
+
+	if m.TotalPrice != nil {
+		if err := m.TotalPrice.ContextValidate(ctx, formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("total_price")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("total_price")
+			}
+			return err
+		}
+	}
+
 	return nil
 }
 
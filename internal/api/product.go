@@ -19,7 +19,13 @@ import (
 // swagger:model Product
 type Product struct {
 
-	// category name
+	// available stock
+	AvailableStock int32 `json:"availableStock"`
+
+	// set by the server from category_name
+	CategoryID string `json:"category_id,omitempty"`
+
+	// category name, or a path of names like Beyaz Esya/Buzdolabi
 	// Required: true
 	CategoryName *string `json:"category_name"`
 
@@ -32,7 +38,7 @@ type Product struct {
 
 	// price
 	// Required: true
-	Price *int32 `json:"price"`
+	Price *Money `json:"price"`
 
 	// sku
 	// Required: true
@@ -41,6 +47,9 @@ type Product struct {
 	// unit stock
 	// Required: true
 	UnitStock *int32 `json:"unitStock"`
+
+	// shipping weight in grams
+	Weight int64 `json:"weight,omitempty"`
 }
 
 // Validate validates this product
@@ -97,6 +106,17 @@ func (m *Product) validatePrice(formats strfmt.Registry) error {
 		return err
 	}
 
+	if m.Price != nil {
+		if err := m.Price.Validate(formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("price")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("price")
+			}
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -118,8 +138,33 @@ func (m *Product) validateUnitStock(formats strfmt.Registry) error {
 	return nil
 }
 
-// ContextValidate validates this product based on context it is used
+// ContextValidate validate this product based on the context it is used
 func (m *Product) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.contextValidatePrice(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *Product) contextValidatePrice(ctx context.Context, formats strfmt.Registry) error {
+
+	if m.Price != nil {
+		if err := m.Price.ContextValidate(ctx, formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("price")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("price")
+			}
+			return err
+		}
+	}
+
 	return nil
 }
 
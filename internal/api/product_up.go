@@ -8,6 +8,7 @@ package api
 import (
 	"context"
 
+	"github.com/go-openapi/errors"
 	"github.com/go-openapi/strfmt"
 	"github.com/go-openapi/swag"
 )
@@ -17,7 +18,7 @@ import (
 // swagger:model ProductUp
 type ProductUp struct {
 
-	// category name
+	// category name, or a path of names like Beyaz Esya/Buzdolabi
 	CategoryName string `json:"category_name,omitempty"`
 
 	// description
@@ -27,22 +28,78 @@ type ProductUp struct {
 	Name string `json:"name,omitempty"`
 
 	// price
-	Price int32 `json:"price,omitempty"`
+	Price *Money `json:"price,omitempty"`
 
 	// sku
 	Sku int64 `json:"sku,omitempty"`
 
 	// unit stock
 	UnitStock int32 `json:"unitStock,omitempty"`
+
+	// shipping weight in grams
+	Weight int64 `json:"weight,omitempty"`
 }
 
 // Validate validates this product up
 func (m *ProductUp) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.validatePrice(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *ProductUp) validatePrice(formats strfmt.Registry) error {
+	if swag.IsZero(m.Price) { // not required
+		return nil
+	}
+
+	if m.Price != nil {
+		if err := m.Price.Validate(formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("price")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("price")
+			}
+			return err
+		}
+	}
+
 	return nil
 }
 
-// ContextValidate validates this product up based on context it is used
+// ContextValidate validate this product up based on the context it is used
 func (m *ProductUp) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.contextValidatePrice(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *ProductUp) contextValidatePrice(ctx context.Context, formats strfmt.Registry) error {
+
+	if m.Price != nil {
+		if err := m.Price.ContextValidate(ctx, formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("price")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("price")
+			}
+			return err
+		}
+	}
+
 	return nil
 }
 
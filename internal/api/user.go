@@ -30,9 +30,6 @@ import (
 	// id
 	ID int64 `json:"id,omitempty"`
 
-	// is admin
-	IsAdmin bool `json:"isAdmin,omitempty"`
-
 	// last name
 	// Required: true
 	LastName *string `json:"lastName"`
@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gcamlicali/tradeshopExample/internal/cart"
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+	mw "github.com/gcamlicali/tradeshopExample/pkg/middleware"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// MinAdminPasswordLength is the shortest password an admin account accepts
+const MinAdminPasswordLength = 12
+
+// AdminAccount is the admin given to the admin create command
+type AdminAccount struct {
+	Email     string
+	FirstName string
+	LastName  string
+	Password  string
+}
+
+// AdminProvisioner creates super-admins and rotates their passwords, it runs from the command line and not at server start
+type AdminProvisioner struct {
+	repo  IAuthRepository
+	tRepo IRefreshTokenRepository
+	cRepo cart.ICartRepository
+}
+
+func NewAdminProvisioner(repo IAuthRepository, tRepo IRefreshTokenRepository, cRepo cart.ICartRepository) *AdminProvisioner {
+	return &AdminProvisioner{repo: repo, tRepo: tRepo, cRepo: cRepo}
+}
+
+// Provision creates the admin, an existing account gets the new password, the super-admin role
+// and is signed out everywhere. created reports a new account.
+func (p *AdminProvisioner) Provision(account AdminAccount) (created bool, err error) {
+	account.Email = strings.TrimSpace(account.Email)
+	if !strings.Contains(account.Email, "@") {
+		return false, fmt.Errorf("email %q is not valid", account.Email)
+	}
+	if len(account.Password) < MinAdminPasswordLength {
+		return false, fmt.Errorf("password must be at least %d characters", MinAdminPasswordLength)
+	}
+
+	hashPassword, err := bcrypt.GenerateFromPassword([]byte(account.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return false, err
+	}
+	password := string(hashPassword)
+
+	user, err := p.repo.GetByMail(account.Email)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+
+	if user == nil {
+		created = true
+		if user, err = p.create(account, password); err != nil {
+			return false, err
+		}
+	} else {
+		if err := p.repo.UpdatePassword(user.ID, password); err != nil {
+			return false, err
+		}
+		if _, err := p.repo.MarkVerified(user.ID, *user.Mail); err != nil {
+			return false, err
+		}
+		// Access tokens of the old password expire within a session time
+		if _, err := p.tRepo.RevokeUser(user.ID); err != nil {
+			return false, err
+		}
+	}
+
+	if !hasRole(user, mw.RoleSuperAdmin) {
+		roles := append(user.Roles, models.UserRole{UserID: user.ID, Role: mw.RoleSuperAdmin})
+		if err := p.repo.SetRoles(user.ID, roles); err != nil {
+			return false, err
+		}
+	}
+	return created, nil
+}
+
+func (p *AdminProvisioner) create(account AdminAccount, password string) (*models.User, error) {
+	now := time.Now()
+	user, err := p.repo.Create(&models.User{
+		Mail:            &account.Email,
+		Password:        &password,
+		FirstName:       &account.FirstName,
+		LastName:        &account.LastName,
+		EmailVerifiedAt: &now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.cRepo.Create(&models.Cart{UserID: user.ID}); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
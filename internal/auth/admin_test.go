@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+	mw "github.com/gcamlicali/tradeshopExample/pkg/middleware"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const adminPassword = "correct-horse-battery"
+
+func Test_AdminProvisioner_Create(t *testing.T) {
+	repo := &authMockRepo{}
+	cRepo := &cartMockRepo{}
+	p := NewAdminProvisioner(repo, &refreshTokenMockRepo{}, cRepo)
+
+	created, err := p.Provision(AdminAccount{Email: " new@admin.com ", FirstName: "New", LastName: "Admin", Password: adminPassword})
+	if err != nil || !created {
+		t.Fatalf("Provision() created = %v, error = %v", created, err)
+	}
+	admin := repo.Items[0]
+	if *admin.Mail != "new@admin.com" || admin.EmailVerifiedAt == nil || !hasRole(&admin, mw.RoleSuperAdmin) {
+		t.Errorf("Provision() admin = %+v, want a verified super-admin", admin)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(*admin.Password), []byte(adminPassword)); err != nil {
+		t.Errorf("Provision() password is not stored hashed: %v", err)
+	}
+	if len(cRepo.Items) != 1 {
+		t.Errorf("Provision() carts = %v, want a cart for the admin", cRepo.Items)
+	}
+}
+
+func Test_AdminProvisioner_Rotate(t *testing.T) {
+	existing := user
+	existing.ID = uuid.New()
+	existing.Roles = nil
+	repo := &authMockRepo{Items: []models.User{existing}}
+	tRepo := &refreshTokenMockRepo{Items: []models.RefreshToken{{ID: uuid.New(), UserID: existing.ID, FamilyID: uuid.New()}}}
+	p := NewAdminProvisioner(repo, tRepo, &cartMockRepo{})
+
+	created, err := p.Provision(AdminAccount{Email: userMail, Password: adminPassword})
+	if err != nil || created {
+		t.Fatalf("Provision() created = %v, error = %v", created, err)
+	}
+	rotated := repo.Items[0]
+	if err := bcrypt.CompareHashAndPassword([]byte(*rotated.Password), []byte(adminPassword)); err != nil {
+		t.Errorf("Provision() password is not rotated: %v", err)
+	}
+	if !hasRole(&rotated, mw.RoleSuperAdmin) {
+		t.Errorf("Provision() roles = %v, want super-admin", rotated.Roles)
+	}
+	if tRepo.Items[0].RevokedAt == nil {
+		t.Errorf("Provision() sessions of the old password are not revoked")
+	}
+}
+
+func Test_AdminProvisioner_Validation(t *testing.T) {
+	p := NewAdminProvisioner(&authMockRepo{}, &refreshTokenMockRepo{}, &cartMockRepo{})
+	for _, account := range []AdminAccount{
+		{Email: "admin", Password: adminPassword},
+		{Email: "admin@admin.com", Password: "short"},
+	} {
+		if _, err := p.Provision(account); err == nil {
+			t.Errorf("Provision() accepted %+v", account)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AttemptRepositoy keeps failed sign in attempts in the database, it is the attempt store of the server
+// so every instance counts the same failures
+type AttemptRepositoy struct {
+	db *gorm.DB
+}
+
+func NewAttemptRepository(db *gorm.DB) *AttemptRepositoy {
+	return &AttemptRepositoy{db: db}
+}
+
+func (r *AttemptRepositoy) Get(key string) (Attempt, error) {
+	zap.L().Debug("attempt.repo.get", zap.String("key", key))
+
+	var row models.LoginAttempt
+	err := r.db.Where("key = ?", key).First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return Attempt{}, nil
+	}
+	if err != nil {
+		return Attempt{}, err
+	}
+	return attemptFromRow(&row), nil
+}
+
+// Fail counts the failure in one statement, failures of other instances at the same time are all counted
+func (r *AttemptRepositoy) Fail(key string, window time.Duration) (Attempt, error) {
+	zap.L().Debug("attempt.repo.fail", zap.String("key", key))
+
+	//Forget attempts that are neither locked nor recent
+	now := time.Now()
+	stale := now.Add(-window)
+	err := r.db.Where("key <> ? AND last_failure < ? AND (locked_until IS NULL OR locked_until <= ?)", key, stale, now).
+		Delete(&models.LoginAttempt{}).Error
+	if err != nil {
+		zap.L().Error("attempt.repo.Fail failed to delete stale attempts", zap.Error(err))
+		return Attempt{}, err
+	}
+
+	row := models.LoginAttempt{Key: key, Failures: 1, LastFailure: now}
+	err = r.db.Clauses(
+		clause.OnConflict{
+			Columns: []clause.Column{{Name: "key"}},
+			DoUpdates: clause.Set{
+				{Column: clause.Column{Name: "failures"}, Value: gorm.Expr(
+					"CASE WHEN login_attempt.last_failure < ? AND (login_attempt.locked_until IS NULL OR login_attempt.locked_until <= ?) THEN 1 ELSE login_attempt.failures + 1 END",
+					stale, now)},
+				{Column: clause.Column{Name: "last_failure"}, Value: now},
+			},
+		},
+		clause.Returning{},
+	).Create(&row).Error
+	if err != nil {
+		zap.L().Error("attempt.repo.Fail failed to count attempt", zap.Error(err))
+		return Attempt{}, err
+	}
+	return attemptFromRow(&row), nil
+}
+
+func (r *AttemptRepositoy) Lock(key string, until time.Time) error {
+	zap.L().Debug("attempt.repo.lock", zap.String("key", key), zap.Time("until", until))
+
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"locked_until"}),
+	}).Create(&models.LoginAttempt{Key: key, LastFailure: time.Now(), LockedUntil: &until}).Error
+	if err != nil {
+		zap.L().Error("attempt.repo.Lock failed to lock", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *AttemptRepositoy) Reset(key string) error {
+	zap.L().Debug("attempt.repo.reset", zap.String("key", key))
+	return r.db.Where("key = ?", key).Delete(&models.LoginAttempt{}).Error
+}
+
+func attemptFromRow(row *models.LoginAttempt) Attempt {
+	attempt := Attempt{Failures: row.Failures, LastFailure: row.LastFailure}
+	if row.LockedUntil != nil {
+		attempt.LockedUntil = *row.LockedUntil
+	}
+	return attempt
+}
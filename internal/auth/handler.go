@@ -3,8 +3,11 @@ package auth
 import (
 	"github.com/gcamlicali/tradeshopExample/internal/api"
 	httpErr "github.com/gcamlicali/tradeshopExample/internal/httpErrors"
+	jwtHelper "github.com/gcamlicali/tradeshopExample/pkg/jwt"
+	mw "github.com/gcamlicali/tradeshopExample/pkg/middleware"
 	"github.com/gin-gonic/gin"
 	"github.com/go-openapi/strfmt"
+	"github.com/google/uuid"
 
 	"net/http"
 )
@@ -13,11 +16,33 @@ type authHandler struct {
 	service Service
 }
 
-func NewAuthHandler(r *gin.RouterGroup, service Service) {
+func NewAuthHandler(r *gin.RouterGroup, service Service, authMW gin.HandlerFunc) {
 	a := authHandler{service: service}
 
 	r.POST("/signin", a.signin)
 	r.POST("/signup", a.signup)
+	r.POST("/refresh", a.refresh)
+	r.POST("/logout", a.logout)
+	r.POST("/verify-email", a.verifyEmail)
+	r.POST("/verify-email/resend", authMW, a.resendVerification)
+	r.POST("/password/forgot", a.forgotPassword)
+	r.POST("/password/reset", a.resetPassword)
+
+	adminRoute := r.Group("/admin")
+	adminRoute.Use(authMW, mw.RequirePermission(mw.PermUserRoles))
+	adminRoute.GET("/roles", a.listRoles)
+	adminRoute.GET("/:id/roles", a.getRoles)
+	adminRoute.PUT("/:id/roles", a.setRoles)
+
+	r.PUT("/admin/:id/unlock", authMW, mw.RequirePermission(mw.PermUserManage), a.unlock)
+}
+
+// NewJWKSHandler publishes the public keys that verify access tokens
+func NewJWKSHandler(r gin.IRoutes, keys *jwtHelper.KeySet) {
+	r.GET("/.well-known/jwks.json", func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, keys.JWKS())
+	})
 }
 
 func (a *authHandler) signin(c *gin.Context) {
@@ -32,13 +57,13 @@ func (a *authHandler) signin(c *gin.Context) {
 		c.JSON(httpErr.ErrorResponse(err))
 		return
 	}
-	token, err := a.service.SignIn(&req)
+	tokens, err := a.service.SignIn(&req, c.ClientIP())
 	if err != nil {
 		c.JSON(httpErr.ErrorResponse(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, token)
+	c.JSON(http.StatusOK, tokensToResponse(tokens))
 }
 
 func (a *authHandler) signup(c *gin.Context) {
@@ -54,11 +79,180 @@ func (a *authHandler) signup(c *gin.Context) {
 		c.JSON(httpErr.ErrorResponse(err))
 		return
 	}
-	token, err := a.service.SignUp(&reqUser)
+	tokens, err := a.service.SignUp(&reqUser)
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, tokensToResponse(tokens))
+}
+
+func (a *authHandler) refresh(c *gin.Context) {
+	req := api.RefreshRequest{}
+	if err := c.Bind(&req); err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "check your request body", nil)))
+		return
+	}
+	if err := req.Validate(strfmt.NewFormats()); err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	tokens, err := a.service.Refresh(*req.RefreshToken)
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, tokensToResponse(tokens))
+}
+
+func (a *authHandler) logout(c *gin.Context) {
+	req := api.RefreshRequest{}
+	if err := c.Bind(&req); err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "check your request body", nil)))
+		return
+	}
+	if err := req.Validate(strfmt.NewFormats()); err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	if err := a.service.Logout(*req.RefreshToken); err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, "Logout Complete")
+}
+
+func (a *authHandler) verifyEmail(c *gin.Context) {
+	req := api.EmailVerification{}
+	if err := c.Bind(&req); err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "check your request body", nil)))
+		return
+	}
+	if err := req.Validate(strfmt.NewFormats()); err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	if err := a.service.VerifyEmail(*req.Token); err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, "Email verified")
+}
+
+func (a *authHandler) resendVerification(c *gin.Context) {
+	userID := c.MustGet("userId").(uuid.UUID)
+
+	if err := a.service.SendVerification(userID); err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, "Verification mail sent")
+}
+
+func (a *authHandler) forgotPassword(c *gin.Context) {
+	req := api.PasswordResetRequest{}
+	if err := c.Bind(&req); err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "check your request body", nil)))
+		return
+	}
+	if err := req.Validate(strfmt.NewFormats()); err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	if err := a.service.RequestPasswordReset(*req.Email); err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, "If the email has an account, a reset link is sent")
+}
+
+func (a *authHandler) resetPassword(c *gin.Context) {
+	req := api.PasswordReset{}
+	if err := c.Bind(&req); err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "check your request body", nil)))
+		return
+	}
+	if err := req.Validate(strfmt.NewFormats()); err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	if err := a.service.ResetPassword(*req.Token, *req.Password); err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, "Password changed, please sign in again")
+}
+
+func (a *authHandler) unlock(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "User ID is not valid", err.Error())))
+		return
+	}
+
+	if err := a.service.Unlock(userID); err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, "Account unlocked")
+}
+
+func (a *authHandler) listRoles(c *gin.Context) {
+	c.JSON(http.StatusOK, rolesToResponse())
+}
+
+func (a *authHandler) getRoles(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "User ID is not valid", err.Error())))
+		return
+	}
+
+	roles, err := a.service.GetRoles(userID)
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, &api.UserRoles{Roles: roles})
+}
+
+func (a *authHandler) setRoles(c *gin.Context) {
+	actorID := c.MustGet("userId").(uuid.UUID)
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "User ID is not valid", err.Error())))
+		return
+	}
+
+	req := api.UserRoles{}
+	if err := c.Bind(&req); err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "check your request body", err.Error())))
+		return
+	}
+	if err := req.Validate(strfmt.NewFormats()); err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	roles, err := a.service.SetRoles(actorID, userID, req.Roles)
 	if err != nil {
 		c.JSON(httpErr.ErrorResponse(err))
 		return
 	}
 
-	c.JSON(http.StatusCreated, token)
+	c.JSON(http.StatusOK, &api.UserRoles{Roles: roles})
 }
@@ -0,0 +1,188 @@
+package auth
+
+import (
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	httpErr "github.com/gcamlicali/tradeshopExample/internal/httpErrors"
+	"github.com/gcamlicali/tradeshopExample/pkg/config"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Attempt is the failed sign in history of an account or client IP
+type Attempt struct {
+	Failures    int
+	LastFailure time.Time
+	LockedUntil time.Time
+}
+
+// AttemptStore counts failed sign in attempts by key
+type AttemptStore interface {
+	Get(key string) (Attempt, error)
+	// Fail counts a failed attempt, counting restarts when the last failure is older than window
+	Fail(key string, window time.Duration) (Attempt, error)
+	Lock(key string, until time.Time) error
+	Reset(key string) error
+}
+
+// MemoryAttemptStore keeps attempts in process memory of a single instance, the server counts them with AttemptRepositoy
+type MemoryAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]Attempt
+}
+
+func NewMemoryAttemptStore() *MemoryAttemptStore {
+	return &MemoryAttemptStore{attempts: make(map[string]Attempt)}
+}
+
+func (s *MemoryAttemptStore) Get(key string) (Attempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.attempts[key], nil
+}
+
+func (s *MemoryAttemptStore) Fail(key string, window time.Duration) (Attempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	//Forget attempts that are neither locked nor recent
+	now := time.Now()
+	for k, attempt := range s.attempts {
+		if now.Sub(attempt.LastFailure) > window && !attempt.LockedUntil.After(now) {
+			delete(s.attempts, k)
+		}
+	}
+
+	attempt := s.attempts[key]
+	attempt.Failures++
+	attempt.LastFailure = now
+	s.attempts[key] = attempt
+	return attempt, nil
+}
+
+func (s *MemoryAttemptStore) Lock(key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	attempt := s.attempts[key]
+	attempt.LockedUntil = until
+	s.attempts[key] = attempt
+	return nil
+}
+
+func (s *MemoryAttemptStore) Reset(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.attempts, key)
+	return nil
+}
+
+// loginThrottle slows down failed sign ins of an account and of a client IP exponentially and locks them after too many failures
+type loginThrottle struct {
+	store AttemptStore
+	cfg   config.LoginConfig
+}
+
+func newLoginThrottle(store AttemptStore, cfg config.LoginConfig) *loginThrottle {
+	if cfg.MaxFailures == 0 {
+		cfg.MaxFailures = 5
+	}
+	if cfg.IPMaxFailures == 0 {
+		cfg.IPMaxFailures = 50
+	}
+	if cfg.LockoutSecs == 0 {
+		cfg.LockoutSecs = 900
+	}
+	if cfg.WindowSecs == 0 {
+		cfg.WindowSecs = 900
+	}
+	if cfg.BackoffBaseSecs == 0 {
+		cfg.BackoffBaseSecs = 1
+	}
+	if cfg.BackoffMaxSecs == 0 {
+		cfg.BackoffMaxSecs = 60
+	}
+	return &loginThrottle{store: store, cfg: cfg}
+}
+
+func accountKey(mail string) string {
+	return "mail:" + strings.ToLower(strings.TrimSpace(mail))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Check rejects the sign in while the account or the IP is locked or backing off
+func (t *loginThrottle) Check(mail string, ip string) error {
+	now := time.Now()
+	for _, key := range []string{accountKey(mail), ipKey(ip)} {
+		attempt, err := t.store.Get(key)
+		if err != nil {
+			return httpErr.NewRestError(http.StatusInternalServerError, "Sign in attempts get error", err.Error())
+		}
+		if wait := t.wait(attempt, now); wait > 0 {
+			return httpErr.NewRestError(http.StatusTooManyRequests, "Too many failed sign in attempts, try again later",
+				map[string]int64{"retryAfter": int64(math.Ceil(wait.Seconds()))})
+		}
+	}
+	return nil
+}
+
+// Fail counts a failed sign in of the account and the IP, locking them when they reach their limit
+func (t *loginThrottle) Fail(mail string, ip string) error {
+	window := time.Duration(t.cfg.WindowSecs) * time.Second
+	limits := map[string]int{accountKey(mail): t.cfg.MaxFailures, ipKey(ip): t.cfg.IPMaxFailures}
+	for key, maxFailures := range limits {
+		attempt, err := t.store.Fail(key, window)
+		if err != nil {
+			return err
+		}
+		if attempt.Failures >= maxFailures {
+			if err := t.store.Lock(key, time.Now().Add(time.Duration(t.cfg.LockoutSecs)*time.Second)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Unlock forgets the failed sign ins of the account, failures of the IP stay
+func (t *loginThrottle) Unlock(mail string) error {
+	return t.store.Reset(accountKey(mail))
+}
+
+// wait returns how long the next attempt must wait, the backoff doubles with every failure
+func (t *loginThrottle) wait(attempt Attempt, now time.Time) time.Duration {
+	if attempt.LockedUntil.After(now) {
+		return attempt.LockedUntil.Sub(now)
+	}
+	if attempt.Failures == 0 || now.Sub(attempt.LastFailure) > time.Duration(t.cfg.WindowSecs)*time.Second {
+		return 0
+	}
+	backoff := time.Duration(t.cfg.BackoffMaxSecs) * time.Second
+	if attempt.Failures <= 30 {
+		if doubled := time.Duration(t.cfg.BackoffBaseSecs) * time.Second << (attempt.Failures - 1); doubled < backoff {
+			backoff = doubled
+		}
+	}
+	if next := attempt.LastFailure.Add(backoff); next.After(now) {
+		return next.Sub(now)
+	}
+	return 0
+}
+
+var (
+	dummyOnce sync.Once
+	dummy     []byte
+)
+
+// dummyHash is compared for unknown mails, so they take as long as a wrong password
+func dummyHash() []byte {
+	dummyOnce.Do(func() {
+		dummy, _ = bcrypt.GenerateFromPassword([]byte("no-such-user"), bcrypt.DefaultCost)
+	})
+	return dummy
+}
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gcamlicali/tradeshopExample/pkg/config"
+)
+
+var loginConf = config.LoginConfig{
+	MaxFailures:     3,
+	IPMaxFailures:   10,
+	LockoutSecs:     600,
+	WindowSecs:      900,
+	BackoffBaseSecs: 1,
+	BackoffMaxSecs:  4,
+}
+
+func Test_loginThrottle_wait(t *testing.T) {
+	throttle := newLoginThrottle(NewMemoryAttemptStore(), loginConf)
+	now := time.Now()
+	tests := []struct {
+		name    string
+		attempt Attempt
+		want    time.Duration
+	}{
+		{name: "NoFailures", attempt: Attempt{}, want: 0},
+		{name: "FirstFailure", attempt: Attempt{Failures: 1, LastFailure: now}, want: time.Second},
+		{name: "DoublesWithFailures", attempt: Attempt{Failures: 3, LastFailure: now}, want: 4 * time.Second},
+		{name: "CappedAtMax", attempt: Attempt{Failures: 40, LastFailure: now}, want: 4 * time.Second},
+		{name: "BackoffPassed", attempt: Attempt{Failures: 2, LastFailure: now.Add(-3 * time.Second)}, want: 0},
+		{name: "Locked", attempt: Attempt{Failures: 3, LastFailure: now, LockedUntil: now.Add(time.Minute)}, want: time.Minute},
+		{name: "OutsideWindow", attempt: Attempt{Failures: 2, LastFailure: now.Add(-time.Hour)}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := throttle.wait(tt.attempt, now); got != tt.want {
+				t.Errorf("wait() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_loginThrottle_LocksAfterMaxFailures(t *testing.T) {
+	store := NewMemoryAttemptStore()
+	throttle := newLoginThrottle(store, loginConf)
+
+	for i := 0; i < loginConf.MaxFailures; i++ {
+		throttle.Fail("User@Mail.com", "10.0.0.1")
+	}
+	account, _ := store.Get(accountKey("user@mail.com"))
+	if !account.LockedUntil.After(time.Now().Add(9 * time.Minute)) {
+		t.Errorf("Fail() account locked until %v, want lockout", account.LockedUntil)
+	}
+	ip, _ := store.Get(ipKey("10.0.0.1"))
+	if !ip.LockedUntil.IsZero() {
+		t.Errorf("Fail() locked the IP below its limit")
+	}
+
+	// Another account from another IP is not slowed down
+	if err := throttle.Check("other@mail.com", "10.0.0.2"); err != nil {
+		t.Errorf("Check() error = %v for an other account", err)
+	}
+
+	throttle.Unlock("user@mail.com")
+	if account, _ := store.Get(accountKey("user@mail.com")); account.Failures != 0 {
+		t.Errorf("Unlock() failures = %v, want 0", account.Failures)
+	}
+}
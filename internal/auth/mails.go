@@ -0,0 +1,34 @@
+package auth
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/gcamlicali/tradeshopExample/pkg/mail"
+)
+
+func verificationMail(user *models.User, link string, ttl time.Duration) mail.Message {
+	return mail.Message{
+		To:      *user.Mail,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf("Hello %s,\n\nPlease verify your email to place orders:\n%s\n\nThe link expires in %s.\n",
+			greetingName(user), link, ttl),
+	}
+}
+
+func passwordResetMail(user *models.User, link string, ttl time.Duration) mail.Message {
+	return mail.Message{
+		To:      *user.Mail,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hello %s,\n\nYou can set a new password here:\n%s\n\nThe link expires in %s. If you didn't ask for it, you can ignore this mail.\n",
+			greetingName(user), link, ttl),
+	}
+}
+
+func greetingName(user *models.User) string {
+	if user.FirstName != nil && *user.FirstName != "" {
+		return *user.FirstName
+	}
+	return *user.Mail
+}
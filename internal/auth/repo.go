@@ -1,7 +1,10 @@
 package auth
 
 import (
+	"time"
+
 	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
@@ -12,7 +15,10 @@ type AuthRepositoy struct {
 type IAuthRepository interface {
 	Create(a *models.User) (*models.User, error)
 	GetByMail(mail string) (*models.User, error)
-	CheckAndCreateAdmin(user *models.User) bool
+	GetByID(id uuid.UUID) (*models.User, error)
+	SetRoles(userID uuid.UUID, roles []models.UserRole) error
+	MarkVerified(userID uuid.UUID, mail string) (bool, error)
+	UpdatePassword(userID uuid.UUID, password string) error
 }
 
 func NewAuthRepository(db *gorm.DB) *AuthRepositoy {
@@ -32,27 +38,64 @@ func (r *AuthRepositoy) GetByMail(mail string) (*models.User, error) {
 	zap.L().Debug("User.repo.getByID", zap.Reflect("mail", mail))
 
 	var user = &models.User{}
-	if result := r.db.Where(models.User{Mail: &mail}).First(&user); result.Error != nil {
+	if result := r.db.Preload("Roles").Where(models.User{Mail: &mail}).First(&user); result.Error != nil {
 		return nil, result.Error
 	}
 
 	return user, nil
 }
 
-func (r *AuthRepositoy) CheckAndCreateAdmin(user *models.User) bool {
-	zap.L().Debug("User.repo.crateAdmin", zap.Reflect("admin", user))
+func (r *AuthRepositoy) GetByID(id uuid.UUID) (*models.User, error) {
+	zap.L().Debug("User.repo.getByID", zap.Reflect("id", id))
 
-	if r.db.Where("mail = ?", user.Mail).Updates(&user).RowsAffected == 0 {
-		_, err := r.Create(user)
-		if err != nil {
-			zap.L().Error("Create Admin Data Error : ", zap.Error(err))
+	var user = &models.User{}
+	if result := r.db.Preload("Roles").Where(models.User{ID: id}).First(&user); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return user, nil
+}
+
+// SetRoles replaces every role of the user
+func (r *AuthRepositoy) SetRoles(userID uuid.UUID, roles []models.UserRole) error {
+	zap.L().Debug("User.repo.setRoles", zap.Reflect("userID", userID), zap.Reflect("roles", roles))
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where(&models.UserRole{UserID: userID}).Delete(&models.UserRole{}).Error; err != nil {
+			zap.L().Error("User.repo.SetRoles failed to delete roles", zap.Error(err))
+			return err
+		}
+		if len(roles) == 0 {
+			return nil
 		}
+		if err := tx.Create(&roles).Error; err != nil {
+			zap.L().Error("User.repo.SetRoles failed to create roles", zap.Error(err))
+			return err
+		}
+		return nil
+	})
+}
 
-		return true
+// MarkVerified verifies the mail of the user, false means the user has a different mail now
+func (r *AuthRepositoy) MarkVerified(userID uuid.UUID, mail string) (bool, error) {
+	zap.L().Debug("User.repo.markVerified", zap.Reflect("userID", userID))
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND mail = ?", userID, mail).
+		UpdateColumn("email_verified_at", time.Now())
+	if result.Error != nil {
+		zap.L().Error("User.repo.MarkVerified failed to update user", zap.Error(result.Error))
+		return false, result.Error
 	}
-	return false
+	return result.RowsAffected == 1, nil
 }
 
-func (r *AuthRepositoy) Migration() {
-	r.db.AutoMigrate(&models.User{})
+// UpdatePassword stores the given password hash
+func (r *AuthRepositoy) UpdatePassword(userID uuid.UUID, password string) error {
+	zap.L().Debug("User.repo.updatePassword", zap.Reflect("userID", userID))
+	err := r.db.Model(&models.User{}).Where("id = ?", userID).Update("password", password).Error
+	if err != nil {
+		zap.L().Error("User.repo.UpdatePassword failed to update user", zap.Error(err))
+		return err
+	}
+	return nil
 }
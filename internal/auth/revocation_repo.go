@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RevocationRepositoy keeps revoked token families in the database, it is the jwt revocation store
+// of the server so a token revoked on one instance is rejected by all of them
+type RevocationRepositoy struct {
+	db *gorm.DB
+}
+
+func NewRevocationRepository(db *gorm.DB) *RevocationRepositoy {
+	return &RevocationRepositoy{db: db}
+}
+
+func (r *RevocationRepositoy) Revoke(familyID string, until time.Time) error {
+	zap.L().Debug("revocation.repo.revoke", zap.String("familyID", familyID), zap.Time("until", until))
+
+	//Forget families whose tokens are already expired
+	if err := r.db.Where("expires_at <= ?", time.Now()).Delete(&models.RevokedTokenFamily{}).Error; err != nil {
+		zap.L().Error("revocation.repo.Revoke failed to delete expired families", zap.Error(err))
+		return err
+	}
+
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "family_id"}},
+		DoUpdates: clause.Set{{Column: clause.Column{Name: "expires_at"}, Value: gorm.Expr("GREATEST(revoked_token_family.expires_at, EXCLUDED.expires_at)")}},
+	}).Create(&models.RevokedTokenFamily{FamilyID: familyID, ExpiresAt: until}).Error
+	if err != nil {
+		zap.L().Error("revocation.repo.Revoke failed to revoke family", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *RevocationRepositoy) IsRevoked(familyID string) (bool, error) {
+	if familyID == "" {
+		return false, nil
+	}
+	var count int64
+	err := r.db.Model(&models.RevokedTokenFamily{}).Where("family_id = ? AND expires_at > ?", familyID, time.Now()).Count(&count).Error
+	if err != nil {
+		zap.L().Error("revocation.repo.IsRevoked failed to get family", zap.Error(err))
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -3,6 +3,7 @@ package auth
 import (
 	"github.com/gcamlicali/tradeshopExample/internal/api"
 	"github.com/gcamlicali/tradeshopExample/internal/models"
+	mw "github.com/gcamlicali/tradeshopExample/pkg/middleware"
 )
 
 func userApiToModel(a *api.User) *models.User {
@@ -12,6 +13,22 @@ func userApiToModel(a *api.User) *models.User {
 		FirstName: a.FirstName,
 		LastName:  a.LastName,
 		Mobile:    a.Phone,
-		IsAdmin:   false,
 	}
 }
+
+func tokensToResponse(t *Tokens) *api.Token {
+	return &api.Token{
+		AccessToken:  t.AccessToken,
+		RefreshToken: t.RefreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    t.ExpiresIn,
+	}
+}
+
+func rolesToResponse() []*api.Role {
+	roles := make([]*api.Role, 0, len(mw.RolePermissions))
+	for _, name := range mw.RoleNames() {
+		roles = append(roles, &api.Role{Name: name, Permissions: mw.RolePermissions[name]})
+	}
+	return roles
+}
@@ -1,73 +1,112 @@
 package auth
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"github.com/gcamlicali/tradeshopExample/internal/api"
 	"github.com/gcamlicali/tradeshopExample/internal/cart"
 	httpErr "github.com/gcamlicali/tradeshopExample/internal/httpErrors"
 	"github.com/gcamlicali/tradeshopExample/internal/models"
 	"github.com/gcamlicali/tradeshopExample/pkg/config"
 	jwtHelper "github.com/gcamlicali/tradeshopExample/pkg/jwt"
+	"github.com/gcamlicali/tradeshopExample/pkg/mail"
+	mw "github.com/gcamlicali/tradeshopExample/pkg/middleware"
 	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
-	"log"
+	"gorm.io/gorm"
 	"net/http"
 	"os"
 	"time"
 )
 
 type authService struct {
-	cfg   *config.Config
-	repo  IAuthRepository
-	cRepo cart.ICartRepository
+	cfg      *config.Config
+	repo     IAuthRepository
+	tRepo    IRefreshTokenRepository
+	utRepo   IUserTokenRepository
+	cRepo    cart.ICartRepository
+	keys     *jwtHelper.KeySet
+	revoked  jwtHelper.RevocationStore
+	mailer   mail.Mailer
+	throttle *loginThrottle
+}
+
+// Tokens is the access and refresh token pair given to a signed in user
+type Tokens struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    int64
 }
 
 type Service interface {
-	SignIn(login *api.Login) (string, error)
-	SignUp(login *api.User) (string, error)
-	FillAdminData()
+	SignIn(login *api.Login, clientIP string) (*Tokens, error)
+	SignUp(login *api.User) (*Tokens, error)
+	Refresh(refreshToken string) (*Tokens, error)
+	Logout(refreshToken string) error
+	RevokeUserSessions(userID uuid.UUID) error
+	SendVerification(userID uuid.UUID) error
+	VerifyEmail(token string) error
+	RequestPasswordReset(email string) error
+	ResetPassword(token string, password string) error
+	GetRoles(userID uuid.UUID) ([]string, error)
+	SetRoles(actorID uuid.UUID, userID uuid.UUID, roles []string) ([]string, error)
+	Unlock(userID uuid.UUID) error
 }
 
-func NewAuthService(repo IAuthRepository, cRepo cart.ICartRepository, cfg *config.Config) Service {
-	return &authService{repo: repo, cRepo: cRepo, cfg: cfg}
+func NewAuthService(repo IAuthRepository, tRepo IRefreshTokenRepository, utRepo IUserTokenRepository, cRepo cart.ICartRepository, keys *jwtHelper.KeySet, revoked jwtHelper.RevocationStore, mailer mail.Mailer, attempts AttemptStore, cfg *config.Config) Service {
+	return &authService{repo: repo, tRepo: tRepo, utRepo: utRepo, cRepo: cRepo, keys: keys, revoked: revoked, mailer: mailer,
+		throttle: newLoginThrottle(attempts, cfg.LoginConfig), cfg: cfg}
 }
 
-func (a *authService) SignIn(login *api.Login) (string, error) {
+// ErrInvalidCredentials doesn't tell whether the mail or the password is wrong
+var ErrInvalidCredentials = httpErr.NewRestError(http.StatusUnauthorized, "Invalid email or password", nil)
+
+func (a *authService) SignIn(login *api.Login, clientIP string) (*Tokens, error) {
+	if err := a.throttle.Check(*login.Email, clientIP); err != nil {
+		return nil, err
+	}
 
 	//Find user by api response mail in DB
 	user, err := a.repo.GetByMail(*login.Email)
-	if err != nil {
-		return "", httpErr.NewRestError(http.StatusBadRequest, "User get err", err.Error())
-	}
-	if user == nil {
-		return "", httpErr.NewRestError(http.StatusBadRequest, "user not found", nil)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "User get err", err.Error())
 	}
 
-	// Compare user apiModel password with Encrypted user password in Database
-	if err := bcrypt.CompareHashAndPassword([]byte(*user.Password), []byte(*login.Password)); err != nil {
-		return "", httpErr.NewRestError(http.StatusBadRequest, "Wrong Password", err.Error())
+	// Compare user apiModel password with Encrypted user password in Database,
+	// unknown mails are compared too so both fail in the same time
+	hash := dummyHash()
+	if user != nil && user.Password != nil {
+		hash = []byte(*user.Password)
+	}
+	if err := bcrypt.CompareHashAndPassword(hash, []byte(*login.Password)); err != nil || user == nil {
+		if err := a.throttle.Fail(*login.Email, clientIP); err != nil {
+			return nil, httpErr.NewRestError(http.StatusInternalServerError, "Sign in attempts update error", err.Error())
+		}
+		return nil, ErrInvalidCredentials
+	}
+	if err := a.throttle.Unlock(*login.Email); err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Sign in attempts update error", err.Error())
+	}
+	if user.DeactivatedAt != nil {
+		return nil, httpErr.NewRestError(http.StatusForbidden, "Account is deactivated", nil)
 	}
 
-	//Generate token for user
-	jwtClaims := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"userId": user.ID,
-		"email":  user.Mail,
-		"iat":    time.Now().Unix(),
-		"iss":    os.Getenv("ENV"),
-		"exp":    time.Now().Add(24 * time.Hour).Unix(),
-		"roles":  user.IsAdmin,
-	})
-
-	token := jwtHelper.GenerateToken(jwtClaims, a.cfg.JWTConfig.SecretKey)
-
-	return token, nil
+	//Every sign in starts a new token family
+	return a.issueTokens(user, uuid.New())
 }
 
-func (a *authService) SignUp(login *api.User) (string, error) {
+func (a *authService) SignUp(login *api.User) (*Tokens, error) {
 
 	//Encrypt the user password
 	hashPassword, err := bcrypt.GenerateFromPassword([]byte(*login.Password), bcrypt.DefaultCost)
 	if err != nil {
-		return "", httpErr.NewRestError(http.StatusUnprocessableEntity, "encryption error", err.Error())
+		return nil, httpErr.NewRestError(http.StatusUnprocessableEntity, "encryption error", err.Error())
 	}
 	passBeforeReg := string(hashPassword)
 	login.Password = &passBeforeReg
@@ -75,51 +114,361 @@ func (a *authService) SignUp(login *api.User) (string, error) {
 	//Create  api response based user
 	createdUser, err := a.repo.Create(userApiToModel(login))
 	if err != nil {
-		return "", httpErr.NewRestError(http.StatusInternalServerError, "Can't create user", err.Error())
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Can't create user", err.Error())
 	}
 
-	//Generate token for user
-	jwtClaims := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"userId": createdUser.ID,
-		"email":  createdUser.Mail,
-		"iat":    time.Now().Unix(),
-		"iss":    os.Getenv("ENV"),
-		"exp":    time.Now().Add(24 * time.Hour).Unix(),
-		"roles":  createdUser.IsAdmin,
-	})
-	token := jwtHelper.GenerateToken(jwtClaims, a.cfg.JWTConfig.SecretKey)
-
 	//Initial, create a new cart for user
 	var cart = &models.Cart{}
 	cart.UserID = createdUser.ID
 	_, err = a.cRepo.Create(cart)
 	if err != nil {
-		return "", httpErr.NewRestError(http.StatusInternalServerError, "Can't create new cart for new user", err.Error())
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Can't create new cart for new user", err.Error())
 	}
 
-	return token, nil
+	//Unverified users can browse, a failed mail can be sent again later
+	if err := a.sendVerification(createdUser); err != nil {
+		zap.L().Error("auth.service.SignUp failed to send verification mail", zap.Error(err))
+	}
+
+	//Generate tokens for user
+	return a.issueTokens(createdUser, uuid.New())
+}
+
+// GetRoles returns the role names of the user
+func (a *authService) GetRoles(userID uuid.UUID) ([]string, error) {
+	user, err := a.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	return user.RoleNames(), nil
 }
 
-func (a *authService) FillAdminData() {
-	//Get Admin data from json file
-	admin := models.GetAdmin()
+// SetRoles replaces the roles of the user, they are used from the next token refresh on
+func (a *authService) SetRoles(actorID uuid.UUID, userID uuid.UUID, roles []string) ([]string, error) {
+	user, err := a.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
 
-	//Encrypt the admin password
-	hashPassword, _ := bcrypt.GenerateFromPassword([]byte(*admin.Password), bcrypt.DefaultCost)
-	passBeforeReg := string(hashPassword)
-	admin.Password = &passBeforeReg
+	assigned := make([]models.UserRole, 0, len(roles))
+	seen := make(map[string]bool)
+	for _, role := range roles {
+		if !mw.IsRole(role) {
+			return nil, httpErr.NewRestError(http.StatusBadRequest, "Unknown role", role)
+		}
+		if seen[role] {
+			continue
+		}
+		seen[role] = true
+		assigned = append(assigned, models.UserRole{UserID: user.ID, Role: role, AssignedBy: &actorID})
+	}
+
+	//Nobody could manage roles anymore if the last super admin could drop their own role
+	if actorID == userID && hasRole(user, mw.RoleSuperAdmin) && !seen[mw.RoleSuperAdmin] {
+		return nil, httpErr.NewRestError(http.StatusBadRequest, "You can not remove your own super-admin role", nil)
+	}
+
+	if err := a.repo.SetRoles(user.ID, assigned); err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Can't save user roles", err.Error())
+	}
+
+	user.Roles = assigned
+	return user.RoleNames(), nil
+}
+
+func (a *authService) getUser(userID uuid.UUID) (*models.User, error) {
+	user, err := a.repo.GetByID(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, httpErr.NewRestError(http.StatusNotFound, "User not found", err.Error())
+	}
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "User get err", err.Error())
+	}
+	return user, nil
+}
+
+func hasRole(user *models.User, role string) bool {
+	for _, r := range user.Roles {
+		if r.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Refresh rotates a refresh token, a token used twice revokes its whole family
+func (a *authService) Refresh(refreshToken string) (*Tokens, error) {
+	token, err := a.getRefreshToken(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	if token.RevokedAt != nil || !token.ExpiresAt.After(time.Now()) {
+		return nil, httpErr.NewRestError(http.StatusUnauthorized, "Refresh token is expired or revoked", nil)
+	}
+
+	//Only one request can use a refresh token, a second use means the token was stolen
+	unused, err := a.tRepo.MarkUsed(token.ID)
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Refresh token update error", err.Error())
+	}
+	if !unused {
+		if err := a.revokeFamily(token.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, httpErr.NewRestError(http.StatusUnauthorized, "Refresh token is already used", nil)
+	}
+
+	user, err := a.repo.GetByID(token.UserID)
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusUnauthorized, "User not found", err.Error())
+	}
+	if user.DeactivatedAt != nil {
+		return nil, httpErr.NewRestError(http.StatusForbidden, "Account is deactivated", nil)
+	}
 
-	//If admin existed ok, but doesn't exist create a new admin user
-	isNew := a.repo.CheckAndCreateAdmin(&admin)
+	return a.issueTokens(user, token.FamilyID)
+}
 
-	//If new admin created, create a cart for admin
-	if isNew {
-		var cart = &models.Cart{}
-		cart.UserID = admin.ID
-		_, err := a.cRepo.Create(cart)
+// Logout revokes the token family of the given refresh token
+func (a *authService) Logout(refreshToken string) error {
+	token, err := a.getRefreshToken(refreshToken)
+	if err != nil {
+		return err
+	}
+	return a.revokeFamily(token.FamilyID)
+}
 
-		if err != nil {
-			log.Fatal("Can't create new cart for new user")
+// RevokeUserSessions signs the user out everywhere
+func (a *authService) RevokeUserSessions(userID uuid.UUID) error {
+	families, err := a.tRepo.RevokeUser(userID)
+	if err != nil {
+		return httpErr.NewRestError(http.StatusInternalServerError, "Refresh token revoke error", err.Error())
+	}
+	until := time.Now().Add(a.sessionTime())
+	for _, familyID := range families {
+		if err := a.revoked.Revoke(familyID.String(), until); err != nil {
+			return httpErr.NewRestError(http.StatusInternalServerError, "Token revoke error", err.Error())
 		}
 	}
+	return nil
+}
+
+func (a *authService) getRefreshToken(refreshToken string) (*models.RefreshToken, error) {
+	token, err := a.tRepo.GetByHash(hashToken(refreshToken))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, httpErr.NewRestError(http.StatusUnauthorized, "Refresh token is not valid", nil)
+	}
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Get refresh token error", err.Error())
+	}
+	return token, nil
+}
+
+// revokeFamily stops refresh tokens of the family and rejects its access tokens until they expire
+func (a *authService) revokeFamily(familyID uuid.UUID) error {
+	if err := a.tRepo.RevokeFamily(familyID); err != nil {
+		return httpErr.NewRestError(http.StatusInternalServerError, "Refresh token revoke error", err.Error())
+	}
+	until := time.Now().Add(a.sessionTime())
+	if err := a.revoked.Revoke(familyID.String(), until); err != nil {
+		return httpErr.NewRestError(http.StatusInternalServerError, "Token revoke error", err.Error())
+	}
+	return nil
+}
+
+// Unlock forgets the failed sign ins of the user
+func (a *authService) Unlock(userID uuid.UUID) error {
+	user, err := a.getUser(userID)
+	if err != nil {
+		return err
+	}
+	if err := a.throttle.Unlock(*user.Mail); err != nil {
+		return httpErr.NewRestError(http.StatusInternalServerError, "Sign in attempts update error", err.Error())
+	}
+	return nil
+}
+
+// SendVerification mails a new verification link, links mailed before stop working
+func (a *authService) SendVerification(userID uuid.UUID) error {
+	user, err := a.getUser(userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return httpErr.NewRestError(http.StatusBadRequest, "Email is already verified", nil)
+	}
+	if err := a.sendVerification(user); err != nil {
+		return httpErr.NewRestError(http.StatusInternalServerError, "Verification mail error", err.Error())
+	}
+	return nil
+}
+
+// VerifyEmail verifies the mail the token was sent to, if the user still has it
+func (a *authService) VerifyEmail(token string) error {
+	userToken, err := a.useUserToken(token, models.TokenVerifyEmail)
+	if err != nil {
+		return err
+	}
+	verified, err := a.repo.MarkVerified(userToken.UserID, userToken.Mail)
+	if err != nil {
+		return httpErr.NewRestError(http.StatusInternalServerError, "User update error", err.Error())
+	}
+	if !verified {
+		return httpErr.NewRestError(http.StatusBadRequest, "Email has changed, verify the new email", nil)
+	}
+	return nil
+}
+
+// RequestPasswordReset mails a reset link, unknown mails are not reported to not reveal who has an account
+func (a *authService) RequestPasswordReset(email string) error {
+	user, err := a.repo.GetByMail(email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return httpErr.NewRestError(http.StatusInternalServerError, "User get error", err.Error())
+	}
+
+	ttl := time.Duration(a.cfg.AccountConfig.PasswordResetTTLSecs) * time.Second
+	token, err := a.issueUserToken(user, models.TokenResetPassword, ttl)
+	if err != nil {
+		return err
+	}
+	if err := a.mailer.Send(passwordResetMail(user, a.link("reset-password", token), ttl)); err != nil {
+		zap.L().Error("auth.service.RequestPasswordReset failed to send mail", zap.Error(err))
+	}
+	return nil
+}
+
+// ResetPassword sets a new password with a mailed token and signs the user out everywhere
+func (a *authService) ResetPassword(token string, password string) error {
+	userToken, err := a.useUserToken(token, models.TokenResetPassword)
+	if err != nil {
+		return err
+	}
+
+	hashPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return httpErr.NewRestError(http.StatusUnprocessableEntity, "encryption error", err.Error())
+	}
+	if err := a.repo.UpdatePassword(userToken.UserID, string(hashPassword)); err != nil {
+		return httpErr.NewRestError(http.StatusInternalServerError, "User update error", err.Error())
+	}
+	return a.RevokeUserSessions(userToken.UserID)
+}
+
+func (a *authService) sendVerification(user *models.User) error {
+	ttl := time.Duration(a.cfg.AccountConfig.VerificationTTLSecs) * time.Second
+	token, err := a.issueUserToken(user, models.TokenVerifyEmail, ttl)
+	if err != nil {
+		return err
+	}
+	return a.mailer.Send(verificationMail(user, a.link("verify-email", token), ttl))
+}
+
+// issueUserToken stores a new single use token of the purpose, open tokens of the purpose are used up
+func (a *authService) issueUserToken(user *models.User, purpose models.UserTokenPurpose, ttl time.Duration) (string, error) {
+	if err := a.utRepo.Invalidate(user.ID, purpose); err != nil {
+		return "", httpErr.NewRestError(http.StatusInternalServerError, "User token error", err.Error())
+	}
+	token, err := newOpaqueToken()
+	if err != nil {
+		return "", httpErr.NewRestError(http.StatusInternalServerError, "User token generate error", err.Error())
+	}
+	_, err = a.utRepo.Create(&models.UserToken{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		Mail:      *user.Mail,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", httpErr.NewRestError(http.StatusInternalServerError, "Can't save user token", err.Error())
+	}
+	return token, nil
+}
+
+// useUserToken marks a valid token of the purpose as used, a token works only once
+func (a *authService) useUserToken(token string, purpose models.UserTokenPurpose) (*models.UserToken, error) {
+	userToken, err := a.utRepo.GetByHash(hashToken(token))
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && userToken.Purpose != purpose) {
+		return nil, httpErr.NewRestError(http.StatusBadRequest, "Token is not valid", nil)
+	}
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "User token get error", err.Error())
+	}
+	if userToken.UsedAt != nil || time.Now().After(userToken.ExpiresAt) {
+		return nil, httpErr.NewRestError(http.StatusBadRequest, "Token is expired or already used", nil)
+	}
+
+	marked, err := a.utRepo.MarkUsed(userToken.ID)
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "User token update error", err.Error())
+	}
+	if !marked {
+		return nil, httpErr.NewRestError(http.StatusBadRequest, "Token is expired or already used", nil)
+	}
+	return userToken, nil
+}
+
+func (a *authService) link(page string, token string) string {
+	return fmt.Sprintf("%s/%s?token=%s", a.cfg.AccountConfig.LinkBaseURL, page, token)
+}
+
+// issueTokens signs a short lived access token and stores a new refresh token of the family
+func (a *authService) issueTokens(user *models.User, familyID uuid.UUID) (*Tokens, error) {
+	now := time.Now()
+	sessionTime := a.sessionTime()
+
+	accessToken, err := a.keys.Sign(jwt.MapClaims{
+		"userId": user.ID,
+		"email":  user.Mail,
+		"iat":    now.Unix(),
+		"iss":    os.Getenv("ENV"),
+		"exp":    now.Add(sessionTime).Unix(),
+		"roles":  user.RoleNames(),
+		"jti":    uuid.New().String(),
+		"fam":    familyID.String(),
+	})
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Token sign error", err.Error())
+	}
+
+	refreshToken, err := newOpaqueToken()
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Refresh token generate error", err.Error())
+	}
+	_, err = a.tRepo.Create(&models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: now.Add(time.Duration(a.cfg.JWTConfig.RefreshTime) * time.Second),
+	})
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Can't save refresh token", err.Error())
+	}
+
+	return &Tokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(sessionTime.Seconds()),
+	}, nil
+}
+
+func (a *authService) sessionTime() time.Duration {
+	return time.Duration(a.cfg.JWTConfig.SessionTime) * time.Second
+}
+
+// newOpaqueToken returns an opaque random token
+func newOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
@@ -5,10 +5,17 @@ import (
 	"github.com/gcamlicali/tradeshopExample/internal/cart"
 	"github.com/gcamlicali/tradeshopExample/internal/models"
 	"github.com/gcamlicali/tradeshopExample/pkg/config"
+	jwtHelper "github.com/gcamlicali/tradeshopExample/pkg/jwt"
+	"github.com/gcamlicali/tradeshopExample/pkg/mail"
+	mw "github.com/gcamlicali/tradeshopExample/pkg/middleware"
 	"github.com/go-openapi/errors"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 var (
@@ -29,16 +36,15 @@ var (
 	admin = models.User{
 		Mail:      &adminMail,
 		ID:        userID,
-		IsAdmin:   true,
 		Password:  &AdminPassStrEnc,
 		FirstName: &adminName,
 		LastName:  &adminLast,
 		Mobile:    "333111",
+		Roles:     []models.UserRole{{UserID: userID, Role: mw.RoleSuperAdmin}},
 	}
 	user = models.User{
 		Mail:      &userMail,
 		Password:  &userPass,
-		IsAdmin:   false,
 		Mobile:    "2222444",
 		FirstName: &userName,
 		LastName:  &userLast,
@@ -48,9 +54,18 @@ var (
 		JWTConfig: config.JWTConfig{
 			SecretKey:   "Test",
 			SessionTime: 30,
+			RefreshTime: 60,
+		},
+		AccountConfig: config.AccountConfig{
+			VerificationTTLSecs:  60,
+			PasswordResetTTLSecs: 60,
+			LinkBaseURL:          "http://shop.test",
 		},
 	}
 
+	testKeys, _ = jwtHelper.NewKeySetFromConfig(conf.JWTConfig)
+	testIP      = "10.0.0.1"
+
 	logInUser = api.Login{
 		Password: &adminPass,
 		Email:    &adminMail,
@@ -66,7 +81,6 @@ var (
 	SignUpUser = api.User{
 		Email:     &userMail,
 		Password:  &userPass,
-		IsAdmin:   false,
 		Phone:     "2222444",
 		FirstName: &userName,
 		LastName:  &userLast,
@@ -147,11 +161,15 @@ func Test_authService_SignIn(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			a := &authService{
-				cfg:   tt.fields.cfg,
-				repo:  tt.fields.repo,
-				cRepo: tt.fields.cRepo,
+				cfg:      tt.fields.cfg,
+				repo:     tt.fields.repo,
+				tRepo:    &refreshTokenMockRepo{},
+				cRepo:    tt.fields.cRepo,
+				keys:     testKeys,
+				revoked:  jwtHelper.NewMemoryRevocationStore(),
+				throttle: newLoginThrottle(NewMemoryAttemptStore(), conf.LoginConfig),
 			}
-			_, err := a.SignIn(tt.args.login)
+			_, err := a.SignIn(tt.args.login, testIP)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("SignIn() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -215,20 +233,464 @@ func Test_authService_SignUp(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			mailer := mail.NewMemoryMailer()
 			a := &authService{
-				cfg:   tt.fields.cfg,
-				repo:  tt.fields.repo,
-				cRepo: tt.fields.cRepo,
+				cfg:      tt.fields.cfg,
+				repo:     tt.fields.repo,
+				tRepo:    &refreshTokenMockRepo{},
+				utRepo:   &userTokenMockRepo{},
+				cRepo:    tt.fields.cRepo,
+				keys:     testKeys,
+				revoked:  jwtHelper.NewMemoryRevocationStore(),
+				throttle: newLoginThrottle(NewMemoryAttemptStore(), conf.LoginConfig),
+				mailer:   mailer,
 			}
 			_, err := a.SignUp(tt.args.login)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("SignUp() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
+			if !tt.wantErr && len(mailer.Sent(*tt.args.login.Email)) != 1 {
+				t.Errorf("SignUp() verification mail is not sent")
+			}
+		})
+	}
+}
+
+func Test_authService_Refresh(t *testing.T) {
+	newService := func() (*authService, *refreshTokenMockRepo, *Tokens) {
+		a := &authService{
+			cfg:      &conf,
+			repo:     &authMockRepo{Items: []models.User{admin}},
+			tRepo:    &refreshTokenMockRepo{},
+			cRepo:    &cartMockRepo{Items: []models.Cart{}},
+			keys:     testKeys,
+			revoked:  jwtHelper.NewMemoryRevocationStore(),
+			throttle: newLoginThrottle(NewMemoryAttemptStore(), conf.LoginConfig),
+		}
+		tokens, err := a.SignIn(&logInUser, testIP)
+		if err != nil {
+			t.Fatalf("SignIn() error = %v", err)
+		}
+		return a, a.tRepo.(*refreshTokenMockRepo), tokens
+	}
+
+	t.Run("authService_Refresh_ShouldSuccess", func(t *testing.T) {
+		a, _, tokens := newService()
+		got, err := a.Refresh(tokens.RefreshToken)
+		if err != nil {
+			t.Fatalf("Refresh() error = %v", err)
+		}
+		if got.RefreshToken == tokens.RefreshToken || got.AccessToken == "" {
+			t.Errorf("Refresh() did not rotate the refresh token")
+		}
+		decoded, err := testKeys.Verify(got.AccessToken)
+		if err != nil || decoded.UserId != admin.ID {
+			t.Errorf("Refresh() access token = %v, err = %v", decoded, err)
+		}
+	})
+
+	t.Run("authService_Refresh_ErrorReusedToken_ShouldRevokeFamily", func(t *testing.T) {
+		a, tRepo, tokens := newService()
+		rotated, err := a.Refresh(tokens.RefreshToken)
+		if err != nil {
+			t.Fatalf("Refresh() error = %v", err)
+		}
+		if _, err := a.Refresh(tokens.RefreshToken); err == nil {
+			t.Fatalf("Refresh() reused token accepted")
+		}
+		if _, err := a.Refresh(rotated.RefreshToken); err == nil {
+			t.Errorf("Refresh() token of a revoked family accepted")
+		}
+		decoded, _ := testKeys.Verify(rotated.AccessToken)
+		if revoked, _ := a.revoked.IsRevoked(decoded.FamilyID); !revoked {
+			t.Errorf("Refresh() access tokens of the family are not revoked")
+		}
+		for _, item := range tRepo.Items {
+			if item.RevokedAt == nil {
+				t.Errorf("Refresh() refresh token %v is not revoked", item.ID)
+			}
+		}
+	})
+
+	t.Run("authService_Refresh_ErrorExpiredToken_ShouldFail", func(t *testing.T) {
+		a, tRepo, tokens := newService()
+		tRepo.Items[0].ExpiresAt = time.Now().Add(-time.Minute)
+		if _, err := a.Refresh(tokens.RefreshToken); err == nil {
+			t.Errorf("Refresh() expired token accepted")
+		}
+	})
+
+	t.Run("authService_Refresh_ErrorUnknownToken_ShouldFail", func(t *testing.T) {
+		a, _, _ := newService()
+		if _, err := a.Refresh("unknown"); err == nil {
+			t.Errorf("Refresh() unknown token accepted")
+		}
+	})
+}
+
+func Test_authService_Logout(t *testing.T) {
+	a := &authService{
+		cfg:      &conf,
+		repo:     &authMockRepo{Items: []models.User{admin}},
+		tRepo:    &refreshTokenMockRepo{},
+		cRepo:    &cartMockRepo{Items: []models.Cart{}},
+		keys:     testKeys,
+		revoked:  jwtHelper.NewMemoryRevocationStore(),
+		throttle: newLoginThrottle(NewMemoryAttemptStore(), conf.LoginConfig),
+	}
+	tokens, err := a.SignIn(&logInUser, testIP)
+	if err != nil {
+		t.Fatalf("SignIn() error = %v", err)
+	}
+
+	if err := a.Logout(tokens.RefreshToken); err != nil {
+		t.Fatalf("Logout() error = %v", err)
+	}
+	decoded, _ := testKeys.Verify(tokens.AccessToken)
+	if revoked, _ := a.revoked.IsRevoked(decoded.FamilyID); !revoked {
+		t.Errorf("Logout() access token is not revoked")
+	}
+	if _, err := a.Refresh(tokens.RefreshToken); err == nil {
+		t.Errorf("Logout() refresh token still works")
+	}
+}
+
+func Test_authService_SetRoles(t *testing.T) {
+	customerID := uuid.New()
+	customer := models.User{ID: customerID, Mail: &userMail, Password: &AdminPassStrEnc}
+	tests := []struct {
+		name    string
+		actorID uuid.UUID
+		userID  uuid.UUID
+		roles   []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "authService_SetRoles_ShouldSuccess",
+			actorID: userID,
+			userID:  customerID,
+			roles:   []string{mw.RoleCatalogManager, mw.RoleOrderSupport, mw.RoleCatalogManager},
+			want:    []string{mw.RoleCatalogManager, mw.RoleOrderSupport},
+			wantErr: false,
+		},
+		{
+			name:    "authService_SetRoles_RemoveAllRoles_ShouldSuccess",
+			actorID: userID,
+			userID:  customerID,
+			roles:   []string{},
+			want:    []string{},
+			wantErr: false,
+		},
+		{
+			name:    "authService_SetRoles_ErrorUnknownRole_ShouldFail",
+			actorID: userID,
+			userID:  customerID,
+			roles:   []string{"owner"},
+			wantErr: true,
+		},
+		{
+			name:    "authService_SetRoles_ErrorRemoveOwnSuperAdmin_ShouldFail",
+			actorID: userID,
+			userID:  userID,
+			roles:   []string{mw.RoleCatalogManager},
+			wantErr: true,
+		},
+		{
+			name:    "authService_SetRoles_ErrorUserNotFound_ShouldFail",
+			actorID: userID,
+			userID:  uuid.New(),
+			roles:   []string{mw.RoleCatalogManager},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &authMockRepo{Items: []models.User{admin, customer}}
+			a := &authService{cfg: &conf, repo: repo, keys: testKeys}
+			got, err := a.SetRoles(tt.actorID, tt.userID, tt.roles)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SetRoles() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SetRoles() = %v, want %v", got, tt.want)
+			}
+			saved, _ := a.GetRoles(tt.userID)
+			if !reflect.DeepEqual(saved, tt.want) {
+				t.Errorf("GetRoles() = %v, want %v", saved, tt.want)
+			}
 		})
 	}
 }
 
+func Test_authService_SignIn_TokenCarriesRoles(t *testing.T) {
+	a := &authService{
+		cfg:      &conf,
+		repo:     &authMockRepo{Items: []models.User{admin}},
+		tRepo:    &refreshTokenMockRepo{},
+		keys:     testKeys,
+		revoked:  jwtHelper.NewMemoryRevocationStore(),
+		throttle: newLoginThrottle(NewMemoryAttemptStore(), conf.LoginConfig),
+	}
+	tokens, err := a.SignIn(&logInUser, testIP)
+	if err != nil {
+		t.Fatalf("SignIn() error = %v", err)
+	}
+	decoded, err := testKeys.Verify(tokens.AccessToken)
+	if err != nil || !reflect.DeepEqual(decoded.Roles, []string{mw.RoleSuperAdmin}) {
+		t.Errorf("SignIn() token roles = %v, err = %v", decoded, err)
+	}
+}
+
+func Test_authService_RevokeUserSessions(t *testing.T) {
+	a := &authService{
+		cfg:      &conf,
+		repo:     &authMockRepo{Items: []models.User{admin}},
+		tRepo:    &refreshTokenMockRepo{},
+		cRepo:    &cartMockRepo{Items: []models.Cart{}},
+		keys:     testKeys,
+		revoked:  jwtHelper.NewMemoryRevocationStore(),
+		throttle: newLoginThrottle(NewMemoryAttemptStore(), conf.LoginConfig),
+	}
+	tokens, err := a.SignIn(&logInUser, testIP)
+	if err != nil {
+		t.Fatalf("SignIn() error = %v", err)
+	}
+
+	if err := a.RevokeUserSessions(admin.ID); err != nil {
+		t.Fatalf("RevokeUserSessions() error = %v", err)
+	}
+	decoded, _ := testKeys.Verify(tokens.AccessToken)
+	if revoked, _ := a.revoked.IsRevoked(decoded.FamilyID); !revoked {
+		t.Errorf("RevokeUserSessions() access token is not revoked")
+	}
+	if _, err := a.Refresh(tokens.RefreshToken); err == nil {
+		t.Errorf("RevokeUserSessions() refresh token still works")
+	}
+}
+
+func Test_authService_SignIn_Deactivated(t *testing.T) {
+	deactivated := admin
+	now := time.Now()
+	deactivated.DeactivatedAt = &now
+	a := &authService{
+		cfg:      &conf,
+		repo:     &authMockRepo{Items: []models.User{deactivated}},
+		tRepo:    &refreshTokenMockRepo{},
+		keys:     testKeys,
+		revoked:  jwtHelper.NewMemoryRevocationStore(),
+		throttle: newLoginThrottle(NewMemoryAttemptStore(), conf.LoginConfig),
+	}
+	if _, err := a.SignIn(&logInUser, testIP); err == nil {
+		t.Errorf("SignIn() accepted a deactivated user")
+	}
+}
+
+func newAccountService(users ...models.User) (*authService, *authMockRepo, *mail.MemoryMailer) {
+	repo := &authMockRepo{Items: users}
+	mailer := mail.NewMemoryMailer()
+	return &authService{
+		cfg:      &conf,
+		repo:     repo,
+		tRepo:    &refreshTokenMockRepo{},
+		utRepo:   &userTokenMockRepo{},
+		cRepo:    &cartMockRepo{Items: []models.Cart{}},
+		keys:     testKeys,
+		revoked:  jwtHelper.NewMemoryRevocationStore(),
+		throttle: newLoginThrottle(NewMemoryAttemptStore(), conf.LoginConfig),
+		mailer:   mailer,
+	}, repo, mailer
+}
+
+// mailedToken returns the token of the last link mailed to the address
+func mailedToken(t *testing.T, mailer *mail.MemoryMailer, to string) string {
+	sent := mailer.Sent(to)
+	if len(sent) == 0 {
+		t.Fatalf("no mail sent to %s", to)
+	}
+	body := sent[len(sent)-1].Body
+	start := strings.Index(body, "token=")
+	if start < 0 {
+		t.Fatalf("mail has no token link: %s", body)
+	}
+	token := body[start+len("token="):]
+	return token[:strings.IndexByte(token, '\n')]
+}
+
+func Test_authService_VerifyEmail(t *testing.T) {
+	unverified := admin
+	a, repo, mailer := newAccountService(unverified)
+
+	if err := a.SendVerification(admin.ID); err != nil {
+		t.Fatalf("SendVerification() error = %v", err)
+	}
+	first := mailedToken(t, mailer, adminMail)
+	if err := a.SendVerification(admin.ID); err != nil {
+		t.Fatalf("SendVerification() error = %v", err)
+	}
+	token := mailedToken(t, mailer, adminMail)
+
+	if err := a.VerifyEmail(first); err == nil {
+		t.Errorf("VerifyEmail() accepted a token replaced by a newer mail")
+	}
+	if err := a.VerifyEmail("unknown"); err == nil {
+		t.Errorf("VerifyEmail() accepted an unknown token")
+	}
+	if err := a.VerifyEmail(token); err != nil {
+		t.Fatalf("VerifyEmail() error = %v", err)
+	}
+	if repo.Items[0].EmailVerifiedAt == nil {
+		t.Errorf("VerifyEmail() user is not verified")
+	}
+	if err := a.VerifyEmail(token); err == nil {
+		t.Errorf("VerifyEmail() accepted a used token")
+	}
+	if err := a.SendVerification(admin.ID); err == nil {
+		t.Errorf("SendVerification() sent a mail to a verified user")
+	}
+}
+
+func Test_authService_VerifyEmail_MailChanged(t *testing.T) {
+	a, repo, mailer := newAccountService(admin)
+	a.SendVerification(admin.ID)
+	token := mailedToken(t, mailer, adminMail)
+
+	changed := "changed@admin.com"
+	repo.Items[0].Mail = &changed
+	if err := a.VerifyEmail(token); err == nil {
+		t.Errorf("VerifyEmail() verified a mail the user doesn't have anymore")
+	}
+}
+
+func Test_authService_ResetPassword(t *testing.T) {
+	a, _, mailer := newAccountService(admin)
+	tokens, _ := a.SignIn(&logInUser, testIP)
+
+	if err := a.RequestPasswordReset("nobody@admin.com"); err != nil {
+		t.Errorf("RequestPasswordReset() error = %v for an unknown mail", err)
+	}
+	if len(mailer.Sent("nobody@admin.com")) != 0 {
+		t.Errorf("RequestPasswordReset() mailed an unknown address")
+	}
+
+	if err := a.RequestPasswordReset(adminMail); err != nil {
+		t.Fatalf("RequestPasswordReset() error = %v", err)
+	}
+	token := mailedToken(t, mailer, adminMail)
+	if err := a.VerifyEmail(token); err == nil {
+		t.Errorf("VerifyEmail() accepted a password reset token")
+	}
+
+	// The failed verify must not use up the reset token
+	newPass := "newPass"
+	if err := a.ResetPassword(token, newPass); err != nil {
+		t.Fatalf("ResetPassword() error = %v", err)
+	}
+	if err := a.ResetPassword(token, "otherPass"); err == nil {
+		t.Errorf("ResetPassword() accepted a used token")
+	}
+	if _, err := a.Refresh(tokens.RefreshToken); err == nil {
+		t.Errorf("ResetPassword() old session still works")
+	}
+	if _, err := a.SignIn(&api.Login{Email: &adminMail, Password: &newPass}, testIP); err != nil {
+		t.Errorf("SignIn() with the new password error = %v", err)
+	}
+}
+
+func Test_authService_ResetPassword_Expired(t *testing.T) {
+	a, _, mailer := newAccountService(admin)
+	a.RequestPasswordReset(adminMail)
+	token := mailedToken(t, mailer, adminMail)
+
+	utRepo := a.utRepo.(*userTokenMockRepo)
+	utRepo.Items[0].ExpiresAt = time.Now().Add(-time.Second)
+	if err := a.ResetPassword(token, "newPass"); err == nil {
+		t.Errorf("ResetPassword() accepted an expired token")
+	}
+}
+
+func Test_authService_SignIn_GenericCredentialError(t *testing.T) {
+	for _, login := range []api.Login{logInUserU, logInUserW} {
+		a, _, _ := newAccountService(admin)
+		_, err := a.SignIn(&login, testIP)
+		if err != ErrInvalidCredentials {
+			t.Errorf("SignIn() error = %v, want %v", err, ErrInvalidCredentials)
+		}
+	}
+}
+
+func Test_authService_SignIn_Lockout(t *testing.T) {
+	a, _, _ := newAccountService(admin)
+	store := NewMemoryAttemptStore()
+	a.throttle = newLoginThrottle(store, conf.LoginConfig)
+
+	if _, err := a.SignIn(&logInUserW, testIP); err != ErrInvalidCredentials {
+		t.Fatalf("SignIn() error = %v, want %v", err, ErrInvalidCredentials)
+	}
+	if _, err := a.SignIn(&logInUser, testIP); err == nil {
+		t.Errorf("SignIn() accepted a sign in during the backoff")
+	}
+
+	store.Lock(accountKey(adminMail), time.Now().Add(time.Hour))
+	if _, err := a.SignIn(&logInUser, "10.0.0.2"); err == nil {
+		t.Errorf("SignIn() accepted a locked account")
+	}
+
+	if err := a.Unlock(admin.ID); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+	if _, err := a.SignIn(&logInUser, "10.0.0.2"); err != nil {
+		t.Errorf("SignIn() error = %v after unlock", err)
+	}
+}
+
+type userTokenMockRepo struct {
+	Items []models.UserToken
+}
+
+func (r *userTokenMockRepo) Create(a *models.UserToken) (*models.UserToken, error) {
+	a.ID = uuid.New()
+	r.Items = append(r.Items, *a)
+	return a, nil
+}
+func (r *userTokenMockRepo) GetByHash(hash string) (*models.UserToken, error) {
+	for _, item := range r.Items {
+		if item.TokenHash == hash {
+			token := item
+			return &token, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (r *userTokenMockRepo) MarkUsed(id uuid.UUID) (bool, error) {
+	for i, item := range r.Items {
+		if item.ID == id && item.UsedAt == nil {
+			now := time.Now()
+			r.Items[i].UsedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+func (r *userTokenMockRepo) Invalidate(userID uuid.UUID, purpose models.UserTokenPurpose) error {
+	for i, item := range r.Items {
+		if item.UserID == userID && item.Purpose == purpose && item.UsedAt == nil {
+			now := time.Now()
+			r.Items[i].UsedAt = &now
+		}
+	}
+	return nil
+}
+
+type refreshTokenMockRepo struct {
+	Items []models.RefreshToken
+}
+
 type cartMockRepo struct {
 	Items []models.Cart
 }
@@ -277,16 +739,86 @@ func (c *authMockRepo) GetByMail(mail string) (*models.User, error) {
 			return &user, nil
 		}
 	}
-	return nil, errors.New(400, "User not found")
+	return nil, gorm.ErrRecordNotFound
 }
-func (c *authMockRepo) CheckAndCreateAdmin(user *models.User) bool {
-	admin := models.User{}
+func (c *authMockRepo) GetByID(id uuid.UUID) (*models.User, error) {
 	for _, item := range c.Items {
-		if item.Mail == admin.Mail {
-			return true
+		if item.ID == id {
+			user := item
+			return &user, nil
+		}
+	}
+	return nil, errors.New(400, "User not found")
+}
+func (c *authMockRepo) SetRoles(userID uuid.UUID, roles []models.UserRole) error {
+	for i, item := range c.Items {
+		if item.ID == userID {
+			c.Items[i].Roles = roles
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+func (c *authMockRepo) MarkVerified(userID uuid.UUID, mail string) (bool, error) {
+	for i, item := range c.Items {
+		if item.ID == userID && *item.Mail == mail {
+			now := time.Now()
+			c.Items[i].EmailVerifiedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+func (c *authMockRepo) UpdatePassword(userID uuid.UUID, password string) error {
+	for i, item := range c.Items {
+		if item.ID == userID {
+			c.Items[i].Password = &password
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+func (r *refreshTokenMockRepo) Create(a *models.RefreshToken) (*models.RefreshToken, error) {
+	a.ID = uuid.New()
+	r.Items = append(r.Items, *a)
+	return a, nil
+}
+func (r *refreshTokenMockRepo) GetByHash(hash string) (*models.RefreshToken, error) {
+	for _, item := range r.Items {
+		if item.TokenHash == hash {
+			token := item
+			return &token, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (r *refreshTokenMockRepo) MarkUsed(id uuid.UUID) (bool, error) {
+	for i, item := range r.Items {
+		if item.ID == id && item.UsedAt == nil {
+			now := time.Now()
+			r.Items[i].UsedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+func (r *refreshTokenMockRepo) RevokeFamily(familyID uuid.UUID) error {
+	for i, item := range r.Items {
+		if item.FamilyID == familyID && item.RevokedAt == nil {
+			now := time.Now()
+			r.Items[i].RevokedAt = &now
+		}
+	}
+	return nil
+}
+func (r *refreshTokenMockRepo) RevokeUser(userID uuid.UUID) ([]uuid.UUID, error) {
+	families := []uuid.UUID{}
+	for i, item := range r.Items {
+		if item.UserID == userID && item.RevokedAt == nil {
+			now := time.Now()
+			r.Items[i].RevokedAt = &now
+			families = append(families, item.FamilyID)
 		}
 	}
-	c.Create(user)
-	c.Items = append(c.Items, *user)
-	return false
+	return families, nil
 }
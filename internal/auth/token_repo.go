@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type RefreshTokenRepositoy struct {
+	db *gorm.DB
+}
+
+type IRefreshTokenRepository interface {
+	Create(a *models.RefreshToken) (*models.RefreshToken, error)
+	GetByHash(hash string) (*models.RefreshToken, error)
+	MarkUsed(id uuid.UUID) (bool, error)
+	RevokeFamily(familyID uuid.UUID) error
+	RevokeUser(userID uuid.UUID) ([]uuid.UUID, error)
+}
+
+func NewRefreshTokenRepository(db *gorm.DB) *RefreshTokenRepositoy {
+	return &RefreshTokenRepositoy{db: db}
+}
+
+func (r *RefreshTokenRepositoy) Create(a *models.RefreshToken) (*models.RefreshToken, error) {
+	zap.L().Debug("refreshToken.repo.create", zap.Reflect("userID", a.UserID))
+	if err := r.db.Create(a).Error; err != nil {
+		zap.L().Error("refreshToken.repo.Create failed to create refresh token", zap.Error(err))
+		return nil, err
+	}
+	return a, nil
+}
+
+func (r *RefreshTokenRepositoy) GetByHash(hash string) (*models.RefreshToken, error) {
+	zap.L().Debug("refreshToken.repo.getByHash")
+	var token models.RefreshToken
+	if err := r.db.Where(&models.RefreshToken{TokenHash: hash}).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkUsed marks an unused token as used, false means the token was already used before
+func (r *RefreshTokenRepositoy) MarkUsed(id uuid.UUID) (bool, error) {
+	zap.L().Debug("refreshToken.repo.markUsed", zap.Reflect("id", id))
+	result := r.db.Model(&models.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		UpdateColumn("used_at", time.Now())
+	if result.Error != nil {
+		zap.L().Error("refreshToken.repo.MarkUsed failed to update refresh token", zap.Error(result.Error))
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *RefreshTokenRepositoy) RevokeFamily(familyID uuid.UUID) error {
+	zap.L().Debug("refreshToken.repo.revokeFamily", zap.Reflect("familyID", familyID))
+	err := r.db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		UpdateColumn("revoked_at", time.Now()).Error
+	if err != nil {
+		zap.L().Error("refreshToken.repo.RevokeFamily failed to revoke refresh tokens", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// RevokeUser revokes every refresh token of the user and returns the revoked families
+func (r *RefreshTokenRepositoy) RevokeUser(userID uuid.UUID) ([]uuid.UUID, error) {
+	zap.L().Debug("refreshToken.repo.revokeUser", zap.Reflect("userID", userID))
+	var families []uuid.UUID
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Distinct().
+			Pluck("family_id", &families).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			UpdateColumn("revoked_at", time.Now()).Error
+	})
+	if err != nil {
+		zap.L().Error("refreshToken.repo.RevokeUser failed to revoke refresh tokens", zap.Error(err))
+		return nil, err
+	}
+	return families, nil
+}
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type UserTokenRepositoy struct {
+	db *gorm.DB
+}
+
+type IUserTokenRepository interface {
+	Create(a *models.UserToken) (*models.UserToken, error)
+	GetByHash(hash string) (*models.UserToken, error)
+	MarkUsed(id uuid.UUID) (bool, error)
+	Invalidate(userID uuid.UUID, purpose models.UserTokenPurpose) error
+}
+
+func NewUserTokenRepository(db *gorm.DB) *UserTokenRepositoy {
+	return &UserTokenRepositoy{db: db}
+}
+
+func (r *UserTokenRepositoy) Create(a *models.UserToken) (*models.UserToken, error) {
+	zap.L().Debug("userToken.repo.create", zap.Reflect("userID", a.UserID), zap.Reflect("purpose", a.Purpose))
+	if err := r.db.Create(a).Error; err != nil {
+		zap.L().Error("userToken.repo.Create failed to create user token", zap.Error(err))
+		return nil, err
+	}
+	return a, nil
+}
+
+func (r *UserTokenRepositoy) GetByHash(hash string) (*models.UserToken, error) {
+	zap.L().Debug("userToken.repo.getByHash")
+	var token models.UserToken
+	if err := r.db.Where(&models.UserToken{TokenHash: hash}).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkUsed marks an unused token as used, false means the token was already used before
+func (r *UserTokenRepositoy) MarkUsed(id uuid.UUID) (bool, error) {
+	zap.L().Debug("userToken.repo.markUsed", zap.Reflect("id", id))
+	result := r.db.Model(&models.UserToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		UpdateColumn("used_at", time.Now())
+	if result.Error != nil {
+		zap.L().Error("userToken.repo.MarkUsed failed to update user token", zap.Error(result.Error))
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Invalidate uses up every open token of the purpose, only the latest mailed token works
+func (r *UserTokenRepositoy) Invalidate(userID uuid.UUID, purpose models.UserTokenPurpose) error {
+	zap.L().Debug("userToken.repo.invalidate", zap.Reflect("userID", userID), zap.Reflect("purpose", purpose))
+	err := r.db.Model(&models.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		UpdateColumn("used_at", time.Now()).Error
+	if err != nil {
+		zap.L().Error("userToken.repo.Invalidate failed to update user tokens", zap.Error(err))
+		return err
+	}
+	return nil
+}
@@ -18,6 +18,8 @@ func NewCartHandler(r *gin.RouterGroup, service Service) {
 	h := &cartHandler{service: service}
 
 	r.GET("/", h.get)
+	r.POST("/coupon", h.applyCoupon)
+	r.DELETE("/coupon", h.removeCoupon)
 	r.POST("/:SKU", h.add)
 	r.PUT("/:SKU", h.update)
 	r.DELETE("/:SKU", h.delete)
@@ -119,3 +121,37 @@ func (ch *cartHandler) delete(c *gin.Context) {
 
 	c.JSON(http.StatusOK, CartToResponse(cart))
 }
+
+func (ch *cartHandler) applyCoupon(c *gin.Context) {
+	userID := c.MustGet("userId").(uuid.UUID)
+
+	req := api.Coupon{}
+	if err := c.Bind(&req); err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "check your request body", err.Error())))
+		return
+	}
+	if err := req.Validate(strfmt.NewFormats()); err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	cart, err := ch.service.ApplyCoupon(userID, *req.Code)
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, CartToResponse(cart))
+}
+
+func (ch *cartHandler) removeCoupon(c *gin.Context) {
+	userID := c.MustGet("userId").(uuid.UUID)
+
+	cart, err := ch.service.RemoveCoupon(userID)
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, CartToResponse(cart))
+}
@@ -69,7 +69,3 @@ func (r *CartRepositoy) Update(a *models.Cart) (*models.Cart, error) {
 
 	return a, nil
 }
-
-func (r *CartRepositoy) Migration() {
-	r.db.AutoMigrate(&models.Cart{})
-}
@@ -4,6 +4,9 @@ import (
 	"github.com/gcamlicali/tradeshopExample/internal/api"
 	"github.com/gcamlicali/tradeshopExample/internal/cart_item"
 	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/gcamlicali/tradeshopExample/internal/product"
+	"github.com/gcamlicali/tradeshopExample/internal/promotion"
+	"github.com/gcamlicali/tradeshopExample/internal/tax"
 )
 
 func CartToResponse(a *models.Cart) *api.Cart {
@@ -13,8 +16,13 @@ func CartToResponse(a *models.Cart) *api.Cart {
 		items = append(items, cart_item.CartItemtoResponse(&a.CartItems[i]))
 	}
 	return &api.Cart{
-		ID:         a.ID.String(),
-		CartItems:  items,
-		TotalPrice: int32(a.TotalPrice),
+		ID:            a.ID.String(),
+		CartItems:     items,
+		Discounts:     promotion.DiscountsToResponse(a.Discounts),
+		DiscountTotal: product.MoneyToResponse(a.DiscountTotal),
+		Subtotal:      product.MoneyToResponse(a.Subtotal),
+		TaxLines:      tax.TaxLinesToResponse(a.TaxLines),
+		TaxTotal:      product.MoneyToResponse(a.TaxTotal),
+		TotalPrice:    product.MoneyToResponse(a.TotalPrice),
 	}
 }
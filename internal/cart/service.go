@@ -5,15 +5,24 @@ import (
 	httpErr "github.com/gcamlicali/tradeshopExample/internal/httpErrors"
 	"github.com/gcamlicali/tradeshopExample/internal/models"
 	"github.com/gcamlicali/tradeshopExample/internal/product"
+	"github.com/gcamlicali/tradeshopExample/internal/promotion"
+	"github.com/gcamlicali/tradeshopExample/internal/reservation"
+	"github.com/gcamlicali/tradeshopExample/internal/tax"
+	"github.com/gcamlicali/tradeshopExample/pkg/money"
 	"github.com/google/uuid"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 	"net/http"
+	"time"
 )
 
 type cartService struct {
 	crepo  ICartRepository
 	cirepo cart_item.ICartItemRepository
 	prepo  product.IProductRepository
+	holds  reservation.Service
+	taxes  tax.Calculator
+	promos promotion.Discounter
 }
 
 type Service interface {
@@ -21,10 +30,13 @@ type Service interface {
 	Add(userID uuid.UUID, ProductID int) (*models.Cart, error)
 	Update(userID uuid.UUID, ProductID int, Quantity int) (*models.Cart, error)
 	Delete(userID uuid.UUID, ProductID int) (*models.Cart, error)
+	ApplyCoupon(userID uuid.UUID, code string) (*models.Cart, error)
+	RemoveCoupon(userID uuid.UUID) (*models.Cart, error)
 }
 
-func NewCartService(crepo ICartRepository, cirepo cart_item.ICartItemRepository, prepo product.IProductRepository) Service {
-	return &cartService{crepo: crepo, cirepo: cirepo, prepo: prepo}
+// NewCartService creates cart service, holds is nil when stock reservation mode is disabled
+func NewCartService(crepo ICartRepository, cirepo cart_item.ICartItemRepository, prepo product.IProductRepository, holds reservation.Service, taxes tax.Calculator, promos promotion.Discounter) Service {
+	return &cartService{crepo: crepo, cirepo: cirepo, prepo: prepo, holds: holds, taxes: taxes, promos: promos}
 }
 
 //Get all items from cart and list
@@ -35,7 +47,7 @@ func (c *cartService) Get(userID uuid.UUID) (*models.Cart, error) {
 		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Cart get error", err.Error())
 	}
 
-	return cart, nil
+	return c.priced(cart)
 }
 
 //Add item to cart
@@ -64,14 +76,21 @@ func (c *cartService) Add(userID uuid.UUID, ProductSKU int) (*models.Cart, error
 	// If item exists in cart, increase item quantity by 1
 	if cartItem != nil {
 
+		if err := c.hold(cart.ID, ProductSKU, cartItem.Quantity+1); err != nil {
+			return nil, err
+		}
+
 		cartItem.Quantity = cartItem.Quantity + 1
-		cartItem.Price = cartItem.Quantity * product.Price
+		cartItem.Price = product.Price.Mul(int64(cartItem.Quantity))
 		_, err = c.cirepo.Update(cartItem)
 		if err != nil {
+			c.undoHold(cart.ID, ProductSKU, cartItem.Quantity-1)
 			return nil, httpErr.NewRestError(http.StatusInternalServerError, "Cart Item update error", err.Error())
 		}
 
-		cart.TotalPrice = c.calculateCartPrice(cart)
+		if cart.TotalPrice, err = c.calculateCartPrice(cart); err != nil {
+			return nil, err
+		}
 
 		_, err := c.crepo.Update(cart)
 		if err != nil {
@@ -83,10 +102,16 @@ func (c *cartService) Add(userID uuid.UUID, ProductSKU int) (*models.Cart, error
 			return nil, httpErr.NewRestError(http.StatusInternalServerError, "Get Cart error", err.Error())
 		}
 
-		return newCart, nil
+		return c.priced(newCart)
 
 	} else {
 		// If item does not exist in cart, create new item
+		if err := c.checkCurrency(cart, product); err != nil {
+			return nil, err
+		}
+		if err := c.hold(cart.ID, ProductSKU, 1); err != nil {
+			return nil, err
+		}
 
 		newCartItem := models.CartItem{
 			Quantity:   1,
@@ -97,23 +122,31 @@ func (c *cartService) Add(userID uuid.UUID, ProductSKU int) (*models.Cart, error
 
 		addItem, err := c.cirepo.Crate(&newCartItem)
 		if err != nil {
+			c.undoHold(cart.ID, ProductSKU, 0)
 			return nil, httpErr.NewRestError(http.StatusInternalServerError, "Cart Item crate error", err.Error())
 		}
 
 		cart.CartItems = append(cart.CartItems, *addItem)
-		cart.TotalPrice = c.calculateCartPrice(cart) + newCartItem.Price
+		if cart.TotalPrice, err = c.calculateCartPrice(cart, *addItem); err != nil {
+			return nil, err
+		}
 
 		newCart, err := c.crepo.Update(cart)
 		if err != nil {
 			return nil, httpErr.NewRestError(http.StatusInternalServerError, "Cart update error", err.Error())
 		}
 
-		return newCart, nil
+		return c.priced(newCart)
 	}
 }
 
 //Update quantity of given cart item
 func (c *cartService) Update(userID uuid.UUID, ProductSKU int, Quantity int) (*models.Cart, error) {
+	//A zero or negative quantity would give stock back at checkout, the item is deleted instead
+	if Quantity < 1 {
+		return nil, httpErr.NewRestError(http.StatusBadRequest, "Quantity must be at least 1", Quantity)
+	}
+
 	// Get user cart
 	cart, err := c.crepo.GetByUserID(userID)
 	if err != nil {
@@ -126,18 +159,29 @@ func (c *cartService) Update(userID uuid.UUID, ProductSKU int, Quantity int) (*m
 		return nil, httpErr.NewRestError(http.StatusBadRequest, "Product not found", err.Error())
 	}
 
-	productPrice := cartItem.Price / cartItem.Quantity
+	product, err := c.prepo.GetBySKU(ProductSKU)
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusBadRequest, "Product not found", err.Error())
+	}
+
+	if err := c.hold(cart.ID, ProductSKU, Quantity); err != nil {
+		return nil, err
+	}
 
 	// Duzelt Quantity control
+	previous := cartItem.Quantity
 	cartItem.Quantity = Quantity
-	cartItem.Price = Quantity * productPrice
+	cartItem.Price = product.Price.Mul(int64(Quantity))
 	_, err = c.cirepo.Update(cartItem)
 	if err != nil {
+		c.undoHold(cart.ID, ProductSKU, previous)
 		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Cart Item update error", err.Error())
 	}
 
 	// Update Cart Total Price
-	cart.TotalPrice = c.calculateCartPrice(cart)
+	if cart.TotalPrice, err = c.calculateCartPrice(cart); err != nil {
+		return nil, err
+	}
 	_, err = c.crepo.Update(cart)
 	if err != nil {
 		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Cart update error", err.Error())
@@ -147,7 +191,7 @@ func (c *cartService) Update(userID uuid.UUID, ProductSKU int, Quantity int) (*m
 		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Get Cart error", err.Error())
 	}
 
-	return newCart, nil
+	return c.priced(newCart)
 }
 
 //Delete given item from cart
@@ -167,8 +211,16 @@ func (c *cartService) Delete(userID uuid.UUID, ProductSKU int) (*models.Cart, er
 		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Cart item Delete error", err.Error())
 	}
 
+	if c.holds != nil {
+		if err := c.holds.Release(cart.ID, ProductSKU); err != nil {
+			return nil, err
+		}
+	}
+
 	// Update Cart Total Price
-	cart.TotalPrice = c.calculateCartPrice(cart)
+	if cart.TotalPrice, err = c.calculateCartPrice(cart); err != nil {
+		return nil, err
+	}
 	_, err = c.crepo.Update(cart)
 	if err != nil {
 		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Cart update error", err.Error())
@@ -179,17 +231,168 @@ func (c *cartService) Delete(userID uuid.UUID, ProductSKU int) (*models.Cart, er
 		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Get Cart error", err.Error())
 	}
 
-	return newCart, nil
+	return c.priced(newCart)
+}
+
+//ApplyCoupon puts the coupon with code on the cart, it replaces the coupon applied before
+func (c *cartService) ApplyCoupon(userID uuid.UUID, code string) (*models.Cart, error) {
+	cart, err := c.crepo.GetByUserID(userID)
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Get Cart error", err.Error())
+	}
+	cartItems, err := c.cirepo.GetByCartID(cart.ID)
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Get cart items Error", err.Error())
+	}
+
+	coupon, err := c.promos.Coupon(userID, code, discountLines(*cartItems), time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	cart.PromotionID = &coupon.ID
+	return c.saveCoupon(cart)
+}
+
+//RemoveCoupon takes the coupon off the cart
+func (c *cartService) RemoveCoupon(userID uuid.UUID) (*models.Cart, error) {
+	cart, err := c.crepo.GetByUserID(userID)
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Get Cart error", err.Error())
+	}
+	if cart.PromotionID == nil {
+		return nil, httpErr.NewRestError(http.StatusBadRequest, "Cart has no coupon", nil)
+	}
+
+	cart.PromotionID = nil
+	return c.saveCoupon(cart)
 }
 
-func (c *cartService) calculateCartPrice(cart *models.Cart) int {
+// saveCoupon stores the coupon change of the cart with its new total
+func (c *cartService) saveCoupon(cart *models.Cart) (*models.Cart, error) {
+	var err error
+	if cart.TotalPrice, err = c.calculateCartPrice(cart); err != nil {
+		return nil, err
+	}
+	if _, err = c.crepo.Update(cart); err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Cart update error", err.Error())
+	}
+
+	newCart, err := c.crepo.GetByUserID(cart.UserID)
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Get Cart error", err.Error())
+	}
+	return c.priced(newCart)
+}
+
+// calculateCartPrice returns the grand total of the stored cart items and the added ones not stored yet
+func (c *cartService) calculateCartPrice(cart *models.Cart, added ...models.CartItem) (money.Money, error) {
 	cartItems, _ := c.cirepo.GetByCartID(cart.ID)
 
-	var totalPrice int
+	quote, _, err := c.quote(cart, append(*cartItems, added...))
+	if err != nil {
+		return money.Money{}, err
+	}
+	return quote.Total, nil
+}
+
+// priced reloads the cart items and fills the discounts and tax breakdown of the cart and its items
+func (c *cartService) priced(cart *models.Cart) (*models.Cart, error) {
+	cartItems, err := c.cirepo.GetByCartID(cart.ID)
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Get cart items Error", err.Error())
+	}
+	quote, discounts, err := c.quote(cart, *cartItems)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, line := range quote.Lines {
+		(*cartItems)[i].Discount = discounts.LineDiscounts[i]
+		(*cartItems)[i].TaxRate = line.Rate
+		(*cartItems)[i].Net = line.Net
+		(*cartItems)[i].Tax = line.Tax
+	}
+	cart.CartItems = *cartItems
+	cart.Discounts = discounts.Discounts
+	cart.DiscountTotal = discounts.Total
+	cart.Subtotal = quote.Subtotal
+	cart.TaxLines = quote.TaxLines
+	cart.TaxTotal = quote.TaxTotal
+	cart.TotalPrice = quote.Total
+	return cart, nil
+}
 
+// quote takes the discounts off the cart items and taxes what is left at the rates of their product categories today
+func (c *cartService) quote(cart *models.Cart, cartItems []models.CartItem) (*tax.Quote, *promotion.Result, error) {
+	now := time.Now()
+	discounts, err := c.promos.Discount(cart.UserID, cart.PromotionID, discountLines(cartItems), now)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	items := make([]tax.Item, 0, len(cartItems))
+	for i, cartItem := range cartItems {
+		amount, err := cartItem.Price.Sub(discounts.LineDiscounts[i])
+		if err != nil {
+			return nil, nil, httpErr.NewRestError(http.StatusBadRequest, "Discount currency doesn't match the cart", err.Error())
+		}
+		items = append(items, tax.Item{Category: cartItem.Product.CategoryName, Amount: amount})
+	}
+	quote, err := c.taxes.Quote(items, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	return quote, discounts, nil
+}
+
+func discountLines(cartItems []models.CartItem) []promotion.Line {
+	lines := make([]promotion.Line, 0, len(cartItems))
+	for _, cartItem := range cartItems {
+		lines = append(lines, promotion.Line{Category: cartItem.Product.CategoryName, Quantity: cartItem.Quantity, Amount: cartItem.Price})
+	}
+	return lines
+}
+
+// checkCurrency rejects a product priced in another currency than the items already in the cart
+func (c *cartService) checkCurrency(cart *models.Cart, product *models.Product) error {
+	cartItems, err := c.cirepo.GetByCartID(cart.ID)
+	if err != nil {
+		return httpErr.NewRestError(http.StatusInternalServerError, "Get cart items error", err.Error())
+	}
 	for _, cartItem := range *cartItems {
-		totalPrice += cartItem.Price
+		if cartItem.Price.Currency != product.Price.Currency {
+			return httpErr.NewRestError(http.StatusBadRequest, "Product currency doesn't match the cart", product.Price.Currency)
+		}
 	}
+	return nil
+}
+
+// hold places a stock hold for the cart item when reservation mode is enabled, the hold of an item
+// going down to 0 units is released
+func (c *cartService) hold(cartID uuid.UUID, ProductSKU int, Quantity int) error {
+	if c.holds == nil {
+		return nil
+	}
+	if Quantity == 0 {
+		return c.holds.Release(cartID, ProductSKU)
+	}
+	return c.holds.Hold(cartID, ProductSKU, Quantity)
+}
 
-	return totalPrice
+// undoHold puts back the hold of a cart item whose write failed, so it doesn't block stock until it expires.
+// previous is the quantity the item had before, 0 for an item that was not in the cart.
+func (c *cartService) undoHold(cartID uuid.UUID, ProductSKU int, previous int) {
+	if c.holds == nil {
+		return
+	}
+	var err error
+	if previous > 0 {
+		err = c.holds.Hold(cartID, ProductSKU, previous)
+	} else {
+		err = c.holds.Release(cartID, ProductSKU)
+	}
+	if err != nil {
+		zap.L().Error("cart.service.undoHold failed to restore stock hold", zap.Int("SKU", ProductSKU), zap.Error(err))
+	}
 }
@@ -4,6 +4,11 @@ import (
 	"github.com/gcamlicali/tradeshopExample/internal/cart_item"
 	"github.com/gcamlicali/tradeshopExample/internal/models"
 	"github.com/gcamlicali/tradeshopExample/internal/product"
+	"github.com/gcamlicali/tradeshopExample/internal/promotion"
+	"github.com/gcamlicali/tradeshopExample/internal/reservation"
+	"github.com/gcamlicali/tradeshopExample/internal/tax"
+	"github.com/gcamlicali/tradeshopExample/pkg/money"
+	"github.com/gcamlicali/tradeshopExample/pkg/pagination"
 	"github.com/go-openapi/errors"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -11,6 +16,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 var (
@@ -25,7 +31,7 @@ var (
 		ID:           product1ID,
 		CategoryName: "CategoryExample",
 		Name:         "Product1",
-		Price:        10,
+		Price:        money.New(1000, money.DefaultCurrency),
 		SKU:          1,
 		Description:  "ExampleProduct1",
 		UnitStock:    1,
@@ -34,11 +40,20 @@ var (
 		ID:           product1ID,
 		CategoryName: "CategoryExample",
 		Name:         "Product2",
-		Price:        10,
+		Price:        money.New(1000, money.DefaultCurrency),
 		SKU:          2,
 		Description:  "ExampleProduct2",
 		UnitStock:    1,
 	}
+	productUSD = models.Product{
+		ID:           uuid.New(),
+		CategoryName: "CategoryExample",
+		Name:         "ProductUSD",
+		Price:        money.New(500, "USD"),
+		SKU:          3,
+		Description:  "ExampleProductUSD",
+		UnitStock:    1,
+	}
 	cartItem1 = models.CartItem{
 		ID:         cartItemID,
 		CartID:     cartID,
@@ -58,14 +73,59 @@ var (
 	cart1 = models.Cart{
 		ID:         cartID,
 		UserID:     userID,
-		TotalPrice: 10,
+		TotalPrice: money.New(1000, money.DefaultCurrency),
 		IsOrdered:  false,
 	}
+	// cart1 as returned, cartItem1 with %20 KDV included in its price
+	cart1Taxed = models.Cart{
+		ID:     cartID,
+		UserID: userID,
+		CartItems: []models.CartItem{
+			{
+				ID:         cartItemID,
+				CartID:     cartID,
+				ProductSKU: product1.SKU,
+				Price:      product1.Price,
+				Product:    product1,
+				Quantity:   1,
+				Discount:   money.Zero(money.DefaultCurrency),
+				TaxRate:    2000,
+				Net:        money.New(833, money.DefaultCurrency),
+				Tax:        money.New(167, money.DefaultCurrency),
+			},
+		},
+		Discounts:     []models.Discount{},
+		DiscountTotal: money.Zero(money.DefaultCurrency),
+		Subtotal:      money.New(833, money.DefaultCurrency),
+		TaxLines:      []models.TaxLine{{Rate: 2000, Net: money.New(833, money.DefaultCurrency), Tax: money.New(167, money.DefaultCurrency)}},
+		TaxTotal:      money.New(167, money.DefaultCurrency),
+		TotalPrice:    money.New(1000, money.DefaultCurrency),
+		IsOrdered:     false,
+	}
 	cart1updated = models.Cart{
-		ID:         cartID,
-		UserID:     userID,
-		TotalPrice: 30,
-		IsOrdered:  false,
+		ID:     cartID,
+		UserID: userID,
+		CartItems: []models.CartItem{
+			{
+				ID:         cartItemID,
+				CartID:     cartID,
+				ProductSKU: product1.SKU,
+				Price:      money.New(3000, money.DefaultCurrency),
+				Product:    product1,
+				Quantity:   3,
+				Discount:   money.Zero(money.DefaultCurrency),
+				TaxRate:    2000,
+				Net:        money.New(2500, money.DefaultCurrency),
+				Tax:        money.New(500, money.DefaultCurrency),
+			},
+		},
+		Discounts:     []models.Discount{},
+		DiscountTotal: money.Zero(money.DefaultCurrency),
+		Subtotal:      money.New(2500, money.DefaultCurrency),
+		TaxLines:      []models.TaxLine{{Rate: 2000, Net: money.New(2500, money.DefaultCurrency), Tax: money.New(500, money.DefaultCurrency)}},
+		TaxTotal:      money.New(500, money.DefaultCurrency),
+		TotalPrice:    money.New(3000, money.DefaultCurrency),
+		IsOrdered:     false,
 	}
 )
 
@@ -107,7 +167,7 @@ func Test_cartService_Get(t *testing.T) {
 			args: args{
 				userID: userID,
 			},
-			want:    &cart1,
+			want:    &cart1Taxed,
 			wantErr: false,
 		},
 		{
@@ -142,6 +202,8 @@ func Test_cartService_Get(t *testing.T) {
 				crepo:  tt.fields.crepo,
 				cirepo: tt.fields.cirepo,
 				prepo:  tt.fields.prepo,
+				taxes:  &taxMockCalculator{},
+				promos: &promotionMockService{},
 			}
 			got, err := c.Get(tt.args.userID)
 			if (err != nil) != tt.wantErr {
@@ -160,6 +222,7 @@ func Test_cartService_Add(t *testing.T) {
 		crepo  ICartRepository
 		cirepo cart_item.ICartItemRepository
 		prepo  product.IProductRepository
+		holds  reservation.Service
 	}
 	type args struct {
 		userID     uuid.UUID
@@ -264,6 +327,60 @@ func Test_cartService_Add(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "cartService_cartAdd_ReservationNotEnoughStock_ShouldFail",
+			fields: fields{
+				prepo: &productMockRepo{
+					Items: []models.Product{
+						product1,
+					},
+				},
+				cirepo: &cartItemMockRepo{
+					Items: []models.CartItem{
+						cartItem1,
+					},
+				},
+				crepo: &cartMockRepo{
+					Items: []models.Cart{
+						cart1,
+					},
+				},
+				holds: &holdsMockService{
+					Stock: map[int]int{product1.SKU: int(product1.UnitStock)},
+				},
+			},
+			args: args{
+				userID:     userID,
+				ProductSKU: product1.SKU,
+			},
+			wantErr: true,
+		},
+		{
+			name: "cartService_cartAdd_ErrorCurrencyMismatch_ShouldFail",
+			fields: fields{
+				prepo: &productMockRepo{
+					Items: []models.Product{
+						product1,
+						productUSD,
+					},
+				},
+				cirepo: &cartItemMockRepo{
+					Items: []models.CartItem{
+						cartItem1,
+					},
+				},
+				crepo: &cartMockRepo{
+					Items: []models.Cart{
+						cart1,
+					},
+				},
+			},
+			args: args{
+				userID:     userID,
+				ProductSKU: productUSD.SKU,
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -271,6 +388,9 @@ func Test_cartService_Add(t *testing.T) {
 				crepo:  tt.fields.crepo,
 				cirepo: tt.fields.cirepo,
 				prepo:  tt.fields.prepo,
+				taxes:  &taxMockCalculator{},
+				promos: &promotionMockService{},
+				holds:  tt.fields.holds,
 			}
 			_, err := c.Add(tt.args.userID, tt.args.ProductSKU)
 			if (err != nil) != tt.wantErr {
@@ -380,6 +500,60 @@ func Test_cartService_Update(t *testing.T) {
 			want:    nil,
 			wantErr: true,
 		},
+		{
+			name: "cartService_cartUpdate_ErrorZeroQuantity_ShouldFail",
+			fields: fields{
+				prepo: &productMockRepo{
+					Items: []models.Product{
+						product1,
+					},
+				},
+				cirepo: &cartItemMockRepo{
+					Items: []models.CartItem{
+						cartItem1,
+					},
+				},
+				crepo: &cartMockRepo{
+					Items: []models.Cart{
+						cart1,
+					},
+				},
+			},
+			args: args{
+				userID:     userID,
+				ProductSKU: product1.SKU,
+				Quantity:   0,
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "cartService_cartUpdate_ErrorNegativeQuantity_ShouldFail",
+			fields: fields{
+				prepo: &productMockRepo{
+					Items: []models.Product{
+						product1,
+					},
+				},
+				cirepo: &cartItemMockRepo{
+					Items: []models.CartItem{
+						cartItem1,
+					},
+				},
+				crepo: &cartMockRepo{
+					Items: []models.Cart{
+						cart1,
+					},
+				},
+			},
+			args: args{
+				userID:     userID,
+				ProductSKU: product1.SKU,
+				Quantity:   -2,
+			},
+			want:    nil,
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -387,6 +561,8 @@ func Test_cartService_Update(t *testing.T) {
 				crepo:  tt.fields.crepo,
 				cirepo: tt.fields.cirepo,
 				prepo:  tt.fields.prepo,
+				taxes:  &taxMockCalculator{},
+				promos: &promotionMockService{},
 			}
 			got, err := c.Update(tt.args.userID, tt.args.ProductSKU, tt.args.Quantity)
 			if (err != nil) != tt.wantErr {
@@ -400,6 +576,122 @@ func Test_cartService_Update(t *testing.T) {
 	}
 }
 
+func Test_cartService_HoldOfEmptyLineReleased(t *testing.T) {
+	holds := &holdsMockService{Stock: map[int]int{product1.SKU: 5}, Holds: map[int]int{product1.SKU: 2}}
+	c := &cartService{holds: holds}
+
+	if err := c.hold(cart1.ID, product1.SKU, 0); err != nil {
+		t.Fatalf("hold() error = %v", err)
+	}
+	if _, ok := holds.Holds[product1.SKU]; ok {
+		t.Errorf("hold() of 0 units kept hold %v, want it released", holds.Holds)
+	}
+}
+
+func Test_cartService_UndoHoldOnItemWriteError(t *testing.T) {
+	tests := []struct {
+		name     string
+		write    func(c *cartService) error
+		sku      int
+		wantHold int
+	}{
+		{
+			name:  "cartService_cartAdd_ErrorNewItemWrite_ReleasesHold",
+			write: func(c *cartService) error { _, err := c.Add(userID, product2.SKU); return err },
+			sku:   product2.SKU,
+		},
+		{
+			name:     "cartService_cartAdd_ErrorItemWrite_RestoresHold",
+			write:    func(c *cartService) error { _, err := c.Add(userID, product1.SKU); return err },
+			sku:      product1.SKU,
+			wantHold: cartItem1.Quantity,
+		},
+		{
+			name:     "cartService_cartUpdate_ErrorItemWrite_RestoresHold",
+			write:    func(c *cartService) error { _, err := c.Update(userID, product1.SKU, 3); return err },
+			sku:      product1.SKU,
+			wantHold: cartItem1.Quantity,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			holds := &holdsMockService{
+				Stock: map[int]int{product1.SKU: 10, product2.SKU: 10},
+				Holds: map[int]int{product1.SKU: cartItem1.Quantity},
+			}
+			c := &cartService{
+				crepo:  &cartMockRepo{Items: []models.Cart{cart1}},
+				cirepo: &failingCartItemMockRepo{cartItemMockRepo: cartItemMockRepo{Items: []models.CartItem{cartItem1}}},
+				prepo:  &productMockRepo{Items: []models.Product{product1, product2}},
+				taxes:  &taxMockCalculator{},
+				promos: &promotionMockService{},
+				holds:  holds,
+			}
+			if err := tt.write(c); err == nil {
+				t.Fatalf("error = nil, wantErr true")
+			}
+			if holds.Holds[tt.sku] != tt.wantHold {
+				t.Errorf("hold = %d, want %d", holds.Holds[tt.sku], tt.wantHold)
+			}
+		})
+	}
+}
+
+func Test_cartService_ApplyCoupon(t *testing.T) {
+	save10, old, big := "SAVE10", "OLD", "BIG"
+	expiredAt := time.Now().Add(-time.Hour)
+	promotions := []models.Promotion{
+		{ID: uuid.New(), Name: "Save %10", Code: &save10, Kind: models.PromotionPercentage, Percent: 1000},
+		{ID: uuid.New(), Name: "Expired", Code: &old, Kind: models.PromotionPercentage, Percent: 1000, ValidUntil: &expiredAt},
+		{ID: uuid.New(), Name: "Big carts", Code: &big, Kind: models.PromotionFixed, Value: money.New(500, money.DefaultCurrency), MinCartValue: money.New(5000, money.DefaultCurrency)},
+	}
+
+	tests := []struct {
+		name         string
+		code         string
+		wantDiscount money.Money
+		wantErr      bool
+	}{
+		{name: "cartService_ApplyCoupon_ShouldSuccess", code: save10, wantDiscount: money.New(100, money.DefaultCurrency)},
+		{name: "cartService_ApplyCoupon_ErrorUnknownCode_ShouldFail", code: "NOPE", wantErr: true},
+		{name: "cartService_ApplyCoupon_ErrorExpired_ShouldFail", code: old, wantErr: true},
+		{name: "cartService_ApplyCoupon_ErrorBelowMinimum_ShouldFail", code: big, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &cartService{
+				crepo:  &cartMockRepo{Items: []models.Cart{cart1}},
+				cirepo: &cartItemMockRepo{Items: []models.CartItem{cartItem1}},
+				prepo:  &productMockRepo{Items: []models.Product{product1}},
+				taxes:  &taxMockCalculator{},
+				promos: &promotionMockService{Items: promotions},
+			}
+			got, err := c.ApplyCoupon(userID, tt.code)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ApplyCoupon() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.DiscountTotal != tt.wantDiscount || len(got.Discounts) != 1 || got.Discounts[0].Code != tt.code {
+				t.Errorf("ApplyCoupon() discounts = %v %v, want %v", got.DiscountTotal, got.Discounts, tt.wantDiscount)
+			}
+			if want, _ := cartItem1.Price.Sub(tt.wantDiscount); got.TotalPrice != want {
+				t.Errorf("ApplyCoupon() total = %v, want %v", got.TotalPrice, want)
+			}
+
+			// The coupon stays on the cart until it is removed
+			got, err = c.RemoveCoupon(userID)
+			if err != nil || got.PromotionID != nil || !got.DiscountTotal.IsZero() || got.TotalPrice != cartItem1.Price {
+				t.Errorf("RemoveCoupon() = %v, %v", got, err)
+			}
+			if _, err := c.RemoveCoupon(userID); err == nil {
+				t.Errorf("RemoveCoupon() without coupon error = nil")
+			}
+		})
+	}
+}
+
 func Test_cartService_Delete(t *testing.T) {
 	type fields struct {
 		crepo  ICartRepository
@@ -442,7 +734,7 @@ func Test_cartService_Delete(t *testing.T) {
 				userID:     userID,
 				ProductSKU: product2.SKU,
 			},
-			want:    &cart1,
+			want:    &cart1Taxed,
 			wantErr: false,
 		},
 		{
@@ -508,6 +800,8 @@ func Test_cartService_Delete(t *testing.T) {
 				crepo:  tt.fields.crepo,
 				cirepo: tt.fields.cirepo,
 				prepo:  tt.fields.prepo,
+				taxes:  &taxMockCalculator{},
+				promos: &promotionMockService{},
 			}
 			got, err := c.Delete(tt.args.userID, tt.args.ProductSKU)
 			if (err != nil) != tt.wantErr {
@@ -527,9 +821,18 @@ type productMockRepo struct {
 type cartItemMockRepo struct {
 	Items []models.CartItem
 }
+type holdsMockService struct {
+	Stock map[int]int
+	// Holds are the held quantities of the cart keyed by product SKU
+	Holds map[int]int
+}
+type failingCartItemMockRepo struct {
+	cartItemMockRepo
+}
 type cartMockRepo struct {
 	Items []models.Cart
 }
+type taxMockCalculator struct{}
 
 func (p *productMockRepo) Create(a *models.Product) (*models.Product, error) {
 	for _, item := range p.Items {
@@ -540,10 +843,16 @@ func (p *productMockRepo) Create(a *models.Product) (*models.Product, error) {
 	p.Items = append(p.Items, *a)
 	return a, nil
 }
-func (p *productMockRepo) GetAll(pageIndex, pageSize int) (*[]models.Product, int, error) {
+func (p *productMockRepo) GetAll(filter product.ListFilter, pageIndex, pageSize int) (*[]models.Product, int, error) {
 	log.Println("size: ", len(p.Items))
 	return &p.Items, len(p.Items), nil
 }
+func (p *productMockRepo) GetAfter(filter product.ListFilter, keyset *pagination.Keyset, pageSize int) (*[]models.Product, bool, error) {
+	return &p.Items, false, nil
+}
+func (p *productMockRepo) Facets(filter product.ListFilter) (*product.Facets, error) {
+	return &product.Facets{}, nil
+}
 func (p *productMockRepo) GetByName(name string) (*[]models.Product, error) {
 	products := []models.Product{}
 	for _, item := range p.Items {
@@ -557,6 +866,9 @@ func (p *productMockRepo) GetByName(name string) (*[]models.Product, error) {
 		return nil, errors.New(400, "Product not found")
 	}
 }
+func (p *productMockRepo) Search(text string, pageIndex, pageSize int) (*[]product.SearchHit, int, error) {
+	return &[]product.SearchHit{}, 0, nil
+}
 func (p *productMockRepo) GetBySKU(SKU int) (*models.Product, error) {
 	product := models.Product{}
 	for _, item := range p.Items {
@@ -590,6 +902,27 @@ func (p *productMockRepo) Update(a *models.Product) (*models.Product, error) {
 	}
 	return a, nil
 }
+func (p *productMockRepo) DecreaseStock(sku int, quantity int) error {
+	for i, item := range p.Items {
+		if item.SKU == sku {
+			if int(item.UnitStock) < quantity {
+				return product.ErrNotEnoughStock
+			}
+			p.Items[i].UnitStock -= int32(quantity)
+			return nil
+		}
+	}
+	return product.ErrNotEnoughStock
+}
+func (p *productMockRepo) IncreaseStock(sku int, quantity int) error {
+	for i, item := range p.Items {
+		if item.SKU == sku {
+			p.Items[i].UnitStock += int32(quantity)
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
 func (p *productMockRepo) Delete(sku int) error {
 	pro, err := p.GetBySKU(sku)
 	if err != nil {
@@ -604,6 +937,12 @@ func (p *productMockRepo) Delete(sku int) error {
 	return nil
 }
 
+func (ci *failingCartItemMockRepo) Crate(a *models.CartItem) (*models.CartItem, error) {
+	return nil, errors.New(500, "Cart item create failed")
+}
+func (ci *failingCartItemMockRepo) Update(a *models.CartItem) (*models.CartItem, error) {
+	return nil, errors.New(500, "Cart item update failed")
+}
 func (ci *cartItemMockRepo) Crate(a *models.CartItem) (*models.CartItem, error) {
 	ci.Items = append(ci.Items, *a)
 	return a, nil
@@ -654,6 +993,68 @@ func (ci *cartItemMockRepo) Delete(a *models.CartItem) error {
 	return nil
 }
 
+func (h *holdsMockService) Hold(cartID uuid.UUID, sku int, quantity int) error {
+	if quantity < 1 {
+		return errors.New(400, "Quantity must be at least 1")
+	}
+	if quantity > h.Stock[sku] {
+		return errors.New(400, "Not Enough Stock")
+	}
+	if h.Holds == nil {
+		h.Holds = map[int]int{}
+	}
+	h.Holds[sku] = quantity
+	return nil
+}
+func (h *holdsMockService) Release(cartID uuid.UUID, sku int) error {
+	delete(h.Holds, sku)
+	return nil
+}
+func (h *holdsMockService) Held(skus []int) (map[int]int, error) {
+	return map[int]int{}, nil
+}
+func (h *holdsMockService) ReleaseExpired() (int64, error) {
+	return 0, nil
+}
+func (h *holdsMockService) StartSweeper(interval time.Duration) (stop func()) {
+	return func() {}
+}
+
+// Quote taxes every item at %20 included in the price
+func (t *taxMockCalculator) Quote(items []tax.Item, at time.Time) (*tax.Quote, error) {
+	lines := make([]tax.Line, 0, len(items))
+	for _, item := range items {
+		lines = append(lines, tax.PriceLine(item.Amount, 2000, true))
+	}
+	return tax.Summarize(lines)
+}
+
+type promotionMockService struct {
+	Items []models.Promotion
+}
+
+// Discount applies every promotion without a code and the coupon of the cart
+func (p *promotionMockService) Discount(userID uuid.UUID, couponID *uuid.UUID, lines []promotion.Line, at time.Time) (*promotion.Result, error) {
+	promotions := []models.Promotion{}
+	for _, item := range p.Items {
+		if item.Code == nil || (couponID != nil && item.ID == *couponID) {
+			promotions = append(promotions, item)
+		}
+	}
+	return promotion.Apply(promotions, lines, at)
+}
+func (p *promotionMockService) Coupon(userID uuid.UUID, code string, lines []promotion.Line, at time.Time) (*models.Promotion, error) {
+	for i, item := range p.Items {
+		if item.Code != nil && *item.Code == code {
+			if err := promotion.Check(&p.Items[i], lines, at); err != nil {
+				return nil, errors.New(400, err.Error())
+			}
+			return &p.Items[i], nil
+		}
+	}
+	return nil, errors.New(404, "Coupon not found")
+}
+
 func (c *cartMockRepo) Create(a *models.Cart) (*models.Cart, error) {
 	c.Items = append(c.Items, *a)
 	return a, nil
@@ -35,7 +35,7 @@ func (ci *CartItemRepositoy) Crate(a *models.CartItem) (*models.CartItem, error)
 func (ci *CartItemRepositoy) GetByCartID(cartID uuid.UUID) (*[]models.CartItem, error) {
 	zap.L().Debug("cartitem.repo.getByCartID", zap.Reflect("CartID", cartID))
 	var cartItems = []models.CartItem{}
-	err := ci.db.Where(&models.CartItem{CartID: cartID}).Find(&cartItems).Error
+	err := ci.db.Preload("Product").Where(&models.CartItem{CartID: cartID}).Find(&cartItems).Error
 	if err != nil {
 		zap.L().Error("cartitem.repo.GetByCartID failed to get CartItems", zap.Error(err))
 		return nil, err
@@ -73,7 +73,3 @@ func (ci *CartItemRepositoy) Delete(a *models.CartItem) error {
 
 	return nil
 }
-
-func (ci *CartItemRepositoy) Migration() {
-	ci.db.AutoMigrate(&models.CartItem{})
-}
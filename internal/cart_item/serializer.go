@@ -8,11 +8,19 @@ import (
 
 func CartItemtoResponse(ci *models.CartItem) *api.CartItem {
 
+	price := product.MoneyToResponse(ci.Price)
+	discount := product.MoneyToResponse(ci.Discount)
+	net := product.MoneyToResponse(ci.Net)
+	tax := product.MoneyToResponse(ci.Tax)
 	product := product.ProductToResponse(&ci.Product)
 
 	return &api.CartItem{
 		Product:  product,
 		Quantity: int32(ci.Quantity),
-		Price:    int32(ci.Price),
+		Price:    price,
+		Discount: discount,
+		Net:      net,
+		Tax:      tax,
+		TaxRate:  ci.TaxRate,
 	}
 }
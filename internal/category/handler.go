@@ -3,12 +3,12 @@ package category
 import (
 	"github.com/gcamlicali/tradeshopExample/internal/api"
 	httpErr "github.com/gcamlicali/tradeshopExample/internal/httpErrors"
-	"github.com/gcamlicali/tradeshopExample/pkg/config"
+	"github.com/gcamlicali/tradeshopExample/internal/models"
 	mw "github.com/gcamlicali/tradeshopExample/pkg/middleware"
 	"github.com/gcamlicali/tradeshopExample/pkg/pagination"
 	"github.com/gin-gonic/gin"
 	"github.com/go-openapi/strfmt"
-	"github.com/spf13/cast"
+	"github.com/google/uuid"
 	"net/http"
 )
 
@@ -16,45 +16,62 @@ type categoryHandler struct {
 	service Service
 }
 
-func NewCategoryHandler(r *gin.RouterGroup, service Service, cfg *config.Config) {
+func NewCategoryHandler(r *gin.RouterGroup, service Service, authMW gin.HandlerFunc) {
 	a := categoryHandler{service: service}
 
 	r.GET("/", a.getAll)
+	r.GET("/tree", a.getTree)
 
 	signedRoute := r.Group("/signed")
-	signedRoute.Use(mw.AuthMiddleware(cfg.JWTConfig.SecretKey))
+	signedRoute.Use(authMW, mw.RequirePermission(mw.PermCategoryWrite))
 	signedRoute.POST("/addBulk", a.addBulk)
 	signedRoute.POST("/addSingle", a.addSingle)
+	signedRoute.PUT("/:id", a.update)
+	signedRoute.DELETE("/:id", a.delete)
 }
 
 func (h *categoryHandler) getAll(c *gin.Context) {
+	//Requests with a cursor parameter are paged by cursor, the others by page number
+	cursor, byCursor, err := pagination.GetCursorFromRequest(c, SortByName)
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "Cursor is not valid", err.Error())))
+		return
+	}
 
-	pageIndex, pageSize := pagination.GetPaginationParametersFromRequest(c)
-
-	categories, count, err := h.service.GetAll(pageIndex, pageSize)
+	var categories *[]models.Category
+	var paginatedResult *pagination.Pages
+	if byCursor {
+		categories, paginatedResult, err = h.service.GetAfter(cursor, pagination.GetPageSizeFromRequest(c))
+	} else {
+		var count int
+		pageIndex, pageSize := pagination.GetPaginationParametersFromRequest(c)
+		if categories, count, err = h.service.GetAll(pageIndex, pageSize); err == nil {
+			paginatedResult = pagination.NewFromGinRequest(c, count)
+		}
+	}
 	if err != nil {
 		c.JSON(httpErr.ErrorResponse(err))
 		return
 	}
-	paginatedResult := pagination.NewFromGinRequest(c, count)
+
 	paginatedResult.Items = catsModelToApi(categories)
+	pagination.SetLinkHeader(c, paginatedResult)
 
 	c.JSON(http.StatusOK, paginatedResult)
 }
 
-func (h *categoryHandler) addBulk(c *gin.Context) {
-
-	adminInterface, isExist := c.Get("isAdmin")
-	if !isExist {
-		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "Admin not found", nil)))
+func (h *categoryHandler) getTree(c *gin.Context) {
+	tree, err := h.service.GetTree()
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
 		return
 	}
 
-	isAdmin := cast.ToBool(adminInterface)
-	if !isAdmin {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to use this endpoint!"})
-		return
-	}
+	c.JSON(http.StatusOK, treeToApi(tree))
+}
+
+func (h *categoryHandler) addBulk(c *gin.Context) {
+
 	file, _, err := c.Request.FormFile("file")
 	if err != nil {
 		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "Request File download error", err.Error())))
@@ -74,15 +91,30 @@ func (h *categoryHandler) addBulk(c *gin.Context) {
 }
 
 func (h *categoryHandler) addSingle(c *gin.Context) {
-	adminInterface, isExist := c.Get("isAdmin")
-	if !isExist {
-		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "Admin not found", nil)))
+	reqCategory := api.Category{}
+	if err := c.Bind(&reqCategory); err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "check your request body", err.Error())))
+		return
+	}
+
+	if err := reqCategory.Validate(strfmt.NewFormats()); err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
 		return
 	}
 
-	isAdmin := cast.ToBool(adminInterface)
-	if !isAdmin {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to use this endpoint!"})
+	createdCategory, err := h.service.AddSingle(reqCategory)
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, createdCategory)
+}
+
+func (h *categoryHandler) update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "Category ID is not valid", err.Error())))
 		return
 	}
 
@@ -97,11 +129,37 @@ func (h *categoryHandler) addSingle(c *gin.Context) {
 		return
 	}
 
-	createdCategory, err := h.service.AddSingle(reqCategory)
+	updatedCategory, err := h.service.Update(id, reqCategory)
 	if err != nil {
 		c.JSON(httpErr.ErrorResponse(err))
 		return
 	}
 
-	c.JSON(http.StatusCreated, createdCategory)
+	c.JSON(http.StatusOK, catModelToApi(updatedCategory))
+}
+
+func (h *categoryHandler) delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "Category ID is not valid", err.Error())))
+		return
+	}
+
+	//Products of the category are moved to the reassignTo category when it is given
+	var reassignTo *uuid.UUID
+	if param := c.Query("reassignTo"); param != "" {
+		target, err := uuid.Parse(param)
+		if err != nil {
+			c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "Category ID to reassign products to is not valid", err.Error())))
+			return
+		}
+		reassignTo = &target
+	}
+
+	if err := h.service.Delete(id, reassignTo); err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, "Category deleted")
 }
@@ -1,11 +1,21 @@
 package category
 
 import (
+	"errors"
+
 	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/gcamlicali/tradeshopExample/pkg/pagination"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
+// ErrCategoryInUse is returned when a category to delete still has products
+var ErrCategoryInUse = errors.New("category has products")
+
+// ErrCategoryHasChildren is returned when a category to delete still has sub categories
+var ErrCategoryHasChildren = errors.New("category has sub categories")
+
 type CategoryRepositoy struct {
 	db *gorm.DB
 }
@@ -13,7 +23,14 @@ type CategoryRepositoy struct {
 type ICategoryRepository interface {
 	Create(a *models.Category) (*models.Category, error)
 	GetByName(name string) (*models.Category, error)
+	GetByID(id uuid.UUID) (*models.Category, error)
+	GetBySlug(slug string) (*models.Category, error)
+	GetChild(parentID *uuid.UUID, name string) (*models.Category, error)
+	GetTree() (*[]models.Category, error)
+	Update(a *models.Category, oldName string) (*models.Category, error)
+	Delete(a *models.Category, reassignTo *models.Category) error
 	GetAll(pageIndex, pageSize int) (*[]models.Category, int, error)
+	GetAfter(keyset *pagination.Keyset, pageSize int) (*[]models.Category, bool, error)
 }
 
 func NewCategoryRepository(db *gorm.DB) *CategoryRepositoy {
@@ -29,6 +46,83 @@ func (r *CategoryRepositoy) Create(a *models.Category) (*models.Category, error)
 	return a, nil
 }
 
+// Update saves the category, a rename from oldName is carried to the products, tax rates and promotions
+// that keep the category name. Order lines keep the name they were ordered with.
+func (r *CategoryRepositoy) Update(a *models.Category, oldName string) (*models.Category, error) {
+	zap.L().Debug("category.repo.update", zap.Reflect("category", a), zap.Reflect("oldName", oldName))
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(a).Error; err != nil {
+			return err
+		}
+		if oldName == *a.Name {
+			return nil
+		}
+
+		for _, model := range []interface{}{&models.Product{}, &models.TaxRate{}, &models.Promotion{}} {
+			if err := tx.Model(model).Where("category_name = ?", oldName).Update("category_name", *a.Name).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		zap.L().Error("category.repo.update failed to update category", zap.Error(err))
+		return nil, err
+	}
+	return a, nil
+}
+
+// Delete deletes a category without sub categories. Its products are moved to reassignTo, without it
+// ErrCategoryInUse is returned while products use the category. The tax rates and promotions of the
+// category are deleted with it, moved products are taxed and discounted like the rest of reassignTo.
+func (r *CategoryRepositoy) Delete(a *models.Category, reassignTo *models.Category) error {
+	zap.L().Debug("category.repo.delete", zap.Reflect("category", a), zap.Reflect("reassignTo", reassignTo))
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var children int64
+		if err := tx.Model(&models.Category{}).Where("parent_id = ?", a.ID).Count(&children).Error; err != nil {
+			return err
+		}
+		if children > 0 {
+			return ErrCategoryHasChildren
+		}
+
+		products := tx.Model(&models.Product{}).Where("category_id = ? OR category_name = ?", a.ID, *a.Name)
+		if reassignTo != nil {
+			err := products.Updates(map[string]interface{}{"category_id": reassignTo.ID, "category_name": *reassignTo.Name}).Error
+			if err != nil {
+				return err
+			}
+		} else {
+			var count int64
+			if err := products.Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return ErrCategoryInUse
+			}
+		}
+
+		//Deleted products and sub categories still point at the category, they are detached so the
+		//category can be removed for good and its name and slug used again
+		if err := tx.Unscoped().Model(&models.Product{}).Where("category_id = ? AND deleted_at IS NOT NULL", a.ID).Update("category_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.Category{}).Where("parent_id = ? AND deleted_at IS NOT NULL", a.ID).Update("parent_id", nil).Error; err != nil {
+			return err
+		}
+
+		//Rates and promotions find their category by name, a category made later with the name must not get them
+		for _, model := range []interface{}{&models.TaxRate{}, &models.Promotion{}} {
+			if err := tx.Where("category_name = ?", *a.Name).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Unscoped().Delete(a).Error
+	})
+}
+
 func (r *CategoryRepositoy) GetByName(name string) (*models.Category, error) {
 	zap.L().Debug("category.repo.getByName", zap.Reflect("name", name))
 	var category = &models.Category{}
@@ -39,20 +133,91 @@ func (r *CategoryRepositoy) GetByName(name string) (*models.Category, error) {
 	return category, nil
 }
 
+func (r *CategoryRepositoy) GetByID(id uuid.UUID) (*models.Category, error) {
+	zap.L().Debug("category.repo.getByID", zap.Reflect("id", id))
+	var category = &models.Category{}
+	if err := r.db.Where("id = ?", id).First(category).Error; err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+func (r *CategoryRepositoy) GetBySlug(slug string) (*models.Category, error) {
+	zap.L().Debug("category.repo.getBySlug", zap.Reflect("slug", slug))
+	var category = &models.Category{}
+	if err := r.db.Where("slug = ?", slug).First(category).Error; err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// GetChild returns the category under parentID with the name or with the slug of the name, a top level
+// category when parentID is nil
+func (r *CategoryRepositoy) GetChild(parentID *uuid.UUID, name string) (*models.Category, error) {
+	zap.L().Debug("category.repo.getChild", zap.Reflect("parentID", parentID), zap.Reflect("name", name))
+	db := r.db.Where("parent_id IS NULL")
+	if parentID != nil {
+		db = r.db.Where("parent_id = ?", *parentID)
+	}
+	//The session lets the name and the slug lookups share the parent condition
+	db = db.Session(&gorm.Session{})
+
+	var category = &models.Category{}
+	err := db.Where("name = ?", name).First(category).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = db.Where("slug = ?", Slugify(name)).First(category).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// GetTree returns every category by position and name, buildTree nests them
+func (r *CategoryRepositoy) GetTree() (*[]models.Category, error) {
+	zap.L().Debug("category.repo.getTree")
+	var categories []models.Category
+	if err := r.db.Order("position").Order("name").Find(&categories).Error; err != nil {
+		zap.L().Error("category.repo.getTree failed to get categories", zap.Error(err))
+		return nil, err
+	}
+	return &categories, nil
+}
+
 func (r *CategoryRepositoy) GetAll(pageIndex, pageSize int) (*[]models.Category, int, error) {
 	zap.L().Debug("category.repo.getAll")
 
 	var categories = &[]models.Category{}
-	var junk = &[]models.Category{}
 	var count int64
-	if err := r.db.Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&categories).Error; err != nil {
+	if err := r.db.Model(&models.Category{}).Count(&count).Error; err != nil {
+		zap.L().Error("category.repo.getAll failed to count categories", zap.Error(err))
+		return nil, 0, err
+	}
+	if err := r.db.Order("name").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&categories).Error; err != nil {
 		return nil, 0, err
 	}
-	r.db.Find(&junk).Count(&count)
-	junk = nil
 	return categories, int(count), nil
 }
 
-func (r *CategoryRepositoy) Migration() {
-	r.db.AutoMigrate(&models.Category{})
+// GetAfter returns the page of categories of the keyset by name and whether more categories follow
+// in the direction it was read
+func (r *CategoryRepositoy) GetAfter(keyset *pagination.Keyset, pageSize int) (*[]models.Category, bool, error) {
+	zap.L().Debug("category.repo.getAfter", zap.Reflect("keyset", keyset))
+
+	var categories []models.Category
+	if err := r.db.Scopes(keyset.Scope(pageSize)).Find(&categories).Error; err != nil {
+		zap.L().Error("category.repo.getAfter failed to get categories", zap.Error(err))
+		return nil, false, err
+	}
+
+	more := len(categories) > pageSize
+	if more {
+		categories = categories[:pageSize]
+	}
+	if keyset.Backward {
+		for i, j := 0, len(categories)-1; i < j; i, j = i+1, j-1 {
+			categories[i], categories[j] = categories[j], categories[i]
+		}
+	}
+	return &categories, more, nil
 }
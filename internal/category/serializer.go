@@ -6,10 +6,32 @@ import (
 )
 
 func catModelToApi(a *models.Category) *api.Category {
-	return &api.Category{
-		Name: a.Name,
+	category := &api.Category{
+		ID:         a.ID.String(),
+		Name:       a.Name,
+		Slug:       a.Slug,
+		Position:   int32(a.Position),
+		ReturnDays: int32(a.ReturnDays),
 	}
+	if a.ParentID != nil {
+		category.ParentID = a.ParentID.String()
+	}
+	return category
+}
 
+func treeToApi(nodes []*TreeNode) []*api.CategoryNode {
+	tree := make([]*api.CategoryNode, 0, len(nodes))
+	for _, n := range nodes {
+		tree = append(tree, &api.CategoryNode{
+			ID:         n.ID.String(),
+			Name:       *n.Name,
+			Slug:       n.Slug,
+			Position:   int32(n.Position),
+			ReturnDays: int32(n.ReturnDays),
+			Children:   treeToApi(n.Children),
+		})
+	}
+	return tree
 }
 
 func catsModelToApi(cs *[]models.Category) []*api.Category {
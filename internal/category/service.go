@@ -1,12 +1,18 @@
 package category
 
 import (
+	"errors"
 	"github.com/gcamlicali/tradeshopExample/internal/api"
 	httpErr "github.com/gcamlicali/tradeshopExample/internal/httpErrors"
 	"github.com/gcamlicali/tradeshopExample/internal/models"
 	csvRead "github.com/gcamlicali/tradeshopExample/pkg/csv"
+	"github.com/gcamlicali/tradeshopExample/pkg/pagination"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 	"mime/multipart"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
 type categoryService struct {
@@ -16,15 +22,24 @@ type categoryService struct {
 type Service interface {
 	Create(a *models.Category) (*models.Category, error)
 	GetAll(pageIndex, pageSize int) (*[]models.Category, int, error)
+	GetAfter(cursor *pagination.Cursor, pageSize int) (*[]models.Category, *pagination.Pages, error)
 	AddBulk(file multipart.File) error
 	AddSingle(category api.Category) (*models.Category, error)
+	GetTree() ([]*TreeNode, error)
+	Update(id uuid.UUID, category api.Category) (*models.Category, error)
+	Delete(id uuid.UUID, reassignTo *uuid.UUID) error
 }
 
 func NewCategoryService(repo ICategoryRepository) Service {
 	return &categoryService{repo: repo}
 }
 
+// Create adds a category under its parent, the slug is made from the name when it is empty
 func (c categoryService) Create(a *models.Category) (*models.Category, error) {
+	if err := c.validCategory(a); err != nil {
+		return nil, err
+	}
+
 	NewCategory, err := c.repo.Create(a)
 	if err != nil {
 		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Catagory create error", err.Error())
@@ -43,6 +58,34 @@ func (c categoryService) GetAll(pageIndex, pageSize int) (*[]models.Category, in
 	return categories, count, nil
 }
 
+// SortByName names the order categories are listed in, cursors are made for it
+const SortByName = "name"
+
+// GetAfter lists the categories by name after the cursor, the first page when it is nil. The returned
+// Pages have the cursors of the pages around it.
+func (c categoryService) GetAfter(cursor *pagination.Cursor, pageSize int) (*[]models.Category, *pagination.Pages, error) {
+	keyset, err := pagination.NewKeyset("", "name", false, cursor, nil, new(string))
+	if err != nil {
+		return nil, nil, httpErr.NewRestError(http.StatusBadRequest, "Cursor is not valid", err.Error())
+	}
+
+	categories, more, err := c.repo.GetAfter(keyset, pageSize)
+	if err != nil {
+		return nil, nil, httpErr.NewRestError(http.StatusInternalServerError, "Get categories error", err.Error())
+	}
+
+	var first, last *pagination.Cursor
+	if n := len(*categories); n > 0 {
+		if first, err = pagination.NewCursor(SortByName, nil, (*categories)[0].Name); err == nil {
+			last, err = pagination.NewCursor(SortByName, nil, (*categories)[n-1].Name)
+		}
+		if err != nil {
+			return nil, nil, httpErr.NewRestError(http.StatusInternalServerError, "Cursor create error", err.Error())
+		}
+	}
+	return categories, pagination.NewCursorPages(pageSize, cursor, first, last, more), nil
+}
+
 func (c categoryService) AddBulk(file multipart.File) error {
 
 	record, err := csvRead.ReadFile(file)
@@ -50,9 +93,30 @@ func (c categoryService) AddBulk(file multipart.File) error {
 		return httpErr.NewRestError(http.StatusInternalServerError, "Can not read csv file", err.Error())
 	}
 
+	//The first column is a name or a path like Beyaz Esya/Buzdolabi, missing parents of the path are
+	//created. An optional second column is the return window in days.
 	for _, line := range record {
+		names := SplitPath(line[0])
+		if len(names) == 0 {
+			return httpErr.NewRestError(http.StatusBadRequest, "Category name can't be empty", line[0])
+		}
+		parent, err := c.ensurePath(names[:len(names)-1])
+		if err != nil {
+			return err
+		}
+
 		catEntity := models.Category{}
-		catEntity.Name = &line[0]
+		catEntity.Name = &names[len(names)-1]
+		if parent != nil {
+			catEntity.ParentID = &parent.ID
+		}
+		if len(line) > 1 && strings.TrimSpace(line[1]) != "" {
+			days, err := strconv.Atoi(strings.TrimSpace(line[1]))
+			if err != nil || days < 0 {
+				return httpErr.NewRestError(http.StatusBadRequest, "Return days must be a positive number", line[1])
+			}
+			catEntity.ReturnDays = days
+		}
 		_, err = c.Create(&catEntity)
 		if err != nil {
 			return httpErr.NewRestError(http.StatusBadRequest, "Category create error", err.Error())
@@ -62,10 +126,48 @@ func (c categoryService) AddBulk(file multipart.File) error {
 	return nil
 }
 
+// ensurePath returns the category at the path of names from the top level, creating the missing ones.
+// It is nil for an empty path.
+func (c categoryService) ensurePath(names []string) (*models.Category, error) {
+	var parent *models.Category
+	for i := range names {
+		var parentID *uuid.UUID
+		if parent != nil {
+			parentID = &parent.ID
+		}
+
+		category, err := c.repo.GetChild(parentID, names[i])
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			category, err = c.Create(&models.Category{Name: &names[i], ParentID: parentID})
+			if err != nil {
+				return nil, httpErr.NewRestError(http.StatusBadRequest, "Category create error", err.Error())
+			}
+		} else if err != nil {
+			return nil, httpErr.NewRestError(http.StatusInternalServerError, "Get category error", err.Error())
+		}
+		parent = category
+	}
+	return parent, nil
+}
+
 func (c categoryService) AddSingle(category api.Category) (*models.Category, error) {
 
+	if category.ReturnDays < 0 {
+		return nil, httpErr.NewRestError(http.StatusBadRequest, "Return days can't be negative", category.ReturnDays)
+	}
+
 	dbCat := models.Category{}
 	dbCat.Name = category.Name
+	dbCat.Slug = category.Slug
+	dbCat.Position = int(category.Position)
+	dbCat.ReturnDays = int(category.ReturnDays)
+	if category.ParentID != "" {
+		parentID, err := uuid.Parse(category.ParentID)
+		if err != nil {
+			return nil, httpErr.NewRestError(http.StatusBadRequest, "Parent ID is not valid", err.Error())
+		}
+		dbCat.ParentID = &parentID
+	}
 
 	createdCategory, err := c.Create(&dbCat)
 	if err != nil {
@@ -74,3 +176,134 @@ func (c categoryService) AddSingle(category api.Category) (*models.Category, err
 
 	return createdCategory, nil
 }
+
+// GetTree returns the top level categories with their sub categories
+func (c categoryService) GetTree() ([]*TreeNode, error) {
+	categories, err := c.repo.GetTree()
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Get categories error", err.Error())
+	}
+	return buildTree(*categories), nil
+}
+
+// Update replaces the properties of a category. A rename is carried to the products, tax rates and promotions
+// that keep the category name so they still find the category, orders keep the name they were placed with.
+func (c categoryService) Update(id uuid.UUID, category api.Category) (*models.Category, error) {
+	dbCat, err := c.get(id)
+	if err != nil {
+		return nil, err
+	}
+	if category.ReturnDays < 0 {
+		return nil, httpErr.NewRestError(http.StatusBadRequest, "Return days can't be negative", category.ReturnDays)
+	}
+
+	oldName := *dbCat.Name
+	dbCat.Name = category.Name
+	dbCat.Slug = category.Slug
+	dbCat.Position = int(category.Position)
+	dbCat.ReturnDays = int(category.ReturnDays)
+	dbCat.ParentID = nil
+	if category.ParentID != "" {
+		parentID, err := uuid.Parse(category.ParentID)
+		if err != nil {
+			return nil, httpErr.NewRestError(http.StatusBadRequest, "Parent ID is not valid", err.Error())
+		}
+		dbCat.ParentID = &parentID
+	}
+	if err := c.validCategory(dbCat); err != nil {
+		return nil, err
+	}
+
+	updated, err := c.repo.Update(dbCat, oldName)
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusBadRequest, "Category update error", err.Error())
+	}
+	return updated, nil
+}
+
+// Delete deletes a category without sub categories. Products of the category are moved to reassignTo,
+// the category can't be deleted while it has products and reassignTo is nil.
+func (c categoryService) Delete(id uuid.UUID, reassignTo *uuid.UUID) error {
+	dbCat, err := c.get(id)
+	if err != nil {
+		return err
+	}
+
+	var target *models.Category
+	if reassignTo != nil {
+		if *reassignTo == id {
+			return httpErr.NewRestError(http.StatusBadRequest, "Products can't be reassigned to the deleted category", id.String())
+		}
+		target, err = c.repo.GetByID(*reassignTo)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return httpErr.NewRestError(http.StatusBadRequest, "Category to reassign products to not found", reassignTo.String())
+		}
+		if err != nil {
+			return httpErr.NewRestError(http.StatusInternalServerError, "Get category error", err.Error())
+		}
+	}
+
+	err = c.repo.Delete(dbCat, target)
+	if errors.Is(err, ErrCategoryInUse) {
+		return httpErr.NewRestError(http.StatusConflict, "Category has products, reassign them to another category", *dbCat.Name)
+	}
+	if errors.Is(err, ErrCategoryHasChildren) {
+		return httpErr.NewRestError(http.StatusConflict, "Category has sub categories, delete or move them first", *dbCat.Name)
+	}
+	if err != nil {
+		return httpErr.NewRestError(http.StatusInternalServerError, "Category delete error", err.Error())
+	}
+	return nil
+}
+
+func (c categoryService) get(id uuid.UUID) (*models.Category, error) {
+	category, err := c.repo.GetByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, httpErr.NewRestError(http.StatusNotFound, "Category not found", id.String())
+	}
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Get category error", err.Error())
+	}
+	return category, nil
+}
+
+// validCategory checks the name, slug and parent of a category, the slug is made from the name when it
+// is empty. The name can't be used by another category, even under another parent. A category can't be
+// nested under itself or one of its sub categories.
+func (c categoryService) validCategory(a *models.Category) error {
+	if a.Name == nil || strings.TrimSpace(*a.Name) == "" || strings.Contains(*a.Name, PathSeparator) {
+		return httpErr.NewRestError(http.StatusBadRequest, "Category name can't be empty or contain "+PathSeparator, a.Name)
+	}
+	if a.Slug == "" {
+		a.Slug = Slugify(*a.Name)
+	}
+	if a.Slug == "" || a.Slug != Slugify(a.Slug) {
+		return httpErr.NewRestError(http.StatusBadRequest, "Slug must be lower case letters and digits joined by dashes", a.Slug)
+	}
+
+	//Names are unique across the whole tree and not only under a parent, tax rates and promotions keep
+	//the category name and a single name finds its category
+	taken, err := c.repo.GetByName(*a.Name)
+	if err == nil && taken.ID != a.ID {
+		return httpErr.NewRestError(http.StatusConflict, "Category name is already used, names are unique among all categories", *a.Name)
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return httpErr.NewRestError(http.StatusInternalServerError, "Get category error", err.Error())
+	}
+
+	//Walk up from the parent, a new category has no ID yet so it can't be met on the way
+	for parentID := a.ParentID; parentID != nil; {
+		if *parentID == a.ID {
+			return httpErr.NewRestError(http.StatusBadRequest, "Category can't be nested under itself or its sub categories", a.ParentID.String())
+		}
+		parent, err := c.repo.GetByID(*parentID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return httpErr.NewRestError(http.StatusBadRequest, "Parent category not found", parentID.String())
+		}
+		if err != nil {
+			return httpErr.NewRestError(http.StatusInternalServerError, "Get category error", err.Error())
+		}
+		parentID = parent.ParentID
+	}
+	return nil
+}
@@ -2,9 +2,14 @@ package category
 
 import (
 	"github.com/gcamlicali/tradeshopExample/internal/api"
+	httpErr "github.com/gcamlicali/tradeshopExample/internal/httpErrors"
 	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/gcamlicali/tradeshopExample/pkg/pagination"
 	"github.com/go-openapi/errors"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"net/http"
+	"strings"
 	"testing"
 )
 
@@ -135,6 +140,236 @@ func Test_categoryService_GetAll(t *testing.T) {
 	}
 }
 
+func Test_categoryService_GetAfter(t *testing.T) {
+	names := []string{"Beyaz Esya", "Elektronik", "Kitap"}
+	repo := &categoryMockRepo{}
+	for i := range names {
+		repo.Items = append(repo.Items, models.Category{ID: uuid.New(), Name: &names[i]})
+	}
+	c := categoryService{repo: repo}
+
+	first, pages, err := c.GetAfter(nil, 2)
+	if err != nil {
+		t.Fatalf("GetAfter() error = %v", err)
+	}
+	if len(*first) != 2 || pages.Next == "" || pages.Prev != "" {
+		t.Fatalf("GetAfter() first page = %d categories, next %q, prev %q", len(*first), pages.Next, pages.Prev)
+	}
+
+	next, err := pagination.DecodeCursor(pages.Next, SortByName)
+	if err != nil {
+		t.Fatalf("DecodeCursor() error = %v", err)
+	}
+	last, pages, err := c.GetAfter(next, 2)
+	if err != nil {
+		t.Fatalf("GetAfter() error = %v", err)
+	}
+	if len(*last) != 1 || *(*last)[0].Name != "Kitap" || pages.Next != "" || pages.Prev == "" {
+		t.Fatalf("GetAfter() last page = %v, next %q, prev %q", *last, pages.Next, pages.Prev)
+	}
+
+	prev, err := pagination.DecodeCursor(pages.Prev, SortByName)
+	if err != nil {
+		t.Fatalf("DecodeCursor() error = %v", err)
+	}
+	back, pages, err := c.GetAfter(prev, 2)
+	if err != nil {
+		t.Fatalf("GetAfter() error = %v", err)
+	}
+	if len(*back) != 2 || *(*back)[0].Name != "Beyaz Esya" || pages.Prev != "" || pages.Next == "" {
+		t.Errorf("GetAfter() back to first page = %v, next %q, prev %q", *back, pages.Next, pages.Prev)
+	}
+}
+
+func Test_Slugify(t *testing.T) {
+	tests := map[string]string{
+		"Beyaz Eşya":          "beyaz-esya",
+		"  Çamaşır Makinesi ": "camasir-makinesi",
+		"IŞIK & Aydınlatma":   "isik-aydinlatma",
+		"İç Giyim/Çorap":      "ic-giyim-corap",
+		"4K TV's":             "4k-tv-s",
+	}
+	for name, want := range tests {
+		if got := Slugify(name); got != want {
+			t.Errorf("Slugify(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+// csvFile serves a CSV upload from a string
+type csvFile struct {
+	*strings.Reader
+}
+
+func (csvFile) Close() error { return nil }
+
+func Test_categoryService_AddBulk_Paths(t *testing.T) {
+	repo := &categoryMockRepo{}
+	c := categoryService{repo: repo}
+
+	err := c.AddBulk(csvFile{strings.NewReader("Beyaz Eşya;\nBeyaz Esya/Buzdolabı;30\nbeyaz esya / Çamaşır Makinesi;\nKitap;\n")})
+	if err != nil {
+		t.Fatalf("AddBulk() error = %v", err)
+	}
+	if len(repo.Items) != 4 {
+		t.Fatalf("AddBulk() created %d categories, want 4", len(repo.Items))
+	}
+
+	//Beyaz Esya is found by its slug instead of being created twice
+	fridge, err := FindByPath(repo, "Beyaz Esya/Buzdolabi")
+	if err != nil {
+		t.Fatalf("FindByPath() error = %v", err)
+	}
+	if *fridge.Name != "Buzdolabı" || fridge.ReturnDays != 30 || fridge.Slug != "buzdolabi" || fridge.ParentID == nil || *fridge.ParentID != repo.Items[0].ID {
+		t.Errorf("FindByPath() = %+v, want Buzdolabı under Beyaz Eşya", fridge)
+	}
+	if _, err := FindByPath(repo, "Kitap/Buzdolabi"); err != gorm.ErrRecordNotFound {
+		t.Errorf("FindByPath() under another parent error = %v, want not found", err)
+	}
+
+	tree, err := c.GetTree()
+	if err != nil {
+		t.Fatalf("GetTree() error = %v", err)
+	}
+	if len(tree) != 2 || *tree[0].Name != "Beyaz Eşya" || len(tree[0].Children) != 2 || *tree[0].Children[0].Name != "Buzdolabı" || len(tree[1].Children) != 0 {
+		t.Errorf("GetTree() = %v, want Beyaz Eşya with its 2 children and Kitap", tree)
+	}
+
+	//The leaf of a path must be new
+	if err := c.AddBulk(csvFile{strings.NewReader("Beyaz Esya/Buzdolabı;\n")}); err == nil {
+		t.Error("AddBulk() created Buzdolabı twice")
+	}
+
+	//Names are unique among all categories, not only under a parent
+	if err := c.AddBulk(csvFile{strings.NewReader("Kitap/Buzdolabı;\n")}); err == nil {
+		t.Error("AddBulk() created Buzdolabı under Kitap too")
+	}
+	name := "Buzdolabı"
+	_, err = c.Create(&models.Category{Name: &name, ParentID: &tree[1].ID})
+	if status, _ := httpErr.ErrorResponse(err); status != http.StatusConflict {
+		t.Errorf("Create() with a used name status = %d, want %d", status, http.StatusConflict)
+	}
+}
+
+func Test_categoryService_AddSingle_Parent(t *testing.T) {
+	parentName, childName := "Elektronik", "Telefon"
+	parent := models.Category{ID: uuid.New(), Name: &parentName, Slug: "elektronik"}
+	tests := []struct {
+		name     string
+		category api.Category
+		wantErr  bool
+	}{
+		{name: "categoryService_AddSingle_Parent_ShouldSuccess", category: api.Category{Name: &childName, ParentID: parent.ID.String(), Position: 2}},
+		{name: "categoryService_AddSingle_ErrorParentNotFound_ShouldFail", category: api.Category{Name: &childName, ParentID: uuid.New().String()}, wantErr: true},
+		{name: "categoryService_AddSingle_ErrorParentID_ShouldFail", category: api.Category{Name: &childName, ParentID: "phones"}, wantErr: true},
+		{name: "categoryService_AddSingle_ErrorSlug_ShouldFail", category: api.Category{Name: &childName, Slug: "Akıllı Telefon"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := categoryService{repo: &categoryMockRepo{Items: []models.Category{parent}}}
+			got, err := c.AddSingle(tt.category)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AddSingle() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (got.Slug != "telefon" || got.Position != 2 || *got.ParentID != parent.ID) {
+				t.Errorf("AddSingle() = %+v", got)
+			}
+		})
+	}
+}
+
+func Test_categoryService_Update(t *testing.T) {
+	parentName, childName, grandchildName, newName := "Elektronik", "Telefon", "Akilli Telefon", "Cep Telefonu"
+	parent := models.Category{ID: uuid.New(), Name: &parentName, Slug: "elektronik"}
+	child := models.Category{ID: uuid.New(), Name: &childName, Slug: "telefon", ParentID: &parent.ID}
+	grandchild := models.Category{ID: uuid.New(), Name: &grandchildName, Slug: "akilli-telefon", ParentID: &child.ID}
+	tests := []struct {
+		name     string
+		id       uuid.UUID
+		category api.Category
+		wantErr  bool
+	}{
+		{name: "categoryService_Update_Rename_ShouldSuccess", id: child.ID, category: api.Category{Name: &newName, ParentID: parent.ID.String(), ReturnDays: 30}},
+		{name: "categoryService_Update_ErrorNotFound_ShouldFail", id: uuid.New(), category: api.Category{Name: &newName}, wantErr: true},
+		{name: "categoryService_Update_ErrorNameTaken_ShouldFail", id: child.ID, category: api.Category{Name: &parentName, ParentID: parent.ID.String()}, wantErr: true},
+		{name: "categoryService_Update_ErrorParentNotFound_ShouldFail", id: child.ID, category: api.Category{Name: &newName, ParentID: uuid.New().String()}, wantErr: true},
+		{name: "categoryService_Update_ErrorParentIsSelf_ShouldFail", id: child.ID, category: api.Category{Name: &newName, ParentID: child.ID.String()}, wantErr: true},
+		{name: "categoryService_Update_ErrorParentIsDescendant_ShouldFail", id: parent.ID, category: api.Category{Name: &parentName, ParentID: grandchild.ID.String()}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &categoryMockRepo{
+				Items:    []models.Category{parent, child, grandchild},
+				Products: []models.Product{{Name: "Telefon X", CategoryID: &child.ID, CategoryName: childName}},
+			}
+			c := categoryService{repo: repo}
+			got, err := c.Update(tt.id, tt.category)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Update() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if repo.Products[0].CategoryName != childName {
+					t.Errorf("Update() renamed products of a failed update to %v", repo.Products[0].CategoryName)
+				}
+				return
+			}
+			if *got.Name != newName || got.Slug != "cep-telefonu" || got.ReturnDays != 30 || *got.ParentID != parent.ID {
+				t.Errorf("Update() = %+v", got)
+			}
+			if repo.Products[0].CategoryName != newName {
+				t.Errorf("Update() product category = %v, want %v", repo.Products[0].CategoryName, newName)
+			}
+		})
+	}
+}
+
+func Test_categoryService_Delete(t *testing.T) {
+	parentName, childName, otherName := "Elektronik", "Telefon", "Aksesuar"
+	parent := models.Category{ID: uuid.New(), Name: &parentName, Slug: "elektronik"}
+	child := models.Category{ID: uuid.New(), Name: &childName, Slug: "telefon", ParentID: &parent.ID}
+	other := models.Category{ID: uuid.New(), Name: &otherName, Slug: "aksesuar"}
+	missing := uuid.New()
+	tests := []struct {
+		name       string
+		id         uuid.UUID
+		reassignTo *uuid.UUID
+		wantStatus int
+	}{
+		{name: "categoryService_Delete_Reassign_ShouldSuccess", id: child.ID, reassignTo: &other.ID},
+		{name: "categoryService_Delete_Unused_ShouldSuccess", id: other.ID},
+		{name: "categoryService_Delete_ErrorHasProducts_ShouldFail", id: child.ID, wantStatus: http.StatusConflict},
+		{name: "categoryService_Delete_ErrorHasChildren_ShouldFail", id: parent.ID, reassignTo: &other.ID, wantStatus: http.StatusConflict},
+		{name: "categoryService_Delete_ErrorNotFound_ShouldFail", id: missing, wantStatus: http.StatusNotFound},
+		{name: "categoryService_Delete_ErrorReassignNotFound_ShouldFail", id: child.ID, reassignTo: &missing, wantStatus: http.StatusBadRequest},
+		{name: "categoryService_Delete_ErrorReassignToSelf_ShouldFail", id: child.ID, reassignTo: &child.ID, wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &categoryMockRepo{
+				Items:    []models.Category{parent, child, other},
+				Products: []models.Product{{Name: "Telefon X", CategoryID: &child.ID, CategoryName: childName}},
+			}
+			c := categoryService{repo: repo}
+			err := c.Delete(tt.id, tt.reassignTo)
+			if tt.wantStatus != 0 {
+				if status, _ := httpErr.ErrorResponse(err); err == nil || status != tt.wantStatus {
+					t.Fatalf("Delete() error = %v, want status %v", err, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if _, err := repo.GetByID(tt.id); err == nil {
+				t.Errorf("Delete() left the category")
+			}
+			if tt.reassignTo != nil && (*repo.Products[0].CategoryID != other.ID || repo.Products[0].CategoryName != otherName) {
+				t.Errorf("Delete() product = %+v, want it in %v", repo.Products[0], otherName)
+			}
+		})
+	}
+}
+
 func Test_categoryService_AddSingle(t *testing.T) {
 
 	type fields struct {
@@ -180,6 +415,21 @@ func Test_categoryService_AddSingle(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "categoryService_CategoryAddSingle_NegativeReturnDays_ShouldFail",
+			fields: fields{
+				repo: &categoryMockRepo{
+					Items: []models.Category{},
+				},
+			},
+			args: args{
+				category: api.Category{
+					Name:       &categoryName,
+					ReturnDays: -1,
+				},
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -197,29 +447,131 @@ func Test_categoryService_AddSingle(t *testing.T) {
 
 type categoryMockRepo struct {
 	Items []models.Category
+	// Products are the products using the categories, renames and deletes are carried to them
+	Products []models.Product
 }
 
 func (c *categoryMockRepo) Create(a *models.Category) (*models.Category, error) {
 
 	for _, item := range c.Items {
-		if item.Name == a.Name {
+		if item.Name == a.Name || *item.Name == *a.Name {
 			return nil, errors.New(400, "Item should be unique on database")
 		}
 	}
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
 	c.Items = append(c.Items, *a)
 	return a, nil
 }
 func (c *categoryMockRepo) GetByName(name string) (*models.Category, error) {
-	category := &models.Category{}
 	for _, cat := range c.Items {
-		if cat.Name == &name {
-			category = &cat
-			break
+		if *cat.Name == name {
+			category := cat
+			return &category, nil
 		}
 	}
-	return category, nil
+	return nil, gorm.ErrRecordNotFound
 
 }
 func (c *categoryMockRepo) GetAll(pageIndex, pageSize int) (*[]models.Category, int, error) {
 	return &c.Items, 1, nil
 }
+func (c *categoryMockRepo) GetByID(id uuid.UUID) (*models.Category, error) {
+	for _, item := range c.Items {
+		if item.ID == id {
+			category := item
+			return &category, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (c *categoryMockRepo) GetBySlug(slug string) (*models.Category, error) {
+	for _, item := range c.Items {
+		if item.Slug == slug {
+			category := item
+			return &category, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (c *categoryMockRepo) GetChild(parentID *uuid.UUID, name string) (*models.Category, error) {
+	for _, item := range c.Items {
+		sameParent := item.ParentID == nil && parentID == nil || item.ParentID != nil && parentID != nil && *item.ParentID == *parentID
+		if sameParent && (*item.Name == name || item.Slug == Slugify(name)) {
+			category := item
+			return &category, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (c *categoryMockRepo) GetTree() (*[]models.Category, error) {
+	return &c.Items, nil
+}
+func (c *categoryMockRepo) Update(a *models.Category, oldName string) (*models.Category, error) {
+	for i, item := range c.Items {
+		if item.ID != a.ID && *item.Name == *a.Name {
+			return nil, errors.New(400, "Item should be unique on database")
+		}
+		if item.ID == a.ID {
+			c.Items[i] = *a
+		}
+	}
+	for i, product := range c.Products {
+		if product.CategoryName == oldName {
+			c.Products[i].CategoryName = *a.Name
+		}
+	}
+	return a, nil
+}
+func (c *categoryMockRepo) Delete(a *models.Category, reassignTo *models.Category) error {
+	for _, item := range c.Items {
+		if item.ParentID != nil && *item.ParentID == a.ID {
+			return ErrCategoryHasChildren
+		}
+	}
+	for i, product := range c.Products {
+		if product.CategoryName != *a.Name {
+			continue
+		}
+		if reassignTo == nil {
+			return ErrCategoryInUse
+		}
+		c.Products[i].CategoryID, c.Products[i].CategoryName = &reassignTo.ID, *reassignTo.Name
+	}
+	for i, item := range c.Items {
+		if item.ID == a.ID {
+			c.Items = append(c.Items[:i], c.Items[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// GetAfter pages Items by name, they must be sorted by name
+func (c *categoryMockRepo) GetAfter(keyset *pagination.Keyset, pageSize int) (*[]models.Category, bool, error) {
+	categories := []models.Category{}
+	for _, item := range c.Items {
+		if keyset.ID == nil || !keyset.Backward && *item.Name > keyset.ID.(string) {
+			categories = append(categories, item)
+		}
+	}
+	if keyset.Backward {
+		for i := len(c.Items) - 1; i >= 0; i-- {
+			if *c.Items[i].Name < keyset.ID.(string) {
+				categories = append(categories, c.Items[i])
+			}
+		}
+	}
+
+	more := len(categories) > pageSize
+	if more {
+		categories = categories[:pageSize]
+	}
+	if keyset.Backward {
+		for i, j := 0, len(categories)-1; i < j; i, j = i+1, j-1 {
+			categories[i], categories[j] = categories[j], categories[i]
+		}
+	}
+	return &categories, more, nil
+}
@@ -0,0 +1,146 @@
+package category
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PathSeparator separates the category names of a path like Beyaz Esya/Buzdolabi
+const PathSeparator = "/"
+
+// slugLetters folds the Turkish letters unicode.ToLower leaves alone
+var slugLetters = strings.NewReplacer("ç", "c", "ğ", "g", "ı", "i", "İ", "i", "ö", "o", "ş", "s", "ü", "u", "â", "a", "î", "i", "û", "u")
+
+// TreeNode is a category with its sub categories
+type TreeNode struct {
+	models.Category
+	Children []*TreeNode
+}
+
+// Slugify folds a category name to lower case ASCII words joined by dashes, Beyaz Eşya is beyaz-esya
+func Slugify(name string) string {
+	name = slugLetters.Replace(strings.ToLower(slugLetters.Replace(name)))
+
+	var b strings.Builder
+	dash := false
+	for _, r := range name {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	return b.String()
+}
+
+// SplitPath returns the trimmed category names of a path, empty names are dropped
+func SplitPath(path string) []string {
+	var names []string
+	for _, name := range strings.Split(path, PathSeparator) {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// FindByPath returns the category at a path from a top level category, each name matches a category
+// name or slug so accents can be left out. A single name finds the category at any level as names are
+// unique. gorm.ErrRecordNotFound is returned when a category of the path doesn't exist.
+func FindByPath(repo ICategoryRepository, path string) (*models.Category, error) {
+	names := SplitPath(path)
+	if len(names) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if len(names) == 1 {
+		category, err := repo.GetByName(names[0])
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return category, err
+		}
+		return repo.GetBySlug(Slugify(names[0]))
+	}
+
+	var category *models.Category
+	var parentID *uuid.UUID
+	for _, name := range names {
+		var err error
+		if category, err = repo.GetChild(parentID, name); err != nil {
+			return nil, err
+		}
+		parentID = &category.ID
+	}
+	return category, nil
+}
+
+// Ancestors returns the name of a category followed by the names of the categories above it, nearest
+// first. A name no category has is returned alone so it still matches itself.
+func Ancestors(repo ICategoryRepository, name string) ([]string, error) {
+	names := []string{name}
+	category, err := repo.GetByName(name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return names, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	//seen stops the walk should the parents ever loop
+	seen := map[uuid.UUID]bool{category.ID: true}
+	for parentID := category.ParentID; parentID != nil && !seen[*parentID]; parentID = category.ParentID {
+		seen[*parentID] = true
+		if category, err = repo.GetByID(*parentID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				break
+			}
+			return nil, err
+		}
+		names = append(names, *category.Name)
+	}
+	return names, nil
+}
+
+// buildTree nests the categories under their parents, categories are sorted by position and then by
+// name on every level. Categories whose parent is missing are put on the top level.
+func buildTree(categories []models.Category) []*TreeNode {
+	nodes := make(map[uuid.UUID]*TreeNode, len(categories))
+	for _, category := range categories {
+		nodes[category.ID] = &TreeNode{Category: category, Children: []*TreeNode{}}
+	}
+
+	roots := []*TreeNode{}
+	for i := range categories {
+		node := nodes[categories[i].ID]
+		if parentID := node.ParentID; parentID != nil {
+			if parent, ok := nodes[*parentID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+
+	sortNodes(roots)
+	return roots
+}
+
+func sortNodes(nodes []*TreeNode) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if nodes[i].Position != nodes[j].Position {
+			return nodes[i].Position < nodes[j].Position
+		}
+		return *nodes[i].Name < *nodes[j].Name
+	})
+	for _, node := range nodes {
+		sortNodes(node.Children)
+	}
+}
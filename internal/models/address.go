@@ -0,0 +1,34 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// PostalAddress is where a parcel is delivered, orders keep a copy of it
+type PostalAddress struct {
+	FullName   string
+	Phone      string
+	Line1      string
+	Line2      string
+	District   string
+	City       string
+	PostalCode string
+	Country    string
+}
+
+// Address is an entry of a user's address book, a user has at most one default address
+type Address struct {
+	ID            uuid.UUID `gorm:"primary_key; type:uuid; default:uuid_generate_v4()"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	UserID        uuid.UUID `gorm:"index"`
+	Title         string
+	PostalAddress `gorm:"embedded"`
+	IsDefault     bool
+}
+
+func (Address) TableName() string {
+	//default table name
+	return "address"
+}
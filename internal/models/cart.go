@@ -1,6 +1,7 @@
 package models
 
 import (
+	"github.com/gcamlicali/tradeshopExample/pkg/money"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"time"
@@ -13,8 +14,16 @@ type Cart struct {
 	DeletedAt  gorm.DeletedAt `gorm:"index"`
 	IsOrdered  bool
 	UserID     uuid.UUID
-	CartItems  []CartItem `gorm:"ForeignKey:CartID"`
-	TotalPrice int
+	CartItems  []CartItem  `gorm:"ForeignKey:CartID"`
+	TotalPrice money.Money `gorm:"embedded;embeddedPrefix:total_price_"`
+	// PromotionID is the coupon applied to the cart
+	PromotionID *uuid.UUID `gorm:"type:uuid"`
+	// Subtotal, TaxLines, TaxTotal and Discounts are priced when the cart is read, they are not stored
+	Subtotal      money.Money `gorm:"-"`
+	TaxLines      []TaxLine   `gorm:"-"`
+	TaxTotal      money.Money `gorm:"-"`
+	Discounts     []Discount  `gorm:"-"`
+	DiscountTotal money.Money `gorm:"-"`
 }
 
 func (Cart) TableName() string {
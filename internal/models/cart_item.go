@@ -1,6 +1,7 @@
 package models
 
 import (
+	"github.com/gcamlicali/tradeshopExample/pkg/money"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"time"
@@ -15,7 +16,14 @@ type CartItem struct {
 	Product    Product `gorm:"ForeignKey:SKU;references:ProductSKU"`
 	ProductSKU int
 	CartID     uuid.UUID
-	Price      int
+	// Price is the line total, unit price times quantity
+	Price money.Money `gorm:"embedded;embeddedPrefix:price_"`
+	// Discount, TaxRate, Net and Tax are priced when the cart is read, they are not stored.
+	// Net plus Tax is Price less Discount.
+	Discount money.Money `gorm:"-"`
+	TaxRate  int64       `gorm:"-"`
+	Net      money.Money `gorm:"-"`
+	Tax      money.Money `gorm:"-"`
 }
 
 func (CartItem) TableName() string {
@@ -12,6 +12,13 @@ type Category struct {
 	UpdatedAt time.Time
 	DeletedAt gorm.DeletedAt `gorm:"index"`
 	Name      *string        `gorm:"unique"`
+	// ParentID is the category this one is nested under, nil for top level categories
+	ParentID *uuid.UUID `gorm:"type:uuid; index"`
+	Slug     string     `gorm:"unique"`
+	// Position orders the categories under the same parent, ties are ordered by name
+	Position int
+	// ReturnDays is the return window of products of the category, 0 uses the shop default
+	ReturnDays int
 }
 
 func (Category) TableName() string {
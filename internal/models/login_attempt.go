@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// LoginAttempt is the failed sign in history of an account or client IP by Key, it is kept in the
+// database so every instance counts the same failures
+type LoginAttempt struct {
+	Key         string `gorm:"primary_key"`
+	Failures    int
+	LastFailure time.Time
+	LockedUntil *time.Time
+}
+
+func (LoginAttempt) TableName() string {
+	//default table name
+	return "login_attempt"
+}
@@ -1,21 +1,48 @@
 package models
 
 import (
+	"github.com/gcamlicali/tradeshopExample/pkg/money"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"time"
 )
 
+type OrderStatus string
+
+const (
+	OrderPending   OrderStatus = "Pending"
+	OrderPaid      OrderStatus = "Paid"
+	OrderShipped   OrderStatus = "Shipped"
+	OrderDelivered OrderStatus = "Delivered"
+	OrderCancelled OrderStatus = "Cancelled"
+	OrderReturned  OrderStatus = "Returned"
+	OrderRefunded  OrderStatus = "Refunded"
+)
+
+// Order TotalPrice is the grand total, Subtotal is the net amount before TaxTotal.
+// DiscountTotal is already taken off both, ShippingCost is added to TotalPrice only.
+// ShippingAddress is a copy of the address book entry AddressID at the time of the order.
 type Order struct {
-	ID         uuid.UUID `gorm:"primary_key; type:uuid; default:uuid_generate_v4()"`
-	CreatedAt  time.Time
-	UpdatedAt  time.Time
-	DeletedAt  gorm.DeletedAt `gorm:"index"`
-	CartID     uuid.UUID
-	UserID     uuid.UUID
-	Status     string
-	Cart       Cart
-	TotalPrice int32
+	ID              uuid.UUID `gorm:"primary_key; type:uuid; default:uuid_generate_v4()"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	DeletedAt       gorm.DeletedAt `gorm:"index"`
+	CartID          uuid.UUID
+	UserID          uuid.UUID
+	Status          OrderStatus
+	Cart            Cart
+	TotalPrice      money.Money `gorm:"embedded;embeddedPrefix:total_price_"`
+	Subtotal        money.Money `gorm:"embedded;embeddedPrefix:subtotal_"`
+	TaxTotal        money.Money `gorm:"embedded;embeddedPrefix:tax_total_"`
+	DiscountTotal   money.Money `gorm:"embedded;embeddedPrefix:discount_total_"`
+	ShippingCost    money.Money `gorm:"embedded;embeddedPrefix:shipping_cost_"`
+	AddressID       *uuid.UUID
+	ShippingAddress PostalAddress        `gorm:"embedded;embeddedPrefix:ship_to_"`
+	Lines           []OrderLine          `gorm:"ForeignKey:OrderID"`
+	Discounts       []OrderDiscount      `gorm:"ForeignKey:OrderID"`
+	Payments        []Payment            `gorm:"ForeignKey:OrderID"`
+	Refunds         []Refund             `gorm:"ForeignKey:OrderID"`
+	StatusHistory   []OrderStatusHistory `gorm:"ForeignKey:OrderID"`
 }
 
 func (Order) TableName() string {
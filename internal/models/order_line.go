@@ -0,0 +1,40 @@
+package models
+
+import (
+	"github.com/gcamlicali/tradeshopExample/pkg/money"
+	"github.com/google/uuid"
+	"time"
+)
+
+// OrderLine is a copy of an ordered cart item taken when the order is placed.
+// It keeps the order unchanged after the product is updated or deleted.
+// CancelledQuantity and ReturnedQuantity are the units taken back of Quantity,
+// Refunded is the part of LineTotal given back or no longer due for them.
+type OrderLine struct {
+	ID         uuid.UUID `gorm:"primary_key; type:uuid; default:uuid_generate_v4()"`
+	CreatedAt  time.Time
+	OrderID    uuid.UUID `gorm:"type:uuid; index"`
+	ProductSKU int
+	Name       string
+	// CategoryName is the category of the product when it was ordered, CategoryID finds the category
+	// for the return window of the line even after it is renamed
+	CategoryName string
+	CategoryID   *uuid.UUID  `gorm:"type:uuid"`
+	UnitPrice    money.Money `gorm:"embedded;embeddedPrefix:unit_price_"`
+	Quantity     int
+	LineTotal    money.Money `gorm:"embedded;embeddedPrefix:line_total_"`
+	// TaxRate in basis points, LineTotal is Net plus Tax after Discount
+	TaxRate  int64
+	Net      money.Money `gorm:"embedded;embeddedPrefix:net_"`
+	Tax      money.Money `gorm:"embedded;embeddedPrefix:tax_"`
+	Discount money.Money `gorm:"embedded;embeddedPrefix:discount_"`
+
+	CancelledQuantity int
+	ReturnedQuantity  int
+	Refunded          money.Money `gorm:"embedded;embeddedPrefix:refunded_"`
+}
+
+func (OrderLine) TableName() string {
+	//default table name
+	return "order_line"
+}
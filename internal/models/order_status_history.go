@@ -0,0 +1,21 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type OrderStatusHistory struct {
+	ID         uuid.UUID `gorm:"primary_key; type:uuid; default:uuid_generate_v4()"`
+	CreatedAt  time.Time
+	OrderID    uuid.UUID `gorm:"type:uuid; index"`
+	FromStatus OrderStatus
+	ToStatus   OrderStatus
+	ChangedBy  uuid.UUID `gorm:"type:uuid"`
+	Note       string
+}
+
+func (OrderStatusHistory) TableName() string {
+	//default table name
+	return "order_status_history"
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"github.com/gcamlicali/tradeshopExample/pkg/money"
+	"github.com/google/uuid"
+	"time"
+)
+
+type PaymentStatus string
+
+const (
+	// PaymentPending waits for the gateway to confirm the authorization with a webhook
+	PaymentPending    PaymentStatus = "Pending"
+	PaymentAuthorized PaymentStatus = "Authorized"
+	PaymentCaptured   PaymentStatus = "Captured"
+	PaymentDeclined   PaymentStatus = "Declined"
+	// PaymentVoiding is voided with the change of the order and waits for the void to be sent to the gateway
+	PaymentVoiding  PaymentStatus = "Voiding"
+	PaymentVoided   PaymentStatus = "Voided"
+	PaymentRefunded PaymentStatus = "Refunded"
+)
+
+// Payment is an attempt to pay an order through a gateway, Reference is the id the gateway gave it.
+// IdempotencyKey is sent to the gateway so retries of the payment are not charged again.
+// Captured is what was taken from the customer, Refunded is what was given back of it.
+type Payment struct {
+	ID             uuid.UUID `gorm:"primary_key; type:uuid; default:uuid_generate_v4()"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	OrderID        uuid.UUID `gorm:"index; uniqueIndex:idx_payment_order_idempotency_key"`
+	IdempotencyKey string    `gorm:"uniqueIndex:idx_payment_order_idempotency_key"`
+	Provider       string
+	Reference      string        `gorm:"index"`
+	Status         PaymentStatus `gorm:"index"`
+	Amount         money.Money   `gorm:"embedded;embeddedPrefix:amount_"`
+	Captured       money.Money   `gorm:"embedded;embeddedPrefix:captured_"`
+	Refunded       money.Money   `gorm:"embedded;embeddedPrefix:refunded_"`
+	Message        string
+}
+
+func (Payment) TableName() string {
+	//default table name
+	return "payment"
+}
+
+type RefundStatus string
+
+const (
+	// RefundPending is recorded with the change of the order and waits to be sent to the gateway
+	RefundPending   RefundStatus = "Pending"
+	RefundSucceeded RefundStatus = "Succeeded"
+)
+
+// Refund is money given back through a captured payment, Reference is the id the gateway gave it.
+// The refund is Pending until the gateway accepts it, its id is the key that makes retries safe.
+type Refund struct {
+	ID        uuid.UUID `gorm:"primary_key; type:uuid; default:uuid_generate_v4()"`
+	CreatedAt time.Time
+	OrderID   uuid.UUID `gorm:"index"`
+	PaymentID uuid.UUID `gorm:"index"`
+	Reference string
+	Amount    money.Money `gorm:"embedded;embeddedPrefix:amount_"`
+	Reason    string
+	Status    RefundStatus `gorm:"index"`
+}
+
+func (Refund) TableName() string {
+	//default table name
+	return "refund"
+}
+
+// PaymentEvent is a processed webhook of a gateway, a webhook delivered again is ignored
+type PaymentEvent struct {
+	ID        uuid.UUID `gorm:"primary_key; type:uuid; default:uuid_generate_v4()"`
+	CreatedAt time.Time
+	Provider  string `gorm:"uniqueIndex:idx_payment_event_provider_event_id"`
+	EventID   string `gorm:"uniqueIndex:idx_payment_event_provider_event_id"`
+	PaymentID uuid.UUID
+	Status    PaymentStatus
+}
+
+func (PaymentEvent) TableName() string {
+	//default table name
+	return "payment_event"
+}
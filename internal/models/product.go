@@ -1,22 +1,29 @@
 package models
 
 import (
+	"github.com/gcamlicali/tradeshopExample/pkg/money"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"time"
 )
 
 type Product struct {
-	ID           uuid.UUID `gorm:"primary_key; type:uuid; default:uuid_generate_v4()"`
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
-	DeletedAt    gorm.DeletedAt `gorm:"index"`
+	ID         uuid.UUID `gorm:"primary_key; type:uuid; default:uuid_generate_v4()"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	DeletedAt  gorm.DeletedAt `gorm:"index"`
+	CategoryID *uuid.UUID     `gorm:"type:uuid; index"`
+	// CategoryName is a copy of the name of the category for search, tax and promotions
 	CategoryName string
 	SKU          int `gorm:"unique"`
 	Name         string
 	Description  string
 	UnitStock    int32
-	Price        int
+	Price        money.Money `gorm:"embedded;embeddedPrefix:price_"`
+	// Weight is the shipping weight in grams
+	Weight int64
+	// ReservedStock is the stock held by active cart reservations, it is not stored
+	ReservedStock int32 `gorm:"-"`
 }
 
 func (Product) TableName() string {
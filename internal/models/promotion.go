@@ -0,0 +1,68 @@
+package models
+
+import (
+	"github.com/gcamlicali/tradeshopExample/pkg/money"
+	"github.com/google/uuid"
+	"time"
+)
+
+type PromotionKind string
+
+const (
+	// PromotionPercentage takes Percent off the items
+	PromotionPercentage PromotionKind = "percentage"
+	// PromotionFixed takes Value off the items once per cart
+	PromotionFixed PromotionKind = "fixed"
+	// PromotionBuyXGetY gives GetQuantity of every BuyQuantity+GetQuantity units of an item for free
+	PromotionBuyXGetY PromotionKind = "buy_x_get_y"
+)
+
+// Promotion is a cart discount. A promotion with a Code is a coupon users apply to their cart,
+// one without a Code applies to every cart it fits.
+type Promotion struct {
+	ID        uuid.UUID `gorm:"primary_key; type:uuid; default:uuid_generate_v4()"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Name      string
+	Code      *string `gorm:"uniqueIndex"`
+	Kind      PromotionKind
+	// Percent is in basis points like tax rates, 1500 is %15
+	Percent     int64
+	Value       money.Money `gorm:"embedded;embeddedPrefix:value_"`
+	BuyQuantity int
+	GetQuantity int
+	// CategoryName limits the promotion to products of the category, every product when empty
+	CategoryName string
+	MinCartValue money.Money `gorm:"embedded;embeddedPrefix:min_cart_value_"`
+	// UsageLimit is how many orders of a user can use the promotion, 0 is no limit
+	UsageLimit int
+	ValidFrom  time.Time
+	ValidUntil *time.Time
+}
+
+func (Promotion) TableName() string {
+	return "promotion"
+}
+
+// Discount is the amount a promotion takes off a cart
+type Discount struct {
+	PromotionID uuid.UUID
+	Code        string
+	Name        string
+	Amount      money.Money
+}
+
+// OrderDiscount is a copy of a discount applied to an order when it is placed
+type OrderDiscount struct {
+	ID          uuid.UUID `gorm:"primary_key; type:uuid; default:uuid_generate_v4()"`
+	CreatedAt   time.Time
+	OrderID     uuid.UUID `gorm:"type:uuid; index"`
+	PromotionID uuid.UUID `gorm:"type:uuid; index"`
+	Code        string
+	Name        string
+	Amount      money.Money `gorm:"embedded;embeddedPrefix:amount_"`
+}
+
+func (OrderDiscount) TableName() string {
+	return "order_discount"
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// RefreshToken is a server side refresh token, only the hash of the token is stored.
+// Tokens rotated from the same sign in share a FamilyID.
+type RefreshToken struct {
+	ID        uuid.UUID `gorm:"primary_key; type:uuid; default:uuid_generate_v4()"`
+	CreatedAt time.Time
+	UserID    uuid.UUID `gorm:"type:uuid; index"`
+	FamilyID  uuid.UUID `gorm:"type:uuid; index"`
+	TokenHash string    `gorm:"uniqueIndex"`
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+}
+
+func (RefreshToken) TableName() string {
+	//default table name
+	return "refresh_token"
+}
+
+// RevokedTokenFamily is a token family whose access tokens are rejected until ExpiresAt, when they
+// expire anyway. It is kept in the database so every instance rejects them.
+type RevokedTokenFamily struct {
+	FamilyID  string    `gorm:"primary_key"`
+	ExpiresAt time.Time `gorm:"index"`
+}
+
+func (RevokedTokenFamily) TableName() string {
+	//default table name
+	return "revoked_token_family"
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type StockReservation struct {
+	ID         uuid.UUID `gorm:"primary_key; type:uuid; default:uuid_generate_v4()"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	CartID     uuid.UUID `gorm:"type:uuid; uniqueIndex:idx_reservation_cart_sku"`
+	ProductSKU int       `gorm:"uniqueIndex:idx_reservation_cart_sku; index"`
+	Quantity   int
+	ExpiresAt  time.Time `gorm:"index"`
+}
+
+func (StockReservation) TableName() string {
+	//default table name
+	return "stock_reservation"
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"github.com/gcamlicali/tradeshopExample/pkg/money"
+	"github.com/google/uuid"
+	"time"
+)
+
+// TaxRate is the KDV rate of a category from ValidFrom on, Rate is in basis points (2000 is %20).
+// A later rate of the same category replaces it, so a rate change is a new row.
+type TaxRate struct {
+	ID           uuid.UUID `gorm:"primary_key; type:uuid; default:uuid_generate_v4()"`
+	CreatedAt    time.Time
+	CategoryName string `gorm:"uniqueIndex:idx_tax_rate_category_from"`
+	Rate         int64
+	ValidFrom    time.Time `gorm:"uniqueIndex:idx_tax_rate_category_from"`
+}
+
+func (TaxRate) TableName() string {
+	return "tax_rate"
+}
+
+// TaxLine sums the net amounts taxed at Rate and their tax
+type TaxLine struct {
+	Rate int64
+	Net  money.Money
+	Tax  money.Money
+}
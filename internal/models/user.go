@@ -1,12 +1,8 @@
 package models
 
 import (
-	"encoding/json"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
-	"io/ioutil"
-	"log"
-	"os"
 	"time"
 )
 
@@ -21,7 +17,11 @@ type User struct {
 	FirstName *string        `json:"firstName"`
 	LastName  *string        `json:"lastName"`
 	Mobile    string
-	IsAdmin   bool
+	Roles     []UserRole `gorm:"ForeignKey:UserID"`
+	// EmailVerifiedAt is cleared when the user changes the email address
+	EmailVerifiedAt *time.Time
+	// DeactivatedAt is set by admins, deactivated users can't sign in
+	DeactivatedAt *time.Time
 }
 
 func (User) TableName() string {
@@ -29,29 +29,11 @@ func (User) TableName() string {
 	return "user"
 }
 
-func GetAdmin() User {
-	admin := User{}
-
-	jsonFile, err := os.Open("./Docs/admin.json")
-
-	// if we os.Open returns an error then handle it
-	if err != nil {
-		log.Fatal("error while opening json: ", err)
-	}
-
-	// defer the closing of our jsonFile so that we can parse it later on
-	defer jsonFile.Close()
-
-	// read our opened xmlFile as a byte array.
-	byteValue, _ := ioutil.ReadAll(jsonFile)
-
-	//Parse to Authors struct
-	err = json.Unmarshal(byteValue, &admin)
-
-	//fmt.Println(byteValue)
-	if err != nil {
-		log.Fatal("Error while unmarshal json: ", err)
+// RoleNames returns the names of the roles assigned to the user
+func (u *User) RoleNames() []string {
+	names := make([]string, 0, len(u.Roles))
+	for _, role := range u.Roles {
+		names = append(names, role.Role)
 	}
-	admin.IsAdmin = true
-	return admin
+	return names
 }
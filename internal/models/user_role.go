@@ -0,0 +1,19 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// UserRole assigns a role to a user, roles are defined in pkg/middleware
+type UserRole struct {
+	UserID     uuid.UUID `gorm:"primaryKey; type:uuid"`
+	Role       string    `gorm:"primaryKey"`
+	CreatedAt  time.Time
+	AssignedBy *uuid.UUID `gorm:"type:uuid"`
+}
+
+func (UserRole) TableName() string {
+	//default table name
+	return "user_role"
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type UserTokenPurpose string
+
+const (
+	TokenVerifyEmail   UserTokenPurpose = "verify-email"
+	TokenResetPassword UserTokenPurpose = "reset-password"
+)
+
+// UserToken is a single use token mailed to the user, only the hash of the token is stored.
+// Mail is the address the token was sent to.
+type UserToken struct {
+	ID        uuid.UUID `gorm:"primary_key; type:uuid; default:uuid_generate_v4()"`
+	CreatedAt time.Time
+	UserID    uuid.UUID `gorm:"type:uuid; index"`
+	Purpose   UserTokenPurpose
+	Mail      string
+	TokenHash string `gorm:"uniqueIndex"`
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+func (UserToken) TableName() string {
+	//default table name
+	return "user_token"
+}
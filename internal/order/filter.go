@@ -0,0 +1,107 @@
+package order
+
+import (
+	"time"
+
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/gcamlicali/tradeshopExample/pkg/money"
+	"github.com/gcamlicali/tradeshopExample/pkg/pagination"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// sortColumns maps the sortBy values accepted by the admin order list to their columns
+var sortColumns = map[string]string{
+	"createdAt":  "created_at",
+	"updatedAt":  "updated_at",
+	"totalPrice": "total_price_amount",
+	"status":     "status",
+}
+
+// SearchFilter narrows the admin order list, zero valued fields are not filtered
+type SearchFilter struct {
+	Status   models.OrderStatus
+	UserID   uuid.UUID
+	From     time.Time
+	To       time.Time
+	MinTotal *money.Money
+	MaxTotal *money.Money
+	SortBy   string
+	SortDesc bool
+}
+
+// scope adds the filter conditions to the given query
+func (f SearchFilter) scope(db *gorm.DB) *gorm.DB {
+	if f.Status != "" {
+		db = db.Where("status = ?", f.Status)
+	}
+	if f.UserID != uuid.Nil {
+		db = db.Where("user_id = ?", f.UserID)
+	}
+	if !f.From.IsZero() {
+		db = db.Where("created_at >= ?", f.From)
+	}
+	if !f.To.IsZero() {
+		db = db.Where("created_at <= ?", f.To)
+	}
+	if f.MinTotal != nil {
+		db = db.Where("total_price_currency = ? AND total_price_amount >= ?", f.MinTotal.Currency, f.MinTotal.Amount)
+	}
+	if f.MaxTotal != nil {
+		db = db.Where("total_price_currency = ? AND total_price_amount <= ?", f.MaxTotal.Currency, f.MaxTotal.Amount)
+	}
+	return db
+}
+
+// orderBy returns the sort clause, newest orders come first when no sort is given. The order ID breaks
+// ties so pages don't overlap.
+func (f SearchFilter) orderBy() []clause.OrderByColumn {
+	by := clause.OrderByColumn{Column: clause.Column{Name: "created_at"}, Desc: true}
+	if column, ok := sortColumns[f.SortBy]; ok {
+		by = clause.OrderByColumn{Column: clause.Column{Name: column}, Desc: f.SortDesc}
+	}
+	return []clause.OrderByColumn{by, {Column: clause.Column{Name: "id"}, Desc: by.Desc}}
+}
+
+// sort names the order of the list, cursors are only good for the order they were made for
+func (f SearchFilter) sort() string {
+	if _, ok := sortColumns[f.SortBy]; !ok {
+		return "createdAt:desc"
+	}
+	if f.SortDesc {
+		return f.SortBy + ":desc"
+	}
+	return f.SortBy + ":asc"
+}
+
+// keyset returns the keyset of the list order starting at the cursor, the order ID breaks ties
+func (f SearchFilter) keyset(cursor *pagination.Cursor) (*pagination.Keyset, error) {
+	var key interface{}
+	switch f.SortBy {
+	case "totalPrice":
+		key = new(int64)
+	case "status":
+		key = new(models.OrderStatus)
+	default:
+		key = new(time.Time)
+	}
+	by := f.orderBy()[0]
+	return pagination.NewKeyset(by.Column.Name, "id", by.Desc, cursor, key, new(uuid.UUID))
+}
+
+// cursor returns the cursor of the order in the list
+func (f SearchFilter) cursor(o *models.Order) (*pagination.Cursor, error) {
+	var key interface{}
+	switch f.SortBy {
+	case "updatedAt":
+		key = o.UpdatedAt
+	case "totalPrice":
+		key = o.TotalPrice.Amount
+	case "status":
+		key = o.Status
+	default:
+		key = o.CreatedAt
+	}
+	return pagination.NewCursor(f.sort(), key, o.ID)
+}
@@ -1,10 +1,17 @@
 package order
 
 import (
+	"github.com/gcamlicali/tradeshopExample/internal/api"
 	httpErr "github.com/gcamlicali/tradeshopExample/internal/httpErrors"
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+	mw "github.com/gcamlicali/tradeshopExample/pkg/middleware"
+	"github.com/gcamlicali/tradeshopExample/pkg/money"
+	"github.com/gcamlicali/tradeshopExample/pkg/pagination"
 	"github.com/gin-gonic/gin"
+	"github.com/go-openapi/strfmt"
 	"github.com/google/uuid"
 	"net/http"
+	"time"
 )
 
 type orderHandler struct {
@@ -14,8 +21,19 @@ type orderHandler struct {
 func NewOrderHandler(r *gin.RouterGroup, service Service) {
 	h := &orderHandler{service: service}
 	r.GET("/", h.getAll)
+	r.GET("/admin", mw.RequirePermission(mw.PermOrderRead), h.search)
+	r.GET("/:id", h.get)
 	r.POST("/", h.add)
 	r.PUT("/:id", h.cancel)
+	r.PUT("/:id/return", h.returnOrder)
+	r.POST("/:id/pay", h.pay)
+	r.PUT("/admin/:id/status", mw.RequirePermission(mw.PermOrderWrite), h.changeStatus)
+}
+
+// NewPaymentHandler serves the webhooks of the payment gateway, they are authenticated by their signature
+func NewPaymentHandler(r *gin.RouterGroup, service Service) {
+	h := &orderHandler{service: service}
+	r.POST("/webhook", h.webhook)
 }
 
 func (o *orderHandler) getAll(c *gin.Context) {
@@ -36,6 +54,138 @@ func (o *orderHandler) getAll(c *gin.Context) {
 
 }
 
+func (o *orderHandler) get(c *gin.Context) {
+	userid, isExist := c.Get("userId")
+	if !isExist {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "User not found", nil)))
+		return
+	}
+	userID := userid.(uuid.UUID)
+	isAdmin := mw.HasPermission(c, mw.PermOrderRead)
+
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "Order ID is not valid", err.Error())))
+		return
+	}
+
+	order, err := o.service.Get(userID, orderID, isAdmin)
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, OrderToResponse(order))
+}
+
+func (o *orderHandler) search(c *gin.Context) {
+	filter, err := parseSearchFilter(c)
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	//Requests with a cursor parameter are paged by cursor, the others by page number
+	cursor, byCursor, err := pagination.GetCursorFromRequest(c, filter.sort())
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "Cursor is not valid", err.Error())))
+		return
+	}
+
+	var orders *[]models.Order
+	var paginatedResult *pagination.Pages
+	if byCursor {
+		orders, paginatedResult, err = o.service.SearchAfter(*filter, cursor, pagination.GetPageSizeFromRequest(c))
+	} else {
+		var count int
+		pageIndex, pageSize := pagination.GetPaginationParametersFromRequest(c)
+		if orders, count, err = o.service.Search(*filter, pageIndex, pageSize); err == nil {
+			paginatedResult = pagination.NewFromGinRequest(c, count)
+		}
+	}
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	paginatedResult.Items = ordersToResponse(*orders)
+	pagination.SetLinkHeader(c, paginatedResult)
+
+	c.JSON(http.StatusOK, paginatedResult)
+}
+
+// parseSearchFilter reads the admin order list filters from the query string
+func parseSearchFilter(c *gin.Context) (*SearchFilter, error) {
+	filter := SearchFilter{
+		SortBy:   c.Query("sortBy"),
+		SortDesc: c.Query("sortOrder") == "desc",
+	}
+
+	if value := c.Query("status"); value != "" {
+		status, ok := ParseStatus(value)
+		if !ok {
+			return nil, httpErr.NewRestError(http.StatusBadRequest, "Unknown order status", value)
+		}
+		filter.Status = status
+	}
+
+	if value := c.Query("userId"); value != "" {
+		userID, err := uuid.Parse(value)
+		if err != nil {
+			return nil, httpErr.NewRestError(http.StatusBadRequest, "User ID is not valid", err.Error())
+		}
+		filter.UserID = userID
+	}
+
+	var err error
+	if filter.From, err = parseDate(c.Query("from"), false); err != nil {
+		return nil, httpErr.NewRestError(http.StatusBadRequest, "from date is not valid", err.Error())
+	}
+	if filter.To, err = parseDate(c.Query("to"), true); err != nil {
+		return nil, httpErr.NewRestError(http.StatusBadRequest, "to date is not valid", err.Error())
+	}
+
+	currency := c.DefaultQuery("currency", money.DefaultCurrency)
+	if filter.MinTotal, err = parseTotal(c.Query("minTotal"), currency); err != nil {
+		return nil, httpErr.NewRestError(http.StatusBadRequest, "minTotal is not valid", err.Error())
+	}
+	if filter.MaxTotal, err = parseTotal(c.Query("maxTotal"), currency); err != nil {
+		return nil, httpErr.NewRestError(http.StatusBadRequest, "maxTotal is not valid", err.Error())
+	}
+
+	return &filter, nil
+}
+
+// parseDate accepts RFC3339 times or plain dates, a plain end date covers the whole day
+func parseDate(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
+
+// parseTotal reads a decimal total like 1299.90 in currency
+func parseTotal(value string, currency string) (*money.Money, error) {
+	if value == "" {
+		return nil, nil
+	}
+	total, err := money.Parse(value, currency)
+	if err != nil {
+		return nil, err
+	}
+	return &total, nil
+}
+
 func (o *orderHandler) add(c *gin.Context) {
 	userid, isExist := c.Get("userId")
 	if !isExist {
@@ -44,7 +194,26 @@ func (o *orderHandler) add(c *gin.Context) {
 	}
 	//userid := cast.ToInt(userID)
 	userID := userid.(uuid.UUID)
-	order, err := o.service.Create(userID)
+
+	//The body is optional, orders without an address go to the default address
+	req := api.OrderCreate{}
+	if c.Request.ContentLength > 0 {
+		if err := c.Bind(&req); err != nil {
+			c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "check your request body", err.Error())))
+			return
+		}
+	}
+	var addressID *uuid.UUID
+	if req.AddressID != "" {
+		id, err := uuid.Parse(req.AddressID)
+		if err != nil {
+			c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "Address ID is not valid", err.Error())))
+			return
+		}
+		addressID = &id
+	}
+
+	order, err := o.service.Create(userID, addressID)
 
 	if err != nil {
 		c.JSON(httpErr.ErrorResponse(err))
@@ -63,14 +232,153 @@ func (o *orderHandler) cancel(c *gin.Context) {
 	}
 	//userID := cast.ToInt(userID)
 	userID := userid.(uuid.UUID)
-	orderID, err := uuid.FromBytes([]byte((c.Param("id"))))
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "Order ID is not valid", err.Error())))
+		return
+	}
+
+	lines, reason, err := bindLinesChange(c)
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	order, err := o.service.Cancel(userID, orderID, lines, reason)
+
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, OrderToResponse(order))
+}
+
+func (o *orderHandler) returnOrder(c *gin.Context) {
+	userid, isExist := c.Get("userId")
+	if !isExist {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "User not found", nil)))
+		return
+	}
+	userID := userid.(uuid.UUID)
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "Order ID is not valid", err.Error())))
+		return
+	}
+
+	lines, reason, err := bindLinesChange(c)
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	order, err := o.service.Return(userID, orderID, lines, reason)
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, OrderToResponse(order))
+}
+
+// bindLinesChange reads the optional body of a cancel or return, no body takes back the whole order
+func bindLinesChange(c *gin.Context) ([]LineQuantity, string, error) {
+	req := api.OrderLinesChange{}
+	if c.Request.ContentLength == 0 {
+		return nil, "", nil
+	}
+	if err := c.Bind(&req); err != nil {
+		return nil, "", httpErr.NewRestError(http.StatusBadRequest, "check your request body", err.Error())
+	}
+	if err := req.Validate(strfmt.NewFormats()); err != nil {
+		return nil, "", err
+	}
 
-	err = o.service.Cancel(userID, orderID)
+	lines := make([]LineQuantity, 0, len(req.Lines))
+	for _, line := range req.Lines {
+		lines = append(lines, LineQuantity{SKU: int(*line.Sku), Quantity: int(*line.Quantity)})
+	}
+	return lines, req.Reason, nil
+}
 
+func (o *orderHandler) changeStatus(c *gin.Context) {
+	adminID := c.MustGet("userId").(uuid.UUID)
+	orderID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "Order ID is not valid", err.Error())))
+		return
+	}
+
+	reqStatus := api.OrderStatusUpdate{}
+	if err := c.Bind(&reqStatus); err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "check your request body", err.Error())))
+		return
+	}
+	if err := reqStatus.Validate(strfmt.NewFormats()); err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	status, ok := ParseStatus(*reqStatus.Status)
+	if !ok {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "Unknown order status", *reqStatus.Status)))
+		return
+	}
+
+	order, err := o.service.ChangeStatus(adminID, orderID, status, reqStatus.Note)
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, OrderToResponse(order))
+}
+
+func (o *orderHandler) pay(c *gin.Context) {
+	userID := c.MustGet("userId").(uuid.UUID)
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "Order ID is not valid", err.Error())))
+		return
+	}
+
+	req := api.PaymentRequest{}
+	if err := c.Bind(&req); err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "check your request body", err.Error())))
+		return
+	}
+	if err := req.Validate(strfmt.NewFormats()); err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	//Retries with the same Idempotency-Key header get the payment made before instead of a new charge
+	order, err := o.service.Pay(userID, orderID, *req.Token, c.GetHeader("Idempotency-Key"))
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	//A payment waiting for the gateway is accepted but the order is not paid yet
+	status := http.StatusOK
+	if order.Status == models.OrderPending {
+		status = http.StatusAccepted
+	}
+	c.JSON(status, OrderToResponse(order))
+}
+
+func (o *orderHandler) webhook(c *gin.Context) {
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "check your request body", err.Error())))
+		return
+	}
+
+	if err := o.service.ConfirmPayment(c.Request.Header, body); err != nil {
 		c.JSON(httpErr.ErrorResponse(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, "Order Cancel Complete")
+	c.JSON(http.StatusOK, "Webhook received")
 }
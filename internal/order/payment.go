@@ -0,0 +1,391 @@
+package order
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	httpErr "github.com/gcamlicali/tradeshopExample/internal/httpErrors"
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/gcamlicali/tradeshopExample/internal/payment"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Pay authorizes and captures the total of an own pending order, the order is Paid once the payment
+// is captured. A delayed payment leaves the order pending until the gateway confirms it with a webhook,
+// a declined payment is kept and the order can be paid again.
+// key makes retries safe, a request with the key of a payment made before answers with that payment
+// instead of charging again. Without a key every attempt to pay the order gets a key of its own.
+func (c *orderService) Pay(userID uuid.UUID, orderID uuid.UUID, token string, key string) (*models.Order, error) {
+	var order *models.Order
+	var paid *models.Payment
+
+	// The payment is recorded before the gateway is called, a request paying the order at the same
+	// time waits for the order lock and finds it
+	err := c.uow.Do(func(tx TxRepositories) error {
+		var err error
+		if order, err = c.getPayableOrder(tx, userID, orderID); err != nil {
+			return err
+		}
+		paid, err = c.startPayment(tx, order, key)
+		return err
+	})
+	if err != nil {
+		return nil, httpErr.ParseErrors(err)
+	}
+
+	// A payment the gateway answered before is not authorized again
+	if paid.Reference == "" {
+		result, err := c.payments.Authorize(payment.AuthorizeRequest{Key: paid.IdempotencyKey, Amount: paid.Amount, Token: token})
+		if err != nil {
+			return nil, httpErr.NewRestError(http.StatusBadGateway, "Payment provider error", err.Error())
+		}
+		if order, paid, err = c.recordAuthorization(userID, orderID, paid.ID, result); err != nil {
+			return nil, err
+		}
+	}
+
+	switch paid.Status {
+	case models.PaymentAuthorized:
+		// A failed capture leaves the payment authorized to be captured again
+		if err := c.capture(paid); err != nil {
+			return nil, err
+		}
+		if order, err = c.recordCapture(userID, orderID, paid); err != nil {
+			return nil, err
+		}
+	case models.PaymentDeclined:
+		return nil, httpErr.NewRestError(http.StatusPaymentRequired, "Payment declined", paid.Message)
+	}
+	return order, nil
+}
+
+// startPayment returns the payment of the order made with key, or records a new pending payment of
+// what is due for it. A payment left authorized by a failed capture is returned to be captured again
+// instead of holding the amount twice.
+func (c *orderService) startPayment(tx TxRepositories, order *models.Order, key string) (*models.Payment, error) {
+	if key == "" {
+		key = attemptKey(order)
+	}
+	for i := range order.Payments {
+		if order.Payments[i].IdempotencyKey == key {
+			return &order.Payments[i], nil
+		}
+	}
+
+	if order.Status != models.OrderPending {
+		return nil, errOrderNotPayable(order)
+	}
+	for i := range order.Payments {
+		switch order.Payments[i].Status {
+		case models.PaymentPending:
+			return nil, httpErr.NewRestError(http.StatusConflict, "Order has a payment in progress", order.Payments[i].Reference)
+		case models.PaymentAuthorized:
+			return &order.Payments[i], nil
+		}
+	}
+
+	amount, err := due(order)
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Order total error", err.Error())
+	}
+	paid := &models.Payment{ID: uuid.New(), OrderID: order.ID, Provider: c.payments.Name(), IdempotencyKey: key, Status: models.PaymentPending, Amount: amount}
+	if _, err := tx.Payments.Create(paid); err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Payment save error", err.Error())
+	}
+	return paid, nil
+}
+
+// recordAuthorization saves the answer of the gateway to the payment. Only the first request of a
+// payment records it, the others get a conflict. An authorization of an order cancelled meanwhile is
+// voided once the transaction commits.
+func (c *orderService) recordAuthorization(userID uuid.UUID, orderID uuid.UUID, paymentID uuid.UUID, result *payment.Result) (*models.Order, *models.Payment, error) {
+	var order *models.Order
+	var paid *models.Payment
+	cancelled := false
+	err := c.uow.Do(func(tx TxRepositories) error {
+		var err error
+		if order, err = c.getPayableOrder(tx, userID, orderID); err != nil {
+			return err
+		}
+		if paid = findPayment(order, paymentID); paid == nil || paid.Reference != "" {
+			return httpErr.NewRestError(http.StatusConflict, "Order has a payment in progress", paymentID.String())
+		}
+
+		cancelled = paid.Status == models.PaymentVoided
+		paid.Reference = result.Reference
+		paid.Status = result.Status
+		paid.Message = result.Message
+		if cancelled {
+			if err := c.release(tx, order, paid, "Order is "+string(order.Status)); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.Payments.Update(paid); err != nil {
+			return httpErr.NewRestError(http.StatusInternalServerError, "Payment save error", err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, httpErr.ParseErrors(err)
+	}
+	if cancelled {
+		c.settle(order)
+		return nil, nil, errOrderNotPayable(order)
+	}
+	return order, paid, nil
+}
+
+// recordCapture saves a captured payment and marks the order Paid, a payment captured for an order
+// cancelled meanwhile is refunded once the transaction commits. A payment another request captured first
+// is a conflict.
+func (c *orderService) recordCapture(userID uuid.UUID, orderID uuid.UUID, captured *models.Payment) (*models.Order, error) {
+	var order *models.Order
+	cancelled := false
+	err := c.uow.Do(func(tx TxRepositories) error {
+		var err error
+		if order, err = c.getPayableOrder(tx, userID, orderID); err != nil {
+			return err
+		}
+		paid := findPayment(order, captured.ID)
+		if paid == nil || paid.Status == models.PaymentCaptured {
+			return httpErr.NewRestError(http.StatusConflict, "Order has a payment in progress", captured.Reference)
+		}
+		*paid = *captured
+
+		cancelled = order.Status != models.OrderPending
+		if cancelled {
+			if err := c.release(tx, order, paid, "Order is "+string(order.Status)); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.Payments.Update(paid); err != nil {
+			return httpErr.NewRestError(http.StatusInternalServerError, "Payment save error", err.Error())
+		}
+		if cancelled {
+			return nil
+		}
+		return c.transition(tx, order, models.OrderPaid, userID, "Payment "+paid.Reference+" captured")
+	})
+	if err != nil {
+		return nil, httpErr.ParseErrors(err)
+	}
+	if cancelled {
+		c.settle(order)
+		return nil, errOrderNotPayable(order)
+	}
+	return order, nil
+}
+
+// getPayableOrder locks an own order and reads it with its payments as they are saved now
+func (c *orderService) getPayableOrder(tx TxRepositories, userID uuid.UUID, orderID uuid.UUID) (*models.Order, error) {
+	order, err := c.getOwnOrder(tx, userID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if err := loadPayments(tx, order); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// loadPayments reads the payments of a locked order as they are saved now
+func loadPayments(tx TxRepositories, order *models.Order) error {
+	payments, err := tx.Payments.GetByOrderID(order.ID)
+	if err != nil {
+		return httpErr.NewRestError(http.StatusInternalServerError, "Get payments error", err.Error())
+	}
+	order.Payments = *payments
+	return nil
+}
+
+// attemptKey is the payment key of the order when the client sends none, each declined payment
+// starts a new attempt so the order can be paid again
+func attemptKey(order *models.Order) string {
+	attempt := 1
+	for _, paid := range order.Payments {
+		if paid.Status == models.PaymentDeclined {
+			attempt++
+		}
+	}
+	return order.ID.String() + "-" + strconv.Itoa(attempt)
+}
+
+func findPayment(order *models.Order, paymentID uuid.UUID) *models.Payment {
+	for i := range order.Payments {
+		if order.Payments[i].ID == paymentID {
+			return &order.Payments[i]
+		}
+	}
+	return nil
+}
+
+func errOrderNotPayable(order *models.Order) error {
+	return httpErr.NewRestError(http.StatusBadRequest, "Order is not waiting for payment", "Order is "+string(order.Status))
+}
+
+// ConfirmPayment applies a webhook of the payment gateway. Every event is applied once, an event
+// delivered again or arriving after the payment moved on is ignored. An authorization of a pending order
+// is captured after the transaction commits, its event is recorded once the capture is, so the gateway
+// delivers it again when the capture fails.
+func (c *orderService) ConfirmPayment(header http.Header, body []byte) error {
+	event, err := c.payments.ParseWebhook(header, body)
+	if err != nil {
+		return httpErr.NewRestError(http.StatusBadRequest, "Webhook is not valid", err.Error())
+	}
+	provider := c.payments.Name()
+
+	var order *models.Order
+	var paid *models.Payment
+	capture := false
+	err = c.uow.Do(func(tx TxRepositories) error {
+		_, err := tx.Payments.GetEvent(provider, event.ID)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return httpErr.NewRestError(http.StatusInternalServerError, "Get payment event error", err.Error())
+		}
+
+		found, err := tx.Payments.GetByReference(provider, event.Reference)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return httpErr.NewRestError(http.StatusNotFound, "Payment not found", event.Reference)
+		}
+		if err != nil {
+			return httpErr.NewRestError(http.StatusInternalServerError, "Get payment error", err.Error())
+		}
+		if order, err = c.getOrder(tx, found.OrderID); err != nil {
+			return err
+		}
+		if err := loadPayments(tx, order); err != nil {
+			return err
+		}
+		if paid = findPayment(order, found.ID); paid == nil {
+			return httpErr.NewRestError(http.StatusNotFound, "Payment not found", event.Reference)
+		}
+
+		if paid.Status == models.PaymentPending {
+			switch event.Status {
+			case models.PaymentAuthorized, models.PaymentCaptured:
+				paid.Status = event.Status
+				if event.Status == models.PaymentCaptured {
+					paid.Captured = paid.Amount
+				}
+				// An order cancelled while the payment was pending must not be charged
+				if order.Status != models.OrderPending {
+					if err := c.release(tx, order, paid, "Order is "+string(order.Status)); err != nil {
+						return err
+					}
+				}
+			case models.PaymentDeclined:
+				paid.Status = models.PaymentDeclined
+				paid.Message = event.Message
+			}
+			if _, err := tx.Payments.Update(paid); err != nil {
+				return httpErr.NewRestError(http.StatusInternalServerError, "Payment save error", err.Error())
+			}
+			if paid.Status == models.PaymentCaptured && order.Status == models.OrderPending {
+				if err := c.transition(tx, order, models.OrderPaid, order.UserID, "Payment "+paid.Reference+" confirmed"); err != nil {
+					return err
+				}
+			}
+		}
+
+		capture = event.Status == models.PaymentAuthorized && paid.Status == models.PaymentAuthorized && order.Status == models.OrderPending
+		if capture {
+			return nil
+		}
+		return recordEvent(tx, provider, event, paid.ID)
+	})
+	if err != nil {
+		return httpErr.ParseErrors(err)
+	}
+	if order != nil {
+		c.settle(order)
+	}
+	if !capture {
+		return nil
+	}
+
+	if err := c.capture(paid); err != nil {
+		return err
+	}
+	// The request paying the order may have recorded the capture first, or the order was cancelled
+	// meanwhile and the capture is refunded, the event is done with either way
+	if _, err := c.recordCapture(order.UserID, order.ID, paid); err != nil {
+		if status, _ := httpErr.ErrorResponse(err); status >= http.StatusInternalServerError {
+			return err
+		}
+	}
+	err = c.uow.Do(func(tx TxRepositories) error {
+		return recordEvent(tx, provider, event, paid.ID)
+	})
+	if err != nil {
+		return httpErr.ParseErrors(err)
+	}
+	return nil
+}
+
+// recordEvent keeps the webhook event applied to the payment, the same event delivered again is ignored
+func recordEvent(tx TxRepositories, provider string, event *payment.Event, paymentID uuid.UUID) error {
+	_, err := tx.Payments.CreateEvent(&models.PaymentEvent{Provider: provider, EventID: event.ID, PaymentID: paymentID, Status: event.Status})
+	if err != nil {
+		return httpErr.NewRestError(http.StatusInternalServerError, "Payment event save error", err.Error())
+	}
+	return nil
+}
+
+// capture takes the authorized amount of the payment
+func (c *orderService) capture(paid *models.Payment) error {
+	result, err := c.payments.Capture(paid.Reference, paid.Amount)
+	if err != nil {
+		return httpErr.NewRestError(http.StatusBadGateway, "Payment capture error", err.Error())
+	}
+	paid.Status = result.Status
+	paid.Captured = paid.Amount
+	return nil
+}
+
+// release voids a payment that is not captured yet and refunds what is left of a captured one. Both are
+// recorded with the transaction and sent to the gateway by settle once it commits.
+func (c *orderService) release(tx TxRepositories, order *models.Order, paid *models.Payment, reason string) error {
+	switch paid.Status {
+	case models.PaymentPending, models.PaymentAuthorized:
+		//The gateway hasn't answered a payment without a reference, the request paying it voids what it gets
+		if paid.Reference == "" {
+			paid.Status = models.PaymentVoided
+			return nil
+		}
+		paid.Status = models.PaymentVoiding
+	case models.PaymentCaptured:
+		left, err := paid.Captured.Sub(paid.Refunded)
+		if err != nil {
+			return httpErr.NewRestError(http.StatusInternalServerError, "Payment refund error", err.Error())
+		}
+		if left.IsZero() || left.IsNegative() {
+			return nil
+		}
+		return c.refundPayment(tx, order, paid, left, reason)
+	}
+	return nil
+}
+
+// settlePayments voids the open payments of a cancelled order and refunds the captured ones of a refunded order
+func (c *orderService) settlePayments(tx TxRepositories, order *models.Order, to models.OrderStatus) error {
+	for i := range order.Payments {
+		paid := &order.Payments[i]
+		open := paid.Status == models.PaymentPending || paid.Status == models.PaymentAuthorized
+		captured := paid.Status == models.PaymentCaptured
+		if !(to == models.OrderCancelled && open) && !(to == models.OrderRefunded && captured) {
+			continue
+		}
+		if err := c.release(tx, order, paid, "Order is "+string(to)); err != nil {
+			return err
+		}
+		if _, err := tx.Payments.Update(paid); err != nil {
+			return httpErr.NewRestError(http.StatusInternalServerError, "Payment save error", err.Error())
+		}
+	}
+	return nil
+}
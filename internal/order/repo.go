@@ -2,9 +2,11 @@ package order
 
 import (
 	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/gcamlicali/tradeshopExample/pkg/pagination"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type OrderRepositoy struct {
@@ -13,9 +15,16 @@ type OrderRepositoy struct {
 
 type IOrderRepository interface {
 	Create(a *models.Order) (*models.Order, error)
+	GetByID(orderID uuid.UUID) (*models.Order, error)
 	GetByOrderAndUserID(userID uuid.UUID, orderID uuid.UUID) (*models.Order, error)
+	Lock(orderID uuid.UUID) error
 	GetByUserID(userID uuid.UUID) (*[]models.Order, error)
 	Update(a *models.Order) (*models.Order, error)
+	UpdateLine(a *models.OrderLine) (*models.OrderLine, error)
+	CreateStatusHistory(a *models.OrderStatusHistory) (*models.OrderStatusHistory, error)
+	GetStatusHistory(orderID uuid.UUID) (*[]models.OrderStatusHistory, error)
+	Search(filter SearchFilter, pageIndex, pageSize int) (*[]models.Order, int, error)
+	SearchAfter(filter SearchFilter, keyset *pagination.Keyset, pageSize int) (*[]models.Order, bool, error)
 }
 
 func NewOrderRepository(db *gorm.DB) *OrderRepositoy {
@@ -31,10 +40,37 @@ func (r *OrderRepositoy) Create(a *models.Order) (*models.Order, error) {
 	return a, nil
 }
 
+func (r *OrderRepositoy) GetByID(orderID uuid.UUID) (*models.Order, error) {
+	zap.L().Debug("order.repo.GetByID", zap.Reflect("orderID", orderID))
+	var order models.Order
+	err := r.db.Preload("Lines").Preload("Discounts").Preload("Payments").Preload("Refunds").Where(&models.Order{ID: orderID}).First(&order).Error
+	if err != nil {
+		zap.L().Error("order.repo.GetByID failed to get Order", zap.Error(err))
+		return nil, err
+	}
+	return &order, nil
+}
+
+// Lock locks the order row until the transaction of the repository ends
+func (r *OrderRepositoy) Lock(orderID uuid.UUID) error {
+	zap.L().Debug("order.repo.Lock", zap.Reflect("orderID", orderID))
+	var order models.Order
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", orderID).Take(&order).Error
+	if err != nil {
+		zap.L().Error("order.repo.Lock failed to lock order", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
 func (r *OrderRepositoy) GetByOrderAndUserID(userID uuid.UUID, orderID uuid.UUID) (*models.Order, error) {
 	zap.L().Debug("order.repo.GetByOrderID", zap.Reflect("userID", orderID))
 	var order models.Order
 	err := r.db.
+		Preload("Lines").
+		Preload("Discounts").
+		Preload("Payments").
+		Preload("Refunds").
 		Where(&models.Order{UserID: userID}).
 		Where(&models.Order{ID: orderID}).
 		First(&order).Error
@@ -49,7 +85,7 @@ func (r *OrderRepositoy) GetByOrderAndUserID(userID uuid.UUID, orderID uuid.UUID
 func (r *OrderRepositoy) GetByUserID(userID uuid.UUID) (*[]models.Order, error) {
 	zap.L().Debug("order.repo.GetByUserID", zap.Reflect("userID", userID.String()))
 	var orders []models.Order
-	err := r.db.Where(&models.Order{UserID: userID}).Find(&orders).Error
+	err := r.db.Preload("Lines").Preload("Discounts").Preload("Payments").Preload("Refunds").Where(&models.Order{UserID: userID}).Find(&orders).Error
 	if err != nil {
 		zap.L().Error("order.repo.GetByUserID failed to get Orders", zap.Error(err))
 		return nil, err
@@ -68,6 +104,91 @@ func (r *OrderRepositoy) Update(a *models.Order) (*models.Order, error) {
 
 	return a, nil
 }
-func (r *OrderRepositoy) Migration() {
-	r.db.AutoMigrate(&models.Order{})
+
+// UpdateLine saves the cancelled and returned quantities of an order line, saving the order doesn't update its lines
+func (r *OrderRepositoy) UpdateLine(a *models.OrderLine) (*models.OrderLine, error) {
+	zap.L().Debug("order.repo.updateLine", zap.Reflect("lineBody", a))
+
+	if result := r.db.Save(a); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return a, nil
+}
+
+func (r *OrderRepositoy) CreateStatusHistory(a *models.OrderStatusHistory) (*models.OrderStatusHistory, error) {
+	zap.L().Debug("order.repo.createStatusHistory", zap.Reflect("historyBody", a))
+	if err := r.db.Create(a).Error; err != nil {
+		zap.L().Error("order.repo.CreateStatusHistory failed to create history", zap.Error(err))
+		return nil, err
+	}
+	return a, nil
+}
+
+func (r *OrderRepositoy) GetStatusHistory(orderID uuid.UUID) (*[]models.OrderStatusHistory, error) {
+	zap.L().Debug("order.repo.GetStatusHistory", zap.Reflect("orderID", orderID))
+	var history []models.OrderStatusHistory
+	err := r.db.Where(&models.OrderStatusHistory{OrderID: orderID}).Order("created_at").Find(&history).Error
+	if err != nil {
+		zap.L().Error("order.repo.GetStatusHistory failed to get history", zap.Error(err))
+		return nil, err
+	}
+	return &history, nil
+}
+
+func (r *OrderRepositoy) Search(filter SearchFilter, pageIndex, pageSize int) (*[]models.Order, int, error) {
+	zap.L().Debug("order.repo.Search", zap.Reflect("filter", filter))
+	var orders []models.Order
+	var count int64
+
+	if err := r.db.Model(&models.Order{}).Scopes(filter.scope).Count(&count).Error; err != nil {
+		zap.L().Error("order.repo.Search failed to count orders", zap.Error(err))
+		return nil, 0, err
+	}
+
+	err := r.db.
+		Preload("Lines").
+		Preload("Discounts").
+		Preload("Payments").
+		Preload("Refunds").
+		Scopes(filter.scope).
+		Order(clause.OrderBy{Columns: filter.orderBy()}).
+		Offset((pageIndex - 1) * pageSize).
+		Limit(pageSize).
+		Find(&orders).Error
+	if err != nil {
+		zap.L().Error("order.repo.Search failed to get orders", zap.Error(err))
+		return nil, 0, err
+	}
+	return &orders, int(count), nil
+}
+
+// SearchAfter returns the page of orders of the keyset in list order and whether more orders follow
+// in the direction it was read
+func (r *OrderRepositoy) SearchAfter(filter SearchFilter, keyset *pagination.Keyset, pageSize int) (*[]models.Order, bool, error) {
+	zap.L().Debug("order.repo.SearchAfter", zap.Reflect("filter", filter), zap.Reflect("keyset", keyset))
+	var orders []models.Order
+
+	err := r.db.
+		Preload("Lines").
+		Preload("Discounts").
+		Preload("Payments").
+		Preload("Refunds").
+		Scopes(filter.scope, keyset.Scope(pageSize)).
+		Find(&orders).Error
+	if err != nil {
+		zap.L().Error("order.repo.SearchAfter failed to get orders", zap.Error(err))
+		return nil, false, err
+	}
+
+	more := len(orders) > pageSize
+	if more {
+		orders = orders[:pageSize]
+	}
+	if keyset.Backward {
+		for i, j := 0, len(orders)-1; i < j; i, j = i+1, j-1 {
+			orders[i], orders[j] = orders[j], orders[i]
+		}
+	}
+	return &orders, more, nil
 }
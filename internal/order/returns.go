@@ -0,0 +1,358 @@
+package order
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	httpErr "github.com/gcamlicali/tradeshopExample/internal/httpErrors"
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/gcamlicali/tradeshopExample/pkg/money"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// LineQuantity is a number of units of the order line of a product
+type LineQuantity struct {
+	SKU      int
+	Quantity int
+}
+
+// Cancel cancels units of an own order before it is shipped, every unit left when lines is empty.
+// Cancelled units go back to stock and their price is refunded through the captured payments. Once no
+// unit is left the order is Cancelled and its shipping cost is refunded too. The refunds are sent to the
+// gateway after the cancellation is saved.
+func (c *orderService) Cancel(userID uuid.UUID, orderID uuid.UUID, lines []LineQuantity, reason string) (*models.Order, error) {
+	var order *models.Order
+
+	err := c.uow.Do(func(tx TxRepositories) error {
+		var err error
+		order, err = c.getOwnOrder(tx, userID, orderID)
+		if err != nil {
+			return err
+		}
+
+		if !CanTransition(Customer, order.Status, models.OrderCancelled) {
+			return httpErr.NewRestError(http.StatusBadRequest, "You can not cancel your order!", "Order is "+string(order.Status))
+		}
+		taken, err := pick(order, lines)
+		if err != nil {
+			return err
+		}
+
+		//The amount of a payment waiting for the gateway can't change, only the whole order can be cancelled
+		if !takesAll(order, taken) {
+			for _, paid := range order.Payments {
+				if paid.Status == models.PaymentPending {
+					return httpErr.NewRestError(http.StatusBadRequest, "Order has a payment waiting for confirmation", paid.Reference)
+				}
+			}
+		}
+
+		return c.takeBackLines(tx, order, taken, models.OrderCancelled, userID, reason)
+	})
+	if err != nil {
+		return nil, httpErr.ParseErrors(err)
+	}
+
+	c.settle(order)
+	return order, nil
+}
+
+// Return returns units of an own delivered order, every unit left when lines is empty. Each line can be
+// returned within the return window of its category after delivery. Returned units go back to stock and
+// their price is refunded, the order is Returned once no unit is left.
+func (c *orderService) Return(userID uuid.UUID, orderID uuid.UUID, lines []LineQuantity, reason string) (*models.Order, error) {
+	var order *models.Order
+
+	err := c.uow.Do(func(tx TxRepositories) error {
+		var err error
+		order, err = c.getOwnOrder(tx, userID, orderID)
+		if err != nil {
+			return err
+		}
+
+		if !CanTransition(Customer, order.Status, models.OrderReturned) {
+			return httpErr.NewRestError(http.StatusBadRequest, "You can not return your order!", "Order is "+string(order.Status))
+		}
+		taken, err := pick(order, lines)
+		if err != nil {
+			return err
+		}
+		if err := c.checkReturnWindow(tx, order, taken); err != nil {
+			return err
+		}
+
+		return c.takeBackLines(tx, order, taken, models.OrderReturned, userID, reason)
+	})
+	if err != nil {
+		return nil, httpErr.ParseErrors(err)
+	}
+
+	c.settle(order)
+	return order, nil
+}
+
+// takeBackLines takes back the picked units of the order and refunds them, the order moves to status to
+// once no unit is left. A cancelled order gets its shipping cost back too.
+func (c *orderService) takeBackLines(tx TxRepositories, order *models.Order, taken map[int]int, to models.OrderStatus, actorID uuid.UUID, reason string) error {
+	whole := takesAll(order, taken)
+
+	refund := money.Zero(order.TotalPrice.Currency)
+	for i := range order.Lines {
+		quantity := taken[i]
+		if quantity == 0 {
+			continue
+		}
+		line := &order.Lines[i]
+		amount := lineRefund(line, quantity)
+
+		var err error
+		if line.Refunded, err = line.Refunded.Add(amount); err != nil {
+			return httpErr.NewRestError(http.StatusInternalServerError, "Refund currency doesn't match the order", err.Error())
+		}
+		if refund, err = refund.Add(amount); err != nil {
+			return httpErr.NewRestError(http.StatusInternalServerError, "Refund currency doesn't match the order", err.Error())
+		}
+		if err := c.takeBack(tx, line, quantity, to); err != nil {
+			return err
+		}
+	}
+
+	if whole && to == models.OrderCancelled {
+		var err error
+		if refund, err = refund.Add(order.ShippingCost); err != nil {
+			return httpErr.NewRestError(http.StatusInternalServerError, "Refund currency doesn't match the order", err.Error())
+		}
+	}
+	if err := c.refund(tx, order, refund, reason); err != nil {
+		return err
+	}
+
+	if !whole {
+		return nil
+	}
+	return c.transition(tx, order, to, actorID, reason)
+}
+
+// takeBack gives quantity units of the line back to stock and counts them as cancelled or returned
+func (c *orderService) takeBack(tx TxRepositories, line *models.OrderLine, quantity int, to models.OrderStatus) error {
+	//The line keeps a copy of the product, a product removed since the purchase has no stock to give back to
+	err := tx.Products.IncreaseStock(line.ProductSKU, quantity)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		zap.L().Warn("order.service.takeBack product is gone, units are not restocked", zap.Int("SKU", line.ProductSKU), zap.Int("quantity", quantity))
+	} else if err != nil {
+		return httpErr.NewRestError(http.StatusInternalServerError, "Ordered Product quantity update error", err.Error())
+	}
+
+	if to == models.OrderReturned {
+		line.ReturnedQuantity += quantity
+	} else {
+		line.CancelledQuantity += quantity
+	}
+	if _, err := tx.Orders.UpdateLine(line); err != nil {
+		return httpErr.NewRestError(http.StatusInternalServerError, "Order line update error", err.Error())
+	}
+	return nil
+}
+
+// refund gives amount back through the captured payments of the order, an unpaid order has nothing to give back
+func (c *orderService) refund(tx TxRepositories, order *models.Order, amount money.Money, reason string) error {
+	for i := range order.Payments {
+		if amount.IsZero() || amount.IsNegative() {
+			return nil
+		}
+		paid := &order.Payments[i]
+		if paid.Status != models.PaymentCaptured {
+			continue
+		}
+
+		take, err := paid.Captured.Sub(paid.Refunded)
+		if err != nil {
+			return httpErr.NewRestError(http.StatusInternalServerError, "Payment refund error", err.Error())
+		}
+		if cmp, err := amount.Cmp(take); err != nil {
+			return httpErr.NewRestError(http.StatusInternalServerError, "Payment refund error", err.Error())
+		} else if cmp < 0 {
+			take = amount
+		}
+		if take.IsZero() || take.IsNegative() {
+			continue
+		}
+
+		if err := c.refundPayment(tx, order, paid, take, reason); err != nil {
+			return err
+		}
+		if _, err := tx.Payments.Update(paid); err != nil {
+			return httpErr.NewRestError(http.StatusInternalServerError, "Payment save error", err.Error())
+		}
+		amount, _ = amount.Sub(take)
+	}
+	return nil
+}
+
+// refundPayment records a pending refund of amount through a captured payment, the payment is Refunded
+// once all of it is given back. The gateway is asked for the refund by settle once the transaction commits.
+func (c *orderService) refundPayment(tx TxRepositories, order *models.Order, paid *models.Payment, amount money.Money, reason string) error {
+	var err error
+	if paid.Refunded, err = paid.Refunded.Add(amount); err != nil {
+		return httpErr.NewRestError(http.StatusInternalServerError, "Payment refund error", err.Error())
+	}
+	if cmp, err := paid.Refunded.Cmp(paid.Captured); err == nil && cmp >= 0 {
+		paid.Status = models.PaymentRefunded
+	}
+
+	refund, err := tx.Payments.CreateRefund(&models.Refund{
+		OrderID:   order.ID,
+		PaymentID: paid.ID,
+		Amount:    amount,
+		Reason:    reason,
+		Status:    models.RefundPending,
+	})
+	if err != nil {
+		return httpErr.NewRestError(http.StatusInternalServerError, "Refund save error", err.Error())
+	}
+	order.Refunds = append(order.Refunds, *refund)
+	return nil
+}
+
+// checkReturnWindow fails when a picked line is past the return window of its category, the window
+// starts when the order is delivered
+func (c *orderService) checkReturnWindow(tx TxRepositories, order *models.Order, taken map[int]int) error {
+	history, err := tx.Orders.GetStatusHistory(order.ID)
+	if err != nil {
+		return httpErr.NewRestError(http.StatusInternalServerError, "Get order status history error", err.Error())
+	}
+	//Orders delivered before the status history was kept count from the order date
+	deliveredAt := order.CreatedAt
+	for _, change := range *history {
+		if change.ToStatus == models.OrderDelivered {
+			deliveredAt = change.CreatedAt
+		}
+	}
+
+	now := time.Now()
+	windows := map[string]int{}
+	for i := range order.Lines {
+		if taken[i] == 0 {
+			continue
+		}
+		line := &order.Lines[i]
+		key := line.CategoryName
+		if line.CategoryID != nil {
+			key = line.CategoryID.String()
+		}
+		days, ok := windows[key]
+		if !ok {
+			if days, err = c.returnWindow(line); err != nil {
+				return err
+			}
+			windows[key] = days
+		}
+		if now.After(deliveredAt.AddDate(0, 0, days)) {
+			return httpErr.NewRestError(http.StatusBadRequest, "You can not return your order!", "Return date of "+line.Name+" expired")
+		}
+	}
+	return nil
+}
+
+// returnWindow returns the return days of the category of the line, the shop default for categories
+// without their own. Lines ordered before the category ID was kept find their category by name.
+func (c *orderService) returnWindow(line *models.OrderLine) (int, error) {
+	var category *models.Category
+	var err error
+	switch {
+	case line.CategoryID != nil:
+		category, err = c.catRepo.GetByID(*line.CategoryID)
+	case line.CategoryName != "":
+		category, err = c.catRepo.GetByName(line.CategoryName)
+	default:
+		return c.returnDays, nil
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.returnDays, nil
+	}
+	if err != nil {
+		return 0, httpErr.NewRestError(http.StatusInternalServerError, "Get category error", err.Error())
+	}
+	if category.ReturnDays > 0 {
+		return category.ReturnDays, nil
+	}
+	return c.returnDays, nil
+}
+
+// pick returns the units to take back by line index, every unit left when lines is empty
+func pick(order *models.Order, lines []LineQuantity) (map[int]int, error) {
+	taken := map[int]int{}
+	if len(lines) == 0 {
+		for i := range order.Lines {
+			if left := remaining(&order.Lines[i]); left > 0 {
+				taken[i] = left
+			}
+		}
+		if len(taken) == 0 {
+			return nil, httpErr.NewRestError(http.StatusBadRequest, "Nothing is left on the order", order.ID.String())
+		}
+		return taken, nil
+	}
+
+	for _, line := range lines {
+		if line.Quantity < 1 {
+			return nil, httpErr.NewRestError(http.StatusBadRequest, "Quantity must be at least 1", line.SKU)
+		}
+		index := -1
+		for i := range order.Lines {
+			if order.Lines[i].ProductSKU == line.SKU {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return nil, httpErr.NewRestError(http.StatusBadRequest, "Product is not in the order", line.SKU)
+		}
+		taken[index] += line.Quantity
+		if taken[index] > remaining(&order.Lines[index]) {
+			return nil, httpErr.NewRestError(http.StatusBadRequest, "Quantity is more than left on the line", line.SKU)
+		}
+	}
+	return taken, nil
+}
+
+// takesAll reports whether no unit of the order is left once the picked units are taken back
+func takesAll(order *models.Order, taken map[int]int) bool {
+	for i := range order.Lines {
+		if remaining(&order.Lines[i]) != taken[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// remaining is the number of units of the line not cancelled or returned yet
+func remaining(line *models.OrderLine) int {
+	return line.Quantity - line.CancelledQuantity - line.ReturnedQuantity
+}
+
+// lineRefund is the part of the line total for quantity units, the last units of the line get what is
+// left so the rounding adds up to the line total
+func lineRefund(line *models.OrderLine, quantity int) money.Money {
+	if quantity == remaining(line) {
+		if left, err := line.LineTotal.Sub(line.Refunded); err == nil {
+			return left
+		}
+	}
+	return line.LineTotal.MulRatio(int64(quantity), int64(line.Quantity), money.RoundDown)
+}
+
+// due is what is left to pay of the order, units cancelled before the payment are not charged
+func due(order *models.Order) (money.Money, error) {
+	total := order.TotalPrice
+	for _, line := range order.Lines {
+		var err error
+		if total, err = total.Sub(line.Refunded); err != nil {
+			return money.Money{}, err
+		}
+	}
+	return total, nil
+}
@@ -1,17 +1,96 @@
 package order
 
 import (
+	"github.com/gcamlicali/tradeshopExample/internal/address"
 	"github.com/gcamlicali/tradeshopExample/internal/api"
 	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/gcamlicali/tradeshopExample/internal/product"
+	"github.com/gcamlicali/tradeshopExample/internal/promotion"
+	"github.com/gcamlicali/tradeshopExample/internal/tax"
+	"github.com/go-openapi/strfmt"
 )
 
 func OrderToResponse(m *models.Order) *api.Order {
+	lines := make([]*api.OrderLine, 0)
+	taxed := make([]tax.Line, 0, len(m.Lines))
+	for i := range m.Lines {
+		lines = append(lines, orderLineToResponse(&m.Lines[i]))
+		taxed = append(taxed, tax.Line{Rate: m.Lines[i].TaxRate, Net: m.Lines[i].Net, Tax: m.Lines[i].Tax, Gross: m.Lines[i].LineTotal})
+	}
+	// Lines of an order share its currency, the stored totals are kept
+	var taxLines []*api.TaxLine
+	if quote, err := tax.Summarize(taxed); err == nil {
+		taxLines = tax.TaxLinesToResponse(quote.TaxLines)
+	}
+	discounts := make([]models.Discount, 0, len(m.Discounts))
+	for _, discount := range m.Discounts {
+		discounts = append(discounts, models.Discount{PromotionID: discount.PromotionID, Code: discount.Code, Name: discount.Name, Amount: discount.Amount})
+	}
+	payments := make([]*api.Payment, 0, len(m.Payments))
+	for i := range m.Payments {
+		payments = append(payments, paymentToResponse(&m.Payments[i]))
+	}
+	refunds := make([]*api.Refund, 0, len(m.Refunds))
+	for i := range m.Refunds {
+		refunds = append(refunds, refundToResponse(&m.Refunds[i]))
+	}
+	history := make([]*api.OrderStatusChange, 0)
+	for i := range m.StatusHistory {
+		history = append(history, statusChangeToResponse(&m.StatusHistory[i]))
+	}
+
+	// Orders placed before the address book have no shipping address
+	var shippingAddress *api.Address
+	if m.AddressID != nil {
+		shippingAddress = address.PostalAddressToResponse(m.ShippingAddress)
+	}
+
 	return &api.Order{
-		ID:         m.ID.String(),
-		UserID:     m.UserID.String(),
-		CartID:     m.CartID.String(),
-		Status:     m.Status,
-		TotalPrice: m.TotalPrice,
+		ID:              m.ID.String(),
+		UserID:          m.UserID.String(),
+		CartID:          m.CartID.String(),
+		Status:          string(m.Status),
+		Discounts:       promotion.DiscountsToResponse(discounts),
+		DiscountTotal:   product.MoneyToResponse(m.DiscountTotal),
+		Subtotal:        product.MoneyToResponse(m.Subtotal),
+		TaxLines:        taxLines,
+		TaxTotal:        product.MoneyToResponse(m.TaxTotal),
+		ShippingCost:    product.MoneyToResponse(m.ShippingCost),
+		ShippingAddress: shippingAddress,
+		TotalPrice:      product.MoneyToResponse(m.TotalPrice),
+		Lines:           lines,
+		Payments:        payments,
+		Refunds:         refunds,
+		StatusHistory:   history,
+		CreatedAt:       strfmt.DateTime(m.CreatedAt),
+		UpdatedAt:       strfmt.DateTime(m.UpdatedAt),
+	}
+}
+
+func orderLineToResponse(m *models.OrderLine) *api.OrderLine {
+	return &api.OrderLine{
+		Sku:               int64(m.ProductSKU),
+		Name:              m.Name,
+		UnitPrice:         product.MoneyToResponse(m.UnitPrice),
+		Quantity:          int32(m.Quantity),
+		LineTotal:         product.MoneyToResponse(m.LineTotal),
+		Discount:          product.MoneyToResponse(m.Discount),
+		TaxRate:           m.TaxRate,
+		Net:               product.MoneyToResponse(m.Net),
+		Tax:               product.MoneyToResponse(m.Tax),
+		CancelledQuantity: int32(m.CancelledQuantity),
+		ReturnedQuantity:  int32(m.ReturnedQuantity),
+		Refunded:          product.MoneyToResponse(m.Refunded),
+	}
+}
+
+func statusChangeToResponse(m *models.OrderStatusHistory) *api.OrderStatusChange {
+	return &api.OrderStatusChange{
+		FromStatus: string(m.FromStatus),
+		ToStatus:   string(m.ToStatus),
+		ChangedBy:  m.ChangedBy.String(),
+		ChangedAt:  strfmt.DateTime(m.CreatedAt),
+		Note:       m.Note,
 	}
 }
 
@@ -25,3 +104,29 @@ func ordersToResponse(ms []models.Order) []*api.Order {
 
 	return orders
 }
+
+func paymentToResponse(m *models.Payment) *api.Payment {
+	return &api.Payment{
+		ID:        m.ID.String(),
+		Provider:  m.Provider,
+		Reference: m.Reference,
+		Status:    string(m.Status),
+		Amount:    product.MoneyToResponse(m.Amount),
+		Captured:  product.MoneyToResponse(m.Captured),
+		Refunded:  product.MoneyToResponse(m.Refunded),
+		Message:   m.Message,
+		CreatedAt: strfmt.DateTime(m.CreatedAt),
+	}
+}
+
+func refundToResponse(m *models.Refund) *api.Refund {
+	return &api.Refund{
+		ID:        m.ID.String(),
+		PaymentID: m.PaymentID.String(),
+		Reference: m.Reference,
+		Amount:    product.MoneyToResponse(m.Amount),
+		Reason:    m.Reason,
+		Status:    string(m.Status),
+		CreatedAt: strfmt.DateTime(m.CreatedAt),
+	}
+}
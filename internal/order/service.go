@@ -2,37 +2,60 @@ package order
 
 import (
 	"errors"
+	"github.com/gcamlicali/tradeshopExample/internal/address"
 	"github.com/gcamlicali/tradeshopExample/internal/cart"
 	"github.com/gcamlicali/tradeshopExample/internal/cart_item"
+	"github.com/gcamlicali/tradeshopExample/internal/category"
 	httpErr "github.com/gcamlicali/tradeshopExample/internal/httpErrors"
 	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/gcamlicali/tradeshopExample/internal/payment"
 	"github.com/gcamlicali/tradeshopExample/internal/product"
+	"github.com/gcamlicali/tradeshopExample/internal/promotion"
+	"github.com/gcamlicali/tradeshopExample/internal/shipping"
+	"github.com/gcamlicali/tradeshopExample/internal/tax"
+	"github.com/gcamlicali/tradeshopExample/internal/user"
+	"github.com/gcamlicali/tradeshopExample/pkg/config"
+	"github.com/gcamlicali/tradeshopExample/pkg/pagination"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"net/http"
 	"time"
 )
 
-var (
-	OneDay    = 24
-	ExpireDay = 14
-)
-
 type orderService struct {
-	orRepo IOrderRepository
-	cRepo  cart.ICartRepository
-	ciRepo cart_item.ICartItemRepository
-	pRepo  product.IProductRepository
+	orRepo   IOrderRepository
+	cRepo    cart.ICartRepository
+	ciRepo   cart_item.ICartItemRepository
+	pRepo    product.IProductRepository
+	uRepo    user.IUserRepository
+	aRepo    address.IAddressRepository
+	uow      IUnitOfWork
+	taxes    tax.Calculator
+	promos   promotion.Discounter
+	shipping shipping.ShippingRateCalculator
+	payments payment.PaymentProvider
+	catRepo  category.ICategoryRepository
+	// returnDays is the return window of categories without their own
+	returnDays int
 }
 
 type Service interface {
 	GetAll(userID uuid.UUID) (*[]models.Order, error)
-	Create(userID uuid.UUID) (*models.Order, error)
-	Cancel(userID uuid.UUID, orderID uuid.UUID) error
+	Get(userID uuid.UUID, orderID uuid.UUID, isAdmin bool) (*models.Order, error)
+	Search(filter SearchFilter, pageIndex, pageSize int) (*[]models.Order, int, error)
+	SearchAfter(filter SearchFilter, cursor *pagination.Cursor, pageSize int) (*[]models.Order, *pagination.Pages, error)
+	Create(userID uuid.UUID, addressID *uuid.UUID) (*models.Order, error)
+	Cancel(userID uuid.UUID, orderID uuid.UUID, lines []LineQuantity, reason string) (*models.Order, error)
+	Return(userID uuid.UUID, orderID uuid.UUID, lines []LineQuantity, reason string) (*models.Order, error)
+	Pay(userID uuid.UUID, orderID uuid.UUID, token string, key string) (*models.Order, error)
+	ConfirmPayment(header http.Header, body []byte) error
+	ChangeStatus(adminID uuid.UUID, orderID uuid.UUID, status models.OrderStatus, note string) (*models.Order, error)
+	SettlePending() (int, error)
+	StartSettler(interval time.Duration) (stop func())
 }
 
-func NewOrderService(orRepo IOrderRepository, cRepo cart.ICartRepository, ciRepo cart_item.ICartItemRepository, pRepo product.IProductRepository) Service {
-	return &orderService{orRepo: orRepo, cRepo: cRepo, ciRepo: ciRepo, pRepo: pRepo}
+func NewOrderService(orRepo IOrderRepository, cRepo cart.ICartRepository, ciRepo cart_item.ICartItemRepository, pRepo product.IProductRepository, uRepo user.IUserRepository, aRepo address.IAddressRepository, uow IUnitOfWork, taxes tax.Calculator, promos promotion.Discounter, rates shipping.ShippingRateCalculator, payments payment.PaymentProvider, catRepo category.ICategoryRepository, cfg config.OrderConfig) Service {
+	return &orderService{orRepo: orRepo, cRepo: cRepo, ciRepo: ciRepo, pRepo: pRepo, uRepo: uRepo, aRepo: aRepo, uow: uow, taxes: taxes, promos: promos, shipping: rates, payments: payments, catRepo: catRepo, returnDays: cfg.ReturnDays}
 }
 
 func (c *orderService) GetAll(userID uuid.UUID) (*[]models.Order, error) {
@@ -47,102 +70,413 @@ func (c *orderService) GetAll(userID uuid.UUID) (*[]models.Order, error) {
 	return orders, nil
 }
 
-func (c *orderService) Create(userID uuid.UUID) (*models.Order, error) {
+// Get returns an order with its lines and status history, admins may read orders of any user
+func (c *orderService) Get(userID uuid.UUID, orderID uuid.UUID, isAdmin bool) (*models.Order, error) {
+	var order *models.Order
+	var err error
+	if isAdmin {
+		order, err = c.orRepo.GetByID(orderID)
+	} else {
+		order, err = c.orRepo.GetByOrderAndUserID(userID, orderID)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, httpErr.NewRestError(http.StatusNotFound, "Order not found", err.Error())
+	}
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Get order error", err.Error())
+	}
 
-	cart, err := c.cRepo.GetByUserID(userID)
+	history, err := c.orRepo.GetStatusHistory(order.ID)
 	if err != nil {
-		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Cart error", err.Error())
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Get order status history error", err.Error())
 	}
+	order.StatusHistory = *history
 
-	//Check cartItems quantity
-	cartItems, err := c.ciRepo.GetByCartID(cart.ID)
-	for _, cartItem := range *cartItems {
-		product, _ := c.pRepo.GetBySKU(cartItem.ProductSKU)
-		if cartItem.Quantity > int(product.UnitStock) {
-			return nil, httpErr.NewRestError(http.StatusBadRequest, "Not Enough Stock", cartItem.Product.Name)
-		}
+	return order, nil
+}
+
+// Search lists orders of every user for admins
+func (c *orderService) Search(filter SearchFilter, pageIndex, pageSize int) (*[]models.Order, int, error) {
+	if err := validFilter(filter); err != nil {
+		return nil, 0, err
+	}
+
+	orders, count, err := c.orRepo.Search(filter, pageIndex, pageSize)
+	if err != nil {
+		return nil, 0, httpErr.NewRestError(http.StatusInternalServerError, "Can't get orders", err.Error())
 	}
+	return orders, count, nil
+}
 
-	//Create a order of cart
-	newOrder := models.Order{
-		CartID:     cart.ID,
-		UserID:     userID,
-		Cart:       *cart,
-		Status:     "Ordered",
-		TotalPrice: int32(cart.TotalPrice),
+// SearchAfter lists orders of every user after the cursor, the first page when it is nil. The returned
+// Pages have the cursors of the pages around it.
+func (c *orderService) SearchAfter(filter SearchFilter, cursor *pagination.Cursor, pageSize int) (*[]models.Order, *pagination.Pages, error) {
+	if err := validFilter(filter); err != nil {
+		return nil, nil, err
+	}
+	keyset, err := filter.keyset(cursor)
+	if err != nil {
+		return nil, nil, httpErr.NewRestError(http.StatusBadRequest, "Cursor is not valid", err.Error())
 	}
-	order, err := c.orRepo.Create(&newOrder)
+
+	orders, more, err := c.orRepo.SearchAfter(filter, keyset, pageSize)
 	if err != nil {
-		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Order create error", err.Error())
+		return nil, nil, httpErr.NewRestError(http.StatusInternalServerError, "Can't get orders", err.Error())
 	}
 
-	//Change ordered products quantity
-	for _, cartItem := range *cartItems {
-		product, _ := c.pRepo.GetBySKU(cartItem.ProductSKU)
-		product.UnitStock -= int32(cartItem.Quantity)
-		_, err = c.pRepo.Update(product)
+	var first, last *pagination.Cursor
+	if n := len(*orders); n > 0 {
+		if first, err = filter.cursor(&(*orders)[0]); err == nil {
+			last, err = filter.cursor(&(*orders)[n-1])
+		}
 		if err != nil {
-			return nil, httpErr.NewRestError(http.StatusInternalServerError, "Ordered Product quantity update error", err.Error())
+			return nil, nil, httpErr.NewRestError(http.StatusInternalServerError, "Cursor create error", err.Error())
 		}
 	}
+	return orders, pagination.NewCursorPages(pageSize, cursor, first, last, more), nil
+}
 
-	//Change current cart status after order operation
-	cart.IsOrdered = true
-	c.cRepo.Update(cart)
+// validFilter checks the sort field and the ranges of an admin order list filter
+func validFilter(filter SearchFilter) error {
+	if _, ok := sortColumns[filter.SortBy]; filter.SortBy != "" && !ok {
+		return httpErr.NewRestError(http.StatusBadRequest, "Unknown sort field", filter.SortBy)
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.From.After(filter.To) {
+		return httpErr.NewRestError(http.StatusBadRequest, "Date range is not valid", "from is after to")
+	}
+	if filter.MinTotal != nil && filter.MaxTotal != nil && filter.MinTotal.Amount > filter.MaxTotal.Amount {
+		return httpErr.NewRestError(http.StatusBadRequest, "Total range is not valid", "minTotal is greater than maxTotal")
+	}
+	return nil
+}
 
-	//Create a new cart for user, current cart is ordered
-	newCart := models.Cart{
-		UserID: userID,
+// Create orders the cart of the user and ships it to addressID, to the default address when it is nil
+func (c *orderService) Create(userID uuid.UUID, addressID *uuid.UUID) (*models.Order, error) {
+	//Unverified users can browse and fill their cart but can't order
+	customer, err := c.uRepo.GetByID(userID)
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "User get error", err.Error())
+	}
+	if customer.EmailVerifiedAt == nil {
+		return nil, httpErr.NewRestError(http.StatusForbidden, "Verify your email to place orders", nil)
 	}
-	_, err = c.cRepo.Create(&newCart)
+
+	shipTo, err := c.shippingAddress(userID, addressID)
 	if err != nil {
-		return nil, httpErr.NewRestError(http.StatusInternalServerError, "New cart create error after cart ordered", err.Error())
+		return nil, err
+	}
+
+	//An empty cart must not become an order, rotate the cart or open the payment flow
+	cart, err := c.cRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Cart error", err.Error())
+	}
+	cartItems, err := c.ciRepo.GetByCartID(cart.ID)
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Get cart items Error", err.Error())
+	}
+	if len(*cartItems) == 0 {
+		return nil, httpErr.NewRestError(http.StatusBadRequest, "Cart is empty", nil)
+	}
+
+	var order *models.Order
+
+	// Whole checkout runs in a single transaction, any error rolls back every step
+	err = c.uow.Do(func(tx TxRepositories) error {
+		cart, err := tx.Carts.GetByUserID(userID)
+		if err != nil {
+			return httpErr.NewRestError(http.StatusInternalServerError, "Cart error", err.Error())
+		}
+
+		cartItems, err := tx.CartItems.GetByCartID(cart.ID)
+		if err != nil {
+			return httpErr.NewRestError(http.StatusInternalServerError, "Get cart items Error", err.Error())
+		}
+		//The cart may have been emptied since it was checked above
+		if len(*cartItems) == 0 {
+			return httpErr.NewRestError(http.StatusBadRequest, "Cart is empty", nil)
+		}
+
+		//Take ordered products from stock, fails if any product does not have enough stock left
+		lines := make([]models.OrderLine, 0, len(*cartItems))
+		discountLines := make([]promotion.Line, 0, len(*cartItems))
+		weight := int64(0)
+		for _, cartItem := range *cartItems {
+			err = tx.Products.DecreaseStock(cartItem.ProductSKU, cartItem.Quantity)
+			if errors.Is(err, product.ErrNotEnoughStock) {
+				return httpErr.NewRestError(http.StatusBadRequest, "Not Enough Stock", cartItem.ProductSKU)
+			}
+			if errors.Is(err, product.ErrInvalidQuantity) {
+				return httpErr.NewRestError(http.StatusBadRequest, "Cart item quantity must be at least 1", cartItem.ProductSKU)
+			}
+			if err != nil {
+				return httpErr.NewRestError(http.StatusInternalServerError, "Ordered Product quantity update error", err.Error())
+			}
+
+			product, err := tx.Products.GetBySKU(cartItem.ProductSKU)
+			if err != nil {
+				return httpErr.NewRestError(http.StatusBadRequest, "Product not found", err.Error())
+			}
+
+			//Stock left after the sale must still cover active holds of other carts
+			held, err := tx.Reservations.HeldByOthers(cart.ID, cartItem.ProductSKU)
+			if err != nil {
+				return httpErr.NewRestError(http.StatusInternalServerError, "Get stock holds error", err.Error())
+			}
+			if int(product.UnitStock) < held {
+				return httpErr.NewRestError(http.StatusBadRequest, "Not Enough Stock", cartItem.ProductSKU)
+			}
+
+			//Keep a copy of the sold product, later product changes must not change the order
+			line := models.OrderLine{
+				ProductSKU:   product.SKU,
+				Name:         product.Name,
+				CategoryName: product.CategoryName,
+				CategoryID:   product.CategoryID,
+				UnitPrice:    product.Price,
+				Quantity:     cartItem.Quantity,
+			}
+			lines = append(lines, line)
+			discountLines = append(discountLines, promotion.Line{
+				Category: product.CategoryName,
+				Quantity: cartItem.Quantity,
+				Amount:   product.Price.Mul(int64(cartItem.Quantity)),
+			})
+			weight += product.Weight * int64(cartItem.Quantity)
+		}
+
+		//Discounts and tax are fixed at the promotions and rates in effect when the order is placed
+		now := time.Now()
+		discounts, err := c.promos.Discount(userID, cart.PromotionID, discountLines, now)
+		if err != nil {
+			return err
+		}
+		items := make([]tax.Item, 0, len(discountLines))
+		for i, line := range discountLines {
+			amount, err := line.Amount.Sub(discounts.LineDiscounts[i])
+			if err != nil {
+				return httpErr.NewRestError(http.StatusBadRequest, "Discount currency doesn't match the cart", err.Error())
+			}
+			items = append(items, tax.Item{Category: line.Category, Amount: amount})
+		}
+		quote, err := c.taxes.Quote(items, now)
+		if err != nil {
+			return err
+		}
+		for i, taxed := range quote.Lines {
+			lines[i].Discount = discounts.LineDiscounts[i]
+			lines[i].TaxRate = taxed.Rate
+			lines[i].Net = taxed.Net
+			lines[i].Tax = taxed.Tax
+			lines[i].LineTotal = taxed.Gross
+		}
+
+		//Shipping is priced on what the customer pays for the goods
+		shippingCost, err := c.shipping.Rate(shipping.Parcel{Weight: weight, Value: quote.Total})
+		if err != nil {
+			return httpErr.NewRestError(http.StatusBadRequest, "Shipping can't be priced for the cart", err.Error())
+		}
+		totalPrice, err := quote.Total.Add(shippingCost)
+		if err != nil {
+			return httpErr.NewRestError(http.StatusBadRequest, "Shipping can't be priced for the cart", err.Error())
+		}
+
+		//Holds of the cart are turned into the sale above
+		err = tx.Reservations.ReleaseCart(cart.ID)
+		if err != nil {
+			return httpErr.NewRestError(http.StatusInternalServerError, "Stock hold release error", err.Error())
+		}
+
+		//Create a order of cart
+		newOrder := models.Order{
+			CartID:          cart.ID,
+			UserID:          userID,
+			Cart:            *cart,
+			Status:          models.OrderPending,
+			TotalPrice:      totalPrice,
+			Subtotal:        quote.Subtotal,
+			TaxTotal:        quote.TaxTotal,
+			DiscountTotal:   discounts.Total,
+			ShippingCost:    shippingCost,
+			AddressID:       &shipTo.ID,
+			ShippingAddress: shipTo.PostalAddress,
+			Lines:           lines,
+			Discounts:       orderDiscounts(discounts.Discounts),
+		}
+		order, err = tx.Orders.Create(&newOrder)
+		if err != nil {
+			return httpErr.NewRestError(http.StatusInternalServerError, "Order create error", err.Error())
+		}
+
+		history := models.OrderStatusHistory{
+			OrderID:   order.ID,
+			ToStatus:  models.OrderPending,
+			ChangedBy: userID,
+		}
+		_, err = tx.Orders.CreateStatusHistory(&history)
+		if err != nil {
+			return httpErr.NewRestError(http.StatusInternalServerError, "Order status history create error", err.Error())
+		}
+
+		//Change current cart status after order operation
+		cart.IsOrdered = true
+		_, err = tx.Carts.Update(cart)
+		if err != nil {
+			return httpErr.NewRestError(http.StatusInternalServerError, "Cart update error", err.Error())
+		}
+
+		//Create a new cart for user, current cart is ordered
+		newCart := models.Cart{
+			UserID: userID,
+		}
+		_, err = tx.Carts.Create(&newCart)
+		if err != nil {
+			return httpErr.NewRestError(http.StatusInternalServerError, "New cart create error after cart ordered", err.Error())
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, httpErr.ParseErrors(err)
 	}
 
 	return order, nil
 }
 
-func (c *orderService) Cancel(userID uuid.UUID, orderID uuid.UUID) error {
-
-	//Get given order by user and order ID
-	order, err := c.orRepo.GetByOrderAndUserID(userID, orderID)
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return httpErr.NewRestError(http.StatusNotFound, "Order not found", err.Error())
+// shippingAddress returns the address book entry of the user an order is shipped to
+func (c *orderService) shippingAddress(userID uuid.UUID, addressID *uuid.UUID) (*models.Address, error) {
+	var shipTo *models.Address
+	var err error
+	if addressID != nil {
+		shipTo, err = c.aRepo.GetByID(userID, *addressID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, httpErr.NewRestError(http.StatusNotFound, "Address not found", addressID.String())
+		}
+	} else {
+		shipTo, err = c.aRepo.GetDefault(userID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, httpErr.NewRestError(http.StatusBadRequest, "Pick a shipping address", "User has no default address")
+		}
 	}
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Get address error", err.Error())
+	}
+	return shipTo, nil
+}
+
+// ChangeStatus moves any order to given status if the status lifecycle allows it
+func (c *orderService) ChangeStatus(adminID uuid.UUID, orderID uuid.UUID, status models.OrderStatus, note string) (*models.Order, error) {
+	var order *models.Order
 
+	err := c.uow.Do(func(tx TxRepositories) error {
+		var err error
+		if order, err = c.getOrder(tx, orderID); err != nil {
+			return err
+		}
+
+		if !CanTransition(Admin, order.Status, status) {
+			return httpErr.NewRestError(http.StatusBadRequest, "Order status can not be changed", string(order.Status)+" to "+string(status))
+		}
+
+		return c.transition(tx, order, status, adminID, note)
+	})
 	if err != nil {
-		return httpErr.NewRestError(http.StatusInternalServerError, "Get order error", err.Error())
+		return nil, httpErr.ParseErrors(err)
 	}
 
-	//Check order expire date
-	orderExpireDate := order.CreatedAt.Add(time.Duration(ExpireDay*OneDay) * time.Hour)
-	now := time.Now()
+	c.settle(order)
+	return order, nil
+}
 
-	if now.After(orderExpireDate) {
-		return httpErr.NewRestError(http.StatusBadRequest, "You can not cancel your order!", "Order cancel date expired")
+// getOrder locks the order for the rest of the transaction and reads it, changes of the order made at
+// the same time wait for the transaction
+func (c *orderService) getOrder(tx TxRepositories, orderID uuid.UUID) (*models.Order, error) {
+	err := tx.Orders.Lock(orderID)
+	var order *models.Order
+	if err == nil {
+		order, err = tx.Orders.GetByID(orderID)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, httpErr.NewRestError(http.StatusNotFound, "Order not found", err.Error())
 	}
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Get order error", err.Error())
+	}
+	return order, nil
+}
 
-	//Set order status cancelled
-	order.Status = "Cancelled"
-	_, err = c.orRepo.Update(order)
+// getOwnOrder locks an order of the user like getOrder and reads it
+func (c *orderService) getOwnOrder(tx TxRepositories, userID uuid.UUID, orderID uuid.UUID) (*models.Order, error) {
+	err := tx.Orders.Lock(orderID)
+	var order *models.Order
+	if err == nil {
+		order, err = tx.Orders.GetByOrderAndUserID(userID, orderID)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, httpErr.NewRestError(http.StatusNotFound, "Order not found", err.Error())
+	}
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Get order error", err.Error())
+	}
+	return order, nil
+}
+
+// transition saves the new order status with its history, settles the payments and restocks what is
+// left of the lines of cancelled or returned orders
+func (c *orderService) transition(tx TxRepositories, order *models.Order, to models.OrderStatus, actorID uuid.UUID, note string) error {
+	from := order.Status
+
+	order.Status = to
+	_, err := tx.Orders.Update(order)
 	if err != nil {
 		return httpErr.NewRestError(http.StatusInternalServerError, "Order Update Error", err.Error())
 	}
 
-	//Give ordered product quantity back
-	cartItems, err := c.ciRepo.GetByCartID(order.CartID)
+	history := models.OrderStatusHistory{
+		OrderID:    order.ID,
+		FromStatus: from,
+		ToStatus:   to,
+		ChangedBy:  actorID,
+		Note:       note,
+	}
+	_, err = tx.Orders.CreateStatusHistory(&history)
 	if err != nil {
-		return httpErr.NewRestError(http.StatusInternalServerError, "Get cart items Error", err.Error())
+		return httpErr.NewRestError(http.StatusInternalServerError, "Order status history create error", err.Error())
 	}
 
-	for _, cartItem := range *cartItems {
-		product, _ := c.pRepo.GetBySKU(cartItem.ProductSKU)
-		product.UnitStock += int32(cartItem.Quantity)
-		_, err = c.pRepo.Update(product)
-		if err != nil {
-			return httpErr.NewRestError(http.StatusInternalServerError, "Ordered Product quantity update error", err.Error())
+	if err := c.settlePayments(tx, order, to); err != nil {
+		return err
+	}
+
+	if !restocks(to) {
+		return nil
+	}
+
+	//Give the units not cancelled or returned before back
+	for i := range order.Lines {
+		line := &order.Lines[i]
+		left := remaining(line)
+		if left == 0 {
+			continue
+		}
+		if err := c.takeBack(tx, line, left, to); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
+
+// orderDiscounts copies the discounts of the cart, a later change of the promotion must not change the order
+func orderDiscounts(discounts []models.Discount) []models.OrderDiscount {
+	copies := make([]models.OrderDiscount, 0, len(discounts))
+	for _, discount := range discounts {
+		copies = append(copies, models.OrderDiscount{
+			PromotionID: discount.PromotionID,
+			Code:        discount.Code,
+			Name:        discount.Name,
+			Amount:      discount.Amount,
+		})
+	}
+	return copies
+}
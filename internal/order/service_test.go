@@ -3,14 +3,25 @@ package order
 import (
 	"github.com/gcamlicali/tradeshopExample/internal/cart"
 	"github.com/gcamlicali/tradeshopExample/internal/cart_item"
+	"github.com/gcamlicali/tradeshopExample/internal/category"
+	httpErr "github.com/gcamlicali/tradeshopExample/internal/httpErrors"
 	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/gcamlicali/tradeshopExample/internal/payment"
 	"github.com/gcamlicali/tradeshopExample/internal/product"
+	"github.com/gcamlicali/tradeshopExample/internal/promotion"
+	"github.com/gcamlicali/tradeshopExample/internal/shipping"
+	"github.com/gcamlicali/tradeshopExample/internal/tax"
+	"github.com/gcamlicali/tradeshopExample/internal/user"
+	"github.com/gcamlicali/tradeshopExample/pkg/money"
+	"github.com/gcamlicali/tradeshopExample/pkg/pagination"
 	"github.com/go-openapi/errors"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"log"
+	"net/http"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -26,11 +37,27 @@ var (
 	NExProSKU   = 9999999
 	currentTime = time.Now()
 
+	freeShipping = shipping.FlatRate{Amount: money.Zero(money.DefaultCurrency)}
+	homeAddress  = models.Address{
+		ID:     uuid.New(),
+		UserID: userID,
+		Title:  "Home",
+		PostalAddress: models.PostalAddress{
+			FullName: "Ayse Yilmaz",
+			Phone:    "+905551112233",
+			Line1:    "Ataturk Cad. No:1",
+			District: "Kadikoy",
+			City:     "Istanbul",
+			Country:  "TR",
+		},
+		IsDefault: true,
+	}
+
 	product1 = models.Product{
 		ID:           product1ID,
 		CategoryName: "CategoryExample",
 		Name:         "Product1",
-		Price:        10,
+		Price:        money.New(1000, money.DefaultCurrency),
 		SKU:          1,
 		Description:  "ExampleProduct1",
 		UnitStock:    1,
@@ -39,7 +66,7 @@ var (
 		ID:           product1ID,
 		CategoryName: "CategoryExample",
 		Name:         "Product2",
-		Price:        10,
+		Price:        money.New(1000, money.DefaultCurrency),
 		SKU:          2,
 		Description:  "ExampleProduct2",
 		UnitStock:    1,
@@ -63,22 +90,50 @@ var (
 	cart1 = models.Cart{
 		ID:         cartID,
 		UserID:     userID,
-		TotalPrice: 10,
+		TotalPrice: money.New(1000, money.DefaultCurrency),
 		IsOrdered:  false,
 	}
 	cart1updated = models.Cart{
 		ID:         cartID,
 		UserID:     userID,
-		TotalPrice: 30,
+		TotalPrice: money.New(3000, money.DefaultCurrency),
 		IsOrdered:  false,
 	}
+	// orderLine1created is the line Create makes of cartItem1, orderLine1 is the same line once saved
+	orderLine1created = models.OrderLine{
+		ProductSKU:   product1.SKU,
+		Name:         product1.Name,
+		CategoryName: product1.CategoryName,
+		UnitPrice:    product1.Price,
+		Quantity:     cartItem1.Quantity,
+		LineTotal:    product1.Price.Mul(int64(cartItem1.Quantity)),
+		Discount:     money.Zero(money.DefaultCurrency),
+		TaxRate:      2000,
+		Net:          money.New(833, money.DefaultCurrency),
+		Tax:          money.New(167, money.DefaultCurrency),
+	}
+	orderLine1 = models.OrderLine{
+		ID:           uuid.New(),
+		OrderID:      orderID,
+		ProductSKU:   orderLine1created.ProductSKU,
+		Name:         orderLine1created.Name,
+		CategoryName: orderLine1created.CategoryName,
+		UnitPrice:    orderLine1created.UnitPrice,
+		Quantity:     orderLine1created.Quantity,
+		LineTotal:    orderLine1created.LineTotal,
+		Discount:     orderLine1created.Discount,
+		TaxRate:      orderLine1created.TaxRate,
+		Net:          orderLine1created.Net,
+		Tax:          orderLine1created.Tax,
+	}
 	order1 = models.Order{
 		ID:         orderID,
 		UserID:     userID,
 		Cart:       cart1,
 		CartID:     cartID,
-		TotalPrice: int32(cart1.TotalPrice),
-		Status:     "Ordered",
+		TotalPrice: cart1.TotalPrice,
+		Status:     models.OrderPending,
+		Lines:      []models.OrderLine{orderLine1},
 		CreatedAt: time.Date(
 			currentTime.Year(),
 			currentTime.Month(),
@@ -89,6 +144,39 @@ var (
 			0,
 			time.Local),
 	}
+	order1shipped = models.Order{
+		ID:         orderID,
+		UserID:     userID,
+		CartID:     cartID,
+		TotalPrice: cart1.TotalPrice,
+		Status:     models.OrderShipped,
+		Lines:      []models.OrderLine{orderLine1},
+		CreatedAt:  order1.CreatedAt,
+	}
+	order1delivered = models.Order{
+		ID:         orderID,
+		UserID:     userID,
+		CartID:     cartID,
+		TotalPrice: cart1.TotalPrice,
+		Status:     models.OrderDelivered,
+		Lines:      []models.OrderLine{orderLine1},
+		CreatedAt:  order1.CreatedAt,
+	}
+	order1created = models.Order{
+		UserID:          userID,
+		Cart:            cart1,
+		CartID:          cartID,
+		TotalPrice:      cart1.TotalPrice,
+		Subtotal:        orderLine1.Net,
+		TaxTotal:        orderLine1.Tax,
+		DiscountTotal:   money.Zero(money.DefaultCurrency),
+		Discounts:       []models.OrderDiscount{},
+		ShippingCost:    money.Zero(money.DefaultCurrency),
+		AddressID:       &homeAddress.ID,
+		ShippingAddress: homeAddress.PostalAddress,
+		Status:          models.OrderPending,
+		Lines:           []models.OrderLine{orderLine1created},
+	}
 )
 
 func Test_orderService_GetAll(t *testing.T) {
@@ -178,6 +266,7 @@ func Test_orderService_GetAll(t *testing.T) {
 				cRepo:  tt.fields.cRepo,
 				ciRepo: tt.fields.ciRepo,
 				pRepo:  tt.fields.pRepo,
+				uow:    newUowMock(tt.fields.orRepo, tt.fields.cRepo, tt.fields.ciRepo, tt.fields.pRepo),
 			}
 			got, err := c.GetAll(tt.args.userID)
 			if (err != nil) != tt.wantErr {
@@ -233,7 +322,7 @@ func Test_orderService_Create(t *testing.T) {
 			args: args{
 				userID: userID,
 			},
-			want:    &order1,
+			want:    &order1created,
 			wantErr: false,
 		},
 		{
@@ -292,16 +381,48 @@ func Test_orderService_Create(t *testing.T) {
 			want:    nil,
 			wantErr: true,
 		},
+		{
+			name: "orderService_OrderCreate_ErrorEmptyCart_ShouldFail",
+			fields: fields{
+				orRepo: &orderMockRepo{
+					Items: []models.Order{},
+				},
+				pRepo: &productMockRepo{
+					Items: []models.Product{
+						product1,
+					},
+				},
+				ciRepo: &cartItemMockRepo{
+					Items: []models.CartItem{},
+				},
+				cRepo: &cartMockRepo{
+					Items: []models.Cart{
+						cart1,
+					},
+				},
+			},
+			args: args{
+				userID: userID,
+			},
+			want:    nil,
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			c := &orderService{
-				orRepo: tt.fields.orRepo,
-				cRepo:  tt.fields.cRepo,
-				ciRepo: tt.fields.ciRepo,
-				pRepo:  tt.fields.pRepo,
+				orRepo:   tt.fields.orRepo,
+				cRepo:    tt.fields.cRepo,
+				ciRepo:   tt.fields.ciRepo,
+				pRepo:    tt.fields.pRepo,
+				uRepo:    &userMockRepo{},
+				aRepo:    &addressMockRepo{},
+				uow:      newUowMock(tt.fields.orRepo, tt.fields.cRepo, tt.fields.ciRepo, tt.fields.pRepo),
+				taxes:    &taxMockCalculator{},
+				promos:   &promotionMockService{},
+				shipping: freeShipping,
 			}
-			got, err := c.Create(tt.args.userID)
+			got, err := c.Create(tt.args.userID, nil)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Create() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -313,6 +434,302 @@ func Test_orderService_Create(t *testing.T) {
 	}
 }
 
+func Test_orderService_Create_KeepsDiscounts(t *testing.T) {
+	pRepo := &productMockRepo{Items: []models.Product{product1}}
+	cRepo := &cartMockRepo{Items: []models.Cart{cart1}}
+	ciRepo := &cartItemMockRepo{Items: []models.CartItem{cartItem1}}
+	orRepo := &orderMockRepo{}
+	promos := &promotionMockService{
+		Items: []models.Promotion{
+			{ID: uuid.New(), Name: "Save %10", Kind: models.PromotionPercentage, Percent: 1000},
+		},
+	}
+	c := &orderService{
+		orRepo:   orRepo,
+		cRepo:    cRepo,
+		ciRepo:   ciRepo,
+		pRepo:    pRepo,
+		uRepo:    &userMockRepo{},
+		aRepo:    &addressMockRepo{},
+		uow:      newUowMock(orRepo, cRepo, ciRepo, pRepo),
+		taxes:    &taxMockCalculator{},
+		promos:   promos,
+		shipping: freeShipping,
+	}
+
+	got, err := c.Create(userID, nil)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if got.DiscountTotal != money.New(100, money.DefaultCurrency) || got.TotalPrice != money.New(900, money.DefaultCurrency) {
+		t.Errorf("Create() discount = %v, total = %v", got.DiscountTotal, got.TotalPrice)
+	}
+	if len(got.Discounts) != 1 || got.Discounts[0].Name != "Save %10" || got.Discounts[0].Amount != got.DiscountTotal {
+		t.Errorf("Create() discounts = %v", got.Discounts)
+	}
+	if got.Lines[0].Discount != got.DiscountTotal || got.Lines[0].LineTotal != got.TotalPrice {
+		t.Errorf("Create() line = %v", got.Lines[0])
+	}
+}
+
+func Test_orderService_Create_AddsShipping(t *testing.T) {
+	heavy := product1
+	heavy.Weight = 2500
+	heavy.UnitStock = 10
+	work := models.Address{ID: uuid.New(), UserID: userID, Title: "Work", PostalAddress: models.PostalAddress{FullName: "Ayse Yilmaz", City: "Ankara"}}
+	rates := shipping.FreeOver{
+		Threshold:  money.New(5000, money.DefaultCurrency),
+		Calculator: shipping.WeightBased{Base: money.New(1000, money.DefaultCurrency), PerKg: money.New(100, money.DefaultCurrency)},
+	}
+
+	tests := []struct {
+		name         string
+		quantity     int
+		addressID    *uuid.UUID
+		wantShipping money.Money
+		wantTotal    money.Money
+		wantAddress  string
+		wantErr      bool
+	}{
+		{name: "orderService_Create_WeightBased_ShouldSuccess", quantity: 2, addressID: &work.ID, wantShipping: money.New(1500, money.DefaultCurrency), wantTotal: money.New(3500, money.DefaultCurrency), wantAddress: "Ankara"},
+		{name: "orderService_Create_FreeOverThreshold_ShouldSuccess", quantity: 5, wantShipping: money.Zero(money.DefaultCurrency), wantTotal: money.New(5000, money.DefaultCurrency), wantAddress: "Istanbul"},
+		{name: "orderService_Create_ErrorAddressNotFound_ShouldFail", quantity: 1, addressID: &NExOrder, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := cartItem1
+			item.Quantity = tt.quantity
+			pRepo := &productMockRepo{Items: []models.Product{heavy}}
+			cRepo := &cartMockRepo{Items: []models.Cart{cart1}}
+			ciRepo := &cartItemMockRepo{Items: []models.CartItem{item}}
+			orRepo := &orderMockRepo{}
+			c := &orderService{
+				orRepo:   orRepo,
+				cRepo:    cRepo,
+				ciRepo:   ciRepo,
+				pRepo:    pRepo,
+				uRepo:    &userMockRepo{},
+				aRepo:    &addressMockRepo{Items: []models.Address{work}},
+				uow:      newUowMock(orRepo, cRepo, ciRepo, pRepo),
+				taxes:    &taxMockCalculator{},
+				promos:   &promotionMockService{},
+				shipping: rates,
+			}
+
+			got, err := c.Create(userID, tt.addressID)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Create() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.ShippingCost != tt.wantShipping || got.TotalPrice != tt.wantTotal {
+				t.Errorf("Create() shipping = %v, total = %v, want %v and %v", got.ShippingCost, got.TotalPrice, tt.wantShipping, tt.wantTotal)
+			}
+			if got.ShippingAddress.City != tt.wantAddress {
+				t.Errorf("Create() shipped to %v, want %v", got.ShippingAddress.City, tt.wantAddress)
+			}
+		})
+	}
+}
+
+func Test_orderService_Create_RollbackOnError(t *testing.T) {
+	pRepo := &productMockRepo{
+		Items: []models.Product{
+			product1,
+		},
+	}
+	cRepo := &failingCartMockRepo{
+		cartMockRepo: cartMockRepo{
+			Items: []models.Cart{
+				cart1,
+			},
+		},
+	}
+	orRepo := &orderMockRepo{
+		Items: []models.Order{},
+	}
+	ciRepo := &cartItemMockRepo{
+		Items: []models.CartItem{
+			cartItem1,
+		},
+	}
+	c := &orderService{
+		orRepo:   orRepo,
+		cRepo:    cRepo,
+		ciRepo:   ciRepo,
+		pRepo:    pRepo,
+		uRepo:    &userMockRepo{},
+		aRepo:    &addressMockRepo{},
+		taxes:    &taxMockCalculator{},
+		promos:   &promotionMockService{},
+		shipping: freeShipping,
+		uow:      newUowMock(orRepo, cRepo, ciRepo, pRepo),
+	}
+
+	if _, err := c.Create(userID, nil); err == nil {
+		t.Fatalf("Create() error = nil, wantErr true")
+	}
+	if len(orRepo.Items) != 0 {
+		t.Errorf("Create() order is not rolled back, got %d orders", len(orRepo.Items))
+	}
+	if pRepo.Items[0].UnitStock != product1.UnitStock {
+		t.Errorf("Create() stock is not rolled back, got %d want %d", pRepo.Items[0].UnitStock, product1.UnitStock)
+	}
+	if cRepo.Items[0].IsOrdered {
+		t.Errorf("Create() cart status is not rolled back")
+	}
+}
+
+func Test_orderService_Create_RespectsOtherCartHolds(t *testing.T) {
+	pRepo := &productMockRepo{Items: []models.Product{product1}}
+	cRepo := &cartMockRepo{Items: []models.Cart{cart1}}
+	ciRepo := &cartItemMockRepo{Items: []models.CartItem{cartItem1}}
+	orRepo := &orderMockRepo{}
+	rRepo := &reservationMockRepo{
+		Items: []models.StockReservation{
+			{CartID: uuid.New(), ProductSKU: product1.SKU, Quantity: 1, ExpiresAt: time.Now().Add(time.Hour)},
+		},
+	}
+	uow := newUowMock(orRepo, cRepo, ciRepo, pRepo)
+	uow.repos.Reservations = rRepo
+	c := &orderService{orRepo: orRepo, cRepo: cRepo, ciRepo: ciRepo, pRepo: pRepo, uRepo: &userMockRepo{}, aRepo: &addressMockRepo{}, uow: uow, taxes: &taxMockCalculator{}, promos: &promotionMockService{}, shipping: freeShipping}
+
+	if _, err := c.Create(userID, nil); err == nil {
+		t.Fatalf("Create() error = nil, wantErr true")
+	}
+	if pRepo.Items[0].UnitStock != product1.UnitStock {
+		t.Errorf("Create() stock is not rolled back, got %d want %d", pRepo.Items[0].UnitStock, product1.UnitStock)
+	}
+
+	rRepo.Items[0].ExpiresAt = time.Now().Add(-time.Minute)
+	if _, err := c.Create(userID, nil); err != nil {
+		t.Errorf("Create() with expired hold error = %v, wantErr false", err)
+	}
+}
+
+func Test_orderService_Create_ErrorStockTakenConcurrently_ShouldFail(t *testing.T) {
+	//The product still shows stock but the conditional update matches no row, as when another checkout took it first
+	pRepo := &soldOutProductMockRepo{productMockRepo: productMockRepo{Items: []models.Product{product1}}}
+	cRepo := &cartMockRepo{Items: []models.Cart{cart1}}
+	ciRepo := &cartItemMockRepo{Items: []models.CartItem{cartItem1}}
+	orRepo := &orderMockRepo{}
+	c := &orderService{
+		orRepo:   orRepo,
+		cRepo:    cRepo,
+		ciRepo:   ciRepo,
+		pRepo:    pRepo,
+		uRepo:    &userMockRepo{},
+		aRepo:    &addressMockRepo{},
+		taxes:    &taxMockCalculator{},
+		promos:   &promotionMockService{},
+		shipping: freeShipping,
+		uow:      newUowMock(orRepo, cRepo, ciRepo, pRepo),
+	}
+
+	_, err := c.Create(userID, nil)
+	if status, _ := httpErr.ErrorResponse(err); err == nil || status != http.StatusBadRequest {
+		t.Fatalf("Create() error = %v, want status %d", err, http.StatusBadRequest)
+	}
+	if len(orRepo.Items) != 0 || cRepo.Items[0].IsOrdered {
+		t.Errorf("Create() placed an order without stock")
+	}
+}
+
+func Test_orderService_Create_ErrorNegativeQuantity_ShouldFail(t *testing.T) {
+	//A negative cart line must not give stock back or lower the order total
+	item := cartItem1
+	item.Quantity = -3
+	pRepo := &productMockRepo{Items: []models.Product{product1}}
+	cRepo := &cartMockRepo{Items: []models.Cart{cart1}}
+	ciRepo := &cartItemMockRepo{Items: []models.CartItem{item}}
+	orRepo := &orderMockRepo{}
+	c := &orderService{
+		orRepo:   orRepo,
+		cRepo:    cRepo,
+		ciRepo:   ciRepo,
+		pRepo:    pRepo,
+		uRepo:    &userMockRepo{},
+		aRepo:    &addressMockRepo{},
+		taxes:    &taxMockCalculator{},
+		promos:   &promotionMockService{},
+		shipping: freeShipping,
+		uow:      newUowMock(orRepo, cRepo, ciRepo, pRepo),
+	}
+
+	_, err := c.Create(userID, nil)
+	if status, _ := httpErr.ErrorResponse(err); err == nil || status != http.StatusBadRequest {
+		t.Fatalf("Create() error = %v, want status %d", err, http.StatusBadRequest)
+	}
+	if len(orRepo.Items) != 0 || pRepo.Items[0].UnitStock != product1.UnitStock {
+		t.Errorf("Create() placed %d orders, stock = %d, want none and %d", len(orRepo.Items), pRepo.Items[0].UnitStock, product1.UnitStock)
+	}
+}
+
+func Test_orderService_Create_ConcurrentCheckoutsDoNotOversell(t *testing.T) {
+	const stock = 5
+	const customers = 50
+
+	limited := product1
+	limited.UnitStock = stock
+	mu := &sync.Mutex{}
+	pRepo := &lockedProductMockRepo{productMockRepo: &productMockRepo{Items: []models.Product{limited}}, mu: mu, lowest: stock}
+	cRepo := &lockedCartMockRepo{cartMockRepo: &cartMockRepo{}, mu: mu}
+	ciRepo := &lockedCartItemMockRepo{cartItemMockRepo: &cartItemMockRepo{}, mu: mu}
+	orRepo := &lockedOrderMockRepo{orderMockRepo: &orderMockRepo{}, mu: mu}
+
+	customerIDs := make([]uuid.UUID, customers)
+	for i := range customerIDs {
+		customerIDs[i] = uuid.New()
+		customerCart := models.Cart{ID: uuid.New(), UserID: customerIDs[i], TotalPrice: limited.Price}
+		cRepo.Items = append(cRepo.Items, customerCart)
+		ciRepo.Items = append(ciRepo.Items, models.CartItem{ID: uuid.New(), CartID: customerCart.ID, ProductSKU: limited.SKU, Price: limited.Price, Quantity: 1})
+	}
+
+	c := &orderService{
+		orRepo:   orRepo,
+		cRepo:    cRepo,
+		ciRepo:   ciRepo,
+		pRepo:    pRepo,
+		uRepo:    &userMockRepo{},
+		aRepo:    &addressMockRepo{},
+		taxes:    &taxMockCalculator{},
+		promos:   &promotionMockService{},
+		shipping: freeShipping,
+		uow:      &uowPassMock{repos: TxRepositories{Orders: orRepo, Carts: cRepo, CartItems: ciRepo, Products: pRepo, Reservations: &lockedReservationMockRepo{reservationMockRepo: &reservationMockRepo{}, mu: mu}}},
+	}
+
+	// Every checkout reads the stock and takes it at the same time, only the conditional decrement
+	// of the stock keeps them from selling more than there is
+	var wg sync.WaitGroup
+	var succeeded int
+	start := make(chan struct{})
+	for _, id := range customerIDs {
+		wg.Add(1)
+		go func(id uuid.UUID) {
+			defer wg.Done()
+			<-start
+			if _, err := c.Create(id, nil); err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			}
+		}(id)
+	}
+	close(start)
+	wg.Wait()
+
+	if pRepo.lowest < 0 {
+		t.Fatalf("Create() oversold, stock went down to %d", pRepo.lowest)
+	}
+	if succeeded != stock || len(orRepo.Items) != stock {
+		t.Errorf("Create() placed %d orders of %d checkouts, want %d", len(orRepo.Items), succeeded, stock)
+	}
+	if left := pRepo.Items[0].UnitStock; left != 0 {
+		t.Errorf("Create() left %d in stock, want 0", left)
+	}
+}
+
 func Test_orderService_Cancel(t *testing.T) {
 	type fields struct {
 		orRepo IOrderRepository
@@ -360,6 +777,36 @@ func Test_orderService_Cancel(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "orderService_OrderCancel_ErrorShipped_ShouldFail",
+			fields: fields{
+				orRepo: &orderMockRepo{
+					Items: []models.Order{
+						order1shipped,
+					},
+				},
+				pRepo: &productMockRepo{
+					Items: []models.Product{
+						product1,
+					},
+				},
+				ciRepo: &cartItemMockRepo{
+					Items: []models.CartItem{
+						cartItem1,
+					},
+				},
+				cRepo: &cartMockRepo{
+					Items: []models.Cart{
+						cart1,
+					},
+				},
+			},
+			args: args{
+				userID:  userID,
+				orderID: orderID,
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -368,99 +815,1343 @@ func Test_orderService_Cancel(t *testing.T) {
 				cRepo:  tt.fields.cRepo,
 				ciRepo: tt.fields.ciRepo,
 				pRepo:  tt.fields.pRepo,
+				uow:    newUowMock(tt.fields.orRepo, tt.fields.cRepo, tt.fields.ciRepo, tt.fields.pRepo),
 			}
-			if err := c.Cancel(tt.args.userID, tt.args.orderID); (err != nil) != tt.wantErr {
+			if _, err := c.Cancel(tt.args.userID, tt.args.orderID, nil, ""); (err != nil) != tt.wantErr {
 				t.Errorf("Cancel() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
 
-type productMockRepo struct {
-	Items []models.Product
-}
-type cartItemMockRepo struct {
-	Items []models.CartItem
-}
-type cartMockRepo struct {
-	Items []models.Cart
-}
-type orderMockRepo struct {
-	Items []models.Order
-}
+func Test_orderService_Return(t *testing.T) {
+	expired := order1delivered
+	expired.CreatedAt = currentTime.AddDate(0, 0, -15)
 
-func (p *productMockRepo) Create(a *models.Product) (*models.Product, error) {
-	for _, item := range p.Items {
-		if item.SKU == a.SKU {
-			return nil, errors.New(400, "Item should be unique on database")
-		}
+	tests := []struct {
+		name       string
+		order      models.Order
+		wantErr    bool
+		wantStatus models.OrderStatus
+		wantStock  int32
+	}{
+		{
+			name:       "orderService_OrderReturn_ShouldSuccess",
+			order:      order1delivered,
+			wantErr:    false,
+			wantStatus: models.OrderReturned,
+			wantStock:  product1.UnitStock + int32(cartItem1.Quantity),
+		},
+		{
+			name:       "orderService_OrderReturn_ErrorNotDelivered_ShouldFail",
+			order:      order1shipped,
+			wantErr:    true,
+			wantStatus: models.OrderShipped,
+			wantStock:  product1.UnitStock,
+		},
+		{
+			name:       "orderService_OrderReturn_ErrorExpired_ShouldFail",
+			order:      expired,
+			wantErr:    true,
+			wantStatus: models.OrderDelivered,
+			wantStock:  product1.UnitStock,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orRepo := &orderMockRepo{Items: []models.Order{tt.order}}
+			pRepo := &productMockRepo{Items: []models.Product{product1}}
+			ciRepo := &cartItemMockRepo{Items: []models.CartItem{cartItem1}}
+			cRepo := &cartMockRepo{Items: []models.Cart{cart1}}
+			c := &orderService{
+				orRepo:     orRepo,
+				cRepo:      cRepo,
+				ciRepo:     ciRepo,
+				pRepo:      pRepo,
+				uow:        newUowMock(orRepo, cRepo, ciRepo, pRepo),
+				catRepo:    &categoryMockRepo{},
+				returnDays: 14,
+			}
+			_, err := c.Return(userID, tt.order.ID, nil, "")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Return() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if orRepo.Items[0].Status != tt.wantStatus {
+				t.Errorf("Return() status = %v, want %v", orRepo.Items[0].Status, tt.wantStatus)
+			}
+			if pRepo.Items[0].UnitStock != tt.wantStock {
+				t.Errorf("Return() stock = %v, want %v", pRepo.Items[0].UnitStock, tt.wantStock)
+			}
+		})
 	}
-	p.Items = append(p.Items, *a)
-	return a, nil
-}
-func (p *productMockRepo) GetAll(pageIndex, pageSize int) (*[]models.Product, int, error) {
-	log.Println("size: ", len(p.Items))
-	return &p.Items, len(p.Items), nil
 }
-func (p *productMockRepo) GetByName(name string) (*[]models.Product, error) {
-	products := []models.Product{}
-	for _, item := range p.Items {
-		if strings.Contains(item.Name, name) {
-			products = append(products, item)
+
+func Test_orderService_Cancel_Lines(t *testing.T) {
+	// Three units of 10.00 can't be split evenly, the last unit gets the rounding
+	line1 := models.OrderLine{ID: uuid.New(), OrderID: orderID, ProductSKU: product1.SKU, Name: product1.Name, Quantity: 3, LineTotal: money.New(1000, money.DefaultCurrency)}
+	line2 := models.OrderLine{ID: uuid.New(), OrderID: orderID, ProductSKU: product2.SKU, Name: product2.Name, Quantity: 1, LineTotal: money.New(500, money.DefaultCurrency)}
+	total := money.New(1600, money.DefaultCurrency)
+	captured := models.Payment{ID: uuid.New(), OrderID: orderID, Provider: payment.ProviderFake, Reference: "fake_captured", Status: models.PaymentCaptured, Amount: total, Captured: total}
+	paid := models.Order{
+		ID:           orderID,
+		UserID:       userID,
+		Status:       models.OrderPaid,
+		TotalPrice:   total,
+		ShippingCost: money.New(100, money.DefaultCurrency),
+		Lines:        []models.OrderLine{line1, line2},
+		Payments:     []models.Payment{captured},
+	}
+
+	orRepo := &orderMockRepo{Items: []models.Order{paid}}
+	pRepo := &productMockRepo{Items: []models.Product{product1, product2}}
+	payRepo := &paymentMockRepo{Items: []models.Payment{captured}}
+	uow := newUowMock(orRepo, &cartMockRepo{}, &cartItemMockRepo{}, pRepo)
+	uow.repos.Payments = payRepo
+	c := &orderService{orRepo: orRepo, uow: uow, payments: payment.NewFakeProvider("secret", "", 0)}
+
+	for _, lines := range [][]LineQuantity{
+		{{SKU: product1.SKU, Quantity: 4}},
+		{{SKU: product1.SKU, Quantity: 2}, {SKU: product1.SKU, Quantity: 2}},
+		{{SKU: product1.SKU, Quantity: 0}},
+		{{SKU: NExProSKU, Quantity: 1}},
+	} {
+		if _, err := c.Cancel(userID, orderID, lines, ""); err == nil {
+			t.Errorf("Cancel(%v) error = nil", lines)
 		}
 	}
-	if len(products) > 0 {
-		return &products, nil
-	} else {
-		return nil, errors.New(400, "Product not found")
+
+	order, err := c.Cancel(userID, orderID, []LineQuantity{{SKU: product1.SKU, Quantity: 1}}, "Changed my mind")
+	if err != nil {
+		t.Fatalf("Cancel() error = %v", err)
 	}
-}
-func (p *productMockRepo) GetBySKU(SKU int) (*models.Product, error) {
-	product := models.Product{}
-	for _, item := range p.Items {
-		if item.SKU == SKU {
-			product = item
-			return &product, nil
-		}
+	if order.Status != models.OrderPaid || order.Lines[0].CancelledQuantity != 1 || order.Lines[0].Refunded != money.New(333, money.DefaultCurrency) {
+		t.Errorf("Cancel() partial order = %v, line = %+v", order.Status, order.Lines[0])
+	}
+	if pRepo.Items[0].UnitStock != product1.UnitStock+1 || pRepo.Items[1].UnitStock != product2.UnitStock {
+		t.Errorf("Cancel() partial stock = %v and %v", pRepo.Items[0].UnitStock, pRepo.Items[1].UnitStock)
+	}
+	if len(payRepo.Refunds) != 1 || payRepo.Refunds[0].PaymentID != captured.ID || payRepo.Refunds[0].Amount != money.New(333, money.DefaultCurrency) || payRepo.Refunds[0].Reason != "Changed my mind" {
+		t.Errorf("Cancel() partial refunds = %v", payRepo.Refunds)
+	}
+	if payRepo.Items[0].Status != models.PaymentCaptured {
+		t.Errorf("Cancel() partial payment = %v, want %v", payRepo.Items[0].Status, models.PaymentCaptured)
 	}
 
-	return nil, errors.New(400, "Product not found")
-}
-func (p *productMockRepo) GetByCatName(catName string) (*[]models.Product, error) {
-	products := []models.Product{}
-	for _, item := range p.Items {
-		if item.CategoryName == catName {
-			products = append(products, item)
-		}
+	// Cancelling what is left refunds the rest of the lines and the shipping cost,
+	// mock orders don't load their payments from the payment repository
+	orRepo.Items[0].Payments = payRepo.Items
+	order, err = c.Cancel(userID, orderID, nil, "")
+	if err != nil {
+		t.Fatalf("Cancel() rest error = %v", err)
 	}
-	if len(products) > 0 {
-		return &products, nil
-	} else {
-		return nil, errors.New(400, "Product not found")
+	if order.Status != models.OrderCancelled || orRepo.Items[0].Status != models.OrderCancelled {
+		t.Errorf("Cancel() rest status = %v, want %v", order.Status, models.OrderCancelled)
 	}
-}
-func (p *productMockRepo) Update(a *models.Product) (*models.Product, error) {
-	for i, item := range p.Items {
-		if item.SKU == a.SKU {
-			p.Items[i] = *a
-			break
+	if orRepo.Items[0].Lines[0].CancelledQuantity != 3 || orRepo.Items[0].Lines[0].Refunded != line1.LineTotal || orRepo.Items[0].Lines[1].Refunded != line2.LineTotal {
+		t.Errorf("Cancel() rest lines = %+v", orRepo.Items[0].Lines)
+	}
+	if pRepo.Items[0].UnitStock != product1.UnitStock+3 || pRepo.Items[1].UnitStock != product2.UnitStock+1 {
+		t.Errorf("Cancel() rest stock = %v and %v", pRepo.Items[0].UnitStock, pRepo.Items[1].UnitStock)
+	}
+	if len(payRepo.Refunds) != 2 || payRepo.Refunds[1].Amount != money.New(1267, money.DefaultCurrency) {
+		t.Errorf("Cancel() rest refunds = %v", payRepo.Refunds)
+	}
+	for _, refund := range payRepo.Refunds {
+		if refund.Status != models.RefundSucceeded || refund.Reference != "fake_refund_"+refund.ID.String() {
+			t.Errorf("Cancel() refund %v is %v with reference %q, want it sent to the gateway", refund.ID, refund.Status, refund.Reference)
 		}
 	}
-	return a, nil
+	if payRepo.Items[0].Status != models.PaymentRefunded || payRepo.Items[0].Refunded != total {
+		t.Errorf("Cancel() rest payment = %v refunded %v", payRepo.Items[0].Status, payRepo.Items[0].Refunded)
+	}
 }
-func (p *productMockRepo) Delete(sku int) error {
-	pro, err := p.GetBySKU(sku)
+
+func Test_orderService_Cancel_RefundAfterCommit(t *testing.T) {
+	captured := models.Payment{ID: uuid.New(), OrderID: orderID, Provider: payment.ProviderFake, Reference: "fake_captured", Status: models.PaymentCaptured, Amount: order1.TotalPrice, Captured: order1.TotalPrice}
+	paid := order1
+	paid.Status = models.OrderPaid
+	paid.Lines = []models.OrderLine{orderLine1}
+	paid.Payments = []models.Payment{captured}
+
+	orRepo := &orderMockRepo{Items: []models.Order{paid}}
+	payRepo := &paymentMockRepo{Items: []models.Payment{captured}}
+	uow := newUowMock(orRepo, &cartMockRepo{}, &cartItemMockRepo{}, &productMockRepo{Items: []models.Product{product1}})
+	uow.repos.Payments = payRepo
+	provider := &flakyProvider{FakeProvider: payment.NewFakeProvider("secret", "", 0), Fails: 1}
+	c := &orderService{orRepo: orRepo, uow: uow, payments: provider}
+
+	// The cancellation is saved even when the gateway fails, the refund waits to be sent again
+	order, err := c.Cancel(userID, orderID, nil, "")
 	if err != nil {
-		return errors.New(400, "Product not found")
+		t.Fatalf("Cancel() error = %v", err)
 	}
-	for i, item := range p.Items {
-		if item.SKU == pro.SKU {
-			p.Items = append(p.Items[:i], p.Items[i+1:]...)
-			break
-		}
+	if order.Status != models.OrderCancelled || len(payRepo.Refunds) != 1 || payRepo.Refunds[0].Status != models.RefundPending {
+		t.Fatalf("Cancel() order %v, refunds %v, want one pending refund", order.Status, payRepo.Refunds)
+	}
+	if payRepo.Items[0].Status != models.PaymentRefunded || payRepo.Items[0].Refunded != captured.Captured {
+		t.Errorf("Cancel() payment = %v refunded %v", payRepo.Items[0].Status, payRepo.Items[0].Refunded)
 	}
-	return nil
-}
+
+	// mock orders don't load their refunds from the payment repository
+	orRepo.Items[0].Refunds = payRepo.Refunds
+	if settled, err := c.SettlePending(); err != nil || settled != 1 {
+		t.Fatalf("SettlePending() = %d, %v, want 1 order", settled, err)
+	}
+	orRepo.Items[0].Refunds = payRepo.Refunds
+	if settled, err := c.SettlePending(); err != nil || settled != 0 {
+		t.Fatalf("SettlePending() again = %d, %v, want nothing left", settled, err)
+	}
+
+	refund := payRepo.Refunds[0]
+	if refund.Status != models.RefundSucceeded || refund.Reference == "" {
+		t.Errorf("SettlePending() refund = %v with reference %q", refund.Status, refund.Reference)
+	}
+	if len(provider.RefundKeys) != 2 || provider.RefundKeys[0] != refund.ID.String() || provider.RefundKeys[1] != refund.ID.String() {
+		t.Errorf("gateway got refund keys %v, want the refund id twice", provider.RefundKeys)
+	}
+}
+
+func Test_orderService_Cancel_LinesBeforePayment(t *testing.T) {
+	line := orderLine1
+	line.Quantity = 2
+	line.LineTotal = money.New(2000, money.DefaultCurrency)
+	pending := order1
+	pending.TotalPrice = line.LineTotal
+	pending.Lines = []models.OrderLine{line}
+
+	orRepo := &orderMockRepo{Items: []models.Order{pending}}
+	payRepo := &paymentMockRepo{}
+	uow := newUowMock(orRepo, &cartMockRepo{}, &cartItemMockRepo{}, &productMockRepo{Items: []models.Product{product1}})
+	uow.repos.Payments = payRepo
+	c := &orderService{orRepo: orRepo, uow: uow, payments: payment.NewFakeProvider("secret", "", 0)}
+
+	// A payment waiting for the gateway blocks partial cancellations
+	if _, err := c.Pay(userID, orderID, payment.FakeTokenDelay, ""); err != nil {
+		t.Fatalf("Pay() error = %v", err)
+	}
+	orRepo.Items[0].Payments = payRepo.Items
+	if _, err := c.Cancel(userID, orderID, []LineQuantity{{SKU: line.ProductSKU, Quantity: 1}}, ""); err == nil {
+		t.Errorf("Cancel() with a pending payment error = nil")
+	}
+
+	// Units cancelled before the payment are not charged
+	orRepo.Items[0].Payments = nil
+	payRepo.Items = nil
+	if _, err := c.Cancel(userID, orderID, []LineQuantity{{SKU: line.ProductSKU, Quantity: 1}}, ""); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+	order, err := c.Pay(userID, orderID, "tok_visa", "")
+	if err != nil {
+		t.Fatalf("Pay() error = %v", err)
+	}
+	if order.Status != models.OrderPaid || payRepo.Items[0].Amount != money.New(1000, money.DefaultCurrency) {
+		t.Errorf("Pay() after cancel order = %v, charged %v", order.Status, payRepo.Items[0].Amount)
+	}
+}
+
+func Test_orderService_Cancel_DeletedProduct(t *testing.T) {
+	line := orderLine1
+	line.Quantity = 2
+	pending := order1
+	pending.Lines = []models.OrderLine{line}
+
+	orRepo := &orderMockRepo{Items: []models.Order{pending}}
+	pRepo := &productMockRepo{Items: []models.Product{product1}}
+	uow := newUowMock(orRepo, &cartMockRepo{}, &cartItemMockRepo{}, pRepo)
+	c := &orderService{orRepo: orRepo, uow: uow, payments: payment.NewFakeProvider("secret", "", 0)}
+
+	// The order is cancelled from its lines even when the product is gone since the purchase
+	if err := pRepo.Delete(line.ProductSKU); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	order, err := c.Cancel(userID, orderID, nil, "")
+	if err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+	if order.Status != models.OrderCancelled || orRepo.Items[0].Lines[0].CancelledQuantity != 2 {
+		t.Errorf("Cancel() order = %v, line = %+v", order.Status, orRepo.Items[0].Lines[0])
+	}
+}
+
+func Test_orderService_Return_CategoryWindow(t *testing.T) {
+	categoryName := product1.CategoryName
+	deliveredAt := currentTime.AddDate(0, 0, -20)
+	renamed := "Renamed"
+	tests := []struct {
+		name       string
+		returnDays int
+		renamed    bool
+		wantErr    bool
+	}{
+		{name: "orderService_Return_CategoryWindow_ShouldSuccess", returnDays: 30, wantErr: false},
+		{name: "orderService_Return_RenamedCategoryWindow_ShouldSuccess", returnDays: 30, renamed: true, wantErr: false},
+		{name: "orderService_Return_CategoryWindowExpired_ShouldFail", returnDays: 10, wantErr: true},
+		{name: "orderService_Return_DefaultWindowExpired_ShouldFail", returnDays: 0, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := orderLine1
+			line.Quantity = 2
+			// The line finds its category by ID after the category is renamed
+			category := models.Category{ID: uuid.New(), Name: &categoryName, ReturnDays: tt.returnDays}
+			if tt.renamed {
+				category.Name = &renamed
+				line.CategoryID = &category.ID
+			}
+			delivered := order1delivered
+			delivered.CreatedAt = deliveredAt.AddDate(0, 0, -5)
+			delivered.Lines = []models.OrderLine{line}
+			orRepo := &orderMockRepo{
+				Items:   []models.Order{delivered},
+				History: []models.OrderStatusHistory{{OrderID: orderID, FromStatus: models.OrderShipped, ToStatus: models.OrderDelivered, CreatedAt: deliveredAt}},
+			}
+			pRepo := &productMockRepo{Items: []models.Product{product1}}
+			c := &orderService{
+				orRepo:     orRepo,
+				uow:        newUowMock(orRepo, &cartMockRepo{}, &cartItemMockRepo{}, pRepo),
+				catRepo:    &categoryMockRepo{Items: []models.Category{category}},
+				returnDays: 14,
+			}
+
+			order, err := c.Return(userID, orderID, []LineQuantity{{SKU: line.ProductSKU, Quantity: 1}}, "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Return() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if order.Status != models.OrderDelivered || order.Lines[0].ReturnedQuantity != 1 || pRepo.Items[0].UnitStock != product1.UnitStock+1 {
+				t.Errorf("Return() order = %v, line = %+v, stock = %v", order.Status, order.Lines[0], pRepo.Items[0].UnitStock)
+			}
+		})
+	}
+}
+
+func Test_orderService_ChangeStatus(t *testing.T) {
+	adminID := uuid.New()
+	tests := []struct {
+		name    string
+		order   models.Order
+		status  models.OrderStatus
+		wantErr bool
+	}{
+		{
+			name:    "orderService_ChangeStatus_PendingToPaid_ShouldSuccess",
+			order:   order1,
+			status:  models.OrderPaid,
+			wantErr: false,
+		},
+		{
+			name:    "orderService_ChangeStatus_ShippedToDelivered_ShouldSuccess",
+			order:   order1shipped,
+			status:  models.OrderDelivered,
+			wantErr: false,
+		},
+		{
+			name:    "orderService_ChangeStatus_PendingToShipped_ShouldFail",
+			order:   order1,
+			status:  models.OrderShipped,
+			wantErr: true,
+		},
+		{
+			name:    "orderService_ChangeStatus_ShippedToCancelled_ShouldFail",
+			order:   order1shipped,
+			status:  models.OrderCancelled,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orRepo := &orderMockRepo{Items: []models.Order{tt.order}}
+			pRepo := &productMockRepo{Items: []models.Product{product1}}
+			ciRepo := &cartItemMockRepo{Items: []models.CartItem{cartItem1}}
+			cRepo := &cartMockRepo{Items: []models.Cart{cart1}}
+			c := &orderService{
+				orRepo: orRepo,
+				cRepo:  cRepo,
+				ciRepo: ciRepo,
+				pRepo:  pRepo,
+				uow:    newUowMock(orRepo, cRepo, ciRepo, pRepo),
+			}
+			got, err := c.ChangeStatus(adminID, tt.order.ID, tt.status, "")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ChangeStatus() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				if len(orRepo.History) != 0 {
+					t.Errorf("ChangeStatus() history saved for a rejected change")
+				}
+				return
+			}
+			if got.Status != tt.status {
+				t.Errorf("ChangeStatus() status = %v, want %v", got.Status, tt.status)
+			}
+			if len(orRepo.History) != 1 || orRepo.History[0].FromStatus != tt.order.Status || orRepo.History[0].ToStatus != tt.status || orRepo.History[0].ChangedBy != adminID {
+				t.Errorf("ChangeStatus() history = %v", orRepo.History)
+			}
+		})
+	}
+}
+
+func Test_orderService_Get(t *testing.T) {
+	history := models.OrderStatusHistory{OrderID: orderID, ToStatus: models.OrderPending, ChangedBy: userID}
+	tests := []struct {
+		name    string
+		userID  uuid.UUID
+		orderID uuid.UUID
+		isAdmin bool
+		wantErr bool
+	}{
+		{
+			name:    "orderService_Get_OwnOrder_ShouldSuccess",
+			userID:  userID,
+			orderID: orderID,
+			isAdmin: false,
+			wantErr: false,
+		},
+		{
+			name:    "orderService_Get_OtherUsersOrder_ShouldFail",
+			userID:  NExUser,
+			orderID: orderID,
+			isAdmin: false,
+			wantErr: true,
+		},
+		{
+			name:    "orderService_Get_AdminOtherUsersOrder_ShouldSuccess",
+			userID:  NExUser,
+			orderID: orderID,
+			isAdmin: true,
+			wantErr: false,
+		},
+		{
+			name:    "orderService_Get_ErrorOrderNotFound_ShouldFail",
+			userID:  userID,
+			orderID: NExOrder,
+			isAdmin: true,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &orderService{
+				orRepo: &orderMockRepo{
+					Items:   []models.Order{order1},
+					History: []models.OrderStatusHistory{history},
+				},
+			}
+			got, err := c.Get(tt.userID, tt.orderID, tt.isAdmin)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Get() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(got.Lines, order1.Lines) {
+				t.Errorf("Get() lines = %v, want %v", got.Lines, order1.Lines)
+			}
+			if len(got.StatusHistory) != 1 || got.StatusHistory[0].ToStatus != models.OrderPending {
+				t.Errorf("Get() status history = %v", got.StatusHistory)
+			}
+		})
+	}
+}
+
+func Test_orderService_Search(t *testing.T) {
+	otherUserID := uuid.New()
+	otherOrder := models.Order{ID: uuid.New(), UserID: otherUserID, TotalPrice: money.New(10000, money.DefaultCurrency), Status: models.OrderPaid, CreatedAt: currentTime}
+	minTotal, maxTotal := money.New(5000, money.DefaultCurrency), money.New(1000, money.DefaultCurrency)
+	tests := []struct {
+		name    string
+		filter  SearchFilter
+		want    int
+		wantErr bool
+	}{
+		{
+			name:    "orderService_Search_NoFilter_ShouldSuccess",
+			filter:  SearchFilter{},
+			want:    2,
+			wantErr: false,
+		},
+		{
+			name:    "orderService_Search_ByStatusAndUser_ShouldSuccess",
+			filter:  SearchFilter{Status: models.OrderPaid, UserID: otherUserID},
+			want:    1,
+			wantErr: false,
+		},
+		{
+			name:    "orderService_Search_ByMinTotal_ShouldSuccess",
+			filter:  SearchFilter{MinTotal: &minTotal, SortBy: "totalPrice", SortDesc: true},
+			want:    1,
+			wantErr: false,
+		},
+		{
+			name:    "orderService_Search_ErrorUnknownSortField_ShouldFail",
+			filter:  SearchFilter{SortBy: "password"},
+			wantErr: true,
+		},
+		{
+			name:    "orderService_Search_ErrorMinTotalAboveMaxTotal_ShouldFail",
+			filter:  SearchFilter{MinTotal: &minTotal, MaxTotal: &maxTotal},
+			wantErr: true,
+		},
+		{
+			name:    "orderService_Search_ErrorFromAfterTo_ShouldFail",
+			filter:  SearchFilter{From: currentTime, To: currentTime.Add(-time.Hour)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &orderService{
+				orRepo: &orderMockRepo{Items: []models.Order{order1, otherOrder}},
+			}
+			got, count, err := c.Search(tt.filter, 1, 10)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Search() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if count != tt.want || len(*got) != tt.want {
+				t.Errorf("Search() count = %v, len = %v, want %v", count, len(*got), tt.want)
+			}
+		})
+	}
+}
+
+func Test_SearchFilter_orderBy(t *testing.T) {
+	for _, filter := range []SearchFilter{{}, {SortBy: "totalPrice"}, {SortBy: "status", SortDesc: true}} {
+		columns := filter.orderBy()
+		last := columns[len(columns)-1]
+		if len(columns) != 2 || last.Column.Name != "id" || last.Desc != columns[0].Desc {
+			t.Errorf("orderBy(%+v) = %+v, want the order ID to break ties", filter, columns)
+		}
+	}
+}
+
+func Test_orderService_SearchAfter(t *testing.T) {
+	otherOrder := models.Order{ID: uuid.New(), UserID: uuid.New(), TotalPrice: money.New(10000, money.DefaultCurrency), Status: models.OrderPaid, CreatedAt: currentTime}
+	c := &orderService{orRepo: &orderMockRepo{Items: []models.Order{order1, otherOrder}}}
+	filter := SearchFilter{SortBy: "totalPrice", SortDesc: true}
+
+	got, pages, err := c.SearchAfter(filter, nil, 1)
+	if err != nil {
+		t.Fatalf("SearchAfter() error = %v", err)
+	}
+	if len(*got) != 1 || pages.Next == "" || pages.Prev != "" || pages.TotalCount != -1 {
+		t.Fatalf("SearchAfter() got %d orders, next %q, prev %q, total %d", len(*got), pages.Next, pages.Prev, pages.TotalCount)
+	}
+
+	cursor, err := pagination.DecodeCursor(pages.Next, filter.sort())
+	if err != nil {
+		t.Fatalf("DecodeCursor() error = %v", err)
+	}
+	var total int64
+	var id uuid.UUID
+	if err := cursor.Values(&total, &id); err != nil || total != (*got)[0].TotalPrice.Amount || id != (*got)[0].ID {
+		t.Errorf("cursor values = %d %s, %v, want the last order of the page", total, id, err)
+	}
+
+	if _, _, err := c.SearchAfter(SearchFilter{SortBy: "password"}, nil, 1); err == nil {
+		t.Error("SearchAfter() accepted an unknown sort field")
+	}
+}
+
+func Test_orderService_Pay(t *testing.T) {
+	tests := []struct {
+		name        string
+		order       models.Order
+		token       string
+		wantStatus  models.OrderStatus
+		wantPayment models.PaymentStatus
+		wantErr     bool
+	}{
+		{name: "orderService_Pay_ShouldSuccess", order: order1, token: "tok_visa", wantStatus: models.OrderPaid, wantPayment: models.PaymentCaptured},
+		{name: "orderService_Pay_Delayed_ShouldSuccess", order: order1, token: payment.FakeTokenDelay, wantStatus: models.OrderPending, wantPayment: models.PaymentPending},
+		{name: "orderService_Pay_ErrorDeclined_ShouldFail", order: order1, token: payment.FakeTokenDecline, wantStatus: models.OrderPending, wantPayment: models.PaymentDeclined, wantErr: true},
+		{name: "orderService_Pay_ErrorNotPending_ShouldFail", order: order1shipped, token: "tok_visa", wantStatus: models.OrderShipped, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orRepo := &orderMockRepo{Items: []models.Order{tt.order}}
+			payRepo := &paymentMockRepo{}
+			uow := newUowMock(orRepo, &cartMockRepo{}, &cartItemMockRepo{}, &productMockRepo{})
+			uow.repos.Payments = payRepo
+			c := &orderService{orRepo: orRepo, uow: uow, payments: payment.NewFakeProvider("secret", "", 0)}
+
+			_, err := c.Pay(userID, tt.order.ID, tt.token, "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Pay() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if orRepo.Items[0].Status != tt.wantStatus {
+				t.Errorf("Pay() order status = %v, want %v", orRepo.Items[0].Status, tt.wantStatus)
+			}
+			if tt.wantPayment == "" {
+				if len(payRepo.Items) != 0 {
+					t.Errorf("Pay() recorded payments %v, want none", payRepo.Items)
+				}
+				return
+			}
+			if len(payRepo.Items) != 1 || payRepo.Items[0].Status != tt.wantPayment || payRepo.Items[0].Amount != tt.order.TotalPrice {
+				t.Errorf("Pay() payments = %v, want one %v payment", payRepo.Items, tt.wantPayment)
+			}
+		})
+	}
+}
+
+func Test_orderService_Pay_Retry(t *testing.T) {
+	orRepo := &orderMockRepo{Items: []models.Order{order1}}
+	payRepo := &paymentMockRepo{}
+	uow := newUowMock(orRepo, &cartMockRepo{}, &cartItemMockRepo{}, &productMockRepo{})
+	uow.repos.Payments = payRepo
+	c := &orderService{orRepo: orRepo, uow: uow, payments: payment.NewFakeProvider("secret", "", 0)}
+
+	// A request paying the order at the same time finds the payment in progress
+	inFlight := models.Payment{ID: uuid.New(), OrderID: orderID, IdempotencyKey: "first", Status: models.PaymentPending, Amount: order1.TotalPrice}
+	payRepo.Items = []models.Payment{inFlight}
+	_, err := c.Pay(userID, orderID, "tok_visa", "")
+	if status, _ := httpErr.ErrorResponse(err); status != http.StatusConflict {
+		t.Fatalf("Pay() with a payment in progress status = %d, want %d", status, http.StatusConflict)
+	}
+	if len(payRepo.Items) != 1 || orRepo.Items[0].Status != models.OrderPending {
+		t.Errorf("Pay() with a payment in progress recorded %d payments, order %v", len(payRepo.Items), orRepo.Items[0].Status)
+	}
+
+	// The request of the payment in progress goes on with it
+	order, err := c.Pay(userID, orderID, "tok_visa", "first")
+	if err != nil {
+		t.Fatalf("Pay() error = %v", err)
+	}
+	if order.Status != models.OrderPaid || len(payRepo.Items) != 1 || payRepo.Items[0].Status != models.PaymentCaptured {
+		t.Fatalf("Pay() order %v, payments %v, want one captured payment", order.Status, payRepo.Items)
+	}
+
+	// A retry with the same key gets the payment made before
+	history := len(orRepo.History)
+	if order, err = c.Pay(userID, orderID, "tok_visa", "first"); err != nil {
+		t.Fatalf("Pay() again error = %v", err)
+	}
+	if order.Status != models.OrderPaid || len(payRepo.Items) != 1 || len(orRepo.History) != history {
+		t.Errorf("Pay() again charged the order twice")
+	}
+	if _, err := c.Pay(userID, orderID, "tok_visa", "second"); err == nil {
+		t.Errorf("Pay() with a new key of a paid order error = nil")
+	}
+}
+
+func Test_orderService_Pay_CancelledMeanwhile(t *testing.T) {
+	line := orderLine1
+	pending := order1
+	pending.TotalPrice = line.LineTotal
+	pending.Lines = []models.OrderLine{line}
+	inFlight := models.Payment{ID: uuid.New(), OrderID: orderID, IdempotencyKey: "first", Status: models.PaymentPending, Amount: line.LineTotal}
+	pending.Payments = []models.Payment{inFlight}
+
+	orRepo := &orderMockRepo{Items: []models.Order{pending}}
+	payRepo := &paymentMockRepo{Items: []models.Payment{inFlight}}
+	uow := newUowMock(orRepo, &cartMockRepo{}, &cartItemMockRepo{}, &productMockRepo{Items: []models.Product{product1}})
+	uow.repos.Payments = payRepo
+	c := &orderService{orRepo: orRepo, uow: uow, payments: payment.NewFakeProvider("secret", "", 0)}
+
+	// The order is cancelled before the gateway answers the payment
+	if _, err := c.Cancel(userID, orderID, nil, ""); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+	if payRepo.Items[0].Status != models.PaymentVoided {
+		t.Fatalf("Cancel() payment = %v, want %v", payRepo.Items[0].Status, models.PaymentVoided)
+	}
+
+	_, err := c.Pay(userID, orderID, "tok_visa", "first")
+	if status, _ := httpErr.ErrorResponse(err); status != http.StatusBadRequest {
+		t.Fatalf("Pay() of a cancelled order status = %d, want %d", status, http.StatusBadRequest)
+	}
+	if orRepo.Items[0].Status != models.OrderCancelled || len(payRepo.Items) != 1 || payRepo.Items[0].Status != models.PaymentVoided || payRepo.Items[0].Reference == "" {
+		t.Errorf("Pay() of a cancelled order left order %v, payments %v, want the authorization voided", orRepo.Items[0].Status, payRepo.Items)
+	}
+}
+
+func Test_orderService_ConfirmPayment(t *testing.T) {
+	provider := payment.NewFakeProvider("secret", "", 0)
+	tests := []struct {
+		name        string
+		token       string
+		status      models.PaymentStatus
+		wantStatus  models.OrderStatus
+		wantPayment models.PaymentStatus
+	}{
+		{name: "orderService_ConfirmPayment_Authorized_ShouldSuccess", token: payment.FakeTokenDelay, status: models.PaymentAuthorized, wantStatus: models.OrderPaid, wantPayment: models.PaymentCaptured},
+		{name: "orderService_ConfirmPayment_Declined_ShouldSuccess", token: payment.FakeTokenDelayDecline, status: models.PaymentDeclined, wantStatus: models.OrderPending, wantPayment: models.PaymentDeclined},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orRepo := &orderMockRepo{Items: []models.Order{order1}}
+			payRepo := &paymentMockRepo{}
+			uow := newUowMock(orRepo, &cartMockRepo{}, &cartItemMockRepo{}, &productMockRepo{})
+			uow.repos.Payments = payRepo
+			c := &orderService{orRepo: orRepo, uow: uow, payments: provider}
+
+			if _, err := c.Pay(userID, orderID, tt.token, ""); err != nil {
+				t.Fatalf("Pay() error = %v", err)
+			}
+			header, body := provider.Webhook(payRepo.Items[0].Reference, tt.status, "")
+			if err := c.ConfirmPayment(header, body); err != nil {
+				t.Fatalf("ConfirmPayment() error = %v", err)
+			}
+			history := len(orRepo.History)
+			// The gateway may deliver the same webhook again
+			if err := c.ConfirmPayment(header, body); err != nil {
+				t.Fatalf("ConfirmPayment() again error = %v", err)
+			}
+
+			if orRepo.Items[0].Status != tt.wantStatus || payRepo.Items[0].Status != tt.wantPayment {
+				t.Errorf("ConfirmPayment() order %v payment %v, want %v and %v", orRepo.Items[0].Status, payRepo.Items[0].Status, tt.wantStatus, tt.wantPayment)
+			}
+			if len(orRepo.History) != history || len(payRepo.Events) != 1 {
+				t.Errorf("ConfirmPayment() applied the same webhook twice")
+			}
+		})
+	}
+
+	c := &orderService{payments: provider}
+	header, body := payment.NewFakeProvider("other secret", "", 0).Webhook("fake_key", models.PaymentAuthorized, "")
+	if err := c.ConfirmPayment(header, body); err == nil {
+		t.Errorf("ConfirmPayment() accepted a webhook with a wrong signature")
+	}
+}
+
+func Test_orderService_ConfirmPayment_CaptureFails(t *testing.T) {
+	provider := &flakyProvider{FakeProvider: payment.NewFakeProvider("secret", "", 0), CaptureFails: 1}
+	orRepo := &orderMockRepo{Items: []models.Order{order1}}
+	payRepo := &paymentMockRepo{}
+	uow := newUowMock(orRepo, &cartMockRepo{}, &cartItemMockRepo{}, &productMockRepo{})
+	uow.repos.Payments = payRepo
+	c := &orderService{orRepo: orRepo, uow: uow, payments: provider}
+
+	if _, err := c.Pay(userID, orderID, payment.FakeTokenDelay, ""); err != nil {
+		t.Fatalf("Pay() error = %v", err)
+	}
+	header, body := provider.Webhook(payRepo.Items[0].Reference, models.PaymentAuthorized, "")
+
+	// The authorization is saved but the event is not, the gateway delivers it again
+	err := c.ConfirmPayment(header, body)
+	if status, _ := httpErr.ErrorResponse(err); status != http.StatusBadGateway {
+		t.Fatalf("ConfirmPayment() with a failed capture status = %d, want %d", status, http.StatusBadGateway)
+	}
+	if orRepo.Items[0].Status != models.OrderPending || payRepo.Items[0].Status != models.PaymentAuthorized || len(payRepo.Events) != 0 {
+		t.Fatalf("ConfirmPayment() with a failed capture left order %v, payment %v, %d events", orRepo.Items[0].Status, payRepo.Items[0].Status, len(payRepo.Events))
+	}
+
+	if err := c.ConfirmPayment(header, body); err != nil {
+		t.Fatalf("ConfirmPayment() again error = %v", err)
+	}
+	if orRepo.Items[0].Status != models.OrderPaid || payRepo.Items[0].Status != models.PaymentCaptured || len(payRepo.Events) != 1 {
+		t.Errorf("ConfirmPayment() again left order %v, payment %v, %d events", orRepo.Items[0].Status, payRepo.Items[0].Status, len(payRepo.Events))
+	}
+}
+
+func Test_orderService_ChangeStatus_VoidAfterCommit(t *testing.T) {
+	authorized := models.Payment{ID: uuid.New(), OrderID: orderID, Provider: payment.ProviderFake, Reference: "fake_authorized", Status: models.PaymentAuthorized, Amount: order1.TotalPrice}
+	order := order1
+	order.Payments = []models.Payment{authorized}
+	orRepo := &orderMockRepo{Items: []models.Order{order}}
+	payRepo := &paymentMockRepo{Items: []models.Payment{authorized}}
+	uow := newUowMock(orRepo, &cartMockRepo{}, &cartItemMockRepo{}, &productMockRepo{Items: []models.Product{product1}})
+	uow.repos.Payments = payRepo
+	c := &orderService{orRepo: orRepo, uow: uow, payments: &flakyProvider{FakeProvider: payment.NewFakeProvider("secret", "", 0), VoidFails: 1}}
+
+	// The cancellation is saved even when the gateway fails, the void waits to be sent again
+	if _, err := c.ChangeStatus(uuid.New(), orderID, models.OrderCancelled, ""); err != nil {
+		t.Fatalf("ChangeStatus() error = %v", err)
+	}
+	if orRepo.Items[0].Status != models.OrderCancelled || payRepo.Items[0].Status != models.PaymentVoiding {
+		t.Fatalf("ChangeStatus() order %v, payment %v, want the void pending", orRepo.Items[0].Status, payRepo.Items[0].Status)
+	}
+
+	// mock orders don't load their payments from the payment repository
+	orRepo.Items[0].Payments = payRepo.Items
+	if settled, err := c.SettlePending(); err != nil || settled != 1 {
+		t.Fatalf("SettlePending() = %d, %v, want 1 order", settled, err)
+	}
+	if payRepo.Items[0].Status != models.PaymentVoided {
+		t.Errorf("SettlePending() payment = %v, want %v", payRepo.Items[0].Status, models.PaymentVoided)
+	}
+	if settled, err := c.SettlePending(); err != nil || settled != 0 {
+		t.Errorf("SettlePending() again = %d, %v, want nothing left", settled, err)
+	}
+}
+
+func Test_orderService_ChangeStatus_SettlesPayments(t *testing.T) {
+	captured := models.Payment{ID: uuid.New(), OrderID: orderID, Provider: payment.ProviderFake, Reference: "fake_captured", Status: models.PaymentCaptured, Amount: order1.TotalPrice, Captured: order1.TotalPrice}
+	authorized := models.Payment{ID: uuid.New(), OrderID: orderID, Provider: payment.ProviderFake, Reference: "fake_authorized", Status: models.PaymentAuthorized, Amount: order1.TotalPrice}
+	tests := []struct {
+		name        string
+		from        models.OrderStatus
+		to          models.OrderStatus
+		payment     models.Payment
+		wantPayment models.PaymentStatus
+	}{
+		{name: "orderService_ChangeStatus_RefundsCaptured_ShouldSuccess", from: models.OrderPaid, to: models.OrderRefunded, payment: captured, wantPayment: models.PaymentRefunded},
+		{name: "orderService_ChangeStatus_VoidsAuthorized_ShouldSuccess", from: models.OrderPending, to: models.OrderCancelled, payment: authorized, wantPayment: models.PaymentVoided},
+		{name: "orderService_ChangeStatus_KeepsCapturedOnCancel_ShouldSuccess", from: models.OrderPaid, to: models.OrderCancelled, payment: captured, wantPayment: models.PaymentCaptured},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := order1
+			order.Status = tt.from
+			order.Payments = []models.Payment{tt.payment}
+			orRepo := &orderMockRepo{Items: []models.Order{order}}
+			pRepo := &productMockRepo{Items: []models.Product{product1}}
+			payRepo := &paymentMockRepo{Items: []models.Payment{tt.payment}}
+			uow := newUowMock(orRepo, &cartMockRepo{}, &cartItemMockRepo{}, pRepo)
+			uow.repos.Payments = payRepo
+			c := &orderService{orRepo: orRepo, uow: uow, payments: payment.NewFakeProvider("secret", "", 0)}
+
+			if _, err := c.ChangeStatus(uuid.New(), orderID, tt.to, ""); err != nil {
+				t.Fatalf("ChangeStatus() error = %v", err)
+			}
+			if payRepo.Items[0].Status != tt.wantPayment {
+				t.Errorf("ChangeStatus() payment = %v, want %v", payRepo.Items[0].Status, tt.wantPayment)
+			}
+			if tt.wantPayment == models.PaymentRefunded && payRepo.Items[0].Refunded != tt.payment.Captured {
+				t.Errorf("ChangeStatus() refunded %v, want %v", payRepo.Items[0].Refunded, tt.payment.Captured)
+			}
+		})
+	}
+}
+
+func Test_orderService_Create_RequiresVerifiedEmail(t *testing.T) {
+	pRepo := &productMockRepo{Items: []models.Product{product1}}
+	cRepo := &cartMockRepo{Items: []models.Cart{cart1}}
+	ciRepo := &cartItemMockRepo{Items: []models.CartItem{cartItem1}}
+	orRepo := &orderMockRepo{}
+	c := &orderService{
+		orRepo: orRepo,
+		cRepo:  cRepo,
+		ciRepo: ciRepo,
+		pRepo:  pRepo,
+		uRepo:  &userMockRepo{Unverified: []uuid.UUID{userID}},
+		uow:    newUowMock(orRepo, cRepo, ciRepo, pRepo),
+	}
+
+	if _, err := c.Create(userID, nil); err == nil {
+		t.Fatalf("Create() error = nil, want unverified email error")
+	}
+	if len(orRepo.Items) != 0 || pRepo.Items[0].UnitStock != product1.UnitStock {
+		t.Errorf("Create() changed orders or stock for an unverified user")
+	}
+}
+
+// userMockRepo returns verified users except the Unverified ones
+type userMockRepo struct {
+	Unverified []uuid.UUID
+}
+
+func (r *userMockRepo) GetByID(id uuid.UUID) (*models.User, error) {
+	user := models.User{ID: id}
+	for _, unverified := range r.Unverified {
+		if unverified == id {
+			return &user, nil
+		}
+	}
+	user.EmailVerifiedAt = &currentTime
+	return &user, nil
+}
+func (r *userMockRepo) GetByMail(mail string) (*models.User, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+func (r *userMockRepo) Update(a *models.User) (*models.User, error) {
+	return a, nil
+}
+func (r *userMockRepo) Search(filter user.SearchFilter, pageIndex, pageSize int) (*[]models.User, int, error) {
+	return &[]models.User{}, 0, nil
+}
+func (r *userMockRepo) Delete(a *models.User) error {
+	return nil
+}
+
+// addressMockRepo gives every user the home address as default, Items are the other addresses
+type addressMockRepo struct {
+	Items []models.Address
+}
+
+func (r *addressMockRepo) Create(a *models.Address) (*models.Address, error) {
+	return a, nil
+}
+func (r *addressMockRepo) GetByID(userID uuid.UUID, id uuid.UUID) (*models.Address, error) {
+	for i := range r.Items {
+		if r.Items[i].ID == id && r.Items[i].UserID == userID {
+			return &r.Items[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (r *addressMockRepo) GetByUserID(userID uuid.UUID) (*[]models.Address, error) {
+	return &r.Items, nil
+}
+func (r *addressMockRepo) GetDefault(userID uuid.UUID) (*models.Address, error) {
+	address := homeAddress
+	address.UserID = userID
+	return &address, nil
+}
+func (r *addressMockRepo) Update(a *models.Address) (*models.Address, error) {
+	return a, nil
+}
+func (r *addressMockRepo) Delete(userID uuid.UUID, id uuid.UUID) error {
+	return nil
+}
+func (r *addressMockRepo) ClearDefault(userID uuid.UUID, exceptID uuid.UUID) error {
+	return nil
+}
+
+type paymentMockRepo struct {
+	Items   []models.Payment
+	Events  []models.PaymentEvent
+	Refunds []models.Refund
+}
+
+func (r *paymentMockRepo) Create(a *models.Payment) (*models.Payment, error) {
+	r.Items = append(r.Items, *a)
+	return a, nil
+}
+func (r *paymentMockRepo) Update(a *models.Payment) (*models.Payment, error) {
+	for i, item := range r.Items {
+		if item.ID == a.ID {
+			r.Items[i] = *a
+			return a, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (r *paymentMockRepo) GetByReference(provider string, reference string) (*models.Payment, error) {
+	for _, item := range r.Items {
+		if item.Provider == provider && item.Reference == reference {
+			payment := item
+			return &payment, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (r *paymentMockRepo) GetByOrderID(orderID uuid.UUID) (*[]models.Payment, error) {
+	payments := []models.Payment{}
+	for _, item := range r.Items {
+		if item.OrderID == orderID {
+			payments = append(payments, item)
+		}
+	}
+	return &payments, nil
+}
+func (r *paymentMockRepo) GetEvent(provider string, eventID string) (*models.PaymentEvent, error) {
+	for _, item := range r.Events {
+		if item.Provider == provider && item.EventID == eventID {
+			event := item
+			return &event, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (r *paymentMockRepo) CreateEvent(a *models.PaymentEvent) (*models.PaymentEvent, error) {
+	r.Events = append(r.Events, *a)
+	return a, nil
+}
+func (r *paymentMockRepo) CreateRefund(a *models.Refund) (*models.Refund, error) {
+	a.ID = uuid.New()
+	r.Refunds = append(r.Refunds, *a)
+	return a, nil
+}
+func (r *paymentMockRepo) GetRefund(id uuid.UUID) (*models.Refund, error) {
+	for _, item := range r.Refunds {
+		if item.ID == id {
+			refund := item
+			return &refund, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (r *paymentMockRepo) UpdateRefund(a *models.Refund) (*models.Refund, error) {
+	for i, item := range r.Refunds {
+		if item.ID == a.ID {
+			r.Refunds[i] = *a
+			return a, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (r *paymentMockRepo) GetUnsettledOrderIDs() ([]uuid.UUID, error) {
+	orderIDs := []uuid.UUID{}
+	seen := map[uuid.UUID]bool{}
+	for _, item := range r.Items {
+		if item.Status == models.PaymentVoiding && !seen[item.OrderID] {
+			seen[item.OrderID] = true
+			orderIDs = append(orderIDs, item.OrderID)
+		}
+	}
+	for _, item := range r.Refunds {
+		if item.Status == models.RefundPending && !seen[item.OrderID] {
+			seen[item.OrderID] = true
+			orderIDs = append(orderIDs, item.OrderID)
+		}
+	}
+	return orderIDs, nil
+}
+
+// flakyProvider fails the first Fails refunds, captures and voids and keeps the key of every refund it is asked for
+type flakyProvider struct {
+	*payment.FakeProvider
+	Fails        int
+	CaptureFails int
+	VoidFails    int
+	RefundKeys   []string
+}
+
+func (p *flakyProvider) Refund(reference string, amount money.Money, key string) (*payment.Result, error) {
+	p.RefundKeys = append(p.RefundKeys, key)
+	if p.Fails > 0 {
+		p.Fails--
+		return nil, errors.New(http.StatusBadGateway, "Gateway is down")
+	}
+	return p.FakeProvider.Refund(reference, amount, key)
+}
+func (p *flakyProvider) Capture(reference string, amount money.Money) (*payment.Result, error) {
+	if p.CaptureFails > 0 {
+		p.CaptureFails--
+		return nil, errors.New(http.StatusBadGateway, "Gateway is down")
+	}
+	return p.FakeProvider.Capture(reference, amount)
+}
+func (p *flakyProvider) Void(reference string) (*payment.Result, error) {
+	if p.VoidFails > 0 {
+		p.VoidFails--
+		return nil, errors.New(http.StatusBadGateway, "Gateway is down")
+	}
+	return p.FakeProvider.Void(reference)
+}
+
+type categoryMockRepo struct {
+	Items []models.Category
+}
+
+func (c *categoryMockRepo) Create(a *models.Category) (*models.Category, error) {
+	c.Items = append(c.Items, *a)
+	return a, nil
+}
+func (c *categoryMockRepo) GetByName(name string) (*models.Category, error) {
+	for _, item := range c.Items {
+		if item.Name != nil && *item.Name == name {
+			category := item
+			return &category, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (c *categoryMockRepo) GetAll(pageIndex, pageSize int) (*[]models.Category, int, error) {
+	return &c.Items, len(c.Items), nil
+}
+func (c *categoryMockRepo) GetByID(id uuid.UUID) (*models.Category, error) {
+	for _, item := range c.Items {
+		if item.ID == id {
+			category := item
+			return &category, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (c *categoryMockRepo) GetBySlug(slug string) (*models.Category, error) {
+	for _, item := range c.Items {
+		if item.Slug == slug {
+			category := item
+			return &category, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (c *categoryMockRepo) GetChild(parentID *uuid.UUID, name string) (*models.Category, error) {
+	for _, item := range c.Items {
+		sameParent := item.ParentID == nil && parentID == nil || item.ParentID != nil && parentID != nil && *item.ParentID == *parentID
+		if sameParent && (*item.Name == name || item.Slug == category.Slugify(name)) {
+			category := item
+			return &category, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (c *categoryMockRepo) GetTree() (*[]models.Category, error) {
+	return &c.Items, nil
+}
+func (c *categoryMockRepo) Update(a *models.Category, oldName string) (*models.Category, error) {
+	for i, item := range c.Items {
+		if item.ID == a.ID {
+			c.Items[i] = *a
+		}
+	}
+	return a, nil
+}
+func (c *categoryMockRepo) Delete(a *models.Category, reassignTo *models.Category) error {
+	for i, item := range c.Items {
+		if item.ID == a.ID {
+			c.Items = append(c.Items[:i], c.Items[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+func (c *categoryMockRepo) GetAfter(keyset *pagination.Keyset, pageSize int) (*[]models.Category, bool, error) {
+	return &c.Items, false, nil
+}
+
+type productMockRepo struct {
+	Items []models.Product
+}
+type cartItemMockRepo struct {
+	Items []models.CartItem
+}
+type cartMockRepo struct {
+	Items []models.Cart
+}
+type orderMockRepo struct {
+	Items   []models.Order
+	History []models.OrderStatusHistory
+}
+type reservationMockRepo struct {
+	Items []models.StockReservation
+}
+type failingCartMockRepo struct {
+	cartMockRepo
+}
+
+// uowPassMock runs the given function without a transaction of its own, for repositories shared between goroutines
+type uowPassMock struct {
+	repos TxRepositories
+}
+
+func (u *uowPassMock) Do(fn func(tx TxRepositories) error) error {
+	return fn(u.repos)
+}
+
+// The locked mocks guard every call a checkout makes with a mutex shared between them, each call is
+// atomic like a single statement of the database but a checkout as a whole isn't
+type lockedProductMockRepo struct {
+	*productMockRepo
+	mu *sync.Mutex
+	// lowest is the least stock the product ever had
+	lowest int32
+}
+type lockedCartMockRepo struct {
+	*cartMockRepo
+	mu *sync.Mutex
+}
+type lockedCartItemMockRepo struct {
+	*cartItemMockRepo
+	mu *sync.Mutex
+}
+type lockedOrderMockRepo struct {
+	*orderMockRepo
+	mu *sync.Mutex
+}
+type lockedReservationMockRepo struct {
+	*reservationMockRepo
+	mu *sync.Mutex
+}
+
+// soldOutProductMockRepo takes no stock, like the conditional stock update matching no row
+type soldOutProductMockRepo struct {
+	productMockRepo
+}
+
+type taxMockCalculator struct{}
+
+// Quote taxes every item at %20 included in the price
+func (t *taxMockCalculator) Quote(items []tax.Item, at time.Time) (*tax.Quote, error) {
+	lines := make([]tax.Line, 0, len(items))
+	for _, item := range items {
+		lines = append(lines, tax.PriceLine(item.Amount, 2000, true))
+	}
+	return tax.Summarize(lines)
+}
+
+type promotionMockService struct {
+	Items []models.Promotion
+}
+
+// Discount applies every promotion without a code and the coupon of the cart
+func (p *promotionMockService) Discount(userID uuid.UUID, couponID *uuid.UUID, lines []promotion.Line, at time.Time) (*promotion.Result, error) {
+	promotions := []models.Promotion{}
+	for _, item := range p.Items {
+		if item.Code == nil || (couponID != nil && item.ID == *couponID) {
+			promotions = append(promotions, item)
+		}
+	}
+	return promotion.Apply(promotions, lines, at)
+}
+func (p *promotionMockService) Coupon(userID uuid.UUID, code string, lines []promotion.Line, at time.Time) (*models.Promotion, error) {
+	for i, item := range p.Items {
+		if item.Code != nil && *item.Code == code {
+			if err := promotion.Check(&p.Items[i], lines, at); err != nil {
+				return nil, errors.New(400, err.Error())
+			}
+			return &p.Items[i], nil
+		}
+	}
+	return nil, errors.New(404, "Coupon not found")
+}
+
+// uowMock keeps the mock repositories state and restores it when the given function fails
+type uowMock struct {
+	repos TxRepositories
+}
+
+func newUowMock(orRepo IOrderRepository, cRepo cart.ICartRepository, ciRepo cart_item.ICartItemRepository, pRepo product.IProductRepository) *uowMock {
+	return &uowMock{repos: TxRepositories{Orders: orRepo, Carts: cRepo, CartItems: ciRepo, Products: pRepo, Reservations: &reservationMockRepo{}, Payments: &paymentMockRepo{}}}
+}
+
+func (u *uowMock) Do(fn func(tx TxRepositories) error) error {
+	var orders []models.Order
+	var carts []models.Cart
+	var products []models.Product
+	var history []models.OrderStatusHistory
+	if o, ok := u.repos.Orders.(*orderMockRepo); ok {
+		orders = append(orders, o.Items...)
+		history = append(history, o.History...)
+	}
+	if c, ok := u.repos.Carts.(*failingCartMockRepo); ok {
+		carts = append(carts, c.Items...)
+	}
+	if c, ok := u.repos.Carts.(*cartMockRepo); ok {
+		carts = append(carts, c.Items...)
+	}
+	if p, ok := u.repos.Products.(*productMockRepo); ok {
+		products = append(products, p.Items...)
+	}
+
+	err := fn(u.repos)
+	if err == nil {
+		return nil
+	}
+
+	if o, ok := u.repos.Orders.(*orderMockRepo); ok {
+		o.Items = orders
+		o.History = history
+	}
+	if c, ok := u.repos.Carts.(*failingCartMockRepo); ok {
+		c.Items = carts
+	}
+	if c, ok := u.repos.Carts.(*cartMockRepo); ok {
+		c.Items = carts
+	}
+	if p, ok := u.repos.Products.(*productMockRepo); ok {
+		p.Items = products
+	}
+	return err
+}
+
+func (p *productMockRepo) Create(a *models.Product) (*models.Product, error) {
+	for _, item := range p.Items {
+		if item.SKU == a.SKU {
+			return nil, errors.New(400, "Item should be unique on database")
+		}
+	}
+	p.Items = append(p.Items, *a)
+	return a, nil
+}
+func (p *productMockRepo) GetAll(filter product.ListFilter, pageIndex, pageSize int) (*[]models.Product, int, error) {
+	log.Println("size: ", len(p.Items))
+	return &p.Items, len(p.Items), nil
+}
+func (p *productMockRepo) GetAfter(filter product.ListFilter, keyset *pagination.Keyset, pageSize int) (*[]models.Product, bool, error) {
+	return &p.Items, false, nil
+}
+func (p *productMockRepo) Facets(filter product.ListFilter) (*product.Facets, error) {
+	return &product.Facets{}, nil
+}
+func (p *productMockRepo) GetByName(name string) (*[]models.Product, error) {
+	products := []models.Product{}
+	for _, item := range p.Items {
+		if strings.Contains(item.Name, name) {
+			products = append(products, item)
+		}
+	}
+	if len(products) > 0 {
+		return &products, nil
+	} else {
+		return nil, errors.New(400, "Product not found")
+	}
+}
+func (p *productMockRepo) Search(text string, pageIndex, pageSize int) (*[]product.SearchHit, int, error) {
+	return &[]product.SearchHit{}, 0, nil
+}
+func (p *productMockRepo) GetBySKU(SKU int) (*models.Product, error) {
+	product := models.Product{}
+	for _, item := range p.Items {
+		if item.SKU == SKU {
+			product = item
+			return &product, nil
+		}
+	}
+
+	return nil, errors.New(400, "Product not found")
+}
+func (p *productMockRepo) GetByCatName(catName string) (*[]models.Product, error) {
+	products := []models.Product{}
+	for _, item := range p.Items {
+		if item.CategoryName == catName {
+			products = append(products, item)
+		}
+	}
+	if len(products) > 0 {
+		return &products, nil
+	} else {
+		return nil, errors.New(400, "Product not found")
+	}
+}
+func (p *productMockRepo) Update(a *models.Product) (*models.Product, error) {
+	for i, item := range p.Items {
+		if item.SKU == a.SKU {
+			p.Items[i] = *a
+			break
+		}
+	}
+	return a, nil
+}
+func (p *productMockRepo) DecreaseStock(sku int, quantity int) error {
+	if quantity < 1 {
+		return product.ErrInvalidQuantity
+	}
+	for i, item := range p.Items {
+		if item.SKU == sku {
+			if int(item.UnitStock) < quantity {
+				return product.ErrNotEnoughStock
+			}
+			p.Items[i].UnitStock -= int32(quantity)
+			return nil
+		}
+	}
+	return product.ErrNotEnoughStock
+}
+func (p *productMockRepo) IncreaseStock(sku int, quantity int) error {
+	for i, item := range p.Items {
+		if item.SKU == sku {
+			p.Items[i].UnitStock += int32(quantity)
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+func (p *productMockRepo) Delete(sku int) error {
+	pro, err := p.GetBySKU(sku)
+	if err != nil {
+		return errors.New(400, "Product not found")
+	}
+	for i, item := range p.Items {
+		if item.SKU == pro.SKU {
+			p.Items = append(p.Items[:i], p.Items[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
 
 func (ci *cartItemMockRepo) Crate(a *models.CartItem) (*models.CartItem, error) {
 	ci.Items = append(ci.Items, *a)
@@ -536,17 +2227,148 @@ func (c *cartMockRepo) Update(a *models.Cart) (*models.Cart, error) {
 	return nil, errors.New(400, "Cart not found")
 }
 
+func (r *reservationMockRepo) Hold(cartID uuid.UUID, sku int, quantity int, expiresAt time.Time) error {
+	r.Items = append(r.Items, models.StockReservation{CartID: cartID, ProductSKU: sku, Quantity: quantity, ExpiresAt: expiresAt})
+	return nil
+}
+func (r *reservationMockRepo) Release(cartID uuid.UUID, sku int) error {
+	for i, item := range r.Items {
+		if item.CartID == cartID && item.ProductSKU == sku {
+			r.Items = append(r.Items[:i], r.Items[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+func (r *reservationMockRepo) ReleaseCart(cartID uuid.UUID) error {
+	items := []models.StockReservation{}
+	for _, item := range r.Items {
+		if item.CartID != cartID {
+			items = append(items, item)
+		}
+	}
+	r.Items = items
+	return nil
+}
+func (r *reservationMockRepo) HeldByOthers(cartID uuid.UUID, sku int) (int, error) {
+	held := 0
+	for _, item := range r.Items {
+		if item.CartID != cartID && item.ProductSKU == sku && item.ExpiresAt.After(time.Now()) {
+			held += item.Quantity
+		}
+	}
+	return held, nil
+}
+func (r *reservationMockRepo) HeldBySKUs(skus []int) (map[int]int, error) {
+	return map[int]int{}, nil
+}
+func (r *reservationMockRepo) DeleteExpired() (int64, error) {
+	return 0, nil
+}
+
+func (c *failingCartMockRepo) Create(a *models.Cart) (*models.Cart, error) {
+	return nil, errors.New(500, "Cart create failed")
+}
+func (p *lockedProductMockRepo) GetBySKU(sku int) (*models.Product, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.productMockRepo.GetBySKU(sku)
+}
+func (p *lockedProductMockRepo) DecreaseStock(sku int, quantity int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.productMockRepo.DecreaseStock(sku, quantity); err != nil {
+		return err
+	}
+	for _, item := range p.Items {
+		if item.SKU == sku && item.UnitStock < p.lowest {
+			p.lowest = item.UnitStock
+		}
+	}
+	return nil
+}
+func (c *lockedCartMockRepo) Create(a *models.Cart) (*models.Cart, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cartMockRepo.Create(a)
+}
+func (c *lockedCartMockRepo) GetByUserID(userID uuid.UUID) (*models.Cart, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cartMockRepo.GetByUserID(userID)
+}
+func (c *lockedCartMockRepo) Update(a *models.Cart) (*models.Cart, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cartMockRepo.Update(a)
+}
+func (ci *lockedCartItemMockRepo) GetByCartID(cartID uuid.UUID) (*[]models.CartItem, error) {
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+	return ci.cartItemMockRepo.GetByCartID(cartID)
+}
+func (o *lockedOrderMockRepo) Create(a *models.Order) (*models.Order, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.orderMockRepo.Create(a)
+}
+func (o *lockedOrderMockRepo) CreateStatusHistory(a *models.OrderStatusHistory) (*models.OrderStatusHistory, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.orderMockRepo.CreateStatusHistory(a)
+}
+func (r *lockedReservationMockRepo) HeldByOthers(cartID uuid.UUID, sku int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reservationMockRepo.HeldByOthers(cartID, sku)
+}
+func (r *lockedReservationMockRepo) ReleaseCart(cartID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reservationMockRepo.ReleaseCart(cartID)
+}
+func (p *soldOutProductMockRepo) DecreaseStock(sku int, quantity int) error {
+	return product.ErrNotEnoughStock
+}
+
 func (o *orderMockRepo) Create(a *models.Order) (*models.Order, error) {
 	o.Items = append(o.Items, *a)
 	return a, nil
 }
+func (o *orderMockRepo) GetByID(orderID uuid.UUID) (*models.Order, error) {
+	for _, item := range o.Items {
+		if item.ID == orderID {
+			return detach(item), nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (o *orderMockRepo) CreateStatusHistory(a *models.OrderStatusHistory) (*models.OrderStatusHistory, error) {
+	o.History = append(o.History, *a)
+	return a, nil
+}
+func (o *orderMockRepo) GetStatusHistory(orderID uuid.UUID) (*[]models.OrderStatusHistory, error) {
+	history := []models.OrderStatusHistory{}
+	for _, item := range o.History {
+		if item.OrderID == orderID {
+			history = append(history, item)
+		}
+	}
+	return &history, nil
+}
+func (o *orderMockRepo) Lock(orderID uuid.UUID) error {
+	for _, item := range o.Items {
+		if item.ID == orderID {
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
 func (o *orderMockRepo) GetByOrderAndUserID(userID uuid.UUID, orderID uuid.UUID) (*models.Order, error) {
-	order := models.Order{}
-	for i, item := range o.Items {
+	for _, item := range o.Items {
 		if item.UserID == userID {
 			if item.ID == orderID {
-				order = o.Items[i]
-				return &order, nil
+				return detach(item), nil
 			}
 		}
 	}
@@ -574,3 +2396,62 @@ func (o *orderMockRepo) Update(a *models.Order) (*models.Order, error) {
 	}
 	return nil, errors.New(400, "Order not found")
 }
+func (o *orderMockRepo) UpdateLine(a *models.OrderLine) (*models.OrderLine, error) {
+	for i, item := range o.Items {
+		if item.ID != a.OrderID {
+			continue
+		}
+		lines := append(item.Lines[:0:0], item.Lines...)
+		for j := range lines {
+			if lines[j].ID == a.ID {
+				lines[j] = *a
+				o.Items[i].Lines = lines
+				return a, nil
+			}
+		}
+	}
+	return nil, errors.New(400, "Order line not found")
+}
+
+// detach copies a stored order with its associations, the service changes them in place like rows loaded from the database
+func detach(order models.Order) *models.Order {
+	order.Lines = append(order.Lines[:0:0], order.Lines...)
+	order.Payments = append(order.Payments[:0:0], order.Payments...)
+	order.Refunds = append(order.Refunds[:0:0], order.Refunds...)
+	return &order
+}
+func (o *orderMockRepo) Search(filter SearchFilter, pageIndex, pageSize int) (*[]models.Order, int, error) {
+	orders := []models.Order{}
+	for _, item := range o.Items {
+		if filter.Status != "" && item.Status != filter.Status {
+			continue
+		}
+		if filter.UserID != uuid.Nil && item.UserID != filter.UserID {
+			continue
+		}
+		if !filter.From.IsZero() && item.CreatedAt.Before(filter.From) {
+			continue
+		}
+		if !filter.To.IsZero() && item.CreatedAt.After(filter.To) {
+			continue
+		}
+		if filter.MinTotal != nil && item.TotalPrice.Amount < filter.MinTotal.Amount {
+			continue
+		}
+		if filter.MaxTotal != nil && item.TotalPrice.Amount > filter.MaxTotal.Amount {
+			continue
+		}
+		orders = append(orders, item)
+	}
+	return &orders, len(orders), nil
+}
+
+// SearchAfter pages the orders of Search in the order of Items, keysets are not applied
+func (o *orderMockRepo) SearchAfter(filter SearchFilter, keyset *pagination.Keyset, pageSize int) (*[]models.Order, bool, error) {
+	orders, _, _ := o.Search(filter, 1, pageSize)
+	more := len(*orders) > pageSize
+	if more {
+		*orders = (*orders)[:pageSize]
+	}
+	return orders, more, nil
+}
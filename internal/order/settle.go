@@ -0,0 +1,163 @@
+package order
+
+import (
+	"net/http"
+	"time"
+
+	httpErr "github.com/gcamlicali/tradeshopExample/internal/httpErrors"
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/gcamlicali/tradeshopExample/internal/payment"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// settle sends the pending voids and refunds of the order to the gateway and records what it answered.
+// It runs after the transaction recording them commits, a gateway call made inside it would be made again
+// when the transaction is rolled back and the change retried. A void or refund the gateway fails stays
+// pending for the settler to send again, the refund id is its key so the gateway doesn't give the money
+// back twice.
+func (c *orderService) settle(order *models.Order) {
+	for i := range order.Payments {
+		paid := &order.Payments[i]
+		if paid.Status != models.PaymentVoiding {
+			continue
+		}
+
+		result, err := c.payments.Void(paid.Reference)
+		if err != nil {
+			zap.L().Error("order.service.settle gateway failed to void, the payment stays voiding", zap.Reflect("paymentID", paid.ID), zap.Error(err))
+			continue
+		}
+		recorded, err := c.recordVoid(order.ID, paid.ID, result)
+		if err != nil {
+			zap.L().Error("order.service.settle failed to record the void", zap.Reflect("paymentID", paid.ID), zap.Error(err))
+			continue
+		}
+		*paid = *recorded
+	}
+
+	for i := range order.Refunds {
+		refund := &order.Refunds[i]
+		if refund.Status != models.RefundPending {
+			continue
+		}
+		paid := findPayment(order, refund.PaymentID)
+		if paid == nil {
+			zap.L().Error("order.service.settle payment of the refund not found", zap.Reflect("refundID", refund.ID), zap.Reflect("paymentID", refund.PaymentID))
+			continue
+		}
+
+		result, err := c.payments.Refund(paid.Reference, refund.Amount, refund.ID.String())
+		if err != nil {
+			zap.L().Error("order.service.settle gateway failed to refund, the refund stays pending", zap.Reflect("refundID", refund.ID), zap.Error(err))
+			continue
+		}
+		recorded, err := c.recordRefund(refund.ID, result)
+		if err != nil {
+			zap.L().Error("order.service.settle failed to record the refund", zap.Reflect("refundID", refund.ID), zap.String("reference", result.Reference), zap.Error(err))
+			continue
+		}
+		*refund = *recorded
+	}
+}
+
+// recordVoid saves the answer of the gateway to a voiding payment, a void recorded by another settle first
+// is kept as it is
+func (c *orderService) recordVoid(orderID uuid.UUID, paymentID uuid.UUID, result *payment.Result) (*models.Payment, error) {
+	var paid *models.Payment
+	err := c.uow.Do(func(tx TxRepositories) error {
+		order, err := c.getOrder(tx, orderID)
+		if err != nil {
+			return err
+		}
+		if err := loadPayments(tx, order); err != nil {
+			return err
+		}
+		if paid = findPayment(order, paymentID); paid == nil {
+			return httpErr.NewRestError(http.StatusNotFound, "Payment not found", paymentID.String())
+		}
+		if paid.Status != models.PaymentVoiding {
+			return nil
+		}
+		paid.Status = result.Status
+		_, err = tx.Payments.Update(paid)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return paid, nil
+}
+
+// recordRefund saves the answer of the gateway to a pending refund, a refund recorded by another settle
+// first is kept as it is
+func (c *orderService) recordRefund(refundID uuid.UUID, result *payment.Result) (*models.Refund, error) {
+	var refund *models.Refund
+	err := c.uow.Do(func(tx TxRepositories) error {
+		var err error
+		if refund, err = tx.Payments.GetRefund(refundID); err != nil {
+			return err
+		}
+		if refund.Status != models.RefundPending {
+			return nil
+		}
+		refund.Reference = result.Reference
+		refund.Status = models.RefundSucceeded
+		_, err = tx.Payments.UpdateRefund(refund)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+// SettlePending sends the voids and refunds a failed gateway call left pending again, it returns the
+// number of orders it settled
+func (c *orderService) SettlePending() (int, error) {
+	var orderIDs []uuid.UUID
+	err := c.uow.Do(func(tx TxRepositories) error {
+		var err error
+		orderIDs, err = tx.Payments.GetUnsettledOrderIDs()
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for i, orderID := range orderIDs {
+		order, err := c.orRepo.GetByID(orderID)
+		if err != nil {
+			return i, err
+		}
+		c.settle(order)
+	}
+	return len(orderIDs), nil
+}
+
+// StartSettler settles pending voids and refunds on every interval until the returned stop function is called
+func (c *orderService) StartSettler(interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				settled, err := c.SettlePending()
+				if err != nil {
+					zap.L().Error("order.settler failed to settle pending payments", zap.Error(err))
+					continue
+				}
+				if settled > 0 {
+					zap.L().Debug("order.settler settled orders", zap.Int("count", settled))
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
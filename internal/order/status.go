@@ -0,0 +1,61 @@
+package order
+
+import (
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+)
+
+// Actor tells who asks for an order status change
+type Actor int
+
+const (
+	Customer Actor = iota
+	Admin
+)
+
+// transitions are the order status changes an admin can make
+var transitions = map[models.OrderStatus][]models.OrderStatus{
+	models.OrderPending:   {models.OrderPaid, models.OrderCancelled},
+	models.OrderPaid:      {models.OrderShipped, models.OrderCancelled, models.OrderRefunded},
+	models.OrderShipped:   {models.OrderDelivered, models.OrderReturned},
+	models.OrderDelivered: {models.OrderReturned},
+	models.OrderCancelled: {models.OrderRefunded},
+	models.OrderReturned:  {models.OrderRefunded},
+}
+
+// customerTransitions are the order status changes a customer can make on own orders
+var customerTransitions = map[models.OrderStatus][]models.OrderStatus{
+	models.OrderPending:   {models.OrderCancelled},
+	models.OrderPaid:      {models.OrderCancelled},
+	models.OrderDelivered: {models.OrderReturned},
+}
+
+// ParseStatus returns the order status of given name, ok is false for unknown names
+func ParseStatus(name string) (models.OrderStatus, bool) {
+	status := models.OrderStatus(name)
+	switch status {
+	case models.OrderPending, models.OrderPaid, models.OrderShipped, models.OrderDelivered,
+		models.OrderCancelled, models.OrderReturned, models.OrderRefunded:
+		return status, true
+	}
+	return "", false
+}
+
+// CanTransition reports whether actor is allowed to move an order from one status to another
+func CanTransition(actor Actor, from models.OrderStatus, to models.OrderStatus) bool {
+	allowed := transitions
+	if actor == Customer {
+		allowed = customerTransitions
+	}
+
+	for _, next := range allowed[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// restocks reports whether moving to the status gives ordered products back to stock
+func restocks(to models.OrderStatus) bool {
+	return to == models.OrderCancelled || to == models.OrderReturned
+}
@@ -0,0 +1,46 @@
+package order
+
+import (
+	"testing"
+
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		name  string
+		actor Actor
+		from  models.OrderStatus
+		to    models.OrderStatus
+		want  bool
+	}{
+		{name: "admin pending to paid", actor: Admin, from: models.OrderPending, to: models.OrderPaid, want: true},
+		{name: "admin paid to shipped", actor: Admin, from: models.OrderPaid, to: models.OrderShipped, want: true},
+		{name: "admin shipped to delivered", actor: Admin, from: models.OrderShipped, to: models.OrderDelivered, want: true},
+		{name: "admin returned to refunded", actor: Admin, from: models.OrderReturned, to: models.OrderRefunded, want: true},
+		{name: "admin pending to delivered", actor: Admin, from: models.OrderPending, to: models.OrderDelivered, want: false},
+		{name: "admin refunded is final", actor: Admin, from: models.OrderRefunded, to: models.OrderPaid, want: false},
+		{name: "customer cancels pending", actor: Customer, from: models.OrderPending, to: models.OrderCancelled, want: true},
+		{name: "customer cancels paid", actor: Customer, from: models.OrderPaid, to: models.OrderCancelled, want: true},
+		{name: "customer cancels shipped", actor: Customer, from: models.OrderShipped, to: models.OrderCancelled, want: false},
+		{name: "customer returns delivered", actor: Customer, from: models.OrderDelivered, to: models.OrderReturned, want: true},
+		{name: "customer pays", actor: Customer, from: models.OrderPending, to: models.OrderPaid, want: false},
+		{name: "customer refunds", actor: Customer, from: models.OrderReturned, to: models.OrderRefunded, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanTransition(tt.actor, tt.from, tt.to); got != tt.want {
+				t.Errorf("CanTransition() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStatus(t *testing.T) {
+	if status, ok := ParseStatus("Shipped"); !ok || status != models.OrderShipped {
+		t.Errorf("ParseStatus(Shipped) = %v, %v", status, ok)
+	}
+	if _, ok := ParseStatus("Ordered"); ok {
+		t.Errorf("ParseStatus(Ordered) ok = true, want false")
+	}
+}
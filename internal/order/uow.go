@@ -0,0 +1,47 @@
+package order
+
+import (
+	"github.com/gcamlicali/tradeshopExample/internal/cart"
+	"github.com/gcamlicali/tradeshopExample/internal/cart_item"
+	"github.com/gcamlicali/tradeshopExample/internal/payment"
+	"github.com/gcamlicali/tradeshopExample/internal/product"
+	"github.com/gcamlicali/tradeshopExample/internal/reservation"
+	"gorm.io/gorm"
+)
+
+// TxRepositories holds repositories bound to the same database transaction
+type TxRepositories struct {
+	Orders       IOrderRepository
+	Carts        cart.ICartRepository
+	CartItems    cart_item.ICartItemRepository
+	Products     product.IProductRepository
+	Reservations reservation.IReservationRepository
+	Payments     payment.IPaymentRepository
+}
+
+// IUnitOfWork runs the given function in a single transaction.
+// If fn returns an error every change made through the given repositories is rolled back.
+type IUnitOfWork interface {
+	Do(fn func(tx TxRepositories) error) error
+}
+
+type UnitOfWork struct {
+	db *gorm.DB
+}
+
+func NewUnitOfWork(db *gorm.DB) *UnitOfWork {
+	return &UnitOfWork{db: db}
+}
+
+func (u *UnitOfWork) Do(fn func(tx TxRepositories) error) error {
+	return u.db.Transaction(func(tx *gorm.DB) error {
+		return fn(TxRepositories{
+			Orders:       NewOrderRepository(tx),
+			Carts:        cart.NewCartRepository(tx),
+			CartItems:    cart_item.NewCartItemRepository(tx),
+			Products:     product.NewProductRepository(tx),
+			Reservations: reservation.NewReservationRepository(tx),
+			Payments:     payment.NewPaymentRepository(tx),
+		})
+	})
+}
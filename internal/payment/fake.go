@@ -0,0 +1,171 @@
+package payment
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/gcamlicali/tradeshopExample/pkg/money"
+	"go.uber.org/zap"
+)
+
+const (
+	// FakeTokenDecline is declined at once
+	FakeTokenDecline = "tok_decline"
+	// FakeTokenDelay is pending until a webhook authorizes it
+	FakeTokenDelay = "tok_delay"
+	// FakeTokenDelayDecline is pending until a webhook declines it
+	FakeTokenDelayDecline = "tok_delay_decline"
+
+	FakeSignatureHeader = "X-Fake-Signature"
+)
+
+var ErrUnknownReference = errors.New("unknown payment reference")
+
+// FakeProvider is a local gateway for development and tests, it approves every token but the
+// FakeToken ones. It keeps no state, the reference is made of the request key so the same
+// request always gets the same answer.
+type FakeProvider struct {
+	secret       string
+	webhookURL   string
+	confirmAfter time.Duration
+}
+
+// fakeWebhook is the body of the fake provider's webhooks
+type fakeWebhook struct {
+	ID        string               `json:"id"`
+	Reference string               `json:"reference"`
+	Status    models.PaymentStatus `json:"status"`
+	Message   string               `json:"message,omitempty"`
+}
+
+// NewFakeProvider signs webhooks with secret, delayed payments are confirmed by posting a
+// webhook to webhookURL after confirmAfterSecs when both are set
+func NewFakeProvider(secret string, webhookURL string, confirmAfterSecs int64) *FakeProvider {
+	return &FakeProvider{secret: secret, webhookURL: webhookURL, confirmAfter: time.Duration(confirmAfterSecs) * time.Second}
+}
+
+func (f *FakeProvider) Name() string {
+	return ProviderFake
+}
+
+func (f *FakeProvider) Authorize(req AuthorizeRequest) (*Result, error) {
+	if req.Key == "" || req.Amount.IsNegative() {
+		return nil, errors.New("fake provider needs a key and an amount")
+	}
+	result := &Result{Reference: "fake_" + req.Key}
+
+	switch req.Token {
+	case FakeTokenDecline:
+		result.Status = models.PaymentDeclined
+		result.Message = "card declined"
+	case FakeTokenDelay:
+		result.Status = models.PaymentPending
+		f.confirmLater(result.Reference, models.PaymentAuthorized, "")
+	case FakeTokenDelayDecline:
+		result.Status = models.PaymentPending
+		f.confirmLater(result.Reference, models.PaymentDeclined, "card declined")
+	default:
+		result.Status = models.PaymentAuthorized
+	}
+	return result, nil
+}
+
+func (f *FakeProvider) Capture(reference string, amount money.Money) (*Result, error) {
+	return f.answer(reference, amount, models.PaymentCaptured)
+}
+
+func (f *FakeProvider) Refund(reference string, amount money.Money, key string) (*Result, error) {
+	if key == "" {
+		return nil, errors.New("fake provider needs a refund key")
+	}
+	result, err := f.answer(reference, amount, models.PaymentRefunded)
+	if err != nil {
+		return nil, err
+	}
+	result.Reference = "fake_refund_" + key
+	return result, nil
+}
+
+func (f *FakeProvider) Void(reference string) (*Result, error) {
+	if !strings.HasPrefix(reference, "fake_") {
+		return nil, ErrUnknownReference
+	}
+	return &Result{Reference: reference, Status: models.PaymentVoided}, nil
+}
+
+func (f *FakeProvider) ParseWebhook(header http.Header, body []byte) (*Event, error) {
+	signature, err := hex.DecodeString(header.Get(FakeSignatureHeader))
+	if err != nil || !hmac.Equal(signature, f.sign(body)) {
+		return nil, ErrInvalidSignature
+	}
+
+	var webhook fakeWebhook
+	if err := json.Unmarshal(body, &webhook); err != nil {
+		return nil, err
+	}
+	if webhook.ID == "" || webhook.Reference == "" {
+		return nil, errors.New("webhook has no id or reference")
+	}
+	return &Event{ID: webhook.ID, Reference: webhook.Reference, Status: webhook.Status, Message: webhook.Message}, nil
+}
+
+// Webhook returns the signed webhook the fake provider sends when a payment changes status.
+// The event id is made of the reference and status, sending the same change again gives the same id.
+func (f *FakeProvider) Webhook(reference string, status models.PaymentStatus, message string) (http.Header, []byte) {
+	body, _ := json.Marshal(fakeWebhook{
+		ID:        "evt_" + reference + "_" + strings.ToLower(string(status)),
+		Reference: reference,
+		Status:    status,
+		Message:   message,
+	})
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set(FakeSignatureHeader, hex.EncodeToString(f.sign(body)))
+	return header, body
+}
+
+func (f *FakeProvider) answer(reference string, amount money.Money, status models.PaymentStatus) (*Result, error) {
+	if !strings.HasPrefix(reference, "fake_") {
+		return nil, ErrUnknownReference
+	}
+	if amount.IsNegative() || amount.IsZero() {
+		return nil, errors.New("fake provider needs a positive amount")
+	}
+	return &Result{Reference: reference, Status: status}, nil
+}
+
+// confirmLater posts the webhook of a delayed payment when a webhook URL is configured
+func (f *FakeProvider) confirmLater(reference string, status models.PaymentStatus, message string) {
+	if f.webhookURL == "" || f.confirmAfter <= 0 {
+		return
+	}
+	time.AfterFunc(f.confirmAfter, func() {
+		header, body := f.Webhook(reference, status, message)
+		req, err := http.NewRequest(http.MethodPost, f.webhookURL, bytes.NewReader(body))
+		if err != nil {
+			zap.L().Error("payment.fake.confirmLater failed to build webhook", zap.Error(err))
+			return
+		}
+		req.Header = header
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			zap.L().Error("payment.fake.confirmLater failed to post webhook", zap.String("reference", reference), zap.Error(err))
+			return
+		}
+		resp.Body.Close()
+	})
+}
+
+func (f *FakeProvider) sign(body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(f.secret))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,100 @@
+package payment
+
+import (
+	"testing"
+
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/gcamlicali/tradeshopExample/pkg/config"
+	"github.com/gcamlicali/tradeshopExample/pkg/money"
+)
+
+func Test_FakeProvider_Authorize(t *testing.T) {
+	f := NewFakeProvider("secret", "", 0)
+	tests := []struct {
+		name  string
+		token string
+		want  models.PaymentStatus
+	}{
+		{name: "FakeProvider_Authorize_ShouldSuccess", token: "tok_visa", want: models.PaymentAuthorized},
+		{name: "FakeProvider_Authorize_Decline", token: FakeTokenDecline, want: models.PaymentDeclined},
+		{name: "FakeProvider_Authorize_Delay", token: FakeTokenDelay, want: models.PaymentPending},
+		{name: "FakeProvider_Authorize_DelayDecline", token: FakeTokenDelayDecline, want: models.PaymentPending},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := AuthorizeRequest{Key: "key1", Amount: money.New(1000, "TRY"), Token: tt.token}
+			got, err := f.Authorize(req)
+			if err != nil {
+				t.Fatalf("Authorize() error = %v", err)
+			}
+			if got.Status != tt.want || got.Reference != "fake_key1" {
+				t.Errorf("Authorize() = %+v, want status %v", got, tt.want)
+			}
+			again, _ := f.Authorize(req)
+			if *again != *got {
+				t.Errorf("Authorize() is not deterministic, %+v then %+v", got, again)
+			}
+		})
+	}
+}
+
+func Test_FakeProvider_Capture(t *testing.T) {
+	f := NewFakeProvider("secret", "", 0)
+	if got, err := f.Capture("fake_key1", money.New(1000, "TRY")); err != nil || got.Status != models.PaymentCaptured {
+		t.Errorf("Capture() = %+v, %v", got, err)
+	}
+	if _, err := f.Capture("other_key1", money.New(1000, "TRY")); err == nil {
+		t.Errorf("Capture() accepted an unknown reference")
+	}
+	if _, err := f.Refund("fake_key1", money.Zero("TRY"), "refund1"); err == nil {
+		t.Errorf("Refund() accepted a zero amount")
+	}
+	if _, err := f.Refund("fake_key1", money.New(500, "TRY"), ""); err == nil {
+		t.Errorf("Refund() accepted a refund without a key")
+	}
+	if got, err := f.Refund("fake_key1", money.New(500, "TRY"), "refund1"); err != nil || got.Reference != "fake_refund_refund1" {
+		t.Errorf("Refund() = %+v, %v", got, err)
+	}
+	if got, err := f.Void("fake_key1"); err != nil || got.Status != models.PaymentVoided {
+		t.Errorf("Void() = %+v, %v", got, err)
+	}
+}
+
+func Test_FakeProvider_ParseWebhook(t *testing.T) {
+	f := NewFakeProvider("secret", "", 0)
+	header, body := f.Webhook("fake_key1", models.PaymentAuthorized, "")
+
+	event, err := f.ParseWebhook(header, body)
+	if err != nil {
+		t.Fatalf("ParseWebhook() error = %v", err)
+	}
+	if event.Reference != "fake_key1" || event.Status != models.PaymentAuthorized || event.ID != "evt_fake_key1_authorized" {
+		t.Errorf("ParseWebhook() = %+v", event)
+	}
+
+	other := NewFakeProvider("other secret", "", 0)
+	if _, err := other.ParseWebhook(header, body); err != ErrInvalidSignature {
+		t.Errorf("ParseWebhook() with another secret error = %v, want %v", err, ErrInvalidSignature)
+	}
+	header.Del(FakeSignatureHeader)
+	if _, err := f.ParseWebhook(header, body); err != ErrInvalidSignature {
+		t.Errorf("ParseWebhook() without signature error = %v, want %v", err, ErrInvalidSignature)
+	}
+}
+
+func Test_NewProviderFromConfig(t *testing.T) {
+	if p, err := NewProviderFromConfig(config.PaymentConfig{Provider: ProviderFake, SettleIntervalSecs: 60}); err != nil {
+		t.Errorf("NewProviderFromConfig() error = %v", err)
+	} else if p.Name() != ProviderFake {
+		t.Errorf("NewProviderFromConfig() = %v, want the fake provider", p.Name())
+	}
+	if _, err := NewProviderFromConfig(config.PaymentConfig{SettleIntervalSecs: 60}); err == nil {
+		t.Errorf("NewProviderFromConfig() accepted a config without a provider")
+	}
+	if _, err := NewProviderFromConfig(config.PaymentConfig{Provider: "pigeon", SettleIntervalSecs: 60}); err == nil {
+		t.Errorf("NewProviderFromConfig() accepted an unknown provider")
+	}
+	if _, err := NewProviderFromConfig(config.PaymentConfig{Provider: ProviderFake}); err == nil {
+		t.Errorf("NewProviderFromConfig() accepted a config without a settle interval")
+	}
+}
@@ -0,0 +1,67 @@
+package payment
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/gcamlicali/tradeshopExample/pkg/config"
+	"github.com/gcamlicali/tradeshopExample/pkg/money"
+)
+
+const (
+	ProviderFake = "fake"
+)
+
+var ErrInvalidSignature = errors.New("webhook signature is not valid")
+
+// AuthorizeRequest holds the amount of an order on the customer's payment method.
+// Key makes retries of the same payment safe, Token is the payment method the client got from the gateway.
+type AuthorizeRequest struct {
+	Key    string
+	Amount money.Money
+	Token  string
+}
+
+// Result is what the gateway answered, Status is Pending when a webhook will confirm it later
+type Result struct {
+	Reference string
+	Status    models.PaymentStatus
+	Message   string
+}
+
+// Event is an asynchronous notification of the gateway about a payment
+type Event struct {
+	ID        string
+	Reference string
+	Status    models.PaymentStatus
+	Message   string
+}
+
+// PaymentProvider talks to a payment gateway
+type PaymentProvider interface {
+	Name() string
+	Authorize(req AuthorizeRequest) (*Result, error)
+	Capture(reference string, amount money.Money) (*Result, error)
+	// Refund gives amount of a captured payment back, key makes retries of the same refund safe
+	Refund(reference string, amount money.Money, key string) (*Result, error)
+	Void(reference string) (*Result, error)
+	// ParseWebhook checks the signature of a webhook and reads its event
+	ParseWebhook(header http.Header, body []byte) (*Event, error)
+}
+
+// NewProviderFromConfig returns the provider of the configured gateway, the provider must be set so a
+// missing config never takes payments with the fake one
+func NewProviderFromConfig(cfg config.PaymentConfig) (PaymentProvider, error) {
+	if cfg.SettleIntervalSecs <= 0 {
+		return nil, fmt.Errorf("payment settle interval must be positive, got %d", cfg.SettleIntervalSecs)
+	}
+	switch cfg.Provider {
+	case "":
+		return nil, errors.New("payment provider is not set")
+	case ProviderFake:
+		return NewFakeProvider(cfg.WebhookSecret, cfg.WebhookURL, cfg.ConfirmAfterSecs), nil
+	}
+	return nil, fmt.Errorf("unknown payment provider %q", cfg.Provider)
+}
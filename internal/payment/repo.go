@@ -0,0 +1,128 @@
+package payment
+
+import (
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type PaymentRepositoy struct {
+	db *gorm.DB
+}
+
+type IPaymentRepository interface {
+	Create(a *models.Payment) (*models.Payment, error)
+	Update(a *models.Payment) (*models.Payment, error)
+	GetByReference(provider string, reference string) (*models.Payment, error)
+	GetByOrderID(orderID uuid.UUID) (*[]models.Payment, error)
+	GetEvent(provider string, eventID string) (*models.PaymentEvent, error)
+	CreateEvent(a *models.PaymentEvent) (*models.PaymentEvent, error)
+	CreateRefund(a *models.Refund) (*models.Refund, error)
+	GetRefund(id uuid.UUID) (*models.Refund, error)
+	UpdateRefund(a *models.Refund) (*models.Refund, error)
+	GetUnsettledOrderIDs() ([]uuid.UUID, error)
+}
+
+func NewPaymentRepository(db *gorm.DB) *PaymentRepositoy {
+	return &PaymentRepositoy{db: db}
+}
+
+func (r *PaymentRepositoy) Create(a *models.Payment) (*models.Payment, error) {
+	zap.L().Debug("payment.repo.create", zap.Reflect("payment", a))
+	if err := r.db.Create(a).Error; err != nil {
+		zap.L().Error("payment.repo.Create failed to create payment", zap.Error(err))
+		return nil, err
+	}
+	return a, nil
+}
+
+func (r *PaymentRepositoy) Update(a *models.Payment) (*models.Payment, error) {
+	zap.L().Debug("payment.repo.update", zap.Reflect("payment", a))
+
+	if err := r.db.Save(a).Error; err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (r *PaymentRepositoy) GetByReference(provider string, reference string) (*models.Payment, error) {
+	zap.L().Debug("payment.repo.getByReference", zap.String("provider", provider), zap.String("reference", reference))
+
+	var payment models.Payment
+	if err := r.db.Where("provider = ? AND reference = ?", provider, reference).First(&payment).Error; err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// GetByOrderID returns the payments of the order, newest first
+func (r *PaymentRepositoy) GetByOrderID(orderID uuid.UUID) (*[]models.Payment, error) {
+	zap.L().Debug("payment.repo.getByOrderID", zap.Reflect("orderID", orderID))
+
+	var payments = []models.Payment{}
+	if err := r.db.Where("order_id = ?", orderID).Order("created_at DESC").Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	return &payments, nil
+}
+
+func (r *PaymentRepositoy) GetEvent(provider string, eventID string) (*models.PaymentEvent, error) {
+	zap.L().Debug("payment.repo.getEvent", zap.String("provider", provider), zap.String("eventID", eventID))
+
+	var event models.PaymentEvent
+	if err := r.db.Where("provider = ? AND event_id = ?", provider, eventID).First(&event).Error; err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (r *PaymentRepositoy) CreateEvent(a *models.PaymentEvent) (*models.PaymentEvent, error) {
+	zap.L().Debug("payment.repo.createEvent", zap.Reflect("event", a))
+	if err := r.db.Create(a).Error; err != nil {
+		zap.L().Error("payment.repo.CreateEvent failed to create event", zap.Error(err))
+		return nil, err
+	}
+	return a, nil
+}
+
+func (r *PaymentRepositoy) CreateRefund(a *models.Refund) (*models.Refund, error) {
+	zap.L().Debug("payment.repo.createRefund", zap.Reflect("refund", a))
+	if err := r.db.Create(a).Error; err != nil {
+		zap.L().Error("payment.repo.CreateRefund failed to create refund", zap.Error(err))
+		return nil, err
+	}
+	return a, nil
+}
+
+func (r *PaymentRepositoy) GetRefund(id uuid.UUID) (*models.Refund, error) {
+	zap.L().Debug("payment.repo.getRefund", zap.Reflect("id", id))
+
+	var refund models.Refund
+	if err := r.db.Where("id = ?", id).First(&refund).Error; err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+func (r *PaymentRepositoy) UpdateRefund(a *models.Refund) (*models.Refund, error) {
+	zap.L().Debug("payment.repo.updateRefund", zap.Reflect("refund", a))
+
+	if err := r.db.Save(a).Error; err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// GetUnsettledOrderIDs returns the orders with voids or refunds still waiting to be sent to the gateway
+func (r *PaymentRepositoy) GetUnsettledOrderIDs() ([]uuid.UUID, error) {
+	zap.L().Debug("payment.repo.getUnsettledOrderIDs")
+
+	var orderIDs []uuid.UUID
+	err := r.db.Raw("SELECT order_id FROM payment WHERE status = ? UNION SELECT order_id FROM refund WHERE status = ?",
+		models.PaymentVoiding, models.RefundPending).Scan(&orderIDs).Error
+	if err != nil {
+		return nil, err
+	}
+	return orderIDs, nil
+}
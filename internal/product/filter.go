@@ -0,0 +1,145 @@
+package product
+
+import (
+	"time"
+
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/gcamlicali/tradeshopExample/pkg/money"
+	"github.com/gcamlicali/tradeshopExample/pkg/pagination"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// sortColumns maps the sortBy values accepted by the product list to their columns
+var sortColumns = map[string]string{
+	"price":  "price_amount",
+	"name":   "name",
+	"newest": "created_at",
+}
+
+// PriceBuckets are the lower bounds of the price facets in minor units, the last bucket has no upper bound
+var PriceBuckets = []int64{0, 10000, 50000, 100000, 500000, 1000000}
+
+// ListFilter narrows the product list, zero valued fields are not filtered
+type ListFilter struct {
+	// Categories are names or slugs of categories, products of their sub categories are listed too
+	Categories []string
+	// Currency is the currency of the price range and the price facets
+	Currency string
+	MinPrice *money.Money
+	MaxPrice *money.Money
+	// InStock keeps the products with stock left after the active cart holds
+	InStock   bool
+	SortBy    string
+	SortOrder string
+}
+
+// CategoryCount is the number of products of a category
+type CategoryCount struct {
+	Name  string
+	Count int
+}
+
+// PriceBucketCount is the number of products priced at least Min and below Max, Max is nil for the last bucket
+type PriceBucketCount struct {
+	Min   money.Money
+	Max   *money.Money
+	Count int
+}
+
+// Facets count the products of the list by category and price. Each facet ignores its own filter so
+// the counts show what selecting another category or price range would give.
+type Facets struct {
+	Categories []CategoryCount
+	Prices     []PriceBucketCount
+}
+
+// scope adds the filter conditions to the given query
+func (f ListFilter) scope(db *gorm.DB) *gorm.DB {
+	if len(f.Categories) > 0 {
+		db = db.Where("category_id IN (WITH RECURSIVE tree AS ("+
+			"SELECT id FROM categories WHERE (name IN ? OR slug IN ?) AND deleted_at IS NULL "+
+			"UNION SELECT c.id FROM categories c JOIN tree ON c.parent_id = tree.id WHERE c.deleted_at IS NULL"+
+			") SELECT id FROM tree)", f.Categories, f.Categories)
+	}
+	if f.MinPrice != nil {
+		db = db.Where("price_currency = ? AND price_amount >= ?", f.MinPrice.Currency, f.MinPrice.Amount)
+	}
+	if f.MaxPrice != nil {
+		db = db.Where("price_currency = ? AND price_amount <= ?", f.MaxPrice.Currency, f.MaxPrice.Amount)
+	}
+	if f.InStock {
+		db = db.Where("unit_stock > COALESCE((SELECT SUM(quantity) FROM stock_reservation "+
+			"WHERE stock_reservation.product_sku = products.sku AND stock_reservation.expires_at > ?), 0)", time.Now())
+	}
+	return db
+}
+
+// withoutCategories returns the filter the category facet is counted with
+func (f ListFilter) withoutCategories() ListFilter {
+	f.Categories = nil
+	return f
+}
+
+// withoutPrice returns the filter the price facet is counted with
+func (f ListFilter) withoutPrice() ListFilter {
+	f.MinPrice = nil
+	f.MaxPrice = nil
+	return f
+}
+
+// orderBy returns the sort clause, products are listed by SKU when no sort is given. SKU breaks ties
+// so pages don't overlap.
+func (f ListFilter) orderBy() []clause.OrderByColumn {
+	bySKU := clause.OrderByColumn{Column: clause.Column{Name: "sku"}, Desc: f.desc()}
+	column, ok := sortColumns[f.SortBy]
+	if !ok {
+		return []clause.OrderByColumn{bySKU}
+	}
+	return []clause.OrderByColumn{{Column: clause.Column{Name: column}, Desc: f.desc()}, bySKU}
+}
+
+// desc reports whether the list is sorted in descending order, the newest come first unless asked otherwise
+func (f ListFilter) desc() bool {
+	return f.SortOrder == "desc" || (f.SortOrder == "" && f.SortBy == "newest")
+}
+
+// sort names the order of the list, cursors are only good for the order they were made for
+func (f ListFilter) sort() string {
+	by := f.SortBy
+	if by == "" {
+		by = "sku"
+	}
+	if f.desc() {
+		return by + ":desc"
+	}
+	return by + ":asc"
+}
+
+// keyset returns the keyset of the list order starting at the cursor, nil for the first page
+func (f ListFilter) keyset(cursor *pagination.Cursor) (*pagination.Keyset, error) {
+	var key interface{}
+	switch f.SortBy {
+	case "price":
+		key = new(int64)
+	case "name":
+		key = new(string)
+	case "newest":
+		key = new(time.Time)
+	}
+	return pagination.NewKeyset(sortColumns[f.SortBy], "sku", f.desc(), cursor, key, new(int))
+}
+
+// cursor returns the cursor of the product in the list
+func (f ListFilter) cursor(p *models.Product) (*pagination.Cursor, error) {
+	var key interface{}
+	switch f.SortBy {
+	case "price":
+		key = p.Price.Amount
+	case "name":
+		key = p.Name
+	case "newest":
+		key = p.CreatedAt
+	}
+	return pagination.NewCursor(f.sort(), key, p.SKU)
+}
@@ -3,29 +3,31 @@ package product
 import (
 	"github.com/gcamlicali/tradeshopExample/internal/api"
 	httpErr "github.com/gcamlicali/tradeshopExample/internal/httpErrors"
-	"github.com/gcamlicali/tradeshopExample/pkg/config"
+	"github.com/gcamlicali/tradeshopExample/internal/models"
 	mw "github.com/gcamlicali/tradeshopExample/pkg/middleware"
+	"github.com/gcamlicali/tradeshopExample/pkg/money"
 	"github.com/gcamlicali/tradeshopExample/pkg/pagination"
 	"github.com/gin-gonic/gin"
 	"github.com/go-openapi/strfmt"
-	"github.com/spf13/cast"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
 type productHandler struct {
 	service Service
 }
 
-func NewProductHandler(r *gin.RouterGroup, service Service, cfg *config.Config) {
+func NewProductHandler(r *gin.RouterGroup, service Service, authMW gin.HandlerFunc) {
 	h := &productHandler{service: service}
 
 	r.GET("/", h.getAll)
 	r.GET("/sku/:SKU", h.getBySKU)
 	r.GET("/name/:NAME", h.getByName)
+	r.GET("/search", h.search)
 
 	signedRoute := r.Group("/signed")
-	signedRoute.Use(mw.AuthMiddleware(cfg.JWTConfig.SecretKey))
+	signedRoute.Use(authMW, mw.RequirePermission(mw.PermProductWrite))
 	signedRoute.DELETE("/:SKU", h.delete)
 	signedRoute.PUT("/:SKU", h.update)
 	signedRoute.POST("/addBulk", h.addBulk)
@@ -33,18 +35,6 @@ func NewProductHandler(r *gin.RouterGroup, service Service, cfg *config.Config)
 }
 
 func (p *productHandler) addBulk(c *gin.Context) {
-	adminInterface, isExist := c.Get("isAdmin")
-	if !isExist {
-		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "Admin not found", nil)))
-		return
-	}
-
-	isAdmin := cast.ToBool(adminInterface)
-	if !isAdmin {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to use this endpoint!"})
-		return
-	}
-
 	file, _, err := c.Request.FormFile("file")
 	if err != nil {
 		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "Can not request body", err.Error())))
@@ -61,17 +51,6 @@ func (p *productHandler) addBulk(c *gin.Context) {
 	return
 }
 func (p *productHandler) addSingle(c *gin.Context) {
-	adminInterface, isExist := c.Get("isAdmin")
-	if !isExist {
-		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "Admin not found", nil)))
-		return
-	}
-
-	isAdmin := cast.ToBool(adminInterface)
-	if !isAdmin {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to use this endpoint!"})
-		return
-	}
 	productBody := &api.Product{}
 	if err := c.Bind(&productBody); err != nil {
 		c.JSON(httpErr.ErrorResponse(httpErr.CannotBindGivenData))
@@ -91,33 +70,95 @@ func (p *productHandler) addSingle(c *gin.Context) {
 
 	c.JSON(http.StatusOK, ProductToResponse(product))
 }
+
+// productPage is a page of the product list with the facet counts of its filter
+type productPage struct {
+	*pagination.Pages
+	Facets *api.ProductFacets `json:"facets"`
+}
+
 func (p *productHandler) getAll(c *gin.Context) {
-	pageIndex, pageSize := pagination.GetPaginationParametersFromRequest(c)
+	filter, err := parseListFilter(c)
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	//Requests with a cursor parameter are paged by cursor, the others by page number
+	cursor, byCursor, err := pagination.GetCursorFromRequest(c, filter.sort())
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "Cursor is not valid", err.Error())))
+		return
+	}
 
-	products, count, err := p.service.GetAll(pageIndex, pageSize)
+	var products *[]models.Product
+	var paginatedResult *pagination.Pages
+	if byCursor {
+		products, paginatedResult, err = p.service.GetAfter(*filter, cursor, pagination.GetPageSizeFromRequest(c))
+	} else {
+		var count int
+		pageIndex, pageSize := pagination.GetPaginationParametersFromRequest(c)
+		if products, count, err = p.service.GetAll(*filter, pageIndex, pageSize); err == nil {
+			paginatedResult = pagination.NewFromGinRequest(c, count)
+		}
+	}
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+	facets, err := p.service.Facets(*filter)
 	if err != nil {
 		c.JSON(httpErr.ErrorResponse(err))
 		return
 	}
 
-	paginatedResult := pagination.NewFromGinRequest(c, count)
 	paginatedResult.Items = productsToResponse(*products)
+	pagination.SetLinkHeader(c, paginatedResult)
 
-	c.JSON(http.StatusOK, paginatedResult)
+	c.JSON(http.StatusOK, productPage{Pages: paginatedResult, Facets: facetsToResponse(facets)})
 }
-func (p *productHandler) delete(c *gin.Context) {
-	adminInterface, isExist := c.Get("isAdmin")
-	if !isExist {
-		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "Admin not found", nil)))
-		return
+
+// parseListFilter reads the product list filters from the query string, categories may be repeated
+// or comma separated
+func parseListFilter(c *gin.Context) (*ListFilter, error) {
+	filter := ListFilter{
+		Currency:  c.DefaultQuery("currency", money.DefaultCurrency),
+		InStock:   c.Query("inStock") == "true",
+		SortBy:    c.Query("sortBy"),
+		SortOrder: c.Query("sortOrder"),
 	}
 
-	isAdmin := cast.ToBool(adminInterface)
-	if !isAdmin {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to use this endpoint!"})
-		return
+	for _, value := range c.QueryArray("category") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				filter.Categories = append(filter.Categories, name)
+			}
+		}
+	}
+
+	var err error
+	if filter.MinPrice, err = parsePrice(c.Query("minPrice"), filter.Currency); err != nil {
+		return nil, httpErr.NewRestError(http.StatusBadRequest, "minPrice is not valid", err.Error())
+	}
+	if filter.MaxPrice, err = parsePrice(c.Query("maxPrice"), filter.Currency); err != nil {
+		return nil, httpErr.NewRestError(http.StatusBadRequest, "maxPrice is not valid", err.Error())
 	}
 
+	return &filter, nil
+}
+
+// parsePrice reads a decimal price like 1299.90 in currency
+func parsePrice(value string, currency string) (*money.Money, error) {
+	if value == "" {
+		return nil, nil
+	}
+	price, err := money.Parse(value, currency)
+	if err != nil {
+		return nil, err
+	}
+	return &price, nil
+}
+func (p *productHandler) delete(c *gin.Context) {
 	SKU, err := strconv.Atoi(c.Param("SKU"))
 	if err != nil {
 		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "SKU is not integer", err.Error())))
@@ -134,18 +175,6 @@ func (p *productHandler) delete(c *gin.Context) {
 
 }
 func (p *productHandler) update(c *gin.Context) {
-	adminInterface, isExist := c.Get("isAdmin")
-	if !isExist {
-		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "Admin not found", nil)))
-		return
-	}
-
-	isAdmin := cast.ToBool(adminInterface)
-	if !isAdmin {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to use this endpoint!"})
-		return
-	}
-
 	SKU, err := strconv.Atoi(c.Param("SKU"))
 	if err != nil {
 		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "SKU is not integer", err.Error())))
@@ -179,6 +208,21 @@ func (p *productHandler) getByName(c *gin.Context) {
 	c.JSON(http.StatusOK, productsToResponse(*products))
 }
 
+func (p *productHandler) search(c *gin.Context) {
+	pageIndex, pageSize := pagination.GetPaginationParametersFromRequest(c)
+
+	hits, count, err := p.service.Search(c.Query("q"), pageIndex, pageSize)
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	paginatedResult := pagination.NewFromGinRequest(c, count)
+	paginatedResult.Items = searchHitsToResponse(*hits)
+
+	c.JSON(http.StatusOK, paginatedResult)
+}
+
 func (p *productHandler) getBySKU(c *gin.Context) {
 
 	SKU, err := strconv.Atoi(c.Param("SKU"))
@@ -1,21 +1,53 @@
 package product
 
 import (
+	"errors"
+
 	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/gcamlicali/tradeshopExample/pkg/money"
+	"github.com/gcamlicali/tradeshopExample/pkg/pagination"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrNotEnoughStock is returned when a stock decrease would drive unit stock below zero
+var ErrNotEnoughStock = errors.New("not enough stock")
+
+// ErrInvalidQuantity is returned when a stock decrease is not of a positive quantity, it would add stock instead
+var ErrInvalidQuantity = errors.New("quantity must be at least 1")
+
+const (
+	// SearchConfig is the text search configuration of products, Turkish stemming after accent folding
+	SearchConfig = "turkish_unaccent"
+	// HighlightOptions marks the matched words of search snippets with <mark>
+	HighlightOptions = "StartSel=<mark>, StopSel=</mark>, MaxWords=30, MinWords=10, MaxFragments=2, FragmentDelimiter=\" ... \", HighlightAll=false"
 )
 
+// SearchHit is a product matching a search, HighlightedName and Snippet are its name and description
+// with the matched words highlighted
+type SearchHit struct {
+	models.Product
+	Rank            float64
+	HighlightedName string
+	Snippet         string
+}
+
 type ProductRepositoy struct {
 	db *gorm.DB
 }
 
 type IProductRepository interface {
 	Create(a *models.Product) (*models.Product, error)
-	GetAll(pageIndex, pageSize int) (*[]models.Product, int, error)
+	GetAll(filter ListFilter, pageIndex, pageSize int) (*[]models.Product, int, error)
+	GetAfter(filter ListFilter, keyset *pagination.Keyset, pageSize int) (*[]models.Product, bool, error)
+	Facets(filter ListFilter) (*Facets, error)
 	GetByName(name string) (*[]models.Product, error)
+	Search(text string, pageIndex, pageSize int) (*[]SearchHit, int, error)
 	GetBySKU(sku int) (*models.Product, error)
 	Update(a *models.Product) (*models.Product, error)
+	DecreaseStock(sku int, quantity int) error
+	IncreaseStock(sku int, quantity int) error
 	Delete(sku int) error
 }
 
@@ -32,22 +64,101 @@ func (r *ProductRepositoy) Create(a *models.Product) (*models.Product, error) {
 	return a, nil
 }
 
-func (r *ProductRepositoy) GetAll(pageIndex, pageSize int) (*[]models.Product, int, error) {
-	zap.L().Debug("product.repo.getAll")
+func (r *ProductRepositoy) GetAll(filter ListFilter, pageIndex, pageSize int) (*[]models.Product, int, error) {
+	zap.L().Debug("product.repo.getAll", zap.Reflect("filter", filter))
 
 	var ps = &[]models.Product{}
-	var junk = &[]models.Product{}
 	var count int64
 
-	if err := r.db.Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&ps).Error; err != nil {
+	if err := r.db.Model(&models.Product{}).Scopes(filter.scope).Count(&count).Error; err != nil {
+		zap.L().Error("product.repo.getAll failed to count products", zap.Error(err))
+		return nil, 0, err
+	}
+
+	err := r.db.
+		Scopes(filter.scope).
+		Order(clause.OrderBy{Columns: filter.orderBy()}).
+		Offset((pageIndex - 1) * pageSize).
+		Limit(pageSize).
+		Find(&ps).Error
+	if err != nil {
 		zap.L().Error("product.repo.getAll failed to get products", zap.Error(err))
 		return nil, 0, err
 	}
-	r.db.Find(&junk).Count(&count)
-	junk = nil
 	return ps, int(count), nil
 }
 
+// GetAfter returns the page of products of the keyset in list order and whether more products follow
+// in the direction it was read. Nothing is counted so deep pages cost as much as the first one.
+func (r *ProductRepositoy) GetAfter(filter ListFilter, keyset *pagination.Keyset, pageSize int) (*[]models.Product, bool, error) {
+	zap.L().Debug("product.repo.getAfter", zap.Reflect("filter", filter), zap.Reflect("keyset", keyset))
+
+	var ps []models.Product
+	if err := r.db.Scopes(filter.scope, keyset.Scope(pageSize)).Find(&ps).Error; err != nil {
+		zap.L().Error("product.repo.getAfter failed to get products", zap.Error(err))
+		return nil, false, err
+	}
+
+	more := len(ps) > pageSize
+	if more {
+		ps = ps[:pageSize]
+	}
+	if keyset.Backward {
+		for i, j := 0, len(ps)-1; i < j; i, j = i+1, j-1 {
+			ps[i], ps[j] = ps[j], ps[i]
+		}
+	}
+	return &ps, more, nil
+}
+
+// Facets counts the products of the filter by category and by PriceBuckets in the filter currency
+func (r *ProductRepositoy) Facets(filter ListFilter) (*Facets, error) {
+	zap.L().Debug("product.repo.facets", zap.Reflect("filter", filter))
+
+	facets := &Facets{Categories: []CategoryCount{}, Prices: []PriceBucketCount{}}
+	err := r.db.Model(&models.Product{}).
+		Scopes(filter.withoutCategories().scope).
+		Select("category_name AS name, COUNT(*) AS count").
+		Group("category_name").
+		Order("category_name").
+		Scan(&facets.Categories).Error
+	if err != nil {
+		zap.L().Error("product.repo.facets failed to count categories", zap.Error(err))
+		return nil, err
+	}
+
+	// width_bucket gives 1 to prices in the first bucket and len(PriceBuckets) to the last one
+	var buckets []struct {
+		Bucket int
+		Count  int
+	}
+	err = r.db.Model(&models.Product{}).
+		Scopes(filter.withoutPrice().scope).
+		Where("price_currency = ?", filter.Currency).
+		Select("width_bucket(price_amount, ARRAY[?]::bigint[]) AS bucket, COUNT(*) AS count", PriceBuckets).
+		Group("bucket").
+		Scan(&buckets).Error
+	if err != nil {
+		zap.L().Error("product.repo.facets failed to count prices", zap.Error(err))
+		return nil, err
+	}
+
+	for i, min := range PriceBuckets {
+		bucket := PriceBucketCount{Min: money.New(min, filter.Currency)}
+		if i+1 < len(PriceBuckets) {
+			max := money.New(PriceBuckets[i+1], filter.Currency)
+			bucket.Max = &max
+		}
+		for _, counted := range buckets {
+			if counted.Bucket == i+1 {
+				bucket.Count = counted.Count
+			}
+		}
+		facets.Prices = append(facets.Prices, bucket)
+	}
+	return facets, nil
+}
+
 func (r *ProductRepositoy) GetByName(name string) (*[]models.Product, error) {
 	zap.L().Debug("product.repo.getByName", zap.Reflect("name", name))
 
@@ -62,6 +173,36 @@ func (r *ProductRepositoy) GetByName(name string) (*[]models.Product, error) {
 	return products, nil
 }
 
+// Search finds products by name, category and description with the full text index, best matches first.
+// text is a web search query, quoted phrases, OR and -word are supported.
+func (r *ProductRepositoy) Search(text string, pageIndex, pageSize int) (*[]SearchHit, int, error) {
+	zap.L().Debug("product.repo.search", zap.String("text", text))
+
+	query := gorm.Expr("websearch_to_tsquery(?, ?)", SearchConfig, text)
+	var count int64
+	if err := r.db.Model(&models.Product{}).Where("search_vector @@ ?", query).Count(&count).Error; err != nil {
+		zap.L().Error("product.repo.search failed to count products", zap.Error(err))
+		return nil, 0, err
+	}
+
+	var hits = []SearchHit{}
+	err := r.db.Model(&models.Product{}).
+		Select("products.*, ts_rank_cd(search_vector, ?) AS rank, "+
+			"ts_headline(?, products.name, ?, ?) AS highlighted_name, "+
+			"ts_headline(?, products.description, ?, ?) AS snippet",
+			query, SearchConfig, query, HighlightOptions, SearchConfig, query, HighlightOptions).
+		Where("search_vector @@ ?", query).
+		Order("rank DESC, products.sku").
+		Offset((pageIndex - 1) * pageSize).
+		Limit(pageSize).
+		Scan(&hits).Error
+	if err != nil {
+		zap.L().Error("product.repo.search failed to get products", zap.Error(err))
+		return nil, 0, err
+	}
+	return &hits, int(count), nil
+}
+
 func (r *ProductRepositoy) GetBySKU(sku int) (*models.Product, error) {
 	zap.L().Debug("product.repo.getBySKU", zap.Reflect("SKU", sku))
 
@@ -84,6 +225,47 @@ func (r *ProductRepositoy) Update(a *models.Product) (*models.Product, error) {
 	return a, nil
 }
 
+// DecreaseStock atomically takes quantity from the product stock.
+// The update only matches while enough stock is left, so concurrent checkouts can not oversell.
+func (r *ProductRepositoy) DecreaseStock(sku int, quantity int) error {
+	zap.L().Debug("product.repo.decreaseStock", zap.Reflect("SKU", sku), zap.Reflect("quantity", quantity))
+	if quantity < 1 {
+		return ErrInvalidQuantity
+	}
+
+	result := r.db.Model(&models.Product{}).
+		Where("sku = ? AND unit_stock >= ?", sku, quantity).
+		UpdateColumn("unit_stock", gorm.Expr("unit_stock - ?", quantity))
+	if result.Error != nil {
+		zap.L().Error("product.repo.decreaseStock failed to update stock", zap.Error(result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotEnoughStock
+	}
+
+	return nil
+}
+
+// IncreaseStock atomically gives quantity back to the product stock, deleted products get it back too
+// so the stock is right if they are restored
+func (r *ProductRepositoy) IncreaseStock(sku int, quantity int) error {
+	zap.L().Debug("product.repo.increaseStock", zap.Reflect("SKU", sku), zap.Reflect("quantity", quantity))
+
+	result := r.db.Unscoped().Model(&models.Product{}).
+		Where("sku = ?", sku).
+		UpdateColumn("unit_stock", gorm.Expr("unit_stock + ?", quantity))
+	if result.Error != nil {
+		zap.L().Error("product.repo.increaseStock failed to update stock", zap.Error(result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
 func (r *ProductRepositoy) Delete(sku int) error {
 	zap.L().Debug("product.repo.deleteBySku", zap.Reflect("SKU", sku))
 
@@ -98,7 +280,3 @@ func (r *ProductRepositoy) Delete(sku int) error {
 
 	return nil
 }
-
-func (r *ProductRepositoy) Migration() {
-	r.db.AutoMigrate(&models.Product{})
-}
@@ -3,21 +3,78 @@ package product
 import (
 	"github.com/gcamlicali/tradeshopExample/internal/api"
 	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/gcamlicali/tradeshopExample/pkg/money"
 )
 
 //Data Transfer Object
 func ProductToResponse(p *models.Product) *api.Product {
 	int64Sku := int64(p.SKU)
-	int32Price := int32(p.Price)
+	availableStock := p.UnitStock - p.ReservedStock
+	if availableStock < 0 {
+		availableStock = 0
+	}
+	categoryID := ""
+	if p.CategoryID != nil {
+		categoryID = p.CategoryID.String()
+	}
 	return &api.Product{
 
-		CategoryName: &p.CategoryName,
-		Sku:          &int64Sku,
-		Name:         &p.Name,
-		Description:  p.Description,
-		Price:        &int32Price,
-		UnitStock:    &p.UnitStock,
+		CategoryID:     categoryID,
+		CategoryName:   &p.CategoryName,
+		Sku:            &int64Sku,
+		Name:           &p.Name,
+		Description:    p.Description,
+		Price:          MoneyToResponse(p.Price),
+		UnitStock:      &p.UnitStock,
+		AvailableStock: availableStock,
+		Weight:         p.Weight,
+	}
+}
+
+// MoneyToResponse is shared by every response with a price
+func MoneyToResponse(m money.Money) *api.Money {
+	return &api.Money{
+		Amount:   &m.Amount,
+		Currency: m.Currency,
+	}
+}
+
+// ResponseToMoney fills a missing currency with the default one
+func ResponseToMoney(m *api.Money) money.Money {
+	currency := money.DefaultCurrency
+	if m.Currency != "" {
+		currency = m.Currency
+	}
+	return money.New(*m.Amount, currency)
+}
+
+func searchHitsToResponse(hs []SearchHit) []*api.ProductSearchHit {
+	hits := make([]*api.ProductSearchHit, 0, len(hs))
+	for i := range hs {
+		hits = append(hits, &api.ProductSearchHit{
+			Product:         ProductToResponse(&hs[i].Product),
+			Rank:            hs[i].Rank,
+			HighlightedName: hs[i].HighlightedName,
+			Snippet:         hs[i].Snippet,
+		})
+	}
+	return hits
+}
+
+func facetsToResponse(f *Facets) *api.ProductFacets {
+	categories := make([]*api.CategoryFacet, 0, len(f.Categories))
+	for _, c := range f.Categories {
+		categories = append(categories, &api.CategoryFacet{Name: c.Name, Count: int64(c.Count)})
+	}
+	prices := make([]*api.PriceFacet, 0, len(f.Prices))
+	for _, b := range f.Prices {
+		price := &api.PriceFacet{Min: MoneyToResponse(b.Min), Count: int64(b.Count)}
+		if b.Max != nil {
+			price.Max = MoneyToResponse(*b.Max)
+		}
+		prices = append(prices, price)
 	}
+	return &api.ProductFacets{Categories: categories, Prices: prices}
 }
 
 // return Objects
@@ -39,6 +96,7 @@ func responseToProduct(p *api.Product) *models.Product {
 		SKU:          int(*p.Sku),
 		Description:  p.Description,
 		UnitStock:    *p.UnitStock,
+		Weight:       p.Weight,
 	}
 }
 
@@ -49,5 +107,6 @@ func responseToProductUp(p *api.ProductUp) *models.Product {
 		SKU:          int(p.Sku),
 		Description:  p.Description,
 		UnitStock:    p.UnitStock,
+		Weight:       p.Weight,
 	}
 }
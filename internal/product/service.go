@@ -6,31 +6,44 @@ import (
 	"github.com/gcamlicali/tradeshopExample/internal/category"
 	httpErr "github.com/gcamlicali/tradeshopExample/internal/httpErrors"
 	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/gcamlicali/tradeshopExample/internal/reservation"
 	csvRead "github.com/gcamlicali/tradeshopExample/pkg/csv"
+	"github.com/gcamlicali/tradeshopExample/pkg/money"
+	"github.com/gcamlicali/tradeshopExample/pkg/pagination"
 	"gorm.io/gorm"
 	"log"
 	"mime/multipart"
 	"net/http"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 )
 
+// MaxSearchLength is the longest search text accepted in characters
+const MaxSearchLength = 200
+
 type productService struct {
 	pRepo   IProductRepository
 	catRepo category.ICategoryRepository
+	holds   reservation.Service
 }
 
 type Service interface {
 	AddBulk(file multipart.File) error
 	AddSingle(product api.Product) (*models.Product, error)
-	GetAll(pageIndex, pageSize int) (*[]models.Product, int, error)
+	GetAll(filter ListFilter, pageIndex, pageSize int) (*[]models.Product, int, error)
+	GetAfter(filter ListFilter, cursor *pagination.Cursor, pageSize int) (*[]models.Product, *pagination.Pages, error)
+	Facets(filter ListFilter) (*Facets, error)
 	Delete(SKU int) error
 	Update(SKU int, reqProduct *api.ProductUp) (*models.Product, error)
 	GetByName(name string) (*[]models.Product, error)
+	Search(text string, pageIndex, pageSize int) (*[]SearchHit, int, error)
 	GetBySKU(SKU int) (*models.Product, error)
 }
 
-func NewProductService(pRepo IProductRepository, catRepo category.ICategoryRepository) Service {
-	return &productService{pRepo: pRepo, catRepo: catRepo}
+// NewProductService creates product service, holds is nil when stock reservation mode is disabled
+func NewProductService(pRepo IProductRepository, catRepo category.ICategoryRepository, holds reservation.Service) Service {
+	return &productService{pRepo: pRepo, catRepo: catRepo, holds: holds}
 }
 
 func (p productService) AddBulk(file multipart.File) error {
@@ -42,12 +55,14 @@ func (p productService) AddBulk(file multipart.File) error {
 
 	for _, line := range record {
 		proEntity := models.Product{}
-		proEntity.CategoryName = line[0]
-		_, err := p.catRepo.GetByName(proEntity.CategoryName)
+		// The category is a name or a path like Beyaz Esya/Buzdolabi
+		cat, err := category.FindByPath(p.catRepo, line[0])
 		if err != nil {
 			//c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusNotFound, "Category not found", proEntity.CategoryName)))
 			continue
 		}
+		proEntity.CategoryID = &cat.ID
+		proEntity.CategoryName = *cat.Name
 		proEntity.Name = line[1]
 		SKU, err := strconv.Atoi(line[2])
 		if err != nil {
@@ -56,9 +71,13 @@ func (p productService) AddBulk(file multipart.File) error {
 		}
 		proEntity.SKU = SKU
 		proEntity.Description = line[3]
-		price, err := strconv.Atoi(line[4])
-		if err != nil {
-			//c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "Price is not integer", proEntity.Name)))
+		// Price is a decimal like 1299.90, an optional 7th column sets its currency
+		currency := money.DefaultCurrency
+		if len(line) > 6 && line[6] != "" {
+			currency = line[6]
+		}
+		price, err := money.Parse(line[4], currency)
+		if err != nil || price.IsNegative() {
 			continue
 		}
 		proEntity.Price = price
@@ -68,6 +87,14 @@ func (p productService) AddBulk(file multipart.File) error {
 			continue
 		}
 		proEntity.UnitStock = int32(unitStock)
+		// An optional 8th column is the weight in grams
+		if len(line) > 7 && line[7] != "" {
+			weight, err := strconv.ParseInt(line[7], 10, 64)
+			if err != nil || weight < 0 {
+				continue
+			}
+			proEntity.Weight = weight
+		}
 
 		_, err = p.pRepo.Create(&proEntity)
 		if err != nil {
@@ -80,7 +107,7 @@ func (p productService) AddBulk(file multipart.File) error {
 }
 
 func (p productService) AddSingle(product api.Product) (*models.Product, error) {
-	cat, err := p.catRepo.GetByName(*product.CategoryName)
+	cat, err := category.FindByPath(p.catRepo, *product.CategoryName)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, httpErr.NewRestError(http.StatusBadRequest, "Category not found", err.Error())
 	}
@@ -89,7 +116,14 @@ func (p productService) AddSingle(product api.Product) (*models.Product, error)
 	}
 
 	prod := responseToProduct(&product)
+	if prod.Price, err = validPrice(product.Price); err != nil {
+		return nil, err
+	}
+	if prod.Weight < 0 {
+		return nil, httpErr.NewRestError(http.StatusBadRequest, "Weight can't be negative", prod.Weight)
+	}
 
+	prod.CategoryID = &cat.ID
 	prod.CategoryName = *cat.Name
 
 	NewProduct, err := p.pRepo.Create(prod)
@@ -100,16 +134,85 @@ func (p productService) AddSingle(product api.Product) (*models.Product, error)
 	return NewProduct, nil
 }
 
-func (p productService) GetAll(pageIndex, pageSize int) (*[]models.Product, int, error) {
+// GetAll lists the products matching the filter
+func (p productService) GetAll(filter ListFilter, pageIndex, pageSize int) (*[]models.Product, int, error) {
+	if err := validFilter(filter); err != nil {
+		return nil, 0, err
+	}
 
-	products, count, err := p.pRepo.GetAll(pageIndex, pageSize)
+	products, count, err := p.pRepo.GetAll(filter, pageIndex, pageSize)
 	if err != nil {
+		return nil, 0, httpErr.NewRestError(http.StatusInternalServerError, "Get products error", err.Error())
+	}
+
+	if err := p.fillReservedStock(*products); err != nil {
 		return nil, 0, err
 	}
 
 	return products, count, nil
 }
 
+// GetAfter lists the products matching the filter after the cursor, the first page when it is nil. The
+// returned Pages have the cursors of the pages around it.
+func (p productService) GetAfter(filter ListFilter, cursor *pagination.Cursor, pageSize int) (*[]models.Product, *pagination.Pages, error) {
+	if err := validFilter(filter); err != nil {
+		return nil, nil, err
+	}
+	keyset, err := filter.keyset(cursor)
+	if err != nil {
+		return nil, nil, httpErr.NewRestError(http.StatusBadRequest, "Cursor is not valid", err.Error())
+	}
+
+	products, more, err := p.pRepo.GetAfter(filter, keyset, pageSize)
+	if err != nil {
+		return nil, nil, httpErr.NewRestError(http.StatusInternalServerError, "Get products error", err.Error())
+	}
+	if err := p.fillReservedStock(*products); err != nil {
+		return nil, nil, err
+	}
+
+	var first, last *pagination.Cursor
+	if n := len(*products); n > 0 {
+		if first, err = filter.cursor(&(*products)[0]); err == nil {
+			last, err = filter.cursor(&(*products)[n-1])
+		}
+		if err != nil {
+			return nil, nil, httpErr.NewRestError(http.StatusInternalServerError, "Cursor create error", err.Error())
+		}
+	}
+	return products, pagination.NewCursorPages(pageSize, cursor, first, last, more), nil
+}
+
+// Facets counts the products matching the filter by category and price
+func (p productService) Facets(filter ListFilter) (*Facets, error) {
+	if err := validFilter(filter); err != nil {
+		return nil, err
+	}
+
+	facets, err := p.pRepo.Facets(filter)
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Get product facets error", err.Error())
+	}
+	return facets, nil
+}
+
+// validFilter checks the sort fields and the price range of a product list filter
+func validFilter(filter ListFilter) error {
+	if _, ok := sortColumns[filter.SortBy]; filter.SortBy != "" && !ok {
+		return httpErr.NewRestError(http.StatusBadRequest, "Unknown sort field", filter.SortBy)
+	}
+	if filter.SortOrder != "" && filter.SortOrder != "asc" && filter.SortOrder != "desc" {
+		return httpErr.NewRestError(http.StatusBadRequest, "Sort order must be asc or desc", filter.SortOrder)
+	}
+	if !money.ValidCurrency(filter.Currency) {
+		return httpErr.NewRestError(http.StatusBadRequest, "Unknown currency", filter.Currency)
+	}
+	if filter.MinPrice != nil && filter.MaxPrice != nil && filter.MinPrice.Amount > filter.MaxPrice.Amount {
+		return httpErr.NewRestError(http.StatusBadRequest, "Price range is not valid", "minPrice is greater than maxPrice")
+	}
+	return nil
+}
+
 func (p productService) Delete(SKU int) error {
 	err := p.pRepo.Delete(SKU)
 
@@ -137,20 +240,23 @@ func (p productService) Update(SKU int, reqProduct *api.ProductUp) (*models.Prod
 		product.Name = reqProduct.Name
 	}
 	if reqProduct.CategoryName != "" {
-		//check category name of product
-		_, err := p.catRepo.GetByName(reqProduct.CategoryName)
+		//check category name or path of product
+		cat, err := category.FindByPath(p.catRepo, reqProduct.CategoryName)
 		if err != nil {
 			return nil, httpErr.NewRestError(http.StatusBadRequest, "Product category name not found", err.Error())
 		}
 
-		product.CategoryName = reqProduct.CategoryName
+		product.CategoryID = &cat.ID
+		product.CategoryName = *cat.Name
 	}
 
 	if reqProduct.Description != "" {
 		product.Description = reqProduct.Description
 	}
-	if reqProduct.Price != 0 {
-		product.Price = int(reqProduct.Price)
+	if reqProduct.Price != nil {
+		if product.Price, err = validPrice(reqProduct.Price); err != nil {
+			return nil, err
+		}
 	}
 	if reqProduct.Sku != 0 {
 		pro, err := p.pRepo.GetBySKU(int(reqProduct.Sku))
@@ -163,6 +269,12 @@ func (p productService) Update(SKU int, reqProduct *api.ProductUp) (*models.Prod
 	if reqProduct.UnitStock != 0 {
 		product.UnitStock = reqProduct.UnitStock
 	}
+	if reqProduct.Weight < 0 {
+		return nil, httpErr.NewRestError(http.StatusBadRequest, "Weight can't be negative", reqProduct.Weight)
+	}
+	if reqProduct.Weight != 0 {
+		product.Weight = reqProduct.Weight
+	}
 
 	updatedProduct, err := p.pRepo.Update(product)
 	if err != nil {
@@ -182,9 +294,42 @@ func (p productService) GetByName(name string) (*[]models.Product, error) {
 		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Get product error", err.Error())
 	}
 
+	if err := p.fillReservedStock(*products); err != nil {
+		return nil, err
+	}
+
 	return products, nil
 }
 
+// Search finds products by name, category and description, best matches first
+func (p productService) Search(text string, pageIndex, pageSize int) (*[]SearchHit, int, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, 0, httpErr.NewRestError(http.StatusBadRequest, "Search text is required", nil)
+	}
+	if utf8.RuneCountInString(text) > MaxSearchLength {
+		return nil, 0, httpErr.NewRestError(http.StatusBadRequest, "Search text is too long", MaxSearchLength)
+	}
+
+	hits, count, err := p.pRepo.Search(text, pageIndex, pageSize)
+	if err != nil {
+		return nil, 0, httpErr.NewRestError(http.StatusInternalServerError, "Product search error", err.Error())
+	}
+
+	products := make([]models.Product, 0, len(*hits))
+	for _, hit := range *hits {
+		products = append(products, hit.Product)
+	}
+	if err := p.fillReservedStock(products); err != nil {
+		return nil, 0, err
+	}
+	for i := range products {
+		(*hits)[i].ReservedStock = products[i].ReservedStock
+	}
+
+	return hits, count, nil
+}
+
 func (p productService) GetBySKU(SKU int) (*models.Product, error) {
 	product, err := p.pRepo.GetBySKU(SKU)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -194,5 +339,46 @@ func (p productService) GetBySKU(SKU int) (*models.Product, error) {
 		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Get product error", err.Error())
 	}
 
-	return product, nil
+	products := []models.Product{*product}
+	if err := p.fillReservedStock(products); err != nil {
+		return nil, err
+	}
+
+	return &products[0], nil
+}
+
+// fillReservedStock sets the stock held by active cart reservations on given products
+func (p productService) fillReservedStock(products []models.Product) error {
+	if p.holds == nil || len(products) == 0 {
+		return nil
+	}
+
+	skus := make([]int, 0, len(products))
+	for _, product := range products {
+		skus = append(skus, product.SKU)
+	}
+
+	held, err := p.holds.Held(skus)
+	if err != nil {
+		return err
+	}
+	for i := range products {
+		products[i].ReservedStock = int32(held[products[i].SKU])
+	}
+	return nil
+}
+
+// validPrice converts a requested price, the currency must be supported and the amount not negative
+func validPrice(price *api.Money) (money.Money, error) {
+	if price == nil || price.Amount == nil {
+		return money.Money{}, httpErr.NewRestError(http.StatusBadRequest, "Price amount is required", nil)
+	}
+	m := ResponseToMoney(price)
+	if !money.ValidCurrency(m.Currency) {
+		return money.Money{}, httpErr.NewRestError(http.StatusBadRequest, "Unknown currency", m.Currency)
+	}
+	if m.IsNegative() {
+		return money.Money{}, httpErr.NewRestError(http.StatusBadRequest, "Price can't be negative", m.String())
+	}
+	return m, nil
 }
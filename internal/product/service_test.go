@@ -4,9 +4,13 @@ import (
 	"github.com/gcamlicali/tradeshopExample/internal/api"
 	"github.com/gcamlicali/tradeshopExample/internal/category"
 	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/gcamlicali/tradeshopExample/pkg/money"
+	"github.com/gcamlicali/tradeshopExample/pkg/pagination"
 	"github.com/go-openapi/errors"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 	"log"
+	"sort"
 	"strings"
 	"testing"
 )
@@ -17,11 +21,12 @@ var (
 	productName  = "productExample1"
 	NExProName   = "ProductNameNotExisted"
 	description  = "TestProduct"
-	price        = 1000
+	priceAmount  = int64(100000)
+	price        = money.New(priceAmount, money.DefaultCurrency)
 	sku          = 1
 	NExSku       = 99999
 	sku64        = int64(sku)
-	price32      = int32(price)
+	apiPrice     = api.Money{Amount: &priceAmount, Currency: money.DefaultCurrency}
 	unitStock    = int32(1000)
 
 	apiProductName = "ApiProductName"
@@ -73,7 +78,7 @@ func Test_productService_AddSingle(t *testing.T) {
 				product: api.Product{
 					Name:         &apiProductName,
 					CategoryName: &categoryName,
-					Price:        &price32,
+					Price:        &apiPrice,
 					UnitStock:    &unitStock,
 					Description:  description,
 					Sku:          &apiSKU,
@@ -100,7 +105,7 @@ func Test_productService_AddSingle(t *testing.T) {
 				product: api.Product{
 					Name:         &apiProductName,
 					CategoryName: &NExCatName,
-					Price:        &price32,
+					Price:        &apiPrice,
 					UnitStock:    &unitStock,
 					Description:  description,
 					Sku:          &apiSKU,
@@ -137,7 +142,7 @@ func Test_productService_AddSingle(t *testing.T) {
 				product: api.Product{
 					Name:         &apiProductName,
 					CategoryName: &categoryName,
-					Price:        &price32,
+					Price:        &apiPrice,
 					UnitStock:    &unitStock,
 					Description:  description,
 					Sku:          &sku64,
@@ -161,6 +166,25 @@ func Test_productService_AddSingle(t *testing.T) {
 	}
 }
 
+func Test_productService_AddSingle_CategoryPath(t *testing.T) {
+	parentName, childName := "Beyaz Eşya", "Buzdolabı"
+	parent := models.Category{ID: uuid.New(), Name: &parentName, Slug: "beyaz-esya"}
+	child := models.Category{ID: uuid.New(), Name: &childName, Slug: "buzdolabi", ParentID: &parent.ID}
+	path := "Beyaz Esya/Buzdolabi"
+
+	p := productService{
+		pRepo:   &productMockRepo{},
+		catRepo: &categoryMockRepo{Items: []models.Category{parent, child}},
+	}
+	got, err := p.AddSingle(api.Product{Name: &apiProductName, CategoryName: &path, Price: &apiPrice, UnitStock: &unitStock, Sku: &apiSKU})
+	if err != nil {
+		t.Fatalf("AddSingle() error = %v", err)
+	}
+	if got.CategoryID == nil || *got.CategoryID != child.ID || got.CategoryName != childName {
+		t.Errorf("AddSingle() category = %v %q, want %s %q", got.CategoryID, got.CategoryName, child.ID, childName)
+	}
+}
+
 func Test_productService_GetAll(t *testing.T) {
 	type fields struct {
 		pRepo   IProductRepository
@@ -206,7 +230,7 @@ func Test_productService_GetAll(t *testing.T) {
 				pRepo:   tt.fields.pRepo,
 				catRepo: tt.fields.catRepo,
 			}
-			_, _, err := p.GetAll(tt.args.pageIndex, tt.args.pageSize)
+			_, _, err := p.GetAll(ListFilter{Currency: money.DefaultCurrency}, tt.args.pageIndex, tt.args.pageSize)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetAll() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -215,6 +239,128 @@ func Test_productService_GetAll(t *testing.T) {
 	}
 }
 
+func Test_productService_GetAll_Filter(t *testing.T) {
+	min := money.New(50000, money.DefaultCurrency)
+	max := money.New(200000, money.DefaultCurrency)
+	cheap := money.New(1000, money.DefaultCurrency)
+	other := product1
+	other.SKU, other.CategoryName, other.Price, other.UnitStock = 2, NExCatName, cheap, 0
+
+	tests := []struct {
+		name      string
+		filter    ListFilter
+		wantCount int
+		wantErr   bool
+	}{
+		{name: "productService_GetAll_Category_ShouldSuccess", filter: ListFilter{Categories: []string{categoryName}, Currency: money.DefaultCurrency}, wantCount: 1},
+		{name: "productService_GetAll_PriceRange_ShouldSuccess", filter: ListFilter{Currency: money.DefaultCurrency, MinPrice: &min, MaxPrice: &max}, wantCount: 1},
+		{name: "productService_GetAll_InStock_ShouldSuccess", filter: ListFilter{Currency: money.DefaultCurrency, InStock: true}, wantCount: 1},
+		{name: "productService_GetAll_Sort_ShouldSuccess", filter: ListFilter{Currency: money.DefaultCurrency, SortBy: "price", SortOrder: "desc"}, wantCount: 2},
+		{name: "productService_GetAll_ErrorSortField_ShouldFail", filter: ListFilter{Currency: money.DefaultCurrency, SortBy: "stock"}, wantErr: true},
+		{name: "productService_GetAll_ErrorSortOrder_ShouldFail", filter: ListFilter{Currency: money.DefaultCurrency, SortBy: "name", SortOrder: "up"}, wantErr: true},
+		{name: "productService_GetAll_ErrorCurrency_ShouldFail", filter: ListFilter{Currency: "XXX"}, wantErr: true},
+		{name: "productService_GetAll_ErrorPriceRange_ShouldFail", filter: ListFilter{Currency: money.DefaultCurrency, MinPrice: &max, MaxPrice: &min}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := productService{pRepo: &productMockRepo{Items: []models.Product{product1, other}}}
+			products, count, err := p.GetAll(tt.filter, 1, 10)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetAll() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (count != tt.wantCount || len(*products) != tt.wantCount) {
+				t.Errorf("GetAll() count = %d with %d products, want %d", count, len(*products), tt.wantCount)
+			}
+		})
+	}
+}
+
+func Test_productService_GetAfter(t *testing.T) {
+	filter := ListFilter{Currency: money.DefaultCurrency}
+	items := []models.Product{}
+	for i := 1; i <= 5; i++ {
+		item := product1
+		item.SKU = i
+		items = append(items, item)
+	}
+	p := productService{pRepo: &productMockRepo{Items: items}}
+
+	//Walk forward two by two and back again, the first page has no prev cursor and the last no next
+	var skus []int
+	var cursor *pagination.Cursor
+	for {
+		products, pages, err := p.GetAfter(filter, cursor, 2)
+		if err != nil {
+			t.Fatalf("GetAfter() error = %v", err)
+		}
+		for _, item := range *products {
+			skus = append(skus, item.SKU)
+		}
+		if (cursor == nil) != (pages.Prev == "") {
+			t.Errorf("GetAfter() prev = %q after cursor %v", pages.Prev, cursor)
+		}
+		if pages.Next == "" {
+			break
+		}
+		if cursor, err = pagination.DecodeCursor(pages.Next, filter.sort()); err != nil {
+			t.Fatalf("DecodeCursor() error = %v", err)
+		}
+	}
+	if len(skus) != 5 || skus[0] != 1 || skus[4] != 5 {
+		t.Fatalf("GetAfter() walked %v, want 1 to 5", skus)
+	}
+
+	products, pages, err := p.GetAfter(filter, cursor, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev, err := pagination.DecodeCursor(pages.Prev, filter.sort())
+	if err != nil {
+		t.Fatalf("DecodeCursor() error = %v", err)
+	}
+	products, pages, err = p.GetAfter(filter, prev, 2)
+	if err != nil {
+		t.Fatalf("GetAfter() error = %v", err)
+	}
+	if len(*products) != 2 || (*products)[0].SKU != 3 || (*products)[1].SKU != 4 || pages.Next == "" || pages.Prev == "" {
+		t.Errorf("GetAfter() backward got %v, want SKUs 3 and 4 with both cursors", *products)
+	}
+
+	//A cursor made for another sort is refused
+	if _, err := pagination.DecodeCursor(pages.Next, ListFilter{SortBy: "price"}.sort()); err == nil {
+		t.Error("DecodeCursor() accepted a cursor of another sort")
+	}
+}
+
+func Test_productService_Facets(t *testing.T) {
+	cheap := money.New(1000, money.DefaultCurrency)
+	other := product1
+	other.SKU, other.CategoryName, other.Price = 2, NExCatName, cheap
+
+	p := productService{pRepo: &productMockRepo{Items: []models.Product{product1, other}}}
+	facets, err := p.Facets(ListFilter{Categories: []string{categoryName}, Currency: money.DefaultCurrency, MinPrice: &price})
+	if err != nil {
+		t.Fatalf("Facets() error = %v", err)
+	}
+
+	//The category facet ignores the category filter, the price facet ignores the price range
+	if len(facets.Categories) != 1 || facets.Categories[0].Name != categoryName || facets.Categories[0].Count != 1 {
+		t.Errorf("Facets() categories = %v, want only %s", facets.Categories, categoryName)
+	}
+	if len(facets.Prices) != len(PriceBuckets) {
+		t.Fatalf("Facets() got %d price buckets, want %d", len(facets.Prices), len(PriceBuckets))
+	}
+	for i, bucket := range facets.Prices {
+		want := 0
+		if bucket.Min.Amount == priceAmount {
+			want = 1
+		}
+		if bucket.Count != want {
+			t.Errorf("Facets() bucket %d count = %d, want %d", i, bucket.Count, want)
+		}
+	}
+}
+
 func Test_productService_Delete(t *testing.T) {
 	type fields struct {
 		pRepo   IProductRepository
@@ -348,7 +494,7 @@ func Test_productService_Update(t *testing.T) {
 				reqProduct: &api.ProductUp{
 					Name:         apiProductName,
 					CategoryName: categoryName,
-					Price:        price32,
+					Price:        &apiPrice,
 					UnitStock:    unitStock,
 					Description:  description,
 					Sku:          int64(NExSku),
@@ -386,7 +532,7 @@ func Test_productService_Update(t *testing.T) {
 				reqProduct: &api.ProductUp{
 					Name:         apiProductName,
 					CategoryName: categoryName,
-					Price:        price32,
+					Price:        &apiPrice,
 					UnitStock:    unitStock,
 					Description:  description,
 					Sku:          int64(NExSku),
@@ -424,7 +570,7 @@ func Test_productService_Update(t *testing.T) {
 				reqProduct: &api.ProductUp{
 					Name:         apiProductName,
 					CategoryName: NExCatName,
-					Price:        price32,
+					Price:        &apiPrice,
 					UnitStock:    unitStock,
 					Description:  description,
 					Sku:          int64(NExSku),
@@ -462,7 +608,7 @@ func Test_productService_Update(t *testing.T) {
 				reqProduct: &api.ProductUp{
 					Name:         apiProductName,
 					CategoryName: categoryName,
-					Price:        price32,
+					Price:        &apiPrice,
 					UnitStock:    unitStock,
 					Description:  description,
 					Sku:          int64(sku),
@@ -577,6 +723,32 @@ func Test_productService_GetByName(t *testing.T) {
 	}
 }
 
+func Test_productService_Search(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		wantCount int
+		wantErr   bool
+	}{
+		{name: "productService_Search_ShouldSuccess", text: strings.ToLower(product1.Name), wantCount: 1},
+		{name: "productService_Search_NoMatch_ShouldSuccess", text: "nothing like it", wantCount: 0},
+		{name: "productService_Search_ErrorEmpty_ShouldFail", text: "  ", wantErr: true},
+		{name: "productService_Search_ErrorTooLong_ShouldFail", text: strings.Repeat("ç", MaxSearchLength+1), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := productService{pRepo: &productMockRepo{Items: []models.Product{product1}}}
+			hits, count, err := p.Search(tt.text, 1, 10)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Search() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (count != tt.wantCount || len(*hits) != tt.wantCount) {
+				t.Errorf("Search() count = %d with %d hits, want %d", count, len(*hits), tt.wantCount)
+			}
+		})
+	}
+}
+
 func Test_productService_GetBySKU(t *testing.T) {
 	type fields struct {
 		pRepo   IProductRepository
@@ -700,6 +872,57 @@ func (c *categoryMockRepo) GetAll(pageIndex, pageSize int) (*[]models.Category,
 
 	return &c.Items, len(c.Items), nil
 }
+func (c *categoryMockRepo) GetByID(id uuid.UUID) (*models.Category, error) {
+	for _, item := range c.Items {
+		if item.ID == id {
+			category := item
+			return &category, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (c *categoryMockRepo) GetBySlug(slug string) (*models.Category, error) {
+	for _, item := range c.Items {
+		if item.Slug == slug {
+			category := item
+			return &category, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (c *categoryMockRepo) GetChild(parentID *uuid.UUID, name string) (*models.Category, error) {
+	for _, item := range c.Items {
+		sameParent := item.ParentID == nil && parentID == nil || item.ParentID != nil && parentID != nil && *item.ParentID == *parentID
+		if sameParent && (*item.Name == name || item.Slug == category.Slugify(name)) {
+			category := item
+			return &category, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (c *categoryMockRepo) GetTree() (*[]models.Category, error) {
+	return &c.Items, nil
+}
+func (c *categoryMockRepo) Update(a *models.Category, oldName string) (*models.Category, error) {
+	for i, item := range c.Items {
+		if item.ID == a.ID {
+			c.Items[i] = *a
+		}
+	}
+	return a, nil
+}
+func (c *categoryMockRepo) Delete(a *models.Category, reassignTo *models.Category) error {
+	for i, item := range c.Items {
+		if item.ID == a.ID {
+			c.Items = append(c.Items[:i], c.Items[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+func (c *categoryMockRepo) GetAfter(keyset *pagination.Keyset, pageSize int) (*[]models.Category, bool, error) {
+	return &c.Items, false, nil
+}
 
 func (p *productMockRepo) Create(a *models.Product) (*models.Product, error) {
 	for _, item := range p.Items {
@@ -710,9 +933,85 @@ func (p *productMockRepo) Create(a *models.Product) (*models.Product, error) {
 	p.Items = append(p.Items, *a)
 	return a, nil
 }
-func (p *productMockRepo) GetAll(pageIndex, pageSize int) (*[]models.Product, int, error) {
-	log.Println("size: ", len(p.Items))
-	return &p.Items, len(p.Items), nil
+func (p *productMockRepo) GetAll(filter ListFilter, pageIndex, pageSize int) (*[]models.Product, int, error) {
+	products := []models.Product{}
+	for _, item := range p.Items {
+		if matches(filter, item) {
+			products = append(products, item)
+		}
+	}
+	log.Println("size: ", len(products))
+	return &products, len(products), nil
+}
+
+// GetAfter pages the products matching the filter by SKU, other sorts are not mocked
+func (p *productMockRepo) GetAfter(filter ListFilter, keyset *pagination.Keyset, pageSize int) (*[]models.Product, bool, error) {
+	products := []models.Product{}
+	for _, item := range p.Items {
+		if !matches(filter, item) {
+			continue
+		}
+		if keyset.ID != nil && (!keyset.Backward && item.SKU <= keyset.ID.(int) || keyset.Backward && item.SKU >= keyset.ID.(int)) {
+			continue
+		}
+		products = append(products, item)
+	}
+	sort.Slice(products, func(i, j int) bool { return (products[i].SKU < products[j].SKU) != keyset.Backward })
+
+	more := len(products) > pageSize
+	if more {
+		products = products[:pageSize]
+	}
+	if keyset.Backward {
+		sort.Slice(products, func(i, j int) bool { return products[i].SKU < products[j].SKU })
+	}
+	return &products, more, nil
+}
+func (p *productMockRepo) Facets(filter ListFilter) (*Facets, error) {
+	facets := &Facets{}
+	counts := map[string]int{}
+	for _, item := range p.Items {
+		if matches(filter.withoutCategories(), item) {
+			if counts[item.CategoryName] == 0 {
+				facets.Categories = append(facets.Categories, CategoryCount{Name: item.CategoryName})
+			}
+			counts[item.CategoryName]++
+		}
+	}
+	for i := range facets.Categories {
+		facets.Categories[i].Count = counts[facets.Categories[i].Name]
+	}
+	for i, min := range PriceBuckets {
+		bucket := PriceBucketCount{Min: money.New(min, filter.Currency)}
+		for _, item := range p.Items {
+			if matches(filter.withoutPrice(), item) && item.Price.Currency == filter.Currency && item.Price.Amount >= min &&
+				(i+1 == len(PriceBuckets) || item.Price.Amount < PriceBuckets[i+1]) {
+				bucket.Count++
+			}
+		}
+		facets.Prices = append(facets.Prices, bucket)
+	}
+	return facets, nil
+}
+
+// matches is the in memory version of ListFilter.scope, reservations are not mocked
+func matches(f ListFilter, item models.Product) bool {
+	if len(f.Categories) > 0 {
+		found := false
+		for _, name := range f.Categories {
+			found = found || name == item.CategoryName
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.MinPrice != nil && (item.Price.Currency != f.MinPrice.Currency || item.Price.Amount < f.MinPrice.Amount) {
+		return false
+	}
+	if f.MaxPrice != nil && (item.Price.Currency != f.MaxPrice.Currency || item.Price.Amount > f.MaxPrice.Amount) {
+		return false
+	}
+	return !f.InStock || item.UnitStock > 0
 }
 func (p *productMockRepo) GetByName(name string) (*[]models.Product, error) {
 	products := []models.Product{}
@@ -727,6 +1026,16 @@ func (p *productMockRepo) GetByName(name string) (*[]models.Product, error) {
 		return nil, errors.New(400, "Product not found")
 	}
 }
+func (p *productMockRepo) Search(text string, pageIndex, pageSize int) (*[]SearchHit, int, error) {
+	hits := []SearchHit{}
+	text = strings.ToLower(text)
+	for _, item := range p.Items {
+		if strings.Contains(strings.ToLower(item.Name+" "+item.CategoryName+" "+item.Description), text) {
+			hits = append(hits, SearchHit{Product: item, Rank: 1, HighlightedName: item.Name, Snippet: item.Description})
+		}
+	}
+	return &hits, len(hits), nil
+}
 func (p *productMockRepo) GetBySKU(SKU int) (*models.Product, error) {
 	product := models.Product{}
 	for _, item := range p.Items {
@@ -760,6 +1069,27 @@ func (p *productMockRepo) Update(a *models.Product) (*models.Product, error) {
 	}
 	return a, nil
 }
+func (p *productMockRepo) DecreaseStock(sku int, quantity int) error {
+	for i, item := range p.Items {
+		if item.SKU == sku {
+			if int(item.UnitStock) < quantity {
+				return ErrNotEnoughStock
+			}
+			p.Items[i].UnitStock -= int32(quantity)
+			return nil
+		}
+	}
+	return ErrNotEnoughStock
+}
+func (p *productMockRepo) IncreaseStock(sku int, quantity int) error {
+	for i, item := range p.Items {
+		if item.SKU == sku {
+			p.Items[i].UnitStock += int32(quantity)
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
 func (p *productMockRepo) Delete(sku int) error {
 	pro, err := p.GetBySKU(sku)
 	if err != nil {
@@ -0,0 +1,203 @@
+package promotion
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/gcamlicali/tradeshopExample/pkg/money"
+)
+
+// PercentScale is %100, percents are basis points so 1500 is %15
+const PercentScale = 10000
+
+var (
+	ErrNotStarted      = errors.New("promotion has not started yet")
+	ErrExpired         = errors.New("promotion has expired")
+	ErrMinCartValue    = errors.New("cart total is below the promotion minimum")
+	ErrNoEligibleItems = errors.New("cart has no items the promotion applies to")
+	ErrCurrency        = errors.New("promotion currency doesn't match the cart")
+)
+
+// Line is a cart item to discount, Amount is the line total before discounts. Ancestors are the
+// categories above Category, a promotion of any of them applies to the line too.
+type Line struct {
+	Category  string
+	Ancestors []string
+	Quantity  int
+	Amount    money.Money
+}
+
+// Result is the discounts of a cart, LineDiscounts follow the order of the lines
+type Result struct {
+	LineDiscounts []money.Money
+	Discounts     []models.Discount
+	Total         money.Money
+}
+
+// Check tells why a promotion doesn't fit the lines at the given time, nil when it fits
+func Check(p *models.Promotion, lines []Line, at time.Time) error {
+	if at.Before(p.ValidFrom) {
+		return ErrNotStarted
+	}
+	if p.ValidUntil != nil && !at.Before(*p.ValidUntil) {
+		return ErrExpired
+	}
+
+	total, err := sum(lines)
+	if err != nil {
+		return err
+	}
+	if !p.MinCartValue.IsZero() {
+		cmp, err := total.Cmp(p.MinCartValue)
+		if err != nil {
+			return ErrCurrency
+		}
+		if cmp < 0 {
+			return ErrMinCartValue
+		}
+	}
+	if p.Kind == models.PromotionFixed {
+		if _, err := total.Cmp(p.Value); err != nil {
+			return ErrCurrency
+		}
+	}
+
+	for _, line := range lines {
+		if eligible(p, line) {
+			return nil
+		}
+	}
+	return ErrNoEligibleItems
+}
+
+// Apply takes the promotions off the lines in the given order, each one discounts what the ones
+// before it left. Promotions that don't fit the lines are skipped, a line never goes below zero.
+func Apply(promotions []models.Promotion, lines []Line, at time.Time) (*Result, error) {
+	total, err := sum(lines)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{LineDiscounts: make([]money.Money, len(lines)), Discounts: []models.Discount{}, Total: money.Zero(total.Currency)}
+	left := make([]money.Money, len(lines))
+	for i, line := range lines {
+		result.LineDiscounts[i] = money.Zero(line.Amount.Currency)
+		left[i] = line.Amount
+	}
+
+	for i := range promotions {
+		p := &promotions[i]
+		if Check(p, lines, at) != nil {
+			continue
+		}
+
+		taken := discount(p, lines, left)
+		amount := money.Zero(total.Currency)
+		for j := range lines {
+			left[j], _ = left[j].Sub(taken[j])
+			result.LineDiscounts[j], _ = result.LineDiscounts[j].Add(taken[j])
+			amount, _ = amount.Add(taken[j])
+		}
+		if amount.IsZero() {
+			continue
+		}
+
+		applied := models.Discount{PromotionID: p.ID, Name: p.Name, Amount: amount}
+		if p.Code != nil {
+			applied.Code = *p.Code
+		}
+		result.Discounts = append(result.Discounts, applied)
+		result.Total, _ = result.Total.Add(amount)
+	}
+	return result, nil
+}
+
+// discount returns what p takes off each line given what is left of them
+func discount(p *models.Promotion, lines []Line, left []money.Money) []money.Money {
+	taken := make([]money.Money, len(lines))
+	for i := range lines {
+		taken[i] = money.Zero(left[i].Currency)
+	}
+
+	switch p.Kind {
+	case models.PromotionPercentage:
+		for i, line := range lines {
+			if eligible(p, line) {
+				taken[i] = left[i].MulRatio(p.Percent, PercentScale, money.RoundHalfUp)
+			}
+		}
+	case models.PromotionBuyXGetY:
+		group := p.BuyQuantity + p.GetQuantity
+		for i, line := range lines {
+			if !eligible(p, line) || group <= 0 || line.Quantity <= 0 {
+				continue
+			}
+			free := int64(line.Quantity / group * p.GetQuantity)
+			taken[i] = line.Amount.MulRatio(free, int64(line.Quantity), money.RoundHalfUp)
+		}
+	case models.PromotionFixed:
+		// The fixed amount is shared by the eligible lines in proportion to what is left of them,
+		// the last eligible line takes the rounding difference
+		var base int64
+		last := -1
+		for i, line := range lines {
+			if eligible(p, line) && left[i].Amount > 0 {
+				base += left[i].Amount
+				last = i
+			}
+		}
+		if last < 0 {
+			return taken
+		}
+		amount := p.Value.Amount
+		if amount > base {
+			amount = base
+		}
+		shared := int64(0)
+		for i, line := range lines {
+			if !eligible(p, line) || left[i].Amount <= 0 {
+				continue
+			}
+			if i == last {
+				taken[i] = money.New(amount-shared, left[i].Currency)
+				break
+			}
+			taken[i] = money.New(amount, left[i].Currency).MulRatio(left[i].Amount, base, money.RoundDown)
+			shared += taken[i].Amount
+		}
+	}
+
+	for i := range taken {
+		if taken[i].Amount > left[i].Amount {
+			taken[i] = left[i]
+		}
+	}
+	return taken
+}
+
+func eligible(p *models.Promotion, line Line) bool {
+	if p.CategoryName == "" || p.CategoryName == line.Category {
+		return true
+	}
+	for _, name := range line.Ancestors {
+		if p.CategoryName == name {
+			return true
+		}
+	}
+	return false
+}
+
+// sum returns the total of the lines, zero in the default currency when there are none
+func sum(lines []Line) (money.Money, error) {
+	var total money.Money
+	for _, line := range lines {
+		var err error
+		if total, err = total.Add(line.Amount); err != nil {
+			return money.Money{}, err
+		}
+	}
+	if total.Currency == "" {
+		total.Currency = money.DefaultCurrency
+	}
+	return total, nil
+}
@@ -0,0 +1,117 @@
+package promotion
+
+import (
+	"net/http"
+
+	"github.com/gcamlicali/tradeshopExample/internal/api"
+	httpErr "github.com/gcamlicali/tradeshopExample/internal/httpErrors"
+	mw "github.com/gcamlicali/tradeshopExample/pkg/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/go-openapi/strfmt"
+	"github.com/google/uuid"
+)
+
+type promotionHandler struct {
+	service Service
+}
+
+func NewPromotionHandler(r *gin.RouterGroup, service Service, authMW gin.HandlerFunc) {
+	h := &promotionHandler{service: service}
+
+	r.Use(authMW, mw.RequirePermission(mw.PermPromotionManage))
+	r.GET("", h.list)
+	r.GET("/:id", h.get)
+	r.POST("", h.create)
+	r.PUT("/:id", h.update)
+	r.DELETE("/:id", h.delete)
+}
+
+func (h *promotionHandler) list(c *gin.Context) {
+	promotions, err := h.service.List()
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, promotionsToResponse(*promotions))
+}
+
+func (h *promotionHandler) get(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "Promotion ID is not valid", err.Error())))
+		return
+	}
+
+	promotion, err := h.service.Get(id)
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, promotionToResponse(promotion))
+}
+
+func (h *promotionHandler) create(c *gin.Context) {
+	req, ok := bindPromotion(c)
+	if !ok {
+		return
+	}
+
+	promotion, err := h.service.Create(req)
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, promotionToResponse(promotion))
+}
+
+func (h *promotionHandler) update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "Promotion ID is not valid", err.Error())))
+		return
+	}
+	req, ok := bindPromotion(c)
+	if !ok {
+		return
+	}
+
+	promotion, err := h.service.Update(id, req)
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, promotionToResponse(promotion))
+}
+
+func (h *promotionHandler) delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "Promotion ID is not valid", err.Error())))
+		return
+	}
+
+	if err := h.service.Delete(id); err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, "Promotion deleted")
+}
+
+// bindPromotion writes the error response itself when the body is not valid
+func bindPromotion(c *gin.Context) (api.Promotion, bool) {
+	req := api.Promotion{}
+	if err := c.Bind(&req); err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "check your request body", err.Error())))
+		return req, false
+	}
+	if err := req.Validate(strfmt.NewFormats()); err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return req, false
+	}
+	return req, true
+}
@@ -0,0 +1,117 @@
+package promotion
+
+import (
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type PromotionRepositoy struct {
+	db *gorm.DB
+}
+
+type IPromotionRepository interface {
+	Create(a *models.Promotion) (*models.Promotion, error)
+	GetByID(id uuid.UUID) (*models.Promotion, error)
+	GetByCode(code string) (*models.Promotion, error)
+	List() (*[]models.Promotion, error)
+	Automatic() (*[]models.Promotion, error)
+	Update(a *models.Promotion) (*models.Promotion, error)
+	Delete(id uuid.UUID) error
+	UsageCount(promotionID uuid.UUID, userID uuid.UUID) (int, error)
+}
+
+func NewPromotionRepository(db *gorm.DB) *PromotionRepositoy {
+	return &PromotionRepositoy{db: db}
+}
+
+func (r *PromotionRepositoy) Create(a *models.Promotion) (*models.Promotion, error) {
+	zap.L().Debug("promotion.repo.create", zap.Reflect("promotion", a))
+	if err := r.db.Create(a).Error; err != nil {
+		zap.L().Error("promotion.repo.Create failed to create promotion", zap.Error(err))
+		return nil, err
+	}
+	return a, nil
+}
+
+func (r *PromotionRepositoy) GetByID(id uuid.UUID) (*models.Promotion, error) {
+	zap.L().Debug("promotion.repo.getByID", zap.Reflect("id", id))
+
+	var promotion models.Promotion
+	if err := r.db.Where("id = ?", id).First(&promotion).Error; err != nil {
+		return nil, err
+	}
+	return &promotion, nil
+}
+
+func (r *PromotionRepositoy) GetByCode(code string) (*models.Promotion, error) {
+	zap.L().Debug("promotion.repo.getByCode", zap.String("code", code))
+
+	var promotion models.Promotion
+	if err := r.db.Where("code = ?", code).First(&promotion).Error; err != nil {
+		return nil, err
+	}
+	return &promotion, nil
+}
+
+// List returns every promotion, newest first
+func (r *PromotionRepositoy) List() (*[]models.Promotion, error) {
+	zap.L().Debug("promotion.repo.list")
+
+	var promotions = []models.Promotion{}
+	if err := r.db.Order("created_at DESC").Find(&promotions).Error; err != nil {
+		return nil, err
+	}
+	return &promotions, nil
+}
+
+// Automatic returns the promotions without a coupon code, oldest first
+func (r *PromotionRepositoy) Automatic() (*[]models.Promotion, error) {
+	zap.L().Debug("promotion.repo.automatic")
+
+	var promotions = []models.Promotion{}
+	if err := r.db.Where("code IS NULL").Order("created_at").Find(&promotions).Error; err != nil {
+		return nil, err
+	}
+	return &promotions, nil
+}
+
+func (r *PromotionRepositoy) Update(a *models.Promotion) (*models.Promotion, error) {
+	zap.L().Debug("promotion.repo.update", zap.Reflect("promotion", a))
+
+	if err := r.db.Save(a).Error; err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (r *PromotionRepositoy) Delete(id uuid.UUID) error {
+	zap.L().Debug("promotion.repo.delete", zap.Reflect("id", id))
+
+	result := r.db.Delete(&models.Promotion{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// UsageCount returns how many orders of the user used the promotion, cancelled orders don't count
+func (r *PromotionRepositoy) UsageCount(promotionID uuid.UUID, userID uuid.UUID) (int, error) {
+	zap.L().Debug("promotion.repo.usageCount", zap.Reflect("promotionID", promotionID), zap.Reflect("userID", userID))
+
+	var count int64
+	err := r.db.
+		Table("order_discount").
+		Joins(`JOIN "order" ON "order".id = order_discount.order_id`).
+		Where("order_discount.promotion_id = ?", promotionID).
+		Where(`"order".user_id = ? AND "order".deleted_at IS NULL AND "order".status <> ?`, userID, models.OrderCancelled).
+		Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
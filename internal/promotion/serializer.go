@@ -0,0 +1,60 @@
+package promotion
+
+import (
+	"github.com/gcamlicali/tradeshopExample/internal/api"
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/gcamlicali/tradeshopExample/internal/product"
+	"github.com/go-openapi/strfmt"
+)
+
+func promotionToResponse(m *models.Promotion) *api.Promotion {
+	name := m.Name
+	kind := string(m.Kind)
+	validFrom := strfmt.DateTime(m.ValidFrom)
+	res := &api.Promotion{
+		ID:           m.ID.String(),
+		Name:         &name,
+		Kind:         &kind,
+		Percent:      m.Percent,
+		BuyQuantity:  int32(m.BuyQuantity),
+		GetQuantity:  int32(m.GetQuantity),
+		CategoryName: m.CategoryName,
+		UsageLimit:   int32(m.UsageLimit),
+		ValidFrom:    &validFrom,
+	}
+	if m.Code != nil {
+		res.Code = *m.Code
+	}
+	if m.Kind == models.PromotionFixed {
+		res.Value = product.MoneyToResponse(m.Value)
+	}
+	if !m.MinCartValue.IsZero() {
+		res.MinCartValue = product.MoneyToResponse(m.MinCartValue)
+	}
+	if m.ValidUntil != nil {
+		res.ValidUntil = strfmt.DateTime(*m.ValidUntil)
+	}
+	return res
+}
+
+func promotionsToResponse(ms []models.Promotion) []*api.Promotion {
+	promotions := make([]*api.Promotion, 0, len(ms))
+	for i := range ms {
+		promotions = append(promotions, promotionToResponse(&ms[i]))
+	}
+	return promotions
+}
+
+// DiscountsToResponse is shared by cart and order responses
+func DiscountsToResponse(ms []models.Discount) []*api.Discount {
+	discounts := make([]*api.Discount, 0, len(ms))
+	for _, m := range ms {
+		discounts = append(discounts, &api.Discount{
+			PromotionID: m.PromotionID.String(),
+			Code:        m.Code,
+			Name:        m.Name,
+			Amount:      product.MoneyToResponse(m.Amount),
+		})
+	}
+	return discounts
+}
@@ -0,0 +1,299 @@
+package promotion
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gcamlicali/tradeshopExample/internal/api"
+	"github.com/gcamlicali/tradeshopExample/internal/category"
+	httpErr "github.com/gcamlicali/tradeshopExample/internal/httpErrors"
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/gcamlicali/tradeshopExample/internal/product"
+	"github.com/gcamlicali/tradeshopExample/pkg/money"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type promotionService struct {
+	repo    IPromotionRepository
+	catRepo category.ICategoryRepository
+}
+
+// Discounter works out the discounts of a user's cart
+type Discounter interface {
+	// Discount applies the automatic promotions and the coupon of the cart, couponID is nil without a coupon
+	Discount(userID uuid.UUID, couponID *uuid.UUID, lines []Line, at time.Time) (*Result, error)
+	// Coupon finds the coupon with code and checks it can be applied to the lines
+	Coupon(userID uuid.UUID, code string, lines []Line, at time.Time) (*models.Promotion, error)
+}
+
+type Service interface {
+	Discounter
+	List() (*[]models.Promotion, error)
+	Get(id uuid.UUID) (*models.Promotion, error)
+	Create(req api.Promotion) (*models.Promotion, error)
+	Update(id uuid.UUID, req api.Promotion) (*models.Promotion, error)
+	Delete(id uuid.UUID) error
+}
+
+func NewPromotionService(repo IPromotionRepository, catRepo category.ICategoryRepository) Service {
+	return &promotionService{repo: repo, catRepo: catRepo}
+}
+
+func (s *promotionService) Discount(userID uuid.UUID, couponID *uuid.UUID, lines []Line, at time.Time) (*Result, error) {
+	lines, err := s.withAncestors(lines)
+	if err != nil {
+		return nil, err
+	}
+	automatic, err := s.repo.Automatic()
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Get promotions error", err.Error())
+	}
+	promotions := *automatic
+
+	// The coupon is taken off after the automatic promotions, a deleted coupon no longer applies
+	if couponID != nil {
+		coupon, err := s.repo.GetByID(*couponID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, httpErr.NewRestError(http.StatusInternalServerError, "Get coupon error", err.Error())
+		}
+		if coupon != nil {
+			promotions = append(promotions, *coupon)
+		}
+	}
+
+	usable := make([]models.Promotion, 0, len(promotions))
+	for _, p := range promotions {
+		used, err := s.usedUp(&p, userID)
+		if err != nil {
+			return nil, err
+		}
+		if !used {
+			usable = append(usable, p)
+		}
+	}
+
+	result, err := Apply(usable, lines, at)
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusBadRequest, "Cart has mixed currencies", err.Error())
+	}
+	return result, nil
+}
+
+func (s *promotionService) Coupon(userID uuid.UUID, code string, lines []Line, at time.Time) (*models.Promotion, error) {
+	lines, err := s.withAncestors(lines)
+	if err != nil {
+		return nil, err
+	}
+	coupon, err := s.repo.GetByCode(normalizeCode(code))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, httpErr.NewRestError(http.StatusNotFound, "Coupon not found", code)
+	}
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Get coupon error", err.Error())
+	}
+
+	if err := Check(coupon, lines, at); err != nil {
+		return nil, httpErr.NewRestError(http.StatusBadRequest, "Coupon can't be applied to the cart", err.Error())
+	}
+	used, err := s.usedUp(coupon, userID)
+	if err != nil {
+		return nil, err
+	}
+	if used {
+		return nil, httpErr.NewRestError(http.StatusBadRequest, "Coupon usage limit reached", code)
+	}
+	return coupon, nil
+}
+
+func (s *promotionService) List() (*[]models.Promotion, error) {
+	promotions, err := s.repo.List()
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Get promotions error", err.Error())
+	}
+	return promotions, nil
+}
+
+func (s *promotionService) Get(id uuid.UUID) (*models.Promotion, error) {
+	promotion, err := s.repo.GetByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, httpErr.NewRestError(http.StatusNotFound, "Promotion not found", id.String())
+	}
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Get promotion error", err.Error())
+	}
+	return promotion, nil
+}
+
+func (s *promotionService) Create(req api.Promotion) (*models.Promotion, error) {
+	promotion, err := s.fromRequest(req, uuid.Nil)
+	if err != nil {
+		return nil, err
+	}
+
+	promotion, err = s.repo.Create(promotion)
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Promotion create error", err.Error())
+	}
+	return promotion, nil
+}
+
+// Update replaces every field of the promotion, orders keep the discounts they were given
+func (s *promotionService) Update(id uuid.UUID, req api.Promotion) (*models.Promotion, error) {
+	current, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	promotion, err := s.fromRequest(req, id)
+	if err != nil {
+		return nil, err
+	}
+	promotion.ID = current.ID
+	promotion.CreatedAt = current.CreatedAt
+
+	promotion, err = s.repo.Update(promotion)
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Promotion update error", err.Error())
+	}
+	return promotion, nil
+}
+
+func (s *promotionService) Delete(id uuid.UUID) error {
+	err := s.repo.Delete(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return httpErr.NewRestError(http.StatusNotFound, "Promotion not found", id.String())
+	}
+	if err != nil {
+		return httpErr.NewRestError(http.StatusInternalServerError, "Promotion delete error", err.Error())
+	}
+	return nil
+}
+
+// usedUp reports whether the user has used the promotion as many times as it allows
+func (s *promotionService) usedUp(p *models.Promotion, userID uuid.UUID) (bool, error) {
+	if p.UsageLimit == 0 {
+		return false, nil
+	}
+	count, err := s.repo.UsageCount(p.ID, userID)
+	if err != nil {
+		return false, httpErr.NewRestError(http.StatusInternalServerError, "Get promotion usage error", err.Error())
+	}
+	return count >= p.UsageLimit, nil
+}
+
+// withAncestors returns a copy of the lines with the categories above their categories, so a promotion
+// of a category applies to its sub categories too
+func (s *promotionService) withAncestors(lines []Line) ([]Line, error) {
+	ancestors := map[string][]string{}
+	withAncestors := make([]Line, len(lines))
+	for i, line := range lines {
+		names, ok := ancestors[line.Category]
+		if !ok {
+			var err error
+			if names, err = category.Ancestors(s.catRepo, line.Category); err != nil {
+				return nil, httpErr.NewRestError(http.StatusInternalServerError, "Get category error", err.Error())
+			}
+			ancestors[line.Category] = names
+		}
+		line.Ancestors = names[1:]
+		withAncestors[i] = line
+	}
+	return withAncestors, nil
+}
+
+// fromRequest validates req, id is the promotion being updated and uuid.Nil on create
+func (s *promotionService) fromRequest(req api.Promotion, id uuid.UUID) (*models.Promotion, error) {
+	promotion := &models.Promotion{
+		Name:         strings.TrimSpace(*req.Name),
+		Kind:         models.PromotionKind(*req.Kind),
+		Percent:      req.Percent,
+		BuyQuantity:  int(req.BuyQuantity),
+		GetQuantity:  int(req.GetQuantity),
+		CategoryName: req.CategoryName,
+		UsageLimit:   int(req.UsageLimit),
+		ValidFrom:    time.Time(*req.ValidFrom),
+	}
+
+	switch promotion.Kind {
+	case models.PromotionPercentage:
+		if promotion.Percent <= 0 || promotion.Percent > PercentScale {
+			return nil, httpErr.NewRestError(http.StatusBadRequest, "Percent must be between 1 and 10000 basis points", promotion.Percent)
+		}
+	case models.PromotionFixed:
+		value, err := validAmount(req.Value)
+		if err != nil {
+			return nil, err
+		}
+		if value.IsZero() {
+			return nil, httpErr.NewRestError(http.StatusBadRequest, "Fixed promotions need a value", nil)
+		}
+		promotion.Value = value
+	case models.PromotionBuyXGetY:
+		if promotion.BuyQuantity < 1 || promotion.GetQuantity < 1 {
+			return nil, httpErr.NewRestError(http.StatusBadRequest, "Buy and get quantities must be at least 1", nil)
+		}
+	default:
+		return nil, httpErr.NewRestError(http.StatusBadRequest, "Unknown promotion kind", string(promotion.Kind))
+	}
+
+	if req.MinCartValue != nil {
+		minCartValue, err := validAmount(req.MinCartValue)
+		if err != nil {
+			return nil, err
+		}
+		promotion.MinCartValue = minCartValue
+	}
+	if promotion.UsageLimit < 0 {
+		return nil, httpErr.NewRestError(http.StatusBadRequest, "Usage limit can't be negative", promotion.UsageLimit)
+	}
+	if !time.Time(req.ValidUntil).IsZero() {
+		validUntil := time.Time(req.ValidUntil)
+		if !validUntil.After(promotion.ValidFrom) {
+			return nil, httpErr.NewRestError(http.StatusBadRequest, "Promotion must end after it starts", nil)
+		}
+		promotion.ValidUntil = &validUntil
+	}
+
+	if promotion.CategoryName != "" {
+		_, err := s.catRepo.GetByName(promotion.CategoryName)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, httpErr.NewRestError(http.StatusBadRequest, "Category not found", promotion.CategoryName)
+		}
+		if err != nil {
+			return nil, httpErr.NewRestError(http.StatusInternalServerError, "Get category error", err.Error())
+		}
+	}
+
+	if code := normalizeCode(req.Code); code != "" {
+		existing, err := s.repo.GetByCode(code)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, httpErr.NewRestError(http.StatusInternalServerError, "Get coupon error", err.Error())
+		}
+		if existing != nil && existing.ID != id {
+			return nil, httpErr.NewRestError(http.StatusConflict, "Coupon code is already used", code)
+		}
+		promotion.Code = &code
+	}
+	return promotion, nil
+}
+
+// normalizeCode makes coupon codes case insensitive
+func normalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func validAmount(m *api.Money) (money.Money, error) {
+	if m == nil || m.Amount == nil {
+		return money.Money{}, httpErr.NewRestError(http.StatusBadRequest, "Amount is required", nil)
+	}
+	amount := product.ResponseToMoney(m)
+	if !money.ValidCurrency(amount.Currency) {
+		return money.Money{}, httpErr.NewRestError(http.StatusBadRequest, "Unknown currency", amount.Currency)
+	}
+	if amount.IsNegative() {
+		return money.Money{}, httpErr.NewRestError(http.StatusBadRequest, "Amount can't be negative", amount.String())
+	}
+	return amount, nil
+}
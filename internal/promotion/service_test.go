@@ -0,0 +1,404 @@
+package promotion
+
+import (
+	"github.com/gcamlicali/tradeshopExample/internal/api"
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/gcamlicali/tradeshopExample/pkg/money"
+	"github.com/gcamlicali/tradeshopExample/pkg/pagination"
+	"github.com/go-openapi/strfmt"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var (
+	now       = time.Date(2023, time.March, 1, 12, 0, 0, 0, time.UTC)
+	yesterday = now.AddDate(0, 0, -1)
+	tomorrow  = now.AddDate(0, 0, 1)
+
+	couponCode = "WELCOME"
+
+	// A 100.00 TRY phone and 3 books of 10.00 TRY
+	cartLines = []Line{
+		{Category: "Phone", Quantity: 1, Amount: money.New(10000, "TRY")},
+		{Category: "Book", Quantity: 3, Amount: money.New(3000, "TRY")},
+	}
+)
+
+func tryAmounts(amounts ...int64) []money.Money {
+	ms := make([]money.Money, 0, len(amounts))
+	for _, amount := range amounts {
+		ms = append(ms, money.New(amount, "TRY"))
+	}
+	return ms
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name       string
+		promotions []models.Promotion
+		want       []money.Money
+	}{
+		{
+			name:       "Apply_Percentage",
+			promotions: []models.Promotion{{Kind: models.PromotionPercentage, Percent: 1000}},
+			want:       tryAmounts(1000, 300),
+		},
+		{
+			name:       "Apply_PercentageOfCategory",
+			promotions: []models.Promotion{{Kind: models.PromotionPercentage, Percent: 1000, CategoryName: "Book"}},
+			want:       tryAmounts(0, 300),
+		},
+		{
+			name:       "Apply_FixedSharedByLines",
+			promotions: []models.Promotion{{Kind: models.PromotionFixed, Value: money.New(1000, "TRY")}},
+			want:       tryAmounts(769, 231),
+		},
+		{
+			name:       "Apply_FixedAboveCart",
+			promotions: []models.Promotion{{Kind: models.PromotionFixed, Value: money.New(2000, "TRY"), CategoryName: "Book"}},
+			want:       tryAmounts(0, 2000),
+		},
+		{
+			name:       "Apply_BuyTwoGetOne",
+			promotions: []models.Promotion{{Kind: models.PromotionBuyXGetY, BuyQuantity: 2, GetQuantity: 1}},
+			want:       tryAmounts(0, 1000),
+		},
+		{
+			name: "Apply_StackedOnWhatIsLeft",
+			promotions: []models.Promotion{
+				{Kind: models.PromotionBuyXGetY, BuyQuantity: 2, GetQuantity: 1, CategoryName: "Book"},
+				{Kind: models.PromotionPercentage, Percent: 5000, CategoryName: "Book"},
+			},
+			want: tryAmounts(0, 2000),
+		},
+		{
+			name:       "Apply_BelowMinimumSkipped",
+			promotions: []models.Promotion{{Kind: models.PromotionPercentage, Percent: 1000, MinCartValue: money.New(20000, "TRY")}},
+			want:       tryAmounts(0, 0),
+		},
+		{
+			name:       "Apply_NotStartedSkipped",
+			promotions: []models.Promotion{{Kind: models.PromotionPercentage, Percent: 1000, ValidFrom: tomorrow}},
+			want:       tryAmounts(0, 0),
+		},
+		{
+			name:       "Apply_ExpiredSkipped",
+			promotions: []models.Promotion{{Kind: models.PromotionPercentage, Percent: 1000, ValidUntil: &yesterday}},
+			want:       tryAmounts(0, 0),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply(tt.promotions, cartLines, now)
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if !reflect.DeepEqual(got.LineDiscounts, tt.want) {
+				t.Errorf("Apply() line discounts = %v, want %v", got.LineDiscounts, tt.want)
+			}
+			total, _ := money.Sum("TRY", tt.want...)
+			if got.Total != total {
+				t.Errorf("Apply() total = %v, want %v", got.Total, total)
+			}
+		})
+	}
+}
+
+func TestApply_ListsEachDiscount(t *testing.T) {
+	promotions := []models.Promotion{
+		{ID: uuid.New(), Name: "Books", Kind: models.PromotionPercentage, Percent: 1000, CategoryName: "Book"},
+		{ID: uuid.New(), Name: "Toys", Kind: models.PromotionPercentage, Percent: 1000, CategoryName: "Toy"},
+		{ID: uuid.New(), Name: "Welcome", Code: &couponCode, Kind: models.PromotionFixed, Value: money.New(500, "TRY")},
+	}
+	got, err := Apply(promotions, cartLines, now)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	want := []models.Discount{
+		{PromotionID: promotions[0].ID, Name: "Books", Amount: money.New(300, "TRY")},
+		{PromotionID: promotions[2].ID, Name: "Welcome", Code: couponCode, Amount: money.New(500, "TRY")},
+	}
+	if !reflect.DeepEqual(got.Discounts, want) {
+		t.Errorf("Apply() discounts = %v, want %v", got.Discounts, want)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name      string
+		promotion models.Promotion
+		want      error
+	}{
+		{name: "Check_Fits", promotion: models.Promotion{Kind: models.PromotionPercentage, Percent: 1000}},
+		{name: "Check_NotStarted", promotion: models.Promotion{ValidFrom: tomorrow}, want: ErrNotStarted},
+		{name: "Check_Expired", promotion: models.Promotion{ValidUntil: &now}, want: ErrExpired},
+		{name: "Check_MinCartValue", promotion: models.Promotion{MinCartValue: money.New(13001, "TRY")}, want: ErrMinCartValue},
+		{name: "Check_NoEligibleItems", promotion: models.Promotion{CategoryName: "Toy"}, want: ErrNoEligibleItems},
+		{name: "Check_Currency", promotion: models.Promotion{Kind: models.PromotionFixed, Value: money.New(500, "USD")}, want: ErrCurrency},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Check(&tt.promotion, cartLines, now); got != tt.want {
+				t.Errorf("Check() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_promotionService_Coupon(t *testing.T) {
+	userID := uuid.New()
+	coupon := models.Promotion{ID: uuid.New(), Code: &couponCode, Kind: models.PromotionPercentage, Percent: 1000, UsageLimit: 1}
+
+	tests := []struct {
+		name    string
+		code    string
+		used    int
+		wantErr bool
+	}{
+		{name: "promotionService_Coupon_ShouldSuccess", code: "welcome "},
+		{name: "promotionService_Coupon_ErrorNotFound_ShouldFail", code: "NOPE", wantErr: true},
+		{name: "promotionService_Coupon_ErrorUsedUp_ShouldFail", code: couponCode, used: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &promotionService{repo: &promotionMockRepo{Items: []models.Promotion{coupon}, Used: tt.used}, catRepo: &categoryMockRepo{}}
+			got, err := s.Coupon(userID, tt.code, cartLines, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Coupon() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got.ID != coupon.ID {
+				t.Errorf("Coupon() = %v, want %v", got.ID, coupon.ID)
+			}
+		})
+	}
+}
+
+func Test_promotionService_Discount(t *testing.T) {
+	userID := uuid.New()
+	automatic := models.Promotion{ID: uuid.New(), Name: "Books", Kind: models.PromotionPercentage, Percent: 1000, CategoryName: "Book"}
+	coupon := models.Promotion{ID: uuid.New(), Name: "Welcome", Code: &couponCode, Kind: models.PromotionFixed, Value: money.New(500, "TRY"), UsageLimit: 1}
+
+	s := &promotionService{repo: &promotionMockRepo{Items: []models.Promotion{automatic, coupon}}, catRepo: &categoryMockRepo{}}
+	got, err := s.Discount(userID, &coupon.ID, cartLines, now)
+	if err != nil || got.Total != money.New(800, "TRY") {
+		t.Errorf("Discount() = %v, %v, want 8.00 TRY", got, err)
+	}
+
+	// A used up coupon stays on the cart but no longer discounts it
+	s = &promotionService{repo: &promotionMockRepo{Items: []models.Promotion{automatic, coupon}, Used: 1}, catRepo: &categoryMockRepo{}}
+	got, err = s.Discount(userID, &coupon.ID, cartLines, now)
+	if err != nil || got.Total != money.New(300, "TRY") {
+		t.Errorf("Discount() used up coupon = %v, %v, want 3.00 TRY", got, err)
+	}
+
+	// A promotion of a category applies to the lines of its sub categories
+	s = &promotionService{
+		repo:    &promotionMockRepo{Items: []models.Promotion{automatic}},
+		catRepo: &categoryMockRepo{Names: []string{"Book", "Novel", "Phone"}, Parents: map[string]string{"Novel": "Book"}},
+	}
+	novels := []Line{cartLines[0], {Category: "Novel", Quantity: 3, Amount: money.New(3000, "TRY")}}
+	got, err = s.Discount(userID, nil, novels, now)
+	if err != nil || got.Total != money.New(300, "TRY") || !got.LineDiscounts[0].IsZero() {
+		t.Errorf("Discount() of a sub category = %v, %v, want 3.00 TRY off the novels", got, err)
+	}
+	if novels[1].Ancestors != nil {
+		t.Errorf("Discount() changed the lines it was given")
+	}
+}
+
+func Test_promotionService_Create(t *testing.T) {
+	name := "Spring"
+	percentage, fixed, buyXGetY := string(models.PromotionPercentage), string(models.PromotionFixed), string(models.PromotionBuyXGetY)
+	unknown := "free_shipping"
+	validFrom := strfmt.DateTime(now)
+	amount := int64(500)
+
+	tests := []struct {
+		name    string
+		req     api.Promotion
+		wantErr bool
+	}{
+		{
+			name: "promotionService_CreatePercentage_ShouldSuccess",
+			req:  api.Promotion{Name: &name, Kind: &percentage, Percent: 1500, Code: "spring", ValidFrom: &validFrom},
+		},
+		{
+			name: "promotionService_CreateFixed_ShouldSuccess",
+			req:  api.Promotion{Name: &name, Kind: &fixed, Value: &api.Money{Amount: &amount}, CategoryName: "Book", ValidFrom: &validFrom},
+		},
+		{
+			name: "promotionService_CreateBuyXGetY_ShouldSuccess",
+			req:  api.Promotion{Name: &name, Kind: &buyXGetY, BuyQuantity: 2, GetQuantity: 1, ValidFrom: &validFrom},
+		},
+		{
+			name:    "promotionService_Create_ErrorUnknownKind_ShouldFail",
+			req:     api.Promotion{Name: &name, Kind: &unknown, ValidFrom: &validFrom},
+			wantErr: true,
+		},
+		{
+			name:    "promotionService_Create_ErrorPercentOutOfRange_ShouldFail",
+			req:     api.Promotion{Name: &name, Kind: &percentage, Percent: 10001, ValidFrom: &validFrom},
+			wantErr: true,
+		},
+		{
+			name:    "promotionService_Create_ErrorFixedWithoutValue_ShouldFail",
+			req:     api.Promotion{Name: &name, Kind: &fixed, ValidFrom: &validFrom},
+			wantErr: true,
+		},
+		{
+			name:    "promotionService_Create_ErrorNoFreeUnits_ShouldFail",
+			req:     api.Promotion{Name: &name, Kind: &buyXGetY, BuyQuantity: 2, ValidFrom: &validFrom},
+			wantErr: true,
+		},
+		{
+			name:    "promotionService_Create_ErrorEndsBeforeStart_ShouldFail",
+			req:     api.Promotion{Name: &name, Kind: &percentage, Percent: 1500, ValidFrom: &validFrom, ValidUntil: strfmt.DateTime(yesterday)},
+			wantErr: true,
+		},
+		{
+			name:    "promotionService_Create_ErrorCategoryNotFound_ShouldFail",
+			req:     api.Promotion{Name: &name, Kind: &percentage, Percent: 1500, CategoryName: "Toy", ValidFrom: &validFrom},
+			wantErr: true,
+		},
+		{
+			name:    "promotionService_Create_ErrorDuplicateCode_ShouldFail",
+			req:     api.Promotion{Name: &name, Kind: &percentage, Percent: 1500, Code: "welcome", ValidFrom: &validFrom},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &promotionService{
+				repo:    &promotionMockRepo{Items: []models.Promotion{{ID: uuid.New(), Code: &couponCode}}},
+				catRepo: &categoryMockRepo{Names: []string{"Book", "Phone"}},
+			}
+			got, err := s.Create(tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Create() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && tt.req.Code != "" && *got.Code != normalizeCode(tt.req.Code) {
+				t.Errorf("Create() code = %v, want %v", *got.Code, normalizeCode(tt.req.Code))
+			}
+		})
+	}
+}
+
+type promotionMockRepo struct {
+	Items []models.Promotion
+	Used  int
+}
+type categoryMockRepo struct {
+	Names   []string
+	Parents map[string]string
+}
+
+func (r *promotionMockRepo) Create(a *models.Promotion) (*models.Promotion, error) {
+	a.ID = uuid.New()
+	r.Items = append(r.Items, *a)
+	return a, nil
+}
+func (r *promotionMockRepo) GetByID(id uuid.UUID) (*models.Promotion, error) {
+	for i := range r.Items {
+		if r.Items[i].ID == id {
+			promotion := r.Items[i]
+			return &promotion, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (r *promotionMockRepo) GetByCode(code string) (*models.Promotion, error) {
+	for i := range r.Items {
+		if r.Items[i].Code != nil && *r.Items[i].Code == code {
+			promotion := r.Items[i]
+			return &promotion, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (r *promotionMockRepo) List() (*[]models.Promotion, error) {
+	return &r.Items, nil
+}
+func (r *promotionMockRepo) Automatic() (*[]models.Promotion, error) {
+	promotions := []models.Promotion{}
+	for _, item := range r.Items {
+		if item.Code == nil {
+			promotions = append(promotions, item)
+		}
+	}
+	return &promotions, nil
+}
+func (r *promotionMockRepo) Update(a *models.Promotion) (*models.Promotion, error) {
+	for i := range r.Items {
+		if r.Items[i].ID == a.ID {
+			r.Items[i] = *a
+			return a, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (r *promotionMockRepo) Delete(id uuid.UUID) error {
+	for i := range r.Items {
+		if r.Items[i].ID == id {
+			r.Items = append(r.Items[:i], r.Items[i+1:]...)
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+func (r *promotionMockRepo) UsageCount(promotionID uuid.UUID, userID uuid.UUID) (int, error) {
+	return r.Used, nil
+}
+
+func (c *categoryMockRepo) Create(a *models.Category) (*models.Category, error) {
+	return a, nil
+}
+func (c *categoryMockRepo) GetByName(name string) (*models.Category, error) {
+	for _, item := range c.Names {
+		if item == name {
+			category := &models.Category{ID: mockCategoryID(item), Name: &item}
+			if parent, ok := c.Parents[item]; ok {
+				parentID := mockCategoryID(parent)
+				category.ParentID = &parentID
+			}
+			return category, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (c *categoryMockRepo) GetAll(pageIndex, pageSize int) (*[]models.Category, int, error) {
+	return nil, 0, nil
+}
+func (c *categoryMockRepo) GetByID(id uuid.UUID) (*models.Category, error) {
+	for _, item := range c.Names {
+		if mockCategoryID(item) == id {
+			return c.GetByName(item)
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (c *categoryMockRepo) GetBySlug(slug string) (*models.Category, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+func (c *categoryMockRepo) GetChild(parentID *uuid.UUID, name string) (*models.Category, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+func (c *categoryMockRepo) GetTree() (*[]models.Category, error) {
+	return &[]models.Category{}, nil
+}
+func (c *categoryMockRepo) Update(a *models.Category, oldName string) (*models.Category, error) {
+	return a, nil
+}
+func (c *categoryMockRepo) Delete(a *models.Category, reassignTo *models.Category) error {
+	return nil
+}
+func (c *categoryMockRepo) GetAfter(keyset *pagination.Keyset, pageSize int) (*[]models.Category, bool, error) {
+	return nil, false, nil
+}
+
+// mockCategoryID gives every category name of the mock the same ID
+func mockCategoryID(name string) uuid.UUID {
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(name))
+}
@@ -0,0 +1,151 @@
+package reservation
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrNotEnoughStock is returned when a hold asks more than the stock left by other active holds
+var ErrNotEnoughStock = errors.New("not enough stock")
+
+// ErrInvalidQuantity is returned when a hold is not of a positive quantity, it would free stock other carts hold
+var ErrInvalidQuantity = errors.New("quantity must be at least 1")
+
+type ReservationRepositoy struct {
+	db *gorm.DB
+}
+
+type IReservationRepository interface {
+	Hold(cartID uuid.UUID, sku int, quantity int, expiresAt time.Time) error
+	Release(cartID uuid.UUID, sku int) error
+	ReleaseCart(cartID uuid.UUID) error
+	HeldByOthers(cartID uuid.UUID, sku int) (int, error)
+	HeldBySKUs(skus []int) (map[int]int, error)
+	DeleteExpired() (int64, error)
+}
+
+type heldQuantity struct {
+	ProductSKU int
+	Quantity   int
+}
+
+func NewReservationRepository(db *gorm.DB) *ReservationRepositoy {
+	return &ReservationRepositoy{db: db}
+}
+
+// Hold creates or replaces the cart hold of given product.
+// Product row is locked while active holds are counted, so two carts can not hold the same last unit.
+func (r *ReservationRepositoy) Hold(cartID uuid.UUID, sku int, quantity int, expiresAt time.Time) error {
+	zap.L().Debug("reservation.repo.hold", zap.Reflect("cartID", cartID), zap.Reflect("SKU", sku), zap.Reflect("quantity", quantity))
+	if quantity < 1 {
+		return ErrInvalidQuantity
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var product models.Product
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where(&models.Product{SKU: sku}).
+			First(&product).Error
+		if err != nil {
+			return err
+		}
+
+		held, err := NewReservationRepository(tx).HeldByOthers(cartID, sku)
+		if err != nil {
+			return err
+		}
+		if int(product.UnitStock)-held < quantity {
+			return ErrNotEnoughStock
+		}
+
+		reservation := models.StockReservation{
+			CartID:     cartID,
+			ProductSKU: sku,
+			Quantity:   quantity,
+			ExpiresAt:  expiresAt,
+		}
+		err = tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "cart_id"}, {Name: "product_sku"}},
+			DoUpdates: clause.AssignmentColumns([]string{"quantity", "expires_at", "updated_at"}),
+		}).Create(&reservation).Error
+		if err != nil {
+			zap.L().Error("reservation.repo.hold failed to save reservation", zap.Error(err))
+			return err
+		}
+
+		return nil
+	})
+}
+
+func (r *ReservationRepositoy) Release(cartID uuid.UUID, sku int) error {
+	zap.L().Debug("reservation.repo.release", zap.Reflect("cartID", cartID), zap.Reflect("SKU", sku))
+
+	if err := r.db.Where("cart_id = ? AND product_sku = ?", cartID, sku).Delete(&models.StockReservation{}).Error; err != nil {
+		zap.L().Error("reservation.repo.release failed to delete reservation", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *ReservationRepositoy) ReleaseCart(cartID uuid.UUID) error {
+	zap.L().Debug("reservation.repo.releaseCart", zap.Reflect("cartID", cartID))
+
+	if err := r.db.Where("cart_id = ?", cartID).Delete(&models.StockReservation{}).Error; err != nil {
+		zap.L().Error("reservation.repo.releaseCart failed to delete reservations", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// HeldByOthers returns the quantity of given product held by active reservations of other carts
+func (r *ReservationRepositoy) HeldByOthers(cartID uuid.UUID, sku int) (int, error) {
+	var held int64
+	err := r.db.Model(&models.StockReservation{}).
+		Select("COALESCE(SUM(quantity), 0)").
+		Where("product_sku = ? AND cart_id <> ? AND expires_at > ?", sku, cartID, time.Now()).
+		Scan(&held).Error
+	if err != nil {
+		zap.L().Error("reservation.repo.heldByOthers failed to sum reservations", zap.Error(err))
+		return 0, err
+	}
+	return int(held), nil
+}
+
+// HeldBySKUs returns active held quantities keyed by product SKU
+func (r *ReservationRepositoy) HeldBySKUs(skus []int) (map[int]int, error) {
+	held := make(map[int]int)
+	if len(skus) == 0 {
+		return held, nil
+	}
+
+	var rows []heldQuantity
+	err := r.db.Model(&models.StockReservation{}).
+		Select("product_sku, SUM(quantity) AS quantity").
+		Where("product_sku IN ? AND expires_at > ?", skus, time.Now()).
+		Group("product_sku").
+		Scan(&rows).Error
+	if err != nil {
+		zap.L().Error("reservation.repo.heldBySKUs failed to sum reservations", zap.Error(err))
+		return nil, err
+	}
+
+	for _, row := range rows {
+		held[row.ProductSKU] = row.Quantity
+	}
+	return held, nil
+}
+
+func (r *ReservationRepositoy) DeleteExpired() (int64, error) {
+	result := r.db.Where("expires_at <= ?", time.Now()).Delete(&models.StockReservation{})
+	if result.Error != nil {
+		zap.L().Error("reservation.repo.deleteExpired failed to delete reservations", zap.Error(result.Error))
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
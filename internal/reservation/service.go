@@ -0,0 +1,109 @@
+package reservation
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	httpErr "github.com/gcamlicali/tradeshopExample/internal/httpErrors"
+	"github.com/gcamlicali/tradeshopExample/pkg/config"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type reservationService struct {
+	repo IReservationRepository
+	ttl  time.Duration
+}
+
+type Service interface {
+	Hold(cartID uuid.UUID, sku int, quantity int) error
+	Release(cartID uuid.UUID, sku int) error
+	Held(skus []int) (map[int]int, error)
+	ReleaseExpired() (int64, error)
+	StartSweeper(interval time.Duration) (stop func())
+}
+
+func NewReservationService(repo IReservationRepository, ttl time.Duration) Service {
+	return &reservationService{repo: repo, ttl: ttl}
+}
+
+// NewReservationServiceFromConfig creates the service of an enabled reservation config, the TTL and the
+// sweep interval must be set
+func NewReservationServiceFromConfig(repo IReservationRepository, cfg config.ReservationConfig) (Service, error) {
+	if cfg.TTLSecs <= 0 {
+		return nil, fmt.Errorf("reservation ttl must be positive, got %d", cfg.TTLSecs)
+	}
+	if cfg.SweepIntervalSecs <= 0 {
+		return nil, fmt.Errorf("reservation sweep interval must be positive, got %d", cfg.SweepIntervalSecs)
+	}
+	return NewReservationService(repo, time.Duration(cfg.TTLSecs*int64(time.Second))), nil
+}
+
+// Hold places a hold of quantity units for the cart, replacing the previous hold and restarting its TTL.
+// The quantity must be at least 1, Release drops the hold of a product the cart no longer has.
+func (s *reservationService) Hold(cartID uuid.UUID, sku int, quantity int) error {
+	if quantity < 1 {
+		return httpErr.NewRestError(http.StatusBadRequest, "Quantity must be at least 1", quantity)
+	}
+	err := s.repo.Hold(cartID, sku, quantity, time.Now().Add(s.ttl))
+	if errors.Is(err, ErrNotEnoughStock) {
+		return httpErr.NewRestError(http.StatusBadRequest, "Not Enough Stock", sku)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return httpErr.NewRestError(http.StatusBadRequest, "Product not found", err.Error())
+	}
+	if err != nil {
+		return httpErr.NewRestError(http.StatusInternalServerError, "Stock hold error", err.Error())
+	}
+	return nil
+}
+
+func (s *reservationService) Release(cartID uuid.UUID, sku int) error {
+	if err := s.repo.Release(cartID, sku); err != nil {
+		return httpErr.NewRestError(http.StatusInternalServerError, "Stock hold release error", err.Error())
+	}
+	return nil
+}
+
+// Held returns active held quantities keyed by product SKU
+func (s *reservationService) Held(skus []int) (map[int]int, error) {
+	held, err := s.repo.HeldBySKUs(skus)
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Get stock holds error", err.Error())
+	}
+	return held, nil
+}
+
+func (s *reservationService) ReleaseExpired() (int64, error) {
+	return s.repo.DeleteExpired()
+}
+
+// StartSweeper releases expired holds on every interval until the returned stop function is called
+func (s *reservationService) StartSweeper(interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				released, err := s.ReleaseExpired()
+				if err != nil {
+					zap.L().Error("reservation.sweeper failed to release expired holds", zap.Error(err))
+					continue
+				}
+				if released > 0 {
+					zap.L().Debug("reservation.sweeper released expired holds", zap.Int64("count", released))
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
@@ -0,0 +1,299 @@
+package reservation
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	httpErr "github.com/gcamlicali/tradeshopExample/internal/httpErrors"
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/gcamlicali/tradeshopExample/pkg/config"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	cartID      = uuid.New()
+	otherCartID = uuid.New()
+	productSKU  = 1
+	NExProSKU   = 9999999
+	ttl         = 15 * time.Minute
+
+	activeHold = models.StockReservation{
+		CartID:     otherCartID,
+		ProductSKU: productSKU,
+		Quantity:   2,
+		ExpiresAt:  time.Now().Add(time.Hour),
+	}
+	expiredHold = models.StockReservation{
+		CartID:     otherCartID,
+		ProductSKU: productSKU,
+		Quantity:   2,
+		ExpiresAt:  time.Now().Add(-time.Hour),
+	}
+)
+
+func Test_reservationService_Hold(t *testing.T) {
+	type fields struct {
+		repo *reservationMockRepo
+	}
+	type args struct {
+		cartID   uuid.UUID
+		sku      int
+		quantity int
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		args       args
+		wantStatus int
+		wantErr    bool
+	}{
+		{
+			name: "reservationService_Hold_ShouldSuccess",
+			fields: fields{
+				repo: &reservationMockRepo{
+					Stock: map[int]int{productSKU: 3},
+					Items: []models.StockReservation{},
+				},
+			},
+			args: args{
+				cartID:   cartID,
+				sku:      productSKU,
+				quantity: 3,
+			},
+			wantErr: false,
+		},
+		{
+			name: "reservationService_Hold_OtherCartHold_ShouldFail",
+			fields: fields{
+				repo: &reservationMockRepo{
+					Stock: map[int]int{productSKU: 3},
+					Items: []models.StockReservation{activeHold},
+				},
+			},
+			args: args{
+				cartID:   cartID,
+				sku:      productSKU,
+				quantity: 2,
+			},
+			wantStatus: http.StatusBadRequest,
+			wantErr:    true,
+		},
+		{
+			name: "reservationService_Hold_ExpiredOtherCartHold_ShouldSuccess",
+			fields: fields{
+				repo: &reservationMockRepo{
+					Stock: map[int]int{productSKU: 3},
+					Items: []models.StockReservation{expiredHold},
+				},
+			},
+			args: args{
+				cartID:   cartID,
+				sku:      productSKU,
+				quantity: 3,
+			},
+			wantErr: false,
+		},
+		{
+			name: "reservationService_Hold_ErrorProductNotFound_ShouldFail",
+			fields: fields{
+				repo: &reservationMockRepo{
+					Stock: map[int]int{productSKU: 3},
+					Items: []models.StockReservation{},
+				},
+			},
+			args: args{
+				cartID:   cartID,
+				sku:      NExProSKU,
+				quantity: 1,
+			},
+			wantStatus: http.StatusBadRequest,
+			wantErr:    true,
+		},
+		{
+			name: "reservationService_Hold_ErrorZeroQuantity_ShouldFail",
+			fields: fields{
+				repo: &reservationMockRepo{
+					Stock: map[int]int{productSKU: 3},
+					Items: []models.StockReservation{},
+				},
+			},
+			args: args{
+				cartID:   cartID,
+				sku:      productSKU,
+				quantity: 0,
+			},
+			wantStatus: http.StatusBadRequest,
+			wantErr:    true,
+		},
+		{
+			name: "reservationService_Hold_ErrorNegativeQuantity_ShouldFail",
+			fields: fields{
+				repo: &reservationMockRepo{
+					Stock: map[int]int{productSKU: 3},
+					Items: []models.StockReservation{},
+				},
+			},
+			args: args{
+				cartID:   cartID,
+				sku:      productSKU,
+				quantity: -2,
+			},
+			wantStatus: http.StatusBadRequest,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &reservationService{
+				repo: tt.fields.repo,
+				ttl:  ttl,
+			}
+			err := s.Hold(tt.args.cartID, tt.args.sku, tt.args.quantity)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Hold() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				if status := httpErr.ParseErrors(err).Status(); status != tt.wantStatus {
+					t.Errorf("Hold() status = %v, want %v", status, tt.wantStatus)
+				}
+				return
+			}
+			hold := tt.fields.repo.find(tt.args.cartID, tt.args.sku)
+			if hold == nil || hold.Quantity != tt.args.quantity {
+				t.Errorf("Hold() hold = %v, want quantity %v", hold, tt.args.quantity)
+				return
+			}
+			if hold.ExpiresAt.Before(time.Now().Add(ttl - time.Minute)) {
+				t.Errorf("Hold() expires at %v, want about %v from now", hold.ExpiresAt, ttl)
+			}
+		})
+	}
+}
+
+func Test_reservationService_Hold_ReplacesCartHold(t *testing.T) {
+	repo := &reservationMockRepo{
+		Stock: map[int]int{productSKU: 3},
+		Items: []models.StockReservation{},
+	}
+	s := &reservationService{repo: repo, ttl: ttl}
+
+	if err := s.Hold(cartID, productSKU, 2); err != nil {
+		t.Fatalf("Hold() error = %v", err)
+	}
+	if err := s.Hold(cartID, productSKU, 3); err != nil {
+		t.Fatalf("Hold() updating own hold error = %v", err)
+	}
+	if len(repo.Items) != 1 || repo.Items[0].Quantity != 3 {
+		t.Errorf("Hold() holds = %v, want a single hold of 3", repo.Items)
+	}
+}
+
+func Test_NewReservationServiceFromConfig(t *testing.T) {
+	if _, err := NewReservationServiceFromConfig(&reservationMockRepo{}, config.ReservationConfig{Enabled: true, TTLSecs: 900, SweepIntervalSecs: 60}); err != nil {
+		t.Errorf("NewReservationServiceFromConfig() error = %v", err)
+	}
+	if _, err := NewReservationServiceFromConfig(&reservationMockRepo{}, config.ReservationConfig{Enabled: true, SweepIntervalSecs: 60}); err == nil {
+		t.Errorf("NewReservationServiceFromConfig() accepted a missing ttl")
+	}
+	if _, err := NewReservationServiceFromConfig(&reservationMockRepo{}, config.ReservationConfig{Enabled: true, TTLSecs: 900}); err == nil {
+		t.Errorf("NewReservationServiceFromConfig() accepted a missing sweep interval")
+	}
+}
+
+func Test_reservationService_ReleaseExpired(t *testing.T) {
+	repo := &reservationMockRepo{
+		Items: []models.StockReservation{activeHold, expiredHold},
+	}
+	s := &reservationService{repo: repo, ttl: ttl}
+
+	released, err := s.ReleaseExpired()
+	if err != nil {
+		t.Fatalf("ReleaseExpired() error = %v", err)
+	}
+	if released != 1 || len(repo.Items) != 1 {
+		t.Errorf("ReleaseExpired() released = %v, left = %v, want 1 and 1", released, len(repo.Items))
+	}
+}
+
+type reservationMockRepo struct {
+	Stock map[int]int
+	Items []models.StockReservation
+}
+
+func (r *reservationMockRepo) find(cartID uuid.UUID, sku int) *models.StockReservation {
+	for i, item := range r.Items {
+		if item.CartID == cartID && item.ProductSKU == sku {
+			return &r.Items[i]
+		}
+	}
+	return nil
+}
+
+func (r *reservationMockRepo) Hold(cartID uuid.UUID, sku int, quantity int, expiresAt time.Time) error {
+	stock, ok := r.Stock[sku]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	held, _ := r.HeldByOthers(cartID, sku)
+	if stock-held < quantity {
+		return ErrNotEnoughStock
+	}
+	if hold := r.find(cartID, sku); hold != nil {
+		hold.Quantity = quantity
+		hold.ExpiresAt = expiresAt
+		return nil
+	}
+	r.Items = append(r.Items, models.StockReservation{CartID: cartID, ProductSKU: sku, Quantity: quantity, ExpiresAt: expiresAt})
+	return nil
+}
+func (r *reservationMockRepo) Release(cartID uuid.UUID, sku int) error {
+	for i, item := range r.Items {
+		if item.CartID == cartID && item.ProductSKU == sku {
+			r.Items = append(r.Items[:i], r.Items[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+func (r *reservationMockRepo) ReleaseCart(cartID uuid.UUID) error {
+	items := []models.StockReservation{}
+	for _, item := range r.Items {
+		if item.CartID != cartID {
+			items = append(items, item)
+		}
+	}
+	r.Items = items
+	return nil
+}
+func (r *reservationMockRepo) HeldByOthers(cartID uuid.UUID, sku int) (int, error) {
+	held := 0
+	for _, item := range r.Items {
+		if item.CartID != cartID && item.ProductSKU == sku && item.ExpiresAt.After(time.Now()) {
+			held += item.Quantity
+		}
+	}
+	return held, nil
+}
+func (r *reservationMockRepo) HeldBySKUs(skus []int) (map[int]int, error) {
+	held := make(map[int]int)
+	for _, item := range r.Items {
+		if item.ExpiresAt.After(time.Now()) {
+			held[item.ProductSKU] += item.Quantity
+		}
+	}
+	return held, nil
+}
+func (r *reservationMockRepo) DeleteExpired() (int64, error) {
+	items := []models.StockReservation{}
+	for _, item := range r.Items {
+		if item.ExpiresAt.After(time.Now()) {
+			items = append(items, item)
+		}
+	}
+	released := int64(len(r.Items) - len(items))
+	r.Items = items
+	return released, nil
+}
@@ -0,0 +1,114 @@
+package shipping
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gcamlicali/tradeshopExample/pkg/config"
+	"github.com/gcamlicali/tradeshopExample/pkg/money"
+)
+
+const (
+	CalculatorFlat   = "flat"
+	CalculatorWeight = "weight"
+)
+
+// GramsPerKg is the weight step of weight based rates
+const GramsPerKg = 1000
+
+var ErrCurrency = errors.New("shipping currency doesn't match the order")
+
+// Parcel is what an order ships, Weight is in grams and Value is the order total before shipping
+type Parcel struct {
+	Weight int64
+	Value  money.Money
+}
+
+// ShippingRateCalculator prices the delivery of a parcel
+type ShippingRateCalculator interface {
+	Rate(p Parcel) (money.Money, error)
+}
+
+// FlatRate costs the same for every parcel
+type FlatRate struct {
+	Amount money.Money
+}
+
+func (f FlatRate) Rate(p Parcel) (money.Money, error) {
+	if err := sameCurrency(f.Amount, p); err != nil {
+		return money.Money{}, err
+	}
+	return f.Amount, nil
+}
+
+// WeightBased costs Base plus PerKg for every started kilogram of the parcel
+type WeightBased struct {
+	Base  money.Money
+	PerKg money.Money
+}
+
+func (w WeightBased) Rate(p Parcel) (money.Money, error) {
+	if err := sameCurrency(w.Base, p); err != nil {
+		return money.Money{}, err
+	}
+	kgs := int64(0)
+	if p.Weight > 0 {
+		kgs = (p.Weight + GramsPerKg - 1) / GramsPerKg
+	}
+	rate, err := w.Base.Add(w.PerKg.Mul(kgs))
+	if err != nil {
+		return money.Money{}, ErrCurrency
+	}
+	return rate, nil
+}
+
+// FreeOver ships parcels worth at least Threshold for free, the others cost what Calculator says
+type FreeOver struct {
+	Threshold  money.Money
+	Calculator ShippingRateCalculator
+}
+
+func (f FreeOver) Rate(p Parcel) (money.Money, error) {
+	cmp, err := p.Value.Cmp(f.Threshold)
+	if err != nil {
+		return money.Money{}, ErrCurrency
+	}
+	if cmp >= 0 {
+		return money.Zero(f.Threshold.Currency), nil
+	}
+	return f.Calculator.Rate(p)
+}
+
+// NewCalculatorFromConfig returns the configured calculator wrapped by free shipping when FreeOver is set
+func NewCalculatorFromConfig(cfg config.ShippingConfig) (ShippingRateCalculator, error) {
+	currency := cfg.Currency
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+	if !money.ValidCurrency(currency) {
+		return nil, fmt.Errorf("unknown shipping currency %q", currency)
+	}
+
+	var calculator ShippingRateCalculator
+	switch cfg.Calculator {
+	case CalculatorFlat, "":
+		calculator = FlatRate{Amount: money.New(cfg.FlatRate, currency)}
+	case CalculatorWeight:
+		calculator = WeightBased{Base: money.New(cfg.BaseRate, currency), PerKg: money.New(cfg.PerKgRate, currency)}
+	default:
+		return nil, fmt.Errorf("unknown shipping calculator %q", cfg.Calculator)
+	}
+
+	if cfg.FreeOver > 0 {
+		calculator = FreeOver{Threshold: money.New(cfg.FreeOver, currency), Calculator: calculator}
+	}
+	return calculator, nil
+}
+
+// sameCurrency checks the rate can be added to the parcel value
+func sameCurrency(rate money.Money, p Parcel) error {
+	if p.Value.Currency != "" && p.Value.Currency != rate.Currency {
+		return ErrCurrency
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package shipping
+
+import (
+	"testing"
+
+	"github.com/gcamlicali/tradeshopExample/pkg/config"
+	"github.com/gcamlicali/tradeshopExample/pkg/money"
+)
+
+func TestRate(t *testing.T) {
+	weightBased := WeightBased{Base: money.New(1990, "TRY"), PerKg: money.New(500, "TRY")}
+	tests := []struct {
+		name       string
+		calculator ShippingRateCalculator
+		parcel     Parcel
+		want       money.Money
+		wantErr    bool
+	}{
+		{
+			name:       "FlatRate_ShouldSuccess",
+			calculator: FlatRate{Amount: money.New(2990, "TRY")},
+			parcel:     Parcel{Weight: 12000, Value: money.New(10000, "TRY")},
+			want:       money.New(2990, "TRY"),
+		},
+		{
+			name:       "FlatRate_OtherCurrency_ShouldFail",
+			calculator: FlatRate{Amount: money.New(2990, "TRY")},
+			parcel:     Parcel{Value: money.New(10000, "USD")},
+			wantErr:    true,
+		},
+		{
+			name:       "WeightBased_NoWeight_ShouldSuccess",
+			calculator: weightBased,
+			parcel:     Parcel{Value: money.New(10000, "TRY")},
+			want:       money.New(1990, "TRY"),
+		},
+		{
+			name:       "WeightBased_StartedKilogram_ShouldSuccess",
+			calculator: weightBased,
+			parcel:     Parcel{Weight: 2001, Value: money.New(10000, "TRY")},
+			want:       money.New(3490, "TRY"),
+		},
+		{
+			name:       "WeightBased_WholeKilograms_ShouldSuccess",
+			calculator: weightBased,
+			parcel:     Parcel{Weight: 2000, Value: money.New(10000, "TRY")},
+			want:       money.New(2990, "TRY"),
+		},
+		{
+			name:       "FreeOver_AtThreshold_ShouldSuccess",
+			calculator: FreeOver{Threshold: money.New(50000, "TRY"), Calculator: weightBased},
+			parcel:     Parcel{Weight: 2000, Value: money.New(50000, "TRY")},
+			want:       money.Zero("TRY"),
+		},
+		{
+			name:       "FreeOver_BelowThreshold_ShouldSuccess",
+			calculator: FreeOver{Threshold: money.New(50000, "TRY"), Calculator: weightBased},
+			parcel:     Parcel{Weight: 2000, Value: money.New(49999, "TRY")},
+			want:       money.New(2990, "TRY"),
+		},
+		{
+			name:       "FreeOver_OtherCurrency_ShouldFail",
+			calculator: FreeOver{Threshold: money.New(50000, "TRY"), Calculator: weightBased},
+			parcel:     Parcel{Value: money.New(50000, "EUR")},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.calculator.Rate(tt.parcel)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Rate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Rate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_NewCalculatorFromConfig(t *testing.T) {
+	c, err := NewCalculatorFromConfig(config.ShippingConfig{Calculator: CalculatorWeight, BaseRate: 1000, PerKgRate: 100, FreeOver: 50000})
+	if err != nil {
+		t.Fatalf("NewCalculatorFromConfig() error = %v", err)
+	}
+	if free, ok := c.(FreeOver); !ok {
+		t.Errorf("NewCalculatorFromConfig() = %T, want free shipping over a threshold", c)
+	} else if _, ok := free.Calculator.(WeightBased); !ok {
+		t.Errorf("NewCalculatorFromConfig() wraps %T, want weight based", free.Calculator)
+	}
+
+	if c, err := NewCalculatorFromConfig(config.ShippingConfig{}); err != nil {
+		t.Errorf("NewCalculatorFromConfig() error = %v", err)
+	} else if _, ok := c.(FlatRate); !ok {
+		t.Errorf("NewCalculatorFromConfig() = %T, want flat rate without a calculator", c)
+	}
+	if _, err := NewCalculatorFromConfig(config.ShippingConfig{Calculator: "pigeon"}); err == nil {
+		t.Errorf("NewCalculatorFromConfig() accepted an unknown calculator")
+	}
+	if _, err := NewCalculatorFromConfig(config.ShippingConfig{Currency: "XXX"}); err == nil {
+		t.Errorf("NewCalculatorFromConfig() accepted an unknown currency")
+	}
+}
@@ -0,0 +1,84 @@
+package tax
+
+import (
+	"sort"
+
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/gcamlicali/tradeshopExample/pkg/money"
+)
+
+// RateScale is 100%, rates are basis points so 2000 is %20
+const RateScale = 10000
+
+// Item is an amount to tax at the rate of its category
+type Item struct {
+	Category string
+	Amount   money.Money
+}
+
+// Line is the tax breakdown of an item, Gross is Net plus Tax
+type Line struct {
+	Rate  int64
+	Net   money.Money
+	Tax   money.Money
+	Gross money.Money
+}
+
+// Quote is the tax breakdown of items, Lines follow the order of the items
+type Quote struct {
+	Lines    []Line
+	Subtotal money.Money
+	TaxLines []models.TaxLine
+	TaxTotal money.Money
+	Total    money.Money
+}
+
+// PriceLine splits amount into net and tax. Tax is rounded half up per line,
+// an inclusive amount is the gross and the net is what is left after tax.
+func PriceLine(amount money.Money, rate int64, inclusive bool) Line {
+	if inclusive {
+		net := amount.MulRatio(RateScale, RateScale+rate, money.RoundHalfUp)
+		return Line{Rate: rate, Net: net, Tax: money.New(amount.Amount-net.Amount, amount.Currency), Gross: amount}
+	}
+	tax := amount.MulRatio(rate, RateScale, money.RoundHalfUp)
+	return Line{Rate: rate, Net: amount, Tax: tax, Gross: money.New(amount.Amount+tax.Amount, amount.Currency)}
+}
+
+// Summarize totals lines and groups their tax by rate, lowest rate first.
+// An empty quote is zero in the default currency.
+func Summarize(lines []Line) (*Quote, error) {
+	quote := &Quote{Lines: lines, TaxLines: []models.TaxLine{}}
+	byRate := map[int64]*models.TaxLine{}
+	for _, line := range lines {
+		var err error
+		if quote.Subtotal, err = quote.Subtotal.Add(line.Net); err != nil {
+			return nil, err
+		}
+		if quote.TaxTotal, err = quote.TaxTotal.Add(line.Tax); err != nil {
+			return nil, err
+		}
+		if quote.Total, err = quote.Total.Add(line.Gross); err != nil {
+			return nil, err
+		}
+
+		group, ok := byRate[line.Rate]
+		if !ok {
+			group = &models.TaxLine{Rate: line.Rate}
+			byRate[line.Rate] = group
+		}
+		group.Net, _ = group.Net.Add(line.Net)
+		group.Tax, _ = group.Tax.Add(line.Tax)
+	}
+
+	for _, group := range byRate {
+		quote.TaxLines = append(quote.TaxLines, *group)
+	}
+	sort.Slice(quote.TaxLines, func(i, j int) bool { return quote.TaxLines[i].Rate < quote.TaxLines[j].Rate })
+
+	currency := quote.Total.Currency
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+	quote.Subtotal.Currency, quote.TaxTotal.Currency, quote.Total.Currency = currency, currency, currency
+	return quote, nil
+}
@@ -0,0 +1,71 @@
+package tax
+
+import (
+	"net/http"
+
+	"github.com/gcamlicali/tradeshopExample/internal/api"
+	httpErr "github.com/gcamlicali/tradeshopExample/internal/httpErrors"
+	mw "github.com/gcamlicali/tradeshopExample/pkg/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/go-openapi/strfmt"
+	"github.com/google/uuid"
+)
+
+type taxHandler struct {
+	service Service
+}
+
+func NewTaxHandler(r *gin.RouterGroup, service Service, authMW gin.HandlerFunc) {
+	h := &taxHandler{service: service}
+
+	ratesRoute := r.Group("/rates")
+	ratesRoute.Use(authMW, mw.RequirePermission(mw.PermTaxManage))
+	ratesRoute.GET("", h.list)
+	ratesRoute.POST("", h.create)
+	ratesRoute.DELETE("/:id", h.delete)
+}
+
+func (h *taxHandler) list(c *gin.Context) {
+	rates, err := h.service.List(c.Query("category"))
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, ratesToResponse(*rates))
+}
+
+func (h *taxHandler) create(c *gin.Context) {
+	req := api.TaxRate{}
+	if err := c.Bind(&req); err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "check your request body", err.Error())))
+		return
+	}
+	if err := req.Validate(strfmt.NewFormats()); err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	rate, err := h.service.Create(req)
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, rateToResponse(rate))
+}
+
+func (h *taxHandler) delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "Tax rate ID is not valid", err.Error())))
+		return
+	}
+
+	if err := h.service.Delete(id); err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, "Tax rate deleted")
+}
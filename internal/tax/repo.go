@@ -0,0 +1,77 @@
+package tax
+
+import (
+	"time"
+
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type TaxRepositoy struct {
+	db *gorm.DB
+}
+
+type ITaxRepository interface {
+	Create(a *models.TaxRate) (*models.TaxRate, error)
+	List(category string) (*[]models.TaxRate, error)
+	Delete(id uuid.UUID) error
+	Active(at time.Time) (*[]models.TaxRate, error)
+}
+
+func NewTaxRepository(db *gorm.DB) *TaxRepositoy {
+	return &TaxRepositoy{db: db}
+}
+
+func (r *TaxRepositoy) Create(a *models.TaxRate) (*models.TaxRate, error) {
+	zap.L().Debug("tax.repo.create", zap.Reflect("taxRate", a))
+	if err := r.db.Create(a).Error; err != nil {
+		zap.L().Error("tax.repo.Create failed to create tax rate", zap.Error(err))
+		return nil, err
+	}
+	return a, nil
+}
+
+// List returns the rates of category, or of every category when it is empty, newest first
+func (r *TaxRepositoy) List(category string) (*[]models.TaxRate, error) {
+	zap.L().Debug("tax.repo.list", zap.String("category", category))
+
+	var rates = []models.TaxRate{}
+	db := r.db
+	if category != "" {
+		db = db.Where("category_name = ?", category)
+	}
+	if err := db.Order("category_name").Order("valid_from DESC").Find(&rates).Error; err != nil {
+		return nil, err
+	}
+	return &rates, nil
+}
+
+func (r *TaxRepositoy) Delete(id uuid.UUID) error {
+	zap.L().Debug("tax.repo.delete", zap.Reflect("id", id))
+
+	result := r.db.Delete(&models.TaxRate{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Active returns the rates in effect at the given time, one per category
+func (r *TaxRepositoy) Active(at time.Time) (*[]models.TaxRate, error) {
+	zap.L().Debug("tax.repo.active", zap.Time("at", at))
+
+	var rates = []models.TaxRate{}
+	err := r.db.
+		Raw(`SELECT DISTINCT ON (category_name) * FROM tax_rate
+			WHERE valid_from <= ? ORDER BY category_name, valid_from DESC`, at).
+		Scan(&rates).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rates, nil
+}
@@ -0,0 +1,39 @@
+package tax
+
+import (
+	"github.com/gcamlicali/tradeshopExample/internal/api"
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/gcamlicali/tradeshopExample/internal/product"
+	"github.com/go-openapi/strfmt"
+)
+
+func rateToResponse(m *models.TaxRate) *api.TaxRate {
+	validFrom := strfmt.DateTime(m.ValidFrom)
+	return &api.TaxRate{
+		ID:           m.ID.String(),
+		CategoryName: &m.CategoryName,
+		Rate:         &m.Rate,
+		ValidFrom:    &validFrom,
+	}
+}
+
+func ratesToResponse(ms []models.TaxRate) []*api.TaxRate {
+	rates := make([]*api.TaxRate, 0, len(ms))
+	for i := range ms {
+		rates = append(rates, rateToResponse(&ms[i]))
+	}
+	return rates
+}
+
+// TaxLinesToResponse is shared by cart and order responses
+func TaxLinesToResponse(ms []models.TaxLine) []*api.TaxLine {
+	lines := make([]*api.TaxLine, 0, len(ms))
+	for _, m := range ms {
+		lines = append(lines, &api.TaxLine{
+			Rate: m.Rate,
+			Net:  product.MoneyToResponse(m.Net),
+			Tax:  product.MoneyToResponse(m.Tax),
+		})
+	}
+	return lines
+}
@@ -0,0 +1,133 @@
+package tax
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gcamlicali/tradeshopExample/internal/api"
+	"github.com/gcamlicali/tradeshopExample/internal/category"
+	httpErr "github.com/gcamlicali/tradeshopExample/internal/httpErrors"
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/gcamlicali/tradeshopExample/pkg/config"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type taxService struct {
+	repo        ITaxRepository
+	catRepo     category.ICategoryRepository
+	defaultRate int64
+	inclusive   bool
+}
+
+// Calculator prices items with the tax rates of their categories
+type Calculator interface {
+	Quote(items []Item, at time.Time) (*Quote, error)
+}
+
+type Service interface {
+	Calculator
+	List(category string) (*[]models.TaxRate, error)
+	Create(req api.TaxRate) (*models.TaxRate, error)
+	Delete(id uuid.UUID) error
+}
+
+func NewTaxService(repo ITaxRepository, catRepo category.ICategoryRepository, cfg config.TaxConfig) Service {
+	return &taxService{repo: repo, catRepo: catRepo, defaultRate: cfg.DefaultRate, inclusive: cfg.PricesIncludeTax}
+}
+
+// Quote prices items with the rates in effect at the given time. An item whose category has no rate
+// takes the rate of the nearest category above it, the default rate when none of them has one.
+func (s *taxService) Quote(items []Item, at time.Time) (*Quote, error) {
+	rates, err := s.repo.Active(at)
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Get tax rates error", err.Error())
+	}
+	byCategory := make(map[string]int64, len(*rates))
+	for _, rate := range *rates {
+		byCategory[rate.CategoryName] = rate.Rate
+	}
+
+	ancestors := map[string][]string{}
+	lines := make([]Line, 0, len(items))
+	for _, item := range items {
+		names, ok := ancestors[item.Category]
+		if !ok {
+			if names, err = category.Ancestors(s.catRepo, item.Category); err != nil {
+				return nil, httpErr.NewRestError(http.StatusInternalServerError, "Get category error", err.Error())
+			}
+			ancestors[item.Category] = names
+		}
+
+		rate := s.defaultRate
+		for _, name := range names {
+			if categoryRate, ok := byCategory[name]; ok {
+				rate = categoryRate
+				break
+			}
+		}
+		lines = append(lines, PriceLine(item.Amount, rate, s.inclusive))
+	}
+
+	quote, err := Summarize(lines)
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusBadRequest, "Items have mixed currencies", err.Error())
+	}
+	return quote, nil
+}
+
+func (s *taxService) List(category string) (*[]models.TaxRate, error) {
+	rates, err := s.repo.List(category)
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Get tax rates error", err.Error())
+	}
+	return rates, nil
+}
+
+// Create adds a rate taking effect at ValidFrom, the category must exist
+func (s *taxService) Create(req api.TaxRate) (*models.TaxRate, error) {
+	if *req.Rate < 0 || *req.Rate > RateScale {
+		return nil, httpErr.NewRestError(http.StatusBadRequest, "Tax rate must be between 0 and 10000 basis points", *req.Rate)
+	}
+
+	_, err := s.catRepo.GetByName(*req.CategoryName)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, httpErr.NewRestError(http.StatusBadRequest, "Category not found", *req.CategoryName)
+	}
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Get category error", err.Error())
+	}
+
+	validFrom := time.Time(*req.ValidFrom)
+	rates, err := s.repo.List(*req.CategoryName)
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Get tax rates error", err.Error())
+	}
+	for _, rate := range *rates {
+		if rate.ValidFrom.Equal(validFrom) {
+			return nil, httpErr.NewRestError(http.StatusConflict, "Category already has a rate from this time", rate.ID.String())
+		}
+	}
+
+	rate, err := s.repo.Create(&models.TaxRate{
+		CategoryName: *req.CategoryName,
+		Rate:         *req.Rate,
+		ValidFrom:    validFrom,
+	})
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "Tax rate create error", err.Error())
+	}
+	return rate, nil
+}
+
+func (s *taxService) Delete(id uuid.UUID) error {
+	err := s.repo.Delete(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return httpErr.NewRestError(http.StatusNotFound, "Tax rate not found", id.String())
+	}
+	if err != nil {
+		return httpErr.NewRestError(http.StatusInternalServerError, "Tax rate delete error", err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,296 @@
+package tax
+
+import (
+	"github.com/gcamlicali/tradeshopExample/internal/api"
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/gcamlicali/tradeshopExample/pkg/money"
+	"github.com/gcamlicali/tradeshopExample/pkg/pagination"
+	"github.com/go-openapi/strfmt"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var (
+	foodName       = "Food"
+	electronicName = "Electronic"
+	rateChange     = time.Date(2023, time.July, 10, 0, 0, 0, 0, time.UTC)
+
+	foodRate = models.TaxRate{
+		ID:           uuid.New(),
+		CategoryName: foodName,
+		Rate:         800,
+		ValidFrom:    time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC),
+	}
+	foodRateLater = models.TaxRate{
+		ID:           uuid.New(),
+		CategoryName: foodName,
+		Rate:         1000,
+		ValidFrom:    rateChange,
+	}
+)
+
+func TestPriceLine(t *testing.T) {
+	tests := []struct {
+		name      string
+		amount    money.Money
+		rate      int64
+		inclusive bool
+		want      Line
+	}{
+		{
+			name:      "PriceLine_Exclusive",
+			amount:    money.New(1000, "TRY"),
+			rate:      2000,
+			inclusive: false,
+			want:      Line{Rate: 2000, Net: money.New(1000, "TRY"), Tax: money.New(200, "TRY"), Gross: money.New(1200, "TRY")},
+		},
+		{
+			name:      "PriceLine_Inclusive",
+			amount:    money.New(1200, "TRY"),
+			rate:      2000,
+			inclusive: true,
+			want:      Line{Rate: 2000, Net: money.New(1000, "TRY"), Tax: money.New(200, "TRY"), Gross: money.New(1200, "TRY")},
+		},
+		{
+			name:      "PriceLine_InclusiveRounded",
+			amount:    money.New(1000, "TRY"),
+			rate:      2000,
+			inclusive: true,
+			want:      Line{Rate: 2000, Net: money.New(833, "TRY"), Tax: money.New(167, "TRY"), Gross: money.New(1000, "TRY")},
+		},
+		{
+			name:      "PriceLine_ExclusiveHalfUp",
+			amount:    money.New(1250, "TRY"),
+			rate:      100,
+			inclusive: false,
+			want:      Line{Rate: 100, Net: money.New(1250, "TRY"), Tax: money.New(13, "TRY"), Gross: money.New(1263, "TRY")},
+		},
+		{
+			name:      "PriceLine_ZeroRate",
+			amount:    money.New(999, "TRY"),
+			rate:      0,
+			inclusive: true,
+			want:      Line{Rate: 0, Net: money.New(999, "TRY"), Tax: money.New(0, "TRY"), Gross: money.New(999, "TRY")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PriceLine(tt.amount, tt.rate, tt.inclusive); got != tt.want {
+				t.Errorf("PriceLine() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	quote, err := Summarize([]Line{
+		PriceLine(money.New(1000, "TRY"), 2000, false),
+		PriceLine(money.New(500, "TRY"), 100, false),
+		PriceLine(money.New(3000, "TRY"), 2000, false),
+	})
+	if err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+	wantLines := []models.TaxLine{
+		{Rate: 100, Net: money.New(500, "TRY"), Tax: money.New(5, "TRY")},
+		{Rate: 2000, Net: money.New(4000, "TRY"), Tax: money.New(800, "TRY")},
+	}
+	if !reflect.DeepEqual(quote.TaxLines, wantLines) {
+		t.Errorf("Summarize() tax lines = %+v, want %+v", quote.TaxLines, wantLines)
+	}
+	if quote.Subtotal != money.New(4500, "TRY") || quote.TaxTotal != money.New(805, "TRY") || quote.Total != money.New(5305, "TRY") {
+		t.Errorf("Summarize() totals = %v %v %v", quote.Subtotal, quote.TaxTotal, quote.Total)
+	}
+
+	empty, err := Summarize(nil)
+	if err != nil || empty.Total != money.Zero(money.DefaultCurrency) || len(empty.TaxLines) != 0 {
+		t.Errorf("Summarize() empty = %+v, %v", empty, err)
+	}
+
+	if _, err := Summarize([]Line{
+		PriceLine(money.New(1000, "TRY"), 2000, false),
+		PriceLine(money.New(1000, "USD"), 2000, false),
+	}); err == nil {
+		t.Errorf("Summarize() mixed currencies error = nil")
+	}
+}
+
+func Test_taxService_Quote(t *testing.T) {
+	s := &taxService{
+		repo:        &taxMockRepo{Items: []models.TaxRate{foodRate, foodRateLater}},
+		catRepo:     &categoryMockRepo{Names: []string{foodName, "Fruit", "Apple", electronicName}, Parents: map[string]string{"Apple": "Fruit", "Fruit": foodName}},
+		defaultRate: 2000,
+		inclusive:   false,
+	}
+	// Apple has no rate of its own and takes the rate of Food two levels above it
+	items := []Item{
+		{Category: foodName, Amount: money.New(1000, "TRY")},
+		{Category: electronicName, Amount: money.New(1000, "TRY")},
+		{Category: "Apple", Amount: money.New(1000, "TRY")},
+		{Category: "Unknown", Amount: money.New(1000, "TRY")},
+	}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want []int64
+	}{
+		{name: "taxService_Quote_BeforeRateChange", at: rateChange.Add(-time.Second), want: []int64{800, 2000, 800, 2000}},
+		{name: "taxService_Quote_AtRateChange", at: rateChange, want: []int64{1000, 2000, 1000, 2000}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quote, err := s.Quote(items, tt.at)
+			if err != nil {
+				t.Fatalf("Quote() error = %v", err)
+			}
+			for i, line := range quote.Lines {
+				if line.Rate != tt.want[i] {
+					t.Errorf("Quote() line %d rate = %d, want %d", i, line.Rate, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func Test_taxService_Create(t *testing.T) {
+	tests := []struct {
+		name     string
+		category string
+		rate     int64
+		from     time.Time
+		wantErr  bool
+	}{
+		{name: "taxService_Create_ShouldSuccess", category: foodName, rate: 100, from: rateChange.AddDate(1, 0, 0)},
+		{name: "taxService_Create_RateOutOfRange_ShouldFail", category: foodName, rate: 10001, from: rateChange.AddDate(1, 0, 0), wantErr: true},
+		{name: "taxService_Create_NegativeRate_ShouldFail", category: foodName, rate: -1, from: rateChange.AddDate(1, 0, 0), wantErr: true},
+		{name: "taxService_Create_CategoryNotFound_ShouldFail", category: "Unknown", rate: 100, from: rateChange.AddDate(1, 0, 0), wantErr: true},
+		{name: "taxService_Create_SameValidFrom_ShouldFail", category: foodName, rate: 100, from: rateChange, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &taxService{
+				repo:    &taxMockRepo{Items: []models.TaxRate{foodRate, foodRateLater}},
+				catRepo: &categoryMockRepo{Names: []string{foodName, electronicName}},
+			}
+			from := strfmt.DateTime(tt.from)
+			_, err := s.Create(api.TaxRate{CategoryName: &tt.category, Rate: &tt.rate, ValidFrom: &from})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Create() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_taxService_Delete(t *testing.T) {
+	s := &taxService{repo: &taxMockRepo{Items: []models.TaxRate{foodRate}}}
+
+	if err := s.Delete(foodRate.ID); err != nil {
+		t.Errorf("Delete() error = %v", err)
+	}
+	if err := s.Delete(foodRate.ID); err == nil {
+		t.Errorf("Delete() deleted rate error = nil")
+	}
+}
+
+type taxMockRepo struct {
+	Items []models.TaxRate
+}
+type categoryMockRepo struct {
+	Names   []string
+	Parents map[string]string
+}
+
+func (r *taxMockRepo) Create(a *models.TaxRate) (*models.TaxRate, error) {
+	a.ID = uuid.New()
+	r.Items = append(r.Items, *a)
+	return a, nil
+}
+func (r *taxMockRepo) List(category string) (*[]models.TaxRate, error) {
+	rates := []models.TaxRate{}
+	for _, item := range r.Items {
+		if category == "" || item.CategoryName == category {
+			rates = append(rates, item)
+		}
+	}
+	return &rates, nil
+}
+func (r *taxMockRepo) Delete(id uuid.UUID) error {
+	for i, item := range r.Items {
+		if item.ID == id {
+			r.Items = append(r.Items[:i], r.Items[i+1:]...)
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+func (r *taxMockRepo) Active(at time.Time) (*[]models.TaxRate, error) {
+	latest := map[string]models.TaxRate{}
+	for _, item := range r.Items {
+		if item.ValidFrom.After(at) {
+			continue
+		}
+		if current, ok := latest[item.CategoryName]; !ok || item.ValidFrom.After(current.ValidFrom) {
+			latest[item.CategoryName] = item
+		}
+	}
+	rates := []models.TaxRate{}
+	for _, rate := range latest {
+		rates = append(rates, rate)
+	}
+	return &rates, nil
+}
+
+func (c *categoryMockRepo) Create(a *models.Category) (*models.Category, error) {
+	return a, nil
+}
+func (c *categoryMockRepo) GetByName(name string) (*models.Category, error) {
+	for _, item := range c.Names {
+		if item == name {
+			category := &models.Category{ID: mockCategoryID(item), Name: &item}
+			if parent, ok := c.Parents[item]; ok {
+				parentID := mockCategoryID(parent)
+				category.ParentID = &parentID
+			}
+			return category, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (c *categoryMockRepo) GetAll(pageIndex, pageSize int) (*[]models.Category, int, error) {
+	return nil, 0, nil
+}
+func (c *categoryMockRepo) GetByID(id uuid.UUID) (*models.Category, error) {
+	for _, item := range c.Names {
+		if mockCategoryID(item) == id {
+			return c.GetByName(item)
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (c *categoryMockRepo) GetBySlug(slug string) (*models.Category, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+func (c *categoryMockRepo) GetChild(parentID *uuid.UUID, name string) (*models.Category, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+func (c *categoryMockRepo) GetTree() (*[]models.Category, error) {
+	return &[]models.Category{}, nil
+}
+func (c *categoryMockRepo) Update(a *models.Category, oldName string) (*models.Category, error) {
+	return a, nil
+}
+func (c *categoryMockRepo) Delete(a *models.Category, reassignTo *models.Category) error {
+	return nil
+}
+func (c *categoryMockRepo) GetAfter(keyset *pagination.Keyset, pageSize int) (*[]models.Category, bool, error) {
+	return nil, false, nil
+}
+
+// mockCategoryID gives every category name of the mock the same ID
+func mockCategoryID(name string) uuid.UUID {
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(name))
+}
@@ -0,0 +1,28 @@
+package user
+
+import (
+	"gorm.io/gorm"
+)
+
+// SearchFilter narrows the admin user list, zero valued fields are not filtered
+type SearchFilter struct {
+	// Query matches the mail, first or last name of the user
+	Query  string
+	Active *bool
+}
+
+// scope adds the filter conditions to the given query
+func (f SearchFilter) scope(db *gorm.DB) *gorm.DB {
+	if f.Query != "" {
+		like := "%" + f.Query + "%"
+		db = db.Where("mail ILIKE ? OR first_name ILIKE ? OR last_name ILIKE ?", like, like, like)
+	}
+	if f.Active != nil {
+		if *f.Active {
+			db = db.Where("deactivated_at IS NULL")
+		} else {
+			db = db.Where("deactivated_at IS NOT NULL")
+		}
+	}
+	return db
+}
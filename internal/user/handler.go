@@ -0,0 +1,170 @@
+package user
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gcamlicali/tradeshopExample/internal/api"
+	httpErr "github.com/gcamlicali/tradeshopExample/internal/httpErrors"
+	mw "github.com/gcamlicali/tradeshopExample/pkg/middleware"
+	"github.com/gcamlicali/tradeshopExample/pkg/pagination"
+	"github.com/gin-gonic/gin"
+	"github.com/go-openapi/strfmt"
+	"github.com/google/uuid"
+)
+
+type userHandler struct {
+	service Service
+}
+
+func NewUserHandler(r *gin.RouterGroup, service Service, authMW gin.HandlerFunc) {
+	h := &userHandler{service: service}
+
+	meRoute := r.Group("/me")
+	meRoute.Use(authMW)
+	meRoute.GET("", h.getProfile)
+	meRoute.PATCH("", h.updateProfile)
+	meRoute.DELETE("", h.delete)
+	meRoute.PUT("/password", h.changePassword)
+	meRoute.PUT("/email", h.changeEmail)
+
+	adminRoute := r.Group("/admin")
+	adminRoute.Use(authMW, mw.RequirePermission(mw.PermUserManage))
+	adminRoute.GET("/users", h.search)
+	adminRoute.PUT("/:id/deactivate", h.deactivate)
+}
+
+func (h *userHandler) getProfile(c *gin.Context) {
+	userID := c.MustGet("userId").(uuid.UUID)
+
+	user, err := h.service.GetProfile(userID)
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, userToResponse(user))
+}
+
+func (h *userHandler) updateProfile(c *gin.Context) {
+	userID := c.MustGet("userId").(uuid.UUID)
+
+	req := api.UserProfileUpdate{}
+	if err := c.Bind(&req); err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "check your request body", err.Error())))
+		return
+	}
+
+	user, err := h.service.UpdateProfile(userID, &req)
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, userToResponse(user))
+}
+
+func (h *userHandler) changePassword(c *gin.Context) {
+	userID := c.MustGet("userId").(uuid.UUID)
+
+	req := api.PasswordChange{}
+	if err := c.Bind(&req); err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "check your request body", err.Error())))
+		return
+	}
+	if err := req.Validate(strfmt.NewFormats()); err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	if err := h.service.ChangePassword(userID, &req); err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, "Password changed, please sign in again")
+}
+
+func (h *userHandler) changeEmail(c *gin.Context) {
+	userID := c.MustGet("userId").(uuid.UUID)
+
+	req := api.EmailChange{}
+	if err := c.Bind(&req); err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "check your request body", err.Error())))
+		return
+	}
+	if err := req.Validate(strfmt.NewFormats()); err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	user, err := h.service.ChangeEmail(userID, &req)
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, userToResponse(user))
+}
+
+func (h *userHandler) delete(c *gin.Context) {
+	userID := c.MustGet("userId").(uuid.UUID)
+
+	req := api.AccountDeletion{}
+	if err := c.Bind(&req); err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "check your request body", err.Error())))
+		return
+	}
+	if err := req.Validate(strfmt.NewFormats()); err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	if err := h.service.Delete(userID, *req.Password); err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, "Account deleted")
+}
+
+func (h *userHandler) search(c *gin.Context) {
+	filter := SearchFilter{Query: c.Query("q")}
+	if value := c.Query("active"); value != "" {
+		active, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "active is not a boolean", err.Error())))
+			return
+		}
+		filter.Active = &active
+	}
+
+	pageIndex, pageSize := pagination.GetPaginationParametersFromRequest(c)
+	users, count, err := h.service.Search(filter, pageIndex, pageSize)
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	paginatedResult := pagination.NewFromGinRequest(c, count)
+	paginatedResult.Items = usersToResponse(*users)
+
+	c.JSON(http.StatusOK, paginatedResult)
+}
+
+func (h *userHandler) deactivate(c *gin.Context) {
+	actorID := c.MustGet("userId").(uuid.UUID)
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(httpErr.NewRestError(http.StatusBadRequest, "User ID is not valid", err.Error())))
+		return
+	}
+
+	user, err := h.service.Deactivate(actorID, userID)
+	if err != nil {
+		c.JSON(httpErr.ErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, userToResponse(user))
+}
@@ -0,0 +1,113 @@
+package user
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type UserRepositoy struct {
+	db *gorm.DB
+}
+
+type IUserRepository interface {
+	GetByID(id uuid.UUID) (*models.User, error)
+	GetByMail(mail string) (*models.User, error)
+	Update(a *models.User) (*models.User, error)
+	Search(filter SearchFilter, pageIndex, pageSize int) (*[]models.User, int, error)
+	Delete(a *models.User) error
+}
+
+func NewUserRepository(db *gorm.DB) *UserRepositoy {
+	return &UserRepositoy{db: db}
+}
+
+func (r *UserRepositoy) GetByID(id uuid.UUID) (*models.User, error) {
+	zap.L().Debug("user.repo.GetByID", zap.Reflect("id", id))
+
+	var user = &models.User{}
+	if err := r.db.Preload("Roles").Where(models.User{ID: id}).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (r *UserRepositoy) GetByMail(mail string) (*models.User, error) {
+	zap.L().Debug("user.repo.GetByMail", zap.Reflect("mail", mail))
+
+	var user = &models.User{}
+	if err := r.db.Where(models.User{Mail: &mail}).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (r *UserRepositoy) Update(a *models.User) (*models.User, error) {
+	zap.L().Debug("user.repo.update", zap.Reflect("userID", a.ID))
+
+	if err := r.db.Omit("Roles").Save(a).Error; err != nil {
+		zap.L().Error("user.repo.Update failed to update user", zap.Error(err))
+		return nil, err
+	}
+	return a, nil
+}
+
+func (r *UserRepositoy) Search(filter SearchFilter, pageIndex, pageSize int) (*[]models.User, int, error) {
+	zap.L().Debug("user.repo.Search", zap.Reflect("filter", filter))
+	var users []models.User
+	var count int64
+
+	if err := r.db.Model(&models.User{}).Scopes(filter.scope).Count(&count).Error; err != nil {
+		zap.L().Error("user.repo.Search failed to count users", zap.Error(err))
+		return nil, 0, err
+	}
+
+	err := r.db.
+		Preload("Roles").
+		Scopes(filter.scope).
+		Order("created_at DESC").
+		Offset((pageIndex - 1) * pageSize).
+		Limit(pageSize).
+		Find(&users).Error
+	if err != nil {
+		zap.L().Error("user.repo.Search failed to get users", zap.Error(err))
+		return nil, 0, err
+	}
+	return &users, int(count), nil
+}
+
+// Delete soft deletes the user and closes the open cart, the mail is released for a new sign up
+func (r *UserRepositoy) Delete(a *models.User) error {
+	zap.L().Debug("user.repo.delete", zap.Reflect("userID", a.ID))
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		openCarts := tx.Model(&models.Cart{}).Select("id").Where("user_id = ? AND is_ordered = ?", a.ID, false)
+		if err := tx.Where("cart_id IN (?)", openCarts).Delete(&models.StockReservation{}).Error; err != nil {
+			zap.L().Error("user.repo.Delete failed to release reservations", zap.Error(err))
+			return err
+		}
+		if err := tx.Where("cart_id IN (?)", openCarts).Delete(&models.CartItem{}).Error; err != nil {
+			zap.L().Error("user.repo.Delete failed to delete cart items", zap.Error(err))
+			return err
+		}
+		if err := tx.Where("user_id = ? AND is_ordered = ?", a.ID, false).Delete(&models.Cart{}).Error; err != nil {
+			zap.L().Error("user.repo.Delete failed to delete cart", zap.Error(err))
+			return err
+		}
+
+		deletedMail := fmt.Sprintf("deleted-%s@deleted.invalid", a.ID)
+		err := tx.Model(&models.User{}).Where("id = ?", a.ID).Updates(map[string]interface{}{
+			"mail":       deletedMail,
+			"deleted_at": time.Now(),
+		}).Error
+		if err != nil {
+			zap.L().Error("user.repo.Delete failed to delete user", zap.Error(err))
+			return err
+		}
+		return nil
+	})
+}
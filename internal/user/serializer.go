@@ -0,0 +1,38 @@
+package user
+
+import (
+	"github.com/gcamlicali/tradeshopExample/internal/api"
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/go-openapi/strfmt"
+)
+
+func userToResponse(u *models.User) *api.UserProfile {
+	profile := &api.UserProfile{
+		ID:            u.ID.String(),
+		Phone:         u.Mobile,
+		EmailVerified: u.EmailVerifiedAt != nil,
+		Roles:         u.RoleNames(),
+		CreatedAt:     strfmt.DateTime(u.CreatedAt),
+	}
+	if u.Mail != nil {
+		profile.Email = *u.Mail
+	}
+	if u.FirstName != nil {
+		profile.FirstName = *u.FirstName
+	}
+	if u.LastName != nil {
+		profile.LastName = *u.LastName
+	}
+	if u.DeactivatedAt != nil {
+		profile.DeactivatedAt = strfmt.DateTime(*u.DeactivatedAt)
+	}
+	return profile
+}
+
+func usersToResponse(users []models.User) []*api.UserProfile {
+	profiles := make([]*api.UserProfile, 0, len(users))
+	for i := range users {
+		profiles = append(profiles, userToResponse(&users[i]))
+	}
+	return profiles
+}
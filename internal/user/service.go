@@ -0,0 +1,217 @@
+package user
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gcamlicali/tradeshopExample/internal/api"
+	httpErr "github.com/gcamlicali/tradeshopExample/internal/httpErrors"
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// SessionRevoker signs a user out of every device
+type SessionRevoker interface {
+	RevokeUserSessions(userID uuid.UUID) error
+}
+
+// EmailVerifier mails a verification link to the current mail of a user
+type EmailVerifier interface {
+	SendVerification(userID uuid.UUID) error
+}
+
+type userService struct {
+	repo     IUserRepository
+	sessions SessionRevoker
+	verifier EmailVerifier
+}
+
+type Service interface {
+	GetProfile(userID uuid.UUID) (*models.User, error)
+	UpdateProfile(userID uuid.UUID, req *api.UserProfileUpdate) (*models.User, error)
+	ChangePassword(userID uuid.UUID, req *api.PasswordChange) error
+	ChangeEmail(userID uuid.UUID, req *api.EmailChange) (*models.User, error)
+	Delete(userID uuid.UUID, password string) error
+	Search(filter SearchFilter, pageIndex, pageSize int) (*[]models.User, int, error)
+	Deactivate(actorID uuid.UUID, userID uuid.UUID) (*models.User, error)
+}
+
+func NewUserService(repo IUserRepository, sessions SessionRevoker, verifier EmailVerifier) Service {
+	return &userService{repo: repo, sessions: sessions, verifier: verifier}
+}
+
+func (u *userService) GetProfile(userID uuid.UUID) (*models.User, error) {
+	return u.getUser(userID)
+}
+
+func (u *userService) UpdateProfile(userID uuid.UUID, req *api.UserProfileUpdate) (*models.User, error) {
+	user, err := u.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.FirstName != nil {
+		if strings.TrimSpace(*req.FirstName) == "" {
+			return nil, httpErr.NewRestError(http.StatusBadRequest, "First name can't be empty", nil)
+		}
+		user.FirstName = req.FirstName
+	}
+	if req.LastName != nil {
+		if strings.TrimSpace(*req.LastName) == "" {
+			return nil, httpErr.NewRestError(http.StatusBadRequest, "Last name can't be empty", nil)
+		}
+		user.LastName = req.LastName
+	}
+	if req.Phone != nil {
+		user.Mobile = *req.Phone
+	}
+
+	updatedUser, err := u.repo.Update(user)
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "User update error", err.Error())
+	}
+	return updatedUser, nil
+}
+
+// ChangePassword replaces the password and signs the user out of every session
+func (u *userService) ChangePassword(userID uuid.UUID, req *api.PasswordChange) error {
+	user, err := u.getUser(userID)
+	if err != nil {
+		return err
+	}
+	if err := checkPassword(user, *req.OldPassword); err != nil {
+		return err
+	}
+	if *req.NewPassword == *req.OldPassword {
+		return httpErr.NewRestError(http.StatusBadRequest, "New password must be different", nil)
+	}
+
+	hashPassword, err := bcrypt.GenerateFromPassword([]byte(*req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return httpErr.NewRestError(http.StatusUnprocessableEntity, "encryption error", err.Error())
+	}
+	password := string(hashPassword)
+	user.Password = &password
+
+	if _, err := u.repo.Update(user); err != nil {
+		return httpErr.NewRestError(http.StatusInternalServerError, "User update error", err.Error())
+	}
+	return u.sessions.RevokeUserSessions(user.ID)
+}
+
+// ChangeEmail replaces the mail of the user, the new address has to be verified again
+func (u *userService) ChangeEmail(userID uuid.UUID, req *api.EmailChange) (*models.User, error) {
+	user, err := u.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPassword(user, *req.Password); err != nil {
+		return nil, err
+	}
+
+	mail := strings.TrimSpace(*req.Email)
+	if mail == "" {
+		return nil, httpErr.NewRestError(http.StatusBadRequest, "Email can't be empty", nil)
+	}
+	if user.Mail != nil && *user.Mail == mail {
+		return nil, httpErr.NewRestError(http.StatusBadRequest, "Email is not changed", nil)
+	}
+	_, err = u.repo.GetByMail(mail)
+	if err == nil {
+		return nil, httpErr.NewRestError(http.StatusConflict, "Email is already in use", nil)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "User get error", err.Error())
+	}
+
+	user.Mail = &mail
+	user.EmailVerifiedAt = nil
+	updatedUser, err := u.repo.Update(user)
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "User update error", err.Error())
+	}
+	if err := u.sessions.RevokeUserSessions(user.ID); err != nil {
+		return nil, err
+	}
+	//The mail is already changed, a failed mail can be sent again from the resend endpoint
+	if err := u.verifier.SendVerification(user.ID); err != nil {
+		zap.L().Error("user.service.ChangeEmail failed to send verification mail", zap.Error(err))
+	}
+	return updatedUser, nil
+}
+
+// Delete soft deletes the account of the user after the password is confirmed
+func (u *userService) Delete(userID uuid.UUID, password string) error {
+	user, err := u.getUser(userID)
+	if err != nil {
+		return err
+	}
+	if err := checkPassword(user, password); err != nil {
+		return err
+	}
+
+	if err := u.repo.Delete(user); err != nil {
+		return httpErr.NewRestError(http.StatusInternalServerError, "User delete error", err.Error())
+	}
+	return u.sessions.RevokeUserSessions(user.ID)
+}
+
+func (u *userService) Search(filter SearchFilter, pageIndex, pageSize int) (*[]models.User, int, error) {
+	filter.Query = strings.TrimSpace(filter.Query)
+	users, count, err := u.repo.Search(filter, pageIndex, pageSize)
+	if err != nil {
+		return nil, 0, httpErr.NewRestError(http.StatusInternalServerError, "User search error", err.Error())
+	}
+	return users, count, nil
+}
+
+// Deactivate blocks the user from signing in and ends the current sessions
+func (u *userService) Deactivate(actorID uuid.UUID, userID uuid.UUID) (*models.User, error) {
+	if actorID == userID {
+		return nil, httpErr.NewRestError(http.StatusBadRequest, "You can't deactivate your own account", nil)
+	}
+	user, err := u.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.DeactivatedAt != nil {
+		return user, nil
+	}
+
+	now := time.Now()
+	user.DeactivatedAt = &now
+	updatedUser, err := u.repo.Update(user)
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "User update error", err.Error())
+	}
+	if err := u.sessions.RevokeUserSessions(user.ID); err != nil {
+		return nil, err
+	}
+	return updatedUser, nil
+}
+
+func (u *userService) getUser(userID uuid.UUID) (*models.User, error) {
+	user, err := u.repo.GetByID(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, httpErr.NewRestError(http.StatusNotFound, "User not found", err.Error())
+	}
+	if err != nil {
+		return nil, httpErr.NewRestError(http.StatusInternalServerError, "User get error", err.Error())
+	}
+	return user, nil
+}
+
+func checkPassword(user *models.User, password string) error {
+	if user.Password == nil {
+		return httpErr.NewRestError(http.StatusBadRequest, "Wrong Password", nil)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(*user.Password), []byte(password)); err != nil {
+		return httpErr.NewRestError(http.StatusBadRequest, "Wrong Password", err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,351 @@
+package user
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gcamlicali/tradeshopExample/internal/api"
+	"github.com/gcamlicali/tradeshopExample/internal/models"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var (
+	userID    = uuid.New()
+	adminID   = uuid.New()
+	NExUser   = uuid.New()
+	password  = "password"
+	mail      = "user@example.com"
+	otherMail = "other@example.com"
+)
+
+func newUser(id uuid.UUID, mail string) models.User {
+	hash, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	hashed := string(hash)
+	first, last := "First", "Last"
+	now := time.Now()
+	return models.User{
+		ID:              id,
+		Mail:            &mail,
+		Password:        &hashed,
+		FirstName:       &first,
+		LastName:        &last,
+		EmailVerifiedAt: &now,
+	}
+}
+
+func newService(users ...models.User) (*userService, *userMockRepo, *sessionMock) {
+	repo := &userMockRepo{Items: users}
+	sessions := &sessionMock{}
+	return &userService{repo: repo, sessions: sessions, verifier: sessions}, repo, sessions
+}
+
+func str(s string) *string {
+	return &s
+}
+
+func Test_userService_UpdateProfile(t *testing.T) {
+	tests := []struct {
+		name    string
+		userID  uuid.UUID
+		req     api.UserProfileUpdate
+		wantErr bool
+	}{
+		{
+			name:    "userService_UpdateProfile_ShouldSuccess",
+			userID:  userID,
+			req:     api.UserProfileUpdate{FirstName: str("New"), Phone: str("5551234567")},
+			wantErr: false,
+		},
+		{
+			name:    "userService_UpdateProfile_ErrorEmptyLastName_ShouldFail",
+			userID:  userID,
+			req:     api.UserProfileUpdate{LastName: str(" ")},
+			wantErr: true,
+		},
+		{
+			name:    "userService_UpdateProfile_ErrorUserNotFound_ShouldFail",
+			userID:  NExUser,
+			req:     api.UserProfileUpdate{FirstName: str("New")},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, _, _ := newService(newUser(userID, mail))
+			got, err := u.UpdateProfile(tt.userID, &tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UpdateProfile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if *got.FirstName != "New" || *got.LastName != "Last" || got.Mobile != "5551234567" {
+				t.Errorf("UpdateProfile() got = %v %v %v", *got.FirstName, *got.LastName, got.Mobile)
+			}
+		})
+	}
+}
+
+func Test_userService_ChangePassword(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     api.PasswordChange
+		wantErr bool
+	}{
+		{
+			name:    "userService_ChangePassword_ShouldSuccess",
+			req:     api.PasswordChange{OldPassword: str(password), NewPassword: str("new-password")},
+			wantErr: false,
+		},
+		{
+			name:    "userService_ChangePassword_ErrorWrongOldPassword_ShouldFail",
+			req:     api.PasswordChange{OldPassword: str("wrong"), NewPassword: str("new-password")},
+			wantErr: true,
+		},
+		{
+			name:    "userService_ChangePassword_ErrorSamePassword_ShouldFail",
+			req:     api.PasswordChange{OldPassword: str(password), NewPassword: str(password)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, repo, sessions := newService(newUser(userID, mail))
+			err := u.ChangePassword(userID, &tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ChangePassword() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if len(sessions.Revoked) != 0 {
+					t.Errorf("ChangePassword() revoked sessions on failure")
+				}
+				return
+			}
+			if err := bcrypt.CompareHashAndPassword([]byte(*repo.Items[0].Password), []byte("new-password")); err != nil {
+				t.Errorf("ChangePassword() new password is not stored: %v", err)
+			}
+			if len(sessions.Revoked) != 1 || sessions.Revoked[0] != userID {
+				t.Errorf("ChangePassword() revoked = %v, want %v", sessions.Revoked, userID)
+			}
+		})
+	}
+}
+
+func Test_userService_ChangeEmail(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     api.EmailChange
+		wantErr bool
+	}{
+		{
+			name:    "userService_ChangeEmail_ShouldSuccess",
+			req:     api.EmailChange{Email: str("new@example.com"), Password: str(password)},
+			wantErr: false,
+		},
+		{
+			name:    "userService_ChangeEmail_ErrorWrongPassword_ShouldFail",
+			req:     api.EmailChange{Email: str("new@example.com"), Password: str("wrong")},
+			wantErr: true,
+		},
+		{
+			name:    "userService_ChangeEmail_ErrorMailInUse_ShouldFail",
+			req:     api.EmailChange{Email: str(otherMail), Password: str(password)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, _, sessions := newService(newUser(userID, mail), newUser(uuid.New(), otherMail))
+			got, err := u.ChangeEmail(userID, &tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ChangeEmail() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if *got.Mail != "new@example.com" {
+				t.Errorf("ChangeEmail() mail = %v", *got.Mail)
+			}
+			if got.EmailVerifiedAt != nil {
+				t.Errorf("ChangeEmail() kept the new mail verified")
+			}
+			if len(sessions.Revoked) != 1 {
+				t.Errorf("ChangeEmail() revoked = %v, want one user", sessions.Revoked)
+			}
+			if len(sessions.Verifications) != 1 {
+				t.Errorf("ChangeEmail() verification mails = %v, want one", sessions.Verifications)
+			}
+		})
+	}
+}
+
+func Test_userService_Delete(t *testing.T) {
+	u, repo, sessions := newService(newUser(userID, mail))
+
+	if err := u.Delete(userID, "wrong"); err == nil {
+		t.Fatalf("Delete() accepted a wrong password")
+	}
+	if err := u.Delete(userID, password); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if len(repo.Deleted) != 1 || repo.Deleted[0] != userID {
+		t.Errorf("Delete() deleted = %v, want %v", repo.Deleted, userID)
+	}
+	if len(sessions.Revoked) != 1 {
+		t.Errorf("Delete() revoked = %v, want one user", sessions.Revoked)
+	}
+	if _, err := u.GetProfile(userID); err == nil {
+		t.Errorf("GetProfile() found a deleted user")
+	}
+}
+
+func Test_userService_Deactivate(t *testing.T) {
+	tests := []struct {
+		name    string
+		actorID uuid.UUID
+		userID  uuid.UUID
+		wantErr bool
+	}{
+		{
+			name:    "userService_Deactivate_ShouldSuccess",
+			actorID: adminID,
+			userID:  userID,
+			wantErr: false,
+		},
+		{
+			name:    "userService_Deactivate_ErrorOwnAccount_ShouldFail",
+			actorID: adminID,
+			userID:  adminID,
+			wantErr: true,
+		},
+		{
+			name:    "userService_Deactivate_ErrorUserNotFound_ShouldFail",
+			actorID: adminID,
+			userID:  NExUser,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, _, sessions := newService(newUser(userID, mail), newUser(adminID, otherMail))
+			got, err := u.Deactivate(tt.actorID, tt.userID)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Deactivate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.DeactivatedAt == nil {
+				t.Errorf("Deactivate() user is still active")
+			}
+			if len(sessions.Revoked) != 1 || sessions.Revoked[0] != tt.userID {
+				t.Errorf("Deactivate() revoked = %v, want %v", sessions.Revoked, tt.userID)
+			}
+		})
+	}
+}
+
+func Test_userService_Search(t *testing.T) {
+	deactivated := newUser(uuid.New(), "blocked@example.com")
+	now := time.Now()
+	deactivated.DeactivatedAt = &now
+	active, inactive := true, false
+	tests := []struct {
+		name   string
+		filter SearchFilter
+		want   int
+	}{
+		{name: "userService_Search_NoFilter_ShouldSuccess", filter: SearchFilter{}, want: 3},
+		{name: "userService_Search_ByQuery_ShouldSuccess", filter: SearchFilter{Query: " other "}, want: 1},
+		{name: "userService_Search_Active_ShouldSuccess", filter: SearchFilter{Active: &active}, want: 2},
+		{name: "userService_Search_Inactive_ShouldSuccess", filter: SearchFilter{Active: &inactive}, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, _, _ := newService(newUser(userID, mail), newUser(adminID, otherMail), deactivated)
+			got, count, err := u.Search(tt.filter, 1, 10)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			if count != tt.want || len(*got) != tt.want {
+				t.Errorf("Search() count = %v, want %v", count, tt.want)
+			}
+		})
+	}
+}
+
+type userMockRepo struct {
+	Items   []models.User
+	Deleted []uuid.UUID
+}
+
+func (r *userMockRepo) GetByID(id uuid.UUID) (*models.User, error) {
+	for i, item := range r.Items {
+		if item.ID == id {
+			return &r.Items[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *userMockRepo) GetByMail(mail string) (*models.User, error) {
+	for i, item := range r.Items {
+		if *item.Mail == mail {
+			return &r.Items[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *userMockRepo) Update(a *models.User) (*models.User, error) {
+	for i, item := range r.Items {
+		if item.ID == a.ID {
+			r.Items[i] = *a
+			return a, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *userMockRepo) Search(filter SearchFilter, pageIndex, pageSize int) (*[]models.User, int, error) {
+	users := []models.User{}
+	for _, item := range r.Items {
+		if filter.Query != "" && !strings.Contains(*item.Mail, filter.Query) {
+			continue
+		}
+		if filter.Active != nil && *filter.Active != (item.DeactivatedAt == nil) {
+			continue
+		}
+		users = append(users, item)
+	}
+	return &users, len(users), nil
+}
+
+func (r *userMockRepo) Delete(a *models.User) error {
+	for i, item := range r.Items {
+		if item.ID == a.ID {
+			r.Items = append(r.Items[:i], r.Items[i+1:]...)
+			r.Deleted = append(r.Deleted, a.ID)
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+type sessionMock struct {
+	Revoked       []uuid.UUID
+	Verifications []uuid.UUID
+}
+
+func (s *sessionMock) RevokeUserSessions(userID uuid.UUID) error {
+	s.Revoked = append(s.Revoked, userID)
+	return nil
+}
+
+func (s *sessionMock) SendVerification(userID uuid.UUID) error {
+	s.Verifications = append(s.Verifications, userID)
+	return nil
+}
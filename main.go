@@ -2,26 +2,45 @@ package main
 
 import (
 	"fmt"
+	"github.com/gcamlicali/tradeshopExample/internal/address"
 	"github.com/gcamlicali/tradeshopExample/internal/auth"
 	"github.com/gcamlicali/tradeshopExample/internal/cart"
 	"github.com/gcamlicali/tradeshopExample/internal/cart_item"
 	"github.com/gcamlicali/tradeshopExample/internal/category"
 	"github.com/gcamlicali/tradeshopExample/internal/order"
+	"github.com/gcamlicali/tradeshopExample/internal/payment"
 	"github.com/gcamlicali/tradeshopExample/internal/product"
+	"github.com/gcamlicali/tradeshopExample/internal/promotion"
+	"github.com/gcamlicali/tradeshopExample/internal/reservation"
+	"github.com/gcamlicali/tradeshopExample/internal/shipping"
+	"github.com/gcamlicali/tradeshopExample/internal/tax"
+	"github.com/gcamlicali/tradeshopExample/internal/user"
 	"github.com/gcamlicali/tradeshopExample/pkg/config"
 	db "github.com/gcamlicali/tradeshopExample/pkg/database"
 	"github.com/gcamlicali/tradeshopExample/pkg/graceful"
+	jwtHelper "github.com/gcamlicali/tradeshopExample/pkg/jwt"
 	logger "github.com/gcamlicali/tradeshopExample/pkg/logging"
+	"github.com/gcamlicali/tradeshopExample/pkg/mail"
 	mw "github.com/gcamlicali/tradeshopExample/pkg/middleware"
+	"github.com/gcamlicali/tradeshopExample/pkg/pagination"
 
 	"github.com/gin-gonic/gin"
 
 	"log"
 	"net/http"
+	"os"
 	"time"
 )
 
 func main() {
+	// Maintenance commands run instead of the server, see commands.go
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	log.Println("Trading cart service starting...")
 
 	// Set envs for local development
@@ -34,15 +53,23 @@ func main() {
 	logger.NewLogger(cfg)
 	defer logger.Close()
 
-	// Connect DB
-	// Use golang-migrate instead of gorm auto migrate
-	//https://github.com/golang-migrate/migrate
+	// Connect DB, the schema is migrated by `shop migrate up` before the server starts
 	DB := db.Connect(cfg)
+	if err := checkSchema(DB, cfg); err != nil {
+		log.Fatalf("Schema: %v", err)
+	}
 
 	gin.SetMode(gin.ReleaseMode)
 
+	// List cursors are signed so clients can't forge their sort keys
+	pagination.SetCursorSecret(cfg.PaginationConfig.CursorSecret)
+
 	// Init Gin and start gin engine (Recovery MW: if you don't want to panic exit, recovery returns 500 ErrorCode[read inside comments])
 	r := gin.Default()
+	// Sign in attempts are counted by client IP, a client must not pick its own IP with X-Forwarded-For
+	if err := r.SetTrustedProxies(cfg.ServerConfig.TrustedProxies); err != nil {
+		log.Fatalf("Trusted proxies: %v", err)
+	}
 
 	r.Use(gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
 		// your custom format
@@ -72,41 +99,96 @@ func main() {
 	categoryRouter := rootRouter.Group("/category")
 	cartRouter := rootRouter.Group("/cart")
 	orderRouter := rootRouter.Group("/order")
+	taxRouter := rootRouter.Group("/tax")
+	promotionRouter := rootRouter.Group("/promotion")
+	paymentRouter := rootRouter.Group("/payment")
 
 	//MW Control
-	cartRouter.Use(mw.AuthMiddleware(cfg.JWTConfig.SecretKey))
-	orderRouter.Use(mw.AuthMiddleware(cfg.JWTConfig.SecretKey))
+	// Revoked token families are kept in the database, every instance rejects them
+	keySet, err := jwtHelper.NewKeySetFromConfig(cfg.JWTConfig)
+	if err != nil {
+		log.Fatalf("JWT keys: %v", err)
+	}
+	auth.NewJWKSHandler(r, keySet)
+	revocationStore := auth.NewRevocationRepository(DB)
+	authMW := mw.AuthMiddleware(keySet, revocationStore)
+	cartRouter.Use(authMW)
+	orderRouter.Use(authMW)
 
 	// Category Repository
 	categoryRepo := category.NewCategoryRepository(DB)
-	categoryRepo.Migration()
 	categoryService := category.NewCategoryService(categoryRepo)
-	category.NewCategoryHandler(categoryRouter, categoryService, cfg)
+	category.NewCategoryHandler(categoryRouter, categoryService, authMW)
+
+	// Stock reservation holds cart items stock for a while when enabled
+	reservationRepo := reservation.NewReservationRepository(DB)
+	var reservationService reservation.Service
+	if cfg.ReservationConfig.Enabled {
+		reservationService, err = reservation.NewReservationServiceFromConfig(reservationRepo, cfg.ReservationConfig)
+		if err != nil {
+			log.Fatalf("Reservation: %v", err)
+		}
+		stopSweeper := reservationService.StartSweeper(time.Duration(cfg.ReservationConfig.SweepIntervalSecs * int64(time.Second)))
+		defer stopSweeper()
+	}
 
 	//// Product Repository
 	productRepo := product.NewProductRepository(DB)
-	productRepo.Migration()
-	productService := product.NewProductService(productRepo, categoryRepo)
-	product.NewProductHandler(productRouter, productService, cfg)
+	productService := product.NewProductService(productRepo, categoryRepo, reservationService)
+	product.NewProductHandler(productRouter, productService, authMW)
+
+	// KDV rates per category price carts and orders
+	taxRepo := tax.NewTaxRepository(DB)
+	taxService := tax.NewTaxService(taxRepo, categoryRepo, cfg.TaxConfig)
+	tax.NewTaxHandler(taxRouter, taxService, authMW)
+
+	// Promotions take discounts off carts before tax
+	promotionRepo := promotion.NewPromotionRepository(DB)
+	promotionService := promotion.NewPromotionService(promotionRepo, categoryRepo)
+	promotion.NewPromotionHandler(promotionRouter, promotionService, authMW)
 
 	cartItemRepo := cart_item.NewCartItemRepository(DB)
-	cartItemRepo.Migration()
 
 	cartRepo := cart.NewCartRepository(DB)
-	cartRepo.Migration()
-	cartService := cart.NewCartService(cartRepo, cartItemRepo, productRepo)
+	cartService := cart.NewCartService(cartRepo, cartItemRepo, productRepo, reservationService, taxService, promotionService)
 	cart.NewCartHandler(cartRouter, cartService)
 
 	authRepo := auth.NewAuthRepository(DB)
-	authRepo.Migration()
-	authService := auth.NewAuthService(authRepo, cartRepo, cfg)
-	authService.FillAdminData()
-	auth.NewAuthHandler(authRooter, authService)
+	refreshTokenRepo := auth.NewRefreshTokenRepository(DB)
+	userTokenRepo := auth.NewUserTokenRepository(DB)
+	mailer, err := mail.NewMailerFromConfig(cfg.MailConfig)
+	if err != nil {
+		log.Fatalf("Mailer: %v", err)
+	}
+	// Failed sign ins are counted in the database like revoked tokens
+	attemptStore := auth.NewAttemptRepository(DB)
+	authService := auth.NewAuthService(authRepo, refreshTokenRepo, userTokenRepo, cartRepo, keySet, revocationStore, mailer, attemptStore, cfg)
+	auth.NewAuthHandler(authRooter, authService, authMW)
+
+	userRepo := user.NewUserRepository(DB)
+	userService := user.NewUserService(userRepo, authService, authService)
+	user.NewUserHandler(authRooter, userService, authMW)
+
+	addressRepo := address.NewAddressRepository(DB)
+	addressService := address.NewAddressService(addressRepo)
+	address.NewAddressHandler(authRooter, addressService, authMW)
+
+	shippingRates, err := shipping.NewCalculatorFromConfig(cfg.ShippingConfig)
+	if err != nil {
+		log.Fatalf("Shipping: %v", err)
+	}
+
+	paymentProvider, err := payment.NewProviderFromConfig(cfg.PaymentConfig)
+	if err != nil {
+		log.Fatalf("Payment: %v", err)
+	}
 
 	orderRepo := order.NewOrderRepository(DB)
-	orderRepo.Migration()
-	orderService := order.NewOrderService(orderRepo, cartRepo, cartItemRepo, productRepo)
+	orderService := order.NewOrderService(orderRepo, cartRepo, cartItemRepo, productRepo, userRepo, addressRepo, order.NewUnitOfWork(DB), taxService, promotionService, shippingRates, paymentProvider, categoryRepo, cfg.OrderConfig)
 	order.NewOrderHandler(orderRouter, orderService)
+	order.NewPaymentHandler(paymentRouter, orderService)
+	stopSettler := orderService.StartSettler(time.Duration(cfg.PaymentConfig.SettleIntervalSecs * int64(time.Second)))
+	defer stopSettler()
 
 	go func() {
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS "user";
+DROP TABLE IF EXISTS "order";
+DROP TABLE IF EXISTS cart_item;
+DROP TABLE IF EXISTS cart;
+DROP TABLE IF EXISTS products;
+DROP TABLE IF EXISTS categories;
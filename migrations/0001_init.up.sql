@@ -0,0 +1,74 @@
+-- Tables of the shop before versioned migrations, IF NOT EXISTS keeps databases created by gorm auto migrate
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS categories (
+    id         uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    name       text UNIQUE
+);
+CREATE INDEX IF NOT EXISTS idx_categories_deleted_at ON categories (deleted_at);
+
+CREATE TABLE IF NOT EXISTS products (
+    id            uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at    timestamptz,
+    updated_at    timestamptz,
+    deleted_at    timestamptz,
+    category_name text,
+    sku           bigint UNIQUE,
+    name          text,
+    description   text,
+    unit_stock    integer,
+    price         bigint
+);
+CREATE INDEX IF NOT EXISTS idx_products_deleted_at ON products (deleted_at);
+
+CREATE TABLE IF NOT EXISTS cart (
+    id          uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at  timestamptz,
+    updated_at  timestamptz,
+    deleted_at  timestamptz,
+    is_ordered  boolean,
+    user_id     uuid,
+    total_price bigint
+);
+CREATE INDEX IF NOT EXISTS idx_cart_deleted_at ON cart (deleted_at);
+
+CREATE TABLE IF NOT EXISTS cart_item (
+    id          uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at  timestamptz,
+    updated_at  timestamptz,
+    deleted_at  timestamptz,
+    quantity    bigint,
+    product_sku bigint,
+    cart_id     uuid,
+    price       bigint
+);
+CREATE INDEX IF NOT EXISTS idx_cart_item_deleted_at ON cart_item (deleted_at);
+
+CREATE TABLE IF NOT EXISTS "order" (
+    id          uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at  timestamptz,
+    updated_at  timestamptz,
+    deleted_at  timestamptz,
+    cart_id     uuid,
+    user_id     uuid,
+    status      text,
+    total_price integer
+);
+CREATE INDEX IF NOT EXISTS idx_order_deleted_at ON "order" (deleted_at);
+
+CREATE TABLE IF NOT EXISTS "user" (
+    id         uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    mail       text UNIQUE,
+    password   text,
+    first_name text,
+    last_name  text,
+    mobile     text,
+    is_admin   boolean
+);
+CREATE INDEX IF NOT EXISTS idx_user_deleted_at ON "user" (deleted_at);
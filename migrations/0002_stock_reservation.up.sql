@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS stock_reservation (
+    id          uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at  timestamptz,
+    updated_at  timestamptz,
+    cart_id     uuid,
+    product_sku bigint,
+    quantity    bigint,
+    expires_at  timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reservation_cart_sku ON stock_reservation (cart_id, product_sku);
+CREATE INDEX IF NOT EXISTS idx_stock_reservation_product_sku ON stock_reservation (product_sku);
+CREATE INDEX IF NOT EXISTS idx_stock_reservation_expires_at ON stock_reservation (expires_at);
@@ -0,0 +1,3 @@
+UPDATE "order" SET status = 'Ordered' WHERE status = 'Pending';
+DROP TABLE IF EXISTS order_status_history;
+DROP TABLE IF EXISTS order_line;
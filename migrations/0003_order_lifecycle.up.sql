@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS order_line (
+    id          uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at  timestamptz,
+    order_id    uuid,
+    product_sku bigint,
+    name        text,
+    unit_price  bigint,
+    quantity    bigint,
+    line_total  bigint
+);
+CREATE INDEX IF NOT EXISTS idx_order_line_order_id ON order_line (order_id);
+
+CREATE TABLE IF NOT EXISTS order_status_history (
+    id          uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at  timestamptz,
+    order_id    uuid,
+    from_status text,
+    to_status   text,
+    changed_by  uuid,
+    note        text
+);
+CREATE INDEX IF NOT EXISTS idx_order_status_history_order_id ON order_status_history (order_id);
+
+-- Orders placed before line snapshots copy their lines from the ordered cart
+INSERT INTO order_line (id, created_at, order_id, product_sku, name, unit_price, quantity, line_total)
+SELECT uuid_generate_v4(), o.created_at, o.id, ci.product_sku, COALESCE(p.name, ''), COALESCE(p.price, 0), ci.quantity, ci.price
+FROM "order" o
+JOIN cart_item ci ON ci.cart_id = o.cart_id AND ci.deleted_at IS NULL
+LEFT JOIN products p ON p.sku = ci.product_sku
+WHERE NOT EXISTS (SELECT 1 FROM order_line ol WHERE ol.order_id = o.id);
+
+-- Orders placed before the status lifecycle are waiting for payment
+UPDATE "order" SET status = 'Pending' WHERE status = 'Ordered';
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS refresh_token (
+    id         uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at timestamptz,
+    user_id    uuid,
+    family_id  uuid,
+    token_hash text,
+    expires_at timestamptz,
+    used_at    timestamptz,
+    revoked_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_refresh_token_user_id ON refresh_token (user_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_token_family_id ON refresh_token (family_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refresh_token_token_hash ON refresh_token (token_hash);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS user_role (
+    user_id     uuid,
+    role        text,
+    created_at  timestamptz,
+    assigned_by uuid,
+    PRIMARY KEY (user_id, role)
+);
+
+-- Admins from before roles manage everything
+INSERT INTO user_role (user_id, role, created_at)
+SELECT u.id, 'super-admin', now() FROM "user" u
+WHERE u.is_admin AND NOT EXISTS (SELECT 1 FROM user_role ur WHERE ur.user_id = u.id);
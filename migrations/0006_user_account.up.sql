@@ -0,0 +1,19 @@
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS email_verified_at timestamptz;
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS deactivated_at timestamptz;
+
+-- Users signed up before email verification keep ordering, unless auto migrate already created user_token
+UPDATE "user" SET email_verified_at = created_at
+WHERE email_verified_at IS NULL AND to_regclass('user_token') IS NULL;
+
+CREATE TABLE IF NOT EXISTS user_token (
+    id         uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at timestamptz,
+    user_id    uuid,
+    purpose    text,
+    mail       text,
+    token_hash text,
+    expires_at timestamptz,
+    used_at    timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_user_token_user_id ON user_token (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_token_token_hash ON user_token (token_hash);
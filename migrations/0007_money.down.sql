@@ -0,0 +1,22 @@
+-- Fractions of a lira and currencies are lost going back
+ALTER TABLE order_line ADD COLUMN unit_price bigint, ADD COLUMN line_total bigint;
+UPDATE order_line SET unit_price = unit_price_amount / 100, line_total = line_total_amount / 100;
+ALTER TABLE order_line
+    DROP COLUMN unit_price_amount, DROP COLUMN unit_price_currency,
+    DROP COLUMN line_total_amount, DROP COLUMN line_total_currency;
+
+ALTER TABLE "order" ADD COLUMN total_price integer;
+UPDATE "order" SET total_price = total_price_amount / 100;
+ALTER TABLE "order" DROP COLUMN total_price_amount, DROP COLUMN total_price_currency;
+
+ALTER TABLE cart ADD COLUMN total_price bigint;
+UPDATE cart SET total_price = total_price_amount / 100;
+ALTER TABLE cart DROP COLUMN total_price_amount, DROP COLUMN total_price_currency;
+
+ALTER TABLE cart_item ADD COLUMN price bigint;
+UPDATE cart_item SET price = price_amount / 100;
+ALTER TABLE cart_item DROP COLUMN price_amount, DROP COLUMN price_currency;
+
+ALTER TABLE products ADD COLUMN price bigint;
+UPDATE products SET price = price_amount / 100;
+ALTER TABLE products DROP COLUMN price_amount, DROP COLUMN price_currency;
@@ -0,0 +1,22 @@
+-- Prices were whole liras, money columns keep minor units (kuruş) and an ISO 4217 currency
+ALTER TABLE products ADD COLUMN price_amount bigint NOT NULL DEFAULT 0, ADD COLUMN price_currency text NOT NULL DEFAULT 'TRY';
+UPDATE products SET price_amount = COALESCE(price, 0) * 100;
+ALTER TABLE products DROP COLUMN price;
+
+ALTER TABLE cart_item ADD COLUMN price_amount bigint NOT NULL DEFAULT 0, ADD COLUMN price_currency text NOT NULL DEFAULT 'TRY';
+UPDATE cart_item SET price_amount = COALESCE(price, 0) * 100;
+ALTER TABLE cart_item DROP COLUMN price;
+
+ALTER TABLE cart ADD COLUMN total_price_amount bigint NOT NULL DEFAULT 0, ADD COLUMN total_price_currency text NOT NULL DEFAULT 'TRY';
+UPDATE cart SET total_price_amount = COALESCE(total_price, 0) * 100;
+ALTER TABLE cart DROP COLUMN total_price;
+
+ALTER TABLE "order" ADD COLUMN total_price_amount bigint NOT NULL DEFAULT 0, ADD COLUMN total_price_currency text NOT NULL DEFAULT 'TRY';
+UPDATE "order" SET total_price_amount = COALESCE(total_price, 0) * 100;
+ALTER TABLE "order" DROP COLUMN total_price;
+
+ALTER TABLE order_line
+    ADD COLUMN unit_price_amount bigint NOT NULL DEFAULT 0, ADD COLUMN unit_price_currency text NOT NULL DEFAULT 'TRY',
+    ADD COLUMN line_total_amount bigint NOT NULL DEFAULT 0, ADD COLUMN line_total_currency text NOT NULL DEFAULT 'TRY';
+UPDATE order_line SET unit_price_amount = COALESCE(unit_price, 0) * 100, line_total_amount = COALESCE(line_total, 0) * 100;
+ALTER TABLE order_line DROP COLUMN unit_price, DROP COLUMN line_total;
@@ -0,0 +1,10 @@
+ALTER TABLE "order"
+    DROP COLUMN subtotal_amount, DROP COLUMN subtotal_currency,
+    DROP COLUMN tax_total_amount, DROP COLUMN tax_total_currency;
+
+ALTER TABLE order_line
+    DROP COLUMN tax_rate,
+    DROP COLUMN net_amount, DROP COLUMN net_currency,
+    DROP COLUMN tax_amount, DROP COLUMN tax_currency;
+
+DROP TABLE IF EXISTS tax_rate;
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS tax_rate (
+    id            uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at    timestamptz,
+    category_name text,
+    rate          bigint,
+    valid_from    timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tax_rate_category_from ON tax_rate (category_name, valid_from);
+
+-- Orders placed before KDV was kept are taken as untaxed, their totals are the net amounts
+ALTER TABLE order_line
+    ADD COLUMN tax_rate bigint NOT NULL DEFAULT 0,
+    ADD COLUMN net_amount bigint NOT NULL DEFAULT 0, ADD COLUMN net_currency text NOT NULL DEFAULT 'TRY',
+    ADD COLUMN tax_amount bigint NOT NULL DEFAULT 0, ADD COLUMN tax_currency text NOT NULL DEFAULT 'TRY';
+UPDATE order_line SET net_amount = line_total_amount, net_currency = line_total_currency, tax_currency = line_total_currency;
+
+ALTER TABLE "order"
+    ADD COLUMN subtotal_amount bigint NOT NULL DEFAULT 0, ADD COLUMN subtotal_currency text NOT NULL DEFAULT 'TRY',
+    ADD COLUMN tax_total_amount bigint NOT NULL DEFAULT 0, ADD COLUMN tax_total_currency text NOT NULL DEFAULT 'TRY';
+UPDATE "order" SET subtotal_amount = total_price_amount, subtotal_currency = total_price_currency, tax_total_currency = total_price_currency;
@@ -0,0 +1,6 @@
+ALTER TABLE "order" DROP COLUMN discount_total_amount, DROP COLUMN discount_total_currency;
+ALTER TABLE order_line DROP COLUMN discount_amount, DROP COLUMN discount_currency;
+ALTER TABLE cart DROP COLUMN promotion_id;
+
+DROP TABLE IF EXISTS order_discount;
+DROP TABLE IF EXISTS promotion;
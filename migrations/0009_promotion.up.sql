@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS promotion (
+    id                      uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at              timestamptz,
+    updated_at              timestamptz,
+    name                    text,
+    code                    text,
+    kind                    text,
+    percent                 bigint NOT NULL DEFAULT 0,
+    value_amount            bigint NOT NULL DEFAULT 0,
+    value_currency          text NOT NULL DEFAULT '',
+    buy_quantity            bigint NOT NULL DEFAULT 0,
+    get_quantity            bigint NOT NULL DEFAULT 0,
+    category_name           text,
+    min_cart_value_amount   bigint NOT NULL DEFAULT 0,
+    min_cart_value_currency text NOT NULL DEFAULT '',
+    usage_limit             bigint NOT NULL DEFAULT 0,
+    valid_from              timestamptz,
+    valid_until             timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_promotion_code ON promotion (code);
+
+CREATE TABLE IF NOT EXISTS order_discount (
+    id              uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at      timestamptz,
+    order_id        uuid,
+    promotion_id    uuid,
+    code            text,
+    name            text,
+    amount_amount   bigint NOT NULL DEFAULT 0,
+    amount_currency text NOT NULL DEFAULT 'TRY'
+);
+CREATE INDEX IF NOT EXISTS idx_order_discount_order_id ON order_discount (order_id);
+CREATE INDEX IF NOT EXISTS idx_order_discount_promotion_id ON order_discount (promotion_id);
+
+ALTER TABLE cart ADD COLUMN promotion_id uuid;
+
+ALTER TABLE order_line ADD COLUMN discount_amount bigint NOT NULL DEFAULT 0, ADD COLUMN discount_currency text NOT NULL DEFAULT 'TRY';
+UPDATE order_line SET discount_currency = line_total_currency;
+
+ALTER TABLE "order" ADD COLUMN discount_total_amount bigint NOT NULL DEFAULT 0, ADD COLUMN discount_total_currency text NOT NULL DEFAULT 'TRY';
+UPDATE "order" SET discount_total_currency = total_price_currency;
@@ -0,0 +1,15 @@
+ALTER TABLE "order"
+    DROP COLUMN address_id,
+    DROP COLUMN ship_to_full_name,
+    DROP COLUMN ship_to_phone,
+    DROP COLUMN ship_to_line1,
+    DROP COLUMN ship_to_line2,
+    DROP COLUMN ship_to_district,
+    DROP COLUMN ship_to_city,
+    DROP COLUMN ship_to_postal_code,
+    DROP COLUMN ship_to_country;
+ALTER TABLE "order" DROP COLUMN shipping_cost_amount, DROP COLUMN shipping_cost_currency;
+
+ALTER TABLE products DROP COLUMN weight;
+
+DROP TABLE IF EXISTS address;
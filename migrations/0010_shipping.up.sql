@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS address (
+    id          uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at  timestamptz,
+    updated_at  timestamptz,
+    user_id     uuid,
+    title       text,
+    full_name   text,
+    phone       text,
+    line1       text,
+    line2       text,
+    district    text,
+    city        text,
+    postal_code text,
+    country     text,
+    is_default  boolean NOT NULL DEFAULT false
+);
+CREATE INDEX IF NOT EXISTS idx_address_user_id ON address (user_id);
+
+ALTER TABLE products ADD COLUMN weight bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE "order" ADD COLUMN shipping_cost_amount bigint NOT NULL DEFAULT 0, ADD COLUMN shipping_cost_currency text NOT NULL DEFAULT 'TRY';
+UPDATE "order" SET shipping_cost_currency = total_price_currency;
+
+ALTER TABLE "order"
+    ADD COLUMN address_id uuid,
+    ADD COLUMN ship_to_full_name text,
+    ADD COLUMN ship_to_phone text,
+    ADD COLUMN ship_to_line1 text,
+    ADD COLUMN ship_to_line2 text,
+    ADD COLUMN ship_to_district text,
+    ADD COLUMN ship_to_city text,
+    ADD COLUMN ship_to_postal_code text,
+    ADD COLUMN ship_to_country text;
@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS payment (
+    id                uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at        timestamptz,
+    updated_at        timestamptz,
+    order_id          uuid,
+    provider          text,
+    reference         text,
+    status            text,
+    amount_amount     bigint NOT NULL DEFAULT 0,
+    amount_currency   text NOT NULL DEFAULT 'TRY',
+    captured_amount   bigint NOT NULL DEFAULT 0,
+    captured_currency text NOT NULL DEFAULT '',
+    refunded_amount   bigint NOT NULL DEFAULT 0,
+    refunded_currency text NOT NULL DEFAULT '',
+    message           text
+);
+CREATE INDEX IF NOT EXISTS idx_payment_order_id ON payment (order_id);
+CREATE INDEX IF NOT EXISTS idx_payment_reference ON payment (reference);
+
+CREATE TABLE IF NOT EXISTS payment_event (
+    id         uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at timestamptz,
+    provider   text,
+    event_id   text,
+    payment_id uuid,
+    status     text
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payment_event_provider_event_id ON payment_event (provider, event_id);
@@ -0,0 +1,10 @@
+ALTER TABLE categories DROP COLUMN return_days;
+
+DROP TABLE IF EXISTS refund;
+
+ALTER TABLE order_line
+    DROP COLUMN category_name,
+    DROP COLUMN cancelled_quantity,
+    DROP COLUMN returned_quantity,
+    DROP COLUMN refunded_amount,
+    DROP COLUMN refunded_currency;
@@ -0,0 +1,40 @@
+ALTER TABLE order_line
+    ADD COLUMN category_name      text,
+    ADD COLUMN cancelled_quantity bigint NOT NULL DEFAULT 0,
+    ADD COLUMN returned_quantity  bigint NOT NULL DEFAULT 0,
+    ADD COLUMN refunded_amount    bigint NOT NULL DEFAULT 0,
+    ADD COLUMN refunded_currency  text NOT NULL DEFAULT '';
+
+UPDATE order_line SET category_name = p.category_name
+FROM products p
+WHERE p.sku = order_line.product_sku;
+
+-- Orders cancelled or returned before line quantities were kept gave every unit back. Orders older than
+-- the status history have no history rows, their status tells it. Only a refunded order needs the
+-- history to tell whether it was cancelled or returned before, a refund of a paid order gave nothing back.
+UPDATE order_line SET cancelled_quantity = quantity
+FROM "order" o
+WHERE o.id = order_line.order_id
+  AND (o.status = 'Cancelled'
+    OR (o.status = 'Refunded' AND EXISTS (SELECT 1 FROM order_status_history h WHERE h.order_id = o.id AND h.to_status = 'Cancelled')));
+UPDATE order_line SET returned_quantity = quantity
+FROM "order" o
+WHERE o.id = order_line.order_id
+  AND order_line.cancelled_quantity = 0
+  AND (o.status = 'Returned'
+    OR (o.status = 'Refunded' AND EXISTS (SELECT 1 FROM order_status_history h WHERE h.order_id = o.id AND h.to_status = 'Returned')));
+
+CREATE TABLE IF NOT EXISTS refund (
+    id              uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at      timestamptz,
+    order_id        uuid,
+    payment_id      uuid,
+    reference       text,
+    amount_amount   bigint NOT NULL DEFAULT 0,
+    amount_currency text NOT NULL DEFAULT 'TRY',
+    reason          text
+);
+CREATE INDEX IF NOT EXISTS idx_refund_order_id ON refund (order_id);
+CREATE INDEX IF NOT EXISTS idx_refund_payment_id ON refund (payment_id);
+
+ALTER TABLE categories ADD COLUMN return_days bigint NOT NULL DEFAULT 0;
@@ -0,0 +1,21 @@
+-- Products are searched with the Turkish stemmer after unaccent folds the accents, so Çamaşır
+-- matches Camasir and ıphone matches iPhone
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_ts_config WHERE cfgname = 'turkish_unaccent') THEN
+        CREATE TEXT SEARCH CONFIGURATION turkish_unaccent (COPY = turkish);
+        ALTER TEXT SEARCH CONFIGURATION turkish_unaccent
+            ALTER MAPPING FOR hword, hword_part, word WITH unaccent, turkish_stem;
+    END IF;
+END
+$$;
+
+-- Matches in the name rank above matches in the category and the description
+ALTER TABLE products ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('turkish_unaccent', coalesce(name, '')), 'A') ||
+    setweight(to_tsvector('turkish_unaccent', coalesce(category_name, '')), 'B') ||
+    setweight(to_tsvector('turkish_unaccent', coalesce(description, '')), 'C')
+) STORED;
+CREATE INDEX IF NOT EXISTS idx_products_search_vector ON products USING GIN (search_vector);
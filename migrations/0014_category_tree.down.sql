@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_products_category_id;
+ALTER TABLE products DROP COLUMN IF EXISTS category_id;
+
+DROP INDEX IF EXISTS idx_categories_slug;
+DROP INDEX IF EXISTS idx_categories_parent_id;
+ALTER TABLE categories
+    DROP COLUMN IF EXISTS position,
+    DROP COLUMN IF EXISTS slug,
+    DROP COLUMN IF EXISTS parent_id;
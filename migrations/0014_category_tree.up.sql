@@ -0,0 +1,21 @@
+-- Categories nest under a parent and are ordered by position, their slug is the name folded to ASCII
+ALTER TABLE categories
+    ADD COLUMN parent_id uuid REFERENCES categories (id),
+    ADD COLUMN slug      text,
+    ADD COLUMN position  bigint NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories (parent_id);
+
+UPDATE categories SET slug = trim(BOTH '-' FROM regexp_replace(lower(unaccent(name)), '[^a-z0-9]+', '-', 'g'));
+-- Names that fold to the same slug get a number
+UPDATE categories SET slug = categories.slug || '-' || d.n
+FROM (SELECT id, row_number() OVER (PARTITION BY slug ORDER BY created_at, id) AS n FROM categories) d
+WHERE d.id = categories.id AND d.n > 1;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_slug ON categories (slug);
+
+-- Products reference their category by ID, category_name stays as a copy for search, tax and promotions
+ALTER TABLE products ADD COLUMN category_id uuid REFERENCES categories (id);
+CREATE INDEX IF NOT EXISTS idx_products_category_id ON products (category_id);
+
+UPDATE products SET category_id = c.id
+FROM categories c
+WHERE c.name = products.category_name;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_payment_order_idempotency_key;
+ALTER TABLE payment DROP COLUMN IF EXISTS idempotency_key;
@@ -0,0 +1,6 @@
+-- Payments keep the key they were authorized with so a retried request doesn't charge again.
+-- Payments made before have no key of their own, their id stands in for it.
+ALTER TABLE payment ADD COLUMN idempotency_key text;
+UPDATE payment SET idempotency_key = id::text;
+ALTER TABLE payment ALTER COLUMN idempotency_key SET NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payment_order_idempotency_key ON payment (order_id, idempotency_key);
@@ -0,0 +1 @@
+ALTER TABLE order_line DROP COLUMN IF EXISTS category_id;
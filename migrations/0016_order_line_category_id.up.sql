@@ -0,0 +1,11 @@
+-- Order lines keep the category they were ordered in by ID, renaming a category doesn't change orders
+ALTER TABLE order_line ADD COLUMN category_id uuid;
+
+-- Renames were carried to order lines so far, the name finds the category the line was ordered in
+UPDATE order_line SET category_id = c.id
+FROM categories c
+WHERE c.name = order_line.category_name AND c.deleted_at IS NULL;
+-- Lines whose category is gone fall back to the category of their product
+UPDATE order_line SET category_id = p.category_id
+FROM products p
+WHERE order_line.category_id IS NULL AND p.sku = order_line.product_sku;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_refund_status;
+ALTER TABLE refund DROP COLUMN IF EXISTS status;
@@ -0,0 +1,5 @@
+-- Refunds are recorded as pending with the change of the order and sent to the gateway after it commits.
+-- Refunds made before were sent to the gateway before they were recorded.
+ALTER TABLE refund ADD COLUMN status text NOT NULL DEFAULT 'Succeeded';
+ALTER TABLE refund ALTER COLUMN status DROP DEFAULT;
+CREATE INDEX IF NOT EXISTS idx_refund_status ON refund (status);
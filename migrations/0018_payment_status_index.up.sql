@@ -0,0 +1,2 @@
+-- The settler looks for payments waiting for their void to be sent to the gateway
+CREATE INDEX IF NOT EXISTS idx_payment_status ON payment (status);
@@ -0,0 +1,2 @@
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS is_admin boolean;
+UPDATE "user" u SET is_admin = EXISTS (SELECT 1 FROM user_role ur WHERE ur.user_id = u.id AND ur.role = 'super-admin');
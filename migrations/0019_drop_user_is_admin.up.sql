@@ -0,0 +1,2 @@
+-- Roles replaced the admin flag, 0005 gave the admins from before the super-admin role
+ALTER TABLE "user" DROP COLUMN IF EXISTS is_admin;
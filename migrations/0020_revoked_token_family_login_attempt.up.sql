@@ -0,0 +1,13 @@
+-- Revoked token families and failed sign ins are shared by every instance of the server
+CREATE TABLE IF NOT EXISTS revoked_token_family (
+    family_id  text PRIMARY KEY,
+    expires_at timestamptz NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_revoked_token_family_expires_at ON revoked_token_family (expires_at);
+
+CREATE TABLE IF NOT EXISTS login_attempt (
+    key          text PRIMARY KEY,
+    failures     integer NOT NULL,
+    last_failure timestamptz NOT NULL,
+    locked_until timestamptz
+);
@@ -0,0 +1,8 @@
+// Package migrations holds the versioned SQL migrations of the shop schema.
+// Files are named NNNN_name.up.sql and NNNN_name.down.sql and are embedded into the binary.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -8,12 +8,23 @@ import (
 )
 
 type Config struct {
-	ServerConfig ServerConfig
-	JWTConfig    JWTConfig
-	DBConfig     DBConfig
-	Logger       Logger
+	ServerConfig      ServerConfig
+	JWTConfig         JWTConfig
+	DBConfig          DBConfig
+	Logger            Logger
+	ReservationConfig ReservationConfig
+	MailConfig        MailConfig
+	AccountConfig     AccountConfig
+	LoginConfig       LoginConfig
+	TaxConfig         TaxConfig
+	ShippingConfig    ShippingConfig
+	PaymentConfig     PaymentConfig
+	OrderConfig       OrderConfig
+	PaginationConfig  PaginationConfig
 }
 
+// ServerConfig TrustedProxies are the addresses or CIDRs of the proxies in front of the server, the client
+// IP is read from X-Forwarded-For only for requests coming from them. Empty when there is no proxy.
 type ServerConfig struct {
 	AppVersion       string
 	Mode             string
@@ -23,13 +34,30 @@ type ServerConfig struct {
 	TimeoutSecs      int64
 	ReadTimeoutSecs  int64
 	WriteTimeoutSecs int64
+	TrustedProxies   []string
 }
 
+// JWTConfig times are in seconds, SessionTime is the access token lifetime.
+// Without Keys tokens are signed by SecretKey with HS256.
 type JWTConfig struct {
-	SessionTime int
-	SecretKey   string
+	SessionTime  int
+	RefreshTime  int
+	SecretKey    string
+	SigningKeyID string
+	Keys         []JWTKey
 }
 
+// JWTKey is a PEM encoded RS256 or EdDSA private key.
+// A key with RetiredAt (RFC3339) no longer signs but verifies tokens until they expire.
+type JWTKey struct {
+	ID             string
+	Algorithm      string
+	PrivateKeyFile string
+	RetiredAt      string
+}
+
+// DBConfig MigrationFolder is a golang-migrate source URL like file://migrations,
+// the migrations embedded in the binary are used when it is empty
 type DBConfig struct {
 	DataSourceName  string
 	Name            string
@@ -39,6 +67,84 @@ type DBConfig struct {
 	MaxLifetime     int
 }
 
+// ReservationConfig holds stock for cart items while enabled
+type ReservationConfig struct {
+	Enabled           bool
+	TTLSecs           int64
+	SweepIntervalSecs int64
+}
+
+// MailConfig Driver is smtp, file or memory, file writes mails to Dir
+type MailConfig struct {
+	Driver   string
+	From     string
+	Host     string
+	Port     int
+	Username string
+	Password string
+	Dir      string
+}
+
+// AccountConfig times are in seconds, LinkBaseURL is the frontend page mailed links open
+type AccountConfig struct {
+	VerificationTTLSecs  int64
+	PasswordResetTTLSecs int64
+	LinkBaseURL          string
+}
+
+// LoginConfig times are in seconds. An account or IP is locked for LockoutSecs after
+// MaxFailures or IPMaxFailures failed sign ins, failures older than WindowSecs are forgotten.
+type LoginConfig struct {
+	MaxFailures     int
+	IPMaxFailures   int
+	LockoutSecs     int64
+	WindowSecs      int64
+	BackoffBaseSecs int64
+	BackoffMaxSecs  int64
+}
+
+// TaxConfig rates are basis points (2000 is %20), DefaultRate taxes categories without a rate.
+// Product prices include KDV when PricesIncludeTax is set, otherwise KDV is added on top.
+type TaxConfig struct {
+	DefaultRate      int64
+	PricesIncludeTax bool
+}
+
+// ShippingConfig amounts are in minor units of Currency. Calculator is flat or weight, weight
+// based shipping costs BaseRate plus PerKgRate for every started kilogram. Orders worth at least
+// FreeOver ship free, 0 turns free shipping off.
+type ShippingConfig struct {
+	Calculator string
+	Currency   string
+	FlatRate   int64
+	BaseRate   int64
+	PerKgRate  int64
+	FreeOver   int64
+}
+
+// PaymentConfig Provider is the payment gateway and must be set, only fake for now. Webhooks must be
+// signed with WebhookSecret. The fake provider confirms delayed payments by posting a webhook to
+// WebhookURL after ConfirmAfterSecs, 0 leaves them pending until a webhook is posted by hand.
+// Voids and refunds the gateway failed are sent again every SettleIntervalSecs.
+type PaymentConfig struct {
+	Provider           string
+	WebhookSecret      string
+	WebhookURL         string
+	ConfirmAfterSecs   int64
+	SettleIntervalSecs int64
+}
+
+// OrderConfig ReturnDays is the return window of categories without their own, counted from delivery
+type OrderConfig struct {
+	ReturnDays int
+}
+
+// PaginationConfig CursorSecret signs the list cursors, every instance must share it. Cursors are signed
+// with a random secret and only good until a restart when it is empty.
+type PaginationConfig struct {
+	CursorSecret string
+}
+
 // Logger config
 type Logger struct {
 	Development bool
@@ -0,0 +1,297 @@
+package jwt_helper
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/gcamlicali/tradeshopExample/pkg/config"
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+var (
+	ErrUnknownKey        = errors.New("unknown signing key")
+	ErrKeyRetired        = errors.New("signing key is retired")
+	ErrUnsupportedAlg    = errors.New("unsupported signing algorithm")
+	ErrAlgorithmMismatch = errors.New("token algorithm does not match its key")
+)
+
+// Key is a signing key, Public is used to verify tokens it signed
+type Key struct {
+	ID        string
+	Algorithm string
+	Private   interface{}
+	Public    interface{}
+	// RetiredAt is set when the key stops signing, it verifies tokens until they expire
+	RetiredAt *time.Time
+}
+
+// KeySet signs tokens with the active key and verifies tokens of every key that can still have valid tokens
+type KeySet struct {
+	mu       sync.RWMutex
+	signing  *Key
+	keys     map[string]*Key
+	lifetime time.Duration
+	allowed  []string
+}
+
+// NewKeySet creates an empty key set, lifetime is the longest time a signed token stays valid
+func NewKeySet(lifetime time.Duration) *KeySet {
+	return &KeySet{
+		keys:     make(map[string]*Key),
+		lifetime: lifetime,
+		allowed:  []string{},
+	}
+}
+
+// Add adds a verification key, retired keys are skipped once their last tokens are expired
+func (s *KeySet) Add(key *Key) error {
+	if !supported(key.Algorithm) {
+		return fmt.Errorf("%w: %s", ErrUnsupportedAlg, key.Algorithm)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[key.ID] = key
+	s.pinAlgorithm(key.Algorithm)
+	return nil
+}
+
+// Rotate makes the given key the signing key, the previous signing key is retired
+func (s *KeySet) Rotate(key *Key) error {
+	if err := s.Add(key); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.signing != nil && s.signing.ID != key.ID {
+		now := time.Now()
+		s.signing.RetiredAt = &now
+	}
+	key.RetiredAt = nil
+	s.signing = key
+	return nil
+}
+
+// Sign signs the claims with the active key and writes its kid to the token header
+func (s *KeySet) Sign(claims jwt.Claims) (string, error) {
+	s.mu.RLock()
+	key := s.signing
+	s.mu.RUnlock()
+	if key == nil {
+		return "", ErrUnknownKey
+	}
+
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
+}
+
+// Verify parses a token signed by a known key, only algorithms of the configured keys are accepted
+func (s *KeySet) Verify(token string) (*DecodedToken, error) {
+	s.mu.RLock()
+	parser := jwt.Parser{ValidMethods: s.allowed}
+	s.mu.RUnlock()
+
+	decoded, err := parser.Parse(token, s.keyFunc)
+	if err != nil {
+		return nil, err
+	}
+	if !decoded.Valid {
+		return nil, errors.New("token is not valid")
+	}
+
+	decodedClaims := decoded.Claims.(jwt.MapClaims)
+	var decodedToken DecodedToken
+	jsonString, _ := json.Marshal(decodedClaims)
+	json.Unmarshal(jsonString, &decodedToken)
+
+	return &decodedToken, nil
+}
+
+func (s *KeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok := s.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	//A key only verifies tokens of its own algorithm, HS256 tokens can't use a public key as secret
+	if token.Method.Alg() != key.Algorithm {
+		return nil, ErrAlgorithmMismatch
+	}
+	if s.expired(key) {
+		return nil, ErrKeyRetired
+	}
+	return key.Public, nil
+}
+
+// expired reports a retired key whose tokens can't be valid anymore
+func (s *KeySet) expired(key *Key) bool {
+	return key.RetiredAt != nil && time.Now().After(key.RetiredAt.Add(s.lifetime))
+}
+
+// JWKS returns the public keys that still verify tokens, symmetric keys are never published
+func (s *KeySet) JWKS() JWKS {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	set := JWKS{Keys: []JWK{}}
+	for _, key := range s.keys {
+		if key.Algorithm == AlgHS256 || s.expired(key) {
+			continue
+		}
+		set.Keys = append(set.Keys, publicJWK(key))
+	}
+	return set
+}
+
+func (s *KeySet) pinAlgorithm(alg string) {
+	for _, a := range s.allowed {
+		if a == alg {
+			return
+		}
+	}
+	s.allowed = append(s.allowed, alg)
+}
+
+func supported(alg string) bool {
+	return alg == AlgHS256 || alg == AlgRS256 || alg == AlgEdDSA
+}
+
+// JWKS is a JSON Web Key Set as served at /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK is a public key of RFC 7517, only fields of RSA and Ed25519 keys are used
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+func publicJWK(key *Key) JWK {
+	jwk := JWK{Kid: key.ID, Use: "sig", Alg: key.Algorithm}
+	switch pub := key.Public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	}
+	return jwk
+}
+
+// NewHMACKey creates a shared secret key
+func NewHMACKey(id string, secret string) *Key {
+	return &Key{ID: id, Algorithm: AlgHS256, Private: []byte(secret), Public: []byte(secret)}
+}
+
+// GenerateKey creates a new RS256 or EdDSA key
+func GenerateKey(id string, alg string) (*Key, error) {
+	switch alg {
+	case AlgRS256:
+		private, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, err
+		}
+		return &Key{ID: id, Algorithm: alg, Private: private, Public: &private.PublicKey}, nil
+	case AlgEdDSA:
+		public, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return &Key{ID: id, Algorithm: alg, Private: private, Public: public}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlg, alg)
+}
+
+// LoadKey reads a PEM encoded RS256 or EdDSA private key
+func LoadKey(id string, alg string, privateKeyFile string) (*Key, error) {
+	pem, err := ioutil.ReadFile(privateKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	switch alg {
+	case AlgRS256:
+		private, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+		if err != nil {
+			return nil, err
+		}
+		return &Key{ID: id, Algorithm: alg, Private: private, Public: &private.PublicKey}, nil
+	case AlgEdDSA:
+		parsed, err := jwt.ParseEdPrivateKeyFromPEM(pem)
+		if err != nil {
+			return nil, err
+		}
+		private, ok := parsed.(ed25519.PrivateKey)
+		if !ok {
+			return nil, jwt.ErrNotEdPrivateKey
+		}
+		return &Key{ID: id, Algorithm: alg, Private: private, Public: private.Public()}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlg, alg)
+}
+
+// NewKeySetFromConfig loads the configured keys, without keys the shared SecretKey signs with HS256
+func NewKeySetFromConfig(cfg config.JWTConfig) (*KeySet, error) {
+	set := NewKeySet(time.Duration(cfg.SessionTime) * time.Second)
+
+	if len(cfg.Keys) == 0 {
+		return set, set.Rotate(NewHMACKey("default", cfg.SecretKey))
+	}
+
+	var signing *Key
+	for _, k := range cfg.Keys {
+		key, err := LoadKey(k.ID, k.Algorithm, k.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %s: %w", k.ID, err)
+		}
+		if k.RetiredAt != "" {
+			retiredAt, err := time.Parse(time.RFC3339, k.RetiredAt)
+			if err != nil {
+				return nil, fmt.Errorf("jwt key %s: %w", k.ID, err)
+			}
+			key.RetiredAt = &retiredAt
+		}
+		if err := set.Add(key); err != nil {
+			return nil, fmt.Errorf("jwt key %s: %w", k.ID, err)
+		}
+		if k.ID == cfg.SigningKeyID {
+			signing = key
+		}
+	}
+	if signing == nil {
+		return nil, fmt.Errorf("%w: signing key %q is not configured", ErrUnknownKey, cfg.SigningKeyID)
+	}
+	if signing.RetiredAt != nil {
+		return nil, fmt.Errorf("%w: %s", ErrKeyRetired, signing.ID)
+	}
+	set.signing = signing
+
+	return set, nil
+}
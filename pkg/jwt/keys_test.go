@@ -0,0 +1,116 @@
+package jwt_helper
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+)
+
+var lifetime = time.Minute
+
+func claims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"userId": uuid.New().String(),
+		"exp":    time.Now().Add(lifetime).Unix(),
+	}
+}
+
+func Test_KeySet_SignVerify(t *testing.T) {
+	for _, alg := range []string{AlgRS256, AlgEdDSA} {
+		t.Run(alg, func(t *testing.T) {
+			key, err := GenerateKey("key-1", alg)
+			if err != nil {
+				t.Fatalf("GenerateKey() error = %v", err)
+			}
+			set := NewKeySet(lifetime)
+			if err := set.Rotate(key); err != nil {
+				t.Fatalf("Rotate() error = %v", err)
+			}
+			token, err := set.Sign(claims())
+			if err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+			if _, err := set.Verify(token); err != nil {
+				t.Errorf("Verify() error = %v", err)
+			}
+		})
+	}
+}
+
+func Test_KeySet_Rotate(t *testing.T) {
+	oldKey, _ := GenerateKey("old", AlgRS256)
+	newKey, _ := GenerateKey("new", AlgEdDSA)
+	set := NewKeySet(lifetime)
+	set.Rotate(oldKey)
+	oldToken, _ := set.Sign(claims())
+
+	if err := set.Rotate(newKey); err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	newToken, _ := set.Sign(claims())
+	parsed, _, _ := new(jwt.Parser).ParseUnverified(newToken, jwt.MapClaims{})
+	if parsed.Header["kid"] != "new" {
+		t.Errorf("Sign() kid = %v, want new", parsed.Header["kid"])
+	}
+
+	if _, err := set.Verify(oldToken); err != nil {
+		t.Errorf("Verify() token of the retired key error = %v", err)
+	}
+	if len(set.JWKS().Keys) != 2 {
+		t.Errorf("JWKS() keys = %v, want old and new key", set.JWKS().Keys)
+	}
+
+	//Tokens of the old key are expired once its lifetime has passed
+	retiredAt := time.Now().Add(-2 * lifetime)
+	oldKey.RetiredAt = &retiredAt
+	if _, err := set.Verify(oldToken); err == nil {
+		t.Errorf("Verify() accepted a token of an expired key")
+	}
+	if keys := set.JWKS().Keys; len(keys) != 1 || keys[0].Kid != "new" {
+		t.Errorf("JWKS() keys = %v, want only the new key", keys)
+	}
+}
+
+func Test_KeySet_PinsAlgorithms(t *testing.T) {
+	key, _ := GenerateKey("rsa", AlgRS256)
+	set := NewKeySet(lifetime)
+	set.Rotate(key)
+
+	//A HS256 token using the public key as secret must not be accepted
+	publicDER, _ := x509.MarshalPKIXPublicKey(key.Public.(*rsa.PublicKey))
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, claims())
+	forged.Header["kid"] = "rsa"
+	forgedToken, _ := forged.SignedString(publicPEM)
+	if _, err := set.Verify(forgedToken); err == nil {
+		t.Errorf("Verify() accepted a HS256 token for a RS256 key")
+	}
+
+	unsigned := jwt.NewWithClaims(jwt.SigningMethodNone, claims())
+	unsigned.Header["kid"] = "rsa"
+	unsignedToken, _ := unsigned.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if _, err := set.Verify(unsignedToken); err == nil {
+		t.Errorf("Verify() accepted an unsigned token")
+	}
+
+	unknown, _ := GenerateKey("unknown", AlgRS256)
+	other := NewKeySet(lifetime)
+	other.Rotate(unknown)
+	otherToken, _ := other.Sign(claims())
+	if _, err := set.Verify(otherToken); err == nil {
+		t.Errorf("Verify() accepted a token of an unknown key")
+	}
+}
+
+func Test_KeySet_JWKSHidesSecrets(t *testing.T) {
+	set := NewKeySet(lifetime)
+	set.Rotate(NewHMACKey("default", "secret"))
+	if keys := set.JWKS().Keys; len(keys) != 0 {
+		t.Errorf("JWKS() published a shared secret key: %v", keys)
+	}
+}
@@ -0,0 +1,51 @@
+package jwt_helper
+
+import (
+	"sync"
+	"time"
+)
+
+// RevocationStore keeps token families whose access tokens must be rejected
+type RevocationStore interface {
+	// Revoke rejects tokens of the family until the given time, when they expire anyway
+	Revoke(familyID string, until time.Time) error
+	IsRevoked(familyID string) (bool, error)
+}
+
+// MemoryRevocationStore keeps revoked families in process memory of a single instance
+type MemoryRevocationStore struct {
+	mu       sync.Mutex
+	families map[string]time.Time
+}
+
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{families: make(map[string]time.Time)}
+}
+
+func (s *MemoryRevocationStore) Revoke(familyID string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	//Forget families whose tokens are already expired
+	now := time.Now()
+	for id, expiresAt := range s.families {
+		if !expiresAt.After(now) {
+			delete(s.families, id)
+		}
+	}
+
+	if current, ok := s.families[familyID]; !ok || until.After(current) {
+		s.families[familyID] = until
+	}
+	return nil
+}
+
+func (s *MemoryRevocationStore) IsRevoked(familyID string) (bool, error) {
+	if familyID == "" {
+		return false, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	until, ok := s.families[familyID]
+	return ok && until.After(time.Now()), nil
+}
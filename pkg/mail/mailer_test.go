@@ -0,0 +1,54 @@
+package mail
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gcamlicali/tradeshopExample/pkg/config"
+)
+
+var msg = Message{To: "user@example.com", Subject: "Hello", Body: "first line\nsecond line"}
+
+func Test_MemoryMailer_Sent(t *testing.T) {
+	m := NewMemoryMailer()
+	m.Send(msg)
+	m.Send(Message{To: "other@example.com"})
+
+	if sent := m.Sent(msg.To); len(sent) != 1 || sent[0] != msg {
+		t.Errorf("Sent() = %v, want %v", sent, msg)
+	}
+}
+
+func Test_FileMailer_Send(t *testing.T) {
+	dir := t.TempDir()
+	m, err := NewFileMailer(dir, "shop@example.com")
+	if err != nil {
+		t.Fatalf("NewFileMailer() error = %v", err)
+	}
+	if err := m.Send(msg); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	files, _ := os.ReadDir(dir)
+	if len(files) != 1 || !strings.HasSuffix(files[0].Name(), "user_at_example.com.eml") {
+		t.Fatalf("Send() files = %v, want one mail", files)
+	}
+	content, _ := os.ReadFile(dir + "/" + files[0].Name())
+	for _, want := range []string{"From: shop@example.com\r\n", "To: user@example.com\r\n", "Subject: Hello\r\n", "first line\r\nsecond line"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("Send() mail has no %q:\n%s", want, content)
+		}
+	}
+}
+
+func Test_NewMailerFromConfig(t *testing.T) {
+	if m, err := NewMailerFromConfig(config.MailConfig{}); err != nil {
+		t.Errorf("NewMailerFromConfig() error = %v", err)
+	} else if _, ok := m.(*MemoryMailer); !ok {
+		t.Errorf("NewMailerFromConfig() = %T, want memory mailer without a driver", m)
+	}
+	if _, err := NewMailerFromConfig(config.MailConfig{Driver: "pigeon"}); err == nil {
+		t.Errorf("NewMailerFromConfig() accepted an unknown driver")
+	}
+}
@@ -0,0 +1,59 @@
+package mail
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryMailer keeps sent mails in process memory, for tests and local development
+type MemoryMailer struct {
+	mu   sync.Mutex
+	sent []Message
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+func (m *MemoryMailer) Send(msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+// Sent returns every mail sent to the address
+func (m *MemoryMailer) Sent(to string) []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var sent []Message
+	for _, msg := range m.sent {
+		if msg.To == to {
+			sent = append(sent, msg)
+		}
+	}
+	return sent
+}
+
+// FileMailer writes every mail to a .eml file of the directory, for local development
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+func NewFileMailer(dir string, from string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileMailer{dir: dir, from: from}, nil
+}
+
+func (m *FileMailer) Send(msg Message) error {
+	to := strings.NewReplacer("@", "_at_", "/", "_", "\\", "_").Replace(msg.To)
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102T150405.000000000"), to)
+	return os.WriteFile(filepath.Join(m.dir, name), format(m.from, msg), 0o600)
+}
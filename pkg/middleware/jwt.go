@@ -6,18 +6,27 @@ import (
 	"net/http"
 )
 
-func AuthMiddleware(secretKey string) gin.HandlerFunc {
+// AuthMiddleware accepts tokens signed by the key set whose family is not revoked, revoked may be nil
+func AuthMiddleware(keys *jwtHelper.KeySet, revoked jwtHelper.RevocationStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if c.GetHeader("Authorization") != "" {
-			decodedClaims, err := jwtHelper.VerifyToken(c.GetHeader("Authorization"), secretKey)
+			decodedClaims, err := keys.Verify(c.GetHeader("Authorization"))
 
 			if decodedClaims == nil {
 				c.JSON(http.StatusUnauthorized, gin.H{"Authorization error": err.Error()})
 				c.Abort()
 				return
 			}
+			if revoked != nil {
+				isRevoked, err := revoked.IsRevoked(decodedClaims.FamilyID)
+				if err != nil || isRevoked {
+					c.JSON(http.StatusUnauthorized, gin.H{"Authorization error": "Token is revoked"})
+					c.Abort()
+					return
+				}
+			}
 			c.Set("userId", decodedClaims.UserId)
-			c.Set("isAdmin", decodedClaims.IsAdmin)
+			c.Set("roles", decodedClaims.Roles)
 			c.Next()
 		} else {
 			c.JSON(http.StatusUnauthorized, gin.H{"Authorization error": "You are not authorized!"})
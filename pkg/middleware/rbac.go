@@ -0,0 +1,74 @@
+package mw
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+)
+
+const (
+	PermProductWrite    = "product:write"
+	PermCategoryWrite   = "category:write"
+	PermOrderRead       = "order:read"
+	PermOrderWrite      = "order:write"
+	PermUserRoles       = "user:roles"
+	PermUserManage      = "user:manage"
+	PermTaxManage       = "tax:manage"
+	PermPromotionManage = "promotion:manage"
+)
+
+const (
+	RoleCatalogManager = "catalog-manager"
+	RoleOrderSupport   = "order-support"
+	RoleSuperAdmin     = "super-admin"
+)
+
+// RolePermissions lists the permissions granted by each role
+var RolePermissions = map[string][]string{
+	RoleCatalogManager: {PermProductWrite, PermCategoryWrite},
+	RoleOrderSupport:   {PermOrderRead, PermOrderWrite},
+	RoleSuperAdmin:     {PermProductWrite, PermCategoryWrite, PermOrderRead, PermOrderWrite, PermUserRoles, PermUserManage, PermTaxManage, PermPromotionManage},
+}
+
+// IsRole reports a known role name
+func IsRole(name string) bool {
+	_, ok := RolePermissions[name]
+	return ok
+}
+
+// RoleNames returns every known role in name order
+func RoleNames() []string {
+	names := make([]string, 0, len(RolePermissions))
+	for name := range RolePermissions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HasPermission reports whether a role of the signed in user grants the permission
+func HasPermission(c *gin.Context, permission string) bool {
+	roles, _ := c.Get("roles")
+	for _, role := range cast.ToStringSlice(roles) {
+		for _, p := range RolePermissions[role] {
+			if p == permission {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RequirePermission only lets users through whose roles grant the permission, it runs after AuthMiddleware
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !HasPermission(c, permission) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to use this endpoint!"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
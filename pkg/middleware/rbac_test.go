@@ -0,0 +1,60 @@
+package mw
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Test_RequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name       string
+		roles      []string
+		permission string
+		wantStatus int
+	}{
+		{
+			name:       "RequirePermission_CatalogManagerWritesProducts_ShouldSuccess",
+			roles:      []string{RoleCatalogManager},
+			permission: PermProductWrite,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "RequirePermission_SuperAdminManagesRoles_ShouldSuccess",
+			roles:      []string{RoleSuperAdmin},
+			permission: PermUserRoles,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "RequirePermission_OrderSupportWritesProducts_ShouldFail",
+			roles:      []string{RoleOrderSupport},
+			permission: PermProductWrite,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "RequirePermission_NoRoles_ShouldFail",
+			roles:      nil,
+			permission: PermOrderRead,
+			wantStatus: http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/", func(c *gin.Context) {
+				c.Set("roles", tt.roles)
+			}, RequirePermission(tt.permission), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			if w.Code != tt.wantStatus {
+				t.Errorf("RequirePermission() status = %v, want %v", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/gcamlicali/tradeshopExample/migrations"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"gorm.io/gorm"
+)
+
+var ErrNoMigrations = errors.New("no migrations found")
+
+// Status is the schema version of the database, Version 0 means no migration has run.
+// A dirty schema has a migration that failed half way and needs a manual fix.
+type Status struct {
+	Version  uint
+	Dirty    bool
+	Expected uint
+}
+
+func (s Status) String() string {
+	state := "up to date"
+	switch {
+	case s.Dirty:
+		state = "dirty, fix the failed migration by hand and force the version"
+	case s.Version < s.Expected:
+		state = fmt.Sprintf("%d migration(s) pending", s.Expected-s.Version)
+	case s.Version > s.Expected:
+		state = "newer than this binary"
+	}
+	return fmt.Sprintf("version %d, expected %d: %s", s.Version, s.Expected, state)
+}
+
+// Migrator runs the migrations of folder on the database,
+// the migrations embedded into the binary are used when folder is empty.
+// The expected version is always the one of the embedded migrations, the schema this binary is built for.
+type Migrator struct {
+	m        *migrate.Migrate
+	expected uint
+}
+
+// New opens a migrator on its own connection from the gorm pool, Close returns the connection.
+// folder is a golang-migrate source URL like file://migrations.
+func New(DB *gorm.DB, folder string) (*Migrator, error) {
+	expected, err := Expected()
+	if err != nil {
+		return nil, err
+	}
+	src, err := newSource(folder)
+	if err != nil {
+		return nil, err
+	}
+
+	origin, err := DB.DB()
+	if err != nil {
+		src.Close()
+		return nil, err
+	}
+	ctx := context.Background()
+	conn, err := origin.Conn(ctx)
+	if err != nil {
+		src.Close()
+		return nil, err
+	}
+	driver, err := postgres.WithConnection(ctx, conn, &postgres.Config{})
+	if err != nil {
+		src.Close()
+		conn.Close()
+		return nil, err
+	}
+
+	m, err := migrate.NewWithInstance("migrations", src, "postgres", driver)
+	if err != nil {
+		src.Close()
+		driver.Close()
+		return nil, err
+	}
+	return &Migrator{m: m, expected: expected}, nil
+}
+
+func newSource(folder string) (source.Driver, error) {
+	if folder == "" {
+		return iofs.New(migrations.FS, ".")
+	}
+	return source.Open(folder)
+}
+
+// Up applies all pending migrations
+func (r *Migrator) Up() error {
+	if err := r.m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+	return nil
+}
+
+// Down reverts the last steps migrations
+func (r *Migrator) Down(steps int) error {
+	if steps < 1 {
+		return fmt.Errorf("steps must be positive, got %d", steps)
+	}
+	if err := r.m.Steps(-steps); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+	return nil
+}
+
+func (r *Migrator) Status() (Status, error) {
+	version, dirty, err := r.m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return Status{}, err
+	}
+	return Status{Version: version, Dirty: dirty, Expected: r.expected}, nil
+}
+
+// Check fails unless the database is migrated exactly to the latest migration
+func (r *Migrator) Check() error {
+	status, err := r.Status()
+	if err != nil {
+		return err
+	}
+	return status.Check()
+}
+
+func (s Status) Check() error {
+	if s.Dirty || s.Version != s.Expected {
+		return fmt.Errorf("schema %s, run `shop migrate up`", s)
+	}
+	return nil
+}
+
+func (r *Migrator) Close() error {
+	srcErr, dbErr := r.m.Close()
+	if srcErr != nil {
+		return srcErr
+	}
+	return dbErr
+}
+
+// Expected returns the highest version of the migrations embedded into the binary
+func Expected() (uint, error) {
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+	return Latest(src)
+}
+
+// Latest returns the highest migration version of src
+func Latest(src source.Driver) (uint, error) {
+	version, err := src.First()
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, ErrNoMigrations
+	}
+	if err != nil {
+		return 0, err
+	}
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		version = next
+	}
+}
@@ -0,0 +1,72 @@
+package migration
+
+import (
+	"io/fs"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gcamlicali/tradeshopExample/migrations"
+)
+
+func TestEmbeddedMigrations_ShouldPairUpAndBeSequential(t *testing.T) {
+	names, err := fs.Glob(migrations.FS, "*.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pattern := regexp.MustCompile(`^(\d{4})_\w+\.(up|down)\.sql$`)
+	ups := map[string]bool{}
+	downs := map[string]bool{}
+	for _, name := range names {
+		match := pattern.FindStringSubmatch(name)
+		if match == nil {
+			t.Fatalf("migration %s is not named NNNN_name.up.sql or NNNN_name.down.sql", name)
+		}
+		base := strings.TrimSuffix(name, "."+match[2]+".sql")
+		if match[2] == "up" {
+			ups[base] = true
+		} else {
+			downs[base] = true
+		}
+	}
+	for base := range ups {
+		if !downs[base] {
+			t.Errorf("migration %s has no down", base)
+		}
+	}
+	for base := range downs {
+		if !ups[base] {
+			t.Errorf("migration %s has no up", base)
+		}
+	}
+
+	latest, err := Expected()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if int(latest) != len(ups) {
+		t.Errorf("latest version is %d but there are %d migrations, versions must not skip", latest, len(ups))
+	}
+}
+
+func TestStatus_Check(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  Status
+		wantErr bool
+	}{
+		{name: "Status_Check_UpToDate_ShouldSuccess", status: Status{Version: 3, Expected: 3}},
+		{name: "Status_Check_Pending_ShouldFail", status: Status{Version: 2, Expected: 3}, wantErr: true},
+		{name: "Status_Check_Empty_ShouldFail", status: Status{Expected: 3}, wantErr: true},
+		{name: "Status_Check_Newer_ShouldFail", status: Status{Version: 4, Expected: 3}, wantErr: true},
+		{name: "Status_Check_Dirty_ShouldFail", status: Status{Version: 3, Dirty: true, Expected: 3}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.status.Check()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
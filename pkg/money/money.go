@@ -0,0 +1,208 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is used when a price comes without a currency
+const DefaultCurrency = "TRY"
+
+// exponents are the minor unit digits of the supported ISO 4217 currencies
+var exponents = map[string]int{
+	"TRY": 2,
+	"USD": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"JPY": 0,
+}
+
+var (
+	ErrCurrencyMismatch = errors.New("currencies don't match")
+	ErrUnknownCurrency  = errors.New("unknown currency")
+	ErrInvalidAmount    = errors.New("invalid amount")
+)
+
+// Rounding decides where an amount between two minor units goes
+type Rounding int
+
+const (
+	// RoundHalfUp rounds halves away from zero, 0.5 kuruş becomes 1 kuruş
+	RoundHalfUp Rounding = iota
+	// RoundHalfEven rounds halves to the even minor unit (banker's rounding)
+	RoundHalfEven
+	// RoundDown truncates towards zero
+	RoundDown
+)
+
+// Money is an amount in minor units (kuruş, cent) of an ISO 4217 currency.
+// It is stored embedded, e.g. `gorm:"embedded;embeddedPrefix:price_"` gives price_amount and price_currency.
+// The zero Money has no currency and adds to money of any currency.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// Zero is no money in currency
+func Zero(currency string) Money {
+	return Money{Currency: currency}
+}
+
+// ValidCurrency reports whether currency is supported
+func ValidCurrency(currency string) bool {
+	_, ok := exponents[currency]
+	return ok
+}
+
+// Parse reads a decimal amount like 1299.90 in major units of currency.
+// More fraction digits than the currency has are rejected instead of rounded.
+func Parse(value string, currency string) (Money, error) {
+	exp, ok := exponents[currency]
+	if !ok {
+		return Money{}, fmt.Errorf("%w %q", ErrUnknownCurrency, currency)
+	}
+	value = strings.TrimSpace(value)
+	negative := strings.HasPrefix(value, "-")
+	whole, fraction, hasFraction := strings.Cut(strings.TrimPrefix(value, "-"), ".")
+	if whole == "" || (hasFraction && fraction == "") || len(fraction) > exp || strings.HasPrefix(whole, "+") {
+		return Money{}, fmt.Errorf("%w %q", ErrInvalidAmount, value)
+	}
+	fraction += strings.Repeat("0", exp-len(fraction))
+
+	amount, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil || amount < 0 {
+		return Money{}, fmt.Errorf("%w %q", ErrInvalidAmount, value)
+	}
+	if negative {
+		amount = -amount
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// Add returns m + o, both must be in the same currency
+func (m Money) Add(o Money) (Money, error) {
+	currency, err := m.common(o)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount + o.Amount, Currency: currency}, nil
+}
+
+// Sub returns m - o, both must be in the same currency
+func (m Money) Sub(o Money) (Money, error) {
+	currency, err := m.common(o)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount - o.Amount, Currency: currency}, nil
+}
+
+// Cmp returns -1, 0 or 1 when m is less than, equal to or greater than o
+func (m Money) Cmp(o Money) (int, error) {
+	if _, err := m.common(o); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.Amount < o.Amount:
+		return -1, nil
+	case m.Amount > o.Amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Mul returns m times quantity, it is exact
+func (m Money) Mul(quantity int64) Money {
+	return Money{Amount: m.Amount * quantity, Currency: m.Currency}
+}
+
+// MulRatio returns m * num / den rounded to a minor unit, e.g. a 18% rate is MulRatio(18, 100, RoundHalfUp)
+func (m Money) MulRatio(num int64, den int64, rounding Rounding) Money {
+	if den == 0 {
+		panic("money: zero denominator")
+	}
+	if den < 0 {
+		num, den = -num, -den
+	}
+	product := m.Amount * num
+	quotient, remainder := product/den, product%den
+	if remainder != 0 {
+		quotient += roundUnit(quotient, remainder, den, rounding)
+	}
+	return Money{Amount: quotient, Currency: m.Currency}
+}
+
+// roundUnit returns the -1, 0 or 1 minor unit added to a truncated quotient
+func roundUnit(quotient int64, remainder int64, den int64, rounding Rounding) int64 {
+	sign := int64(1)
+	if remainder < 0 {
+		sign, remainder = -1, -remainder
+	}
+	switch rounding {
+	case RoundHalfUp:
+		if 2*remainder >= den {
+			return sign
+		}
+	case RoundHalfEven:
+		if 2*remainder > den || (2*remainder == den && quotient%2 != 0) {
+			return sign
+		}
+	}
+	return 0
+}
+
+// Sum adds up amounts in currency
+func Sum(currency string, amounts ...Money) (Money, error) {
+	total := Zero(currency)
+	for _, amount := range amounts {
+		var err error
+		if total, err = total.Add(amount); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
+
+// String formats m in major units like 1299.90 TRY
+func (m Money) String() string {
+	exp := exponents[m.Currency]
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	if exp == 0 {
+		return strings.TrimSpace(fmt.Sprintf("%s%d %s", sign, amount, m.Currency))
+	}
+	unit := int64(math.Pow10(exp))
+	return strings.TrimSpace(fmt.Sprintf("%s%d.%0*d %s", sign, amount/unit, exp, amount%unit, m.Currency))
+}
+
+// common returns the currency of m and o, a currency-less zero takes the other currency
+func (m Money) common(o Money) (string, error) {
+	switch {
+	case m.Currency == o.Currency:
+		return m.Currency, nil
+	case m.Currency == "" && m.Amount == 0:
+		return o.Currency, nil
+	case o.Currency == "" && o.Amount == 0:
+		return m.Currency, nil
+	}
+	return "", fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+}
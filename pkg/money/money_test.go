@@ -0,0 +1,115 @@
+package money
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		currency string
+		want     Money
+		wantErr  bool
+	}{
+		{name: "Parse_Whole_ShouldSuccess", value: "13000", currency: "TRY", want: New(1300000, "TRY")},
+		{name: "Parse_Fraction_ShouldSuccess", value: "1299.9", currency: "TRY", want: New(129990, "TRY")},
+		{name: "Parse_Negative_ShouldSuccess", value: "-0.05", currency: "USD", want: New(-5, "USD")},
+		{name: "Parse_NoMinorUnits_ShouldSuccess", value: "500", currency: "JPY", want: New(500, "JPY")},
+		{name: "Parse_TooManyDigits_ShouldFail", value: "1.005", currency: "TRY", wantErr: true},
+		{name: "Parse_FractionOnJPY_ShouldFail", value: "1.5", currency: "JPY", wantErr: true},
+		{name: "Parse_UnknownCurrency_ShouldFail", value: "1", currency: "XYZ", wantErr: true},
+		{name: "Parse_NotNumber_ShouldFail", value: "12a", currency: "TRY", wantErr: true},
+		{name: "Parse_Empty_ShouldFail", value: "", currency: "TRY", wantErr: true},
+		{name: "Parse_DotOnly_ShouldFail", value: "1.", currency: "TRY", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.value, tt.currency)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Parse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoney_Add(t *testing.T) {
+	got, err := New(150, "TRY").Add(New(250, "TRY"))
+	if err != nil || got != New(400, "TRY") {
+		t.Errorf("Add() = %v, %v", got, err)
+	}
+
+	if _, err := New(150, "TRY").Add(New(250, "USD")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Add() mixed currencies error = %v, want %v", err, ErrCurrencyMismatch)
+	}
+
+	// The zero Money takes the other currency
+	got, err = Money{}.Add(New(250, "USD"))
+	if err != nil || got != New(250, "USD") {
+		t.Errorf("Add() to zero = %v, %v", got, err)
+	}
+
+	// A zero amount with a currency is still that currency
+	if _, err := Zero("TRY").Add(New(250, "USD")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Add() zero TRY to USD error = %v, want %v", err, ErrCurrencyMismatch)
+	}
+}
+
+func TestMoney_MulRatio(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   int64
+		num      int64
+		den      int64
+		rounding Rounding
+		want     int64
+	}{
+		{name: "MulRatio_Exact", amount: 1000, num: 18, den: 100, rounding: RoundHalfUp, want: 180},
+		{name: "MulRatio_HalfUp", amount: 25, num: 1, den: 10, rounding: RoundHalfUp, want: 3},
+		{name: "MulRatio_HalfUpNegative", amount: -25, num: 1, den: 10, rounding: RoundHalfUp, want: -3},
+		{name: "MulRatio_HalfEvenDown", amount: 25, num: 1, den: 10, rounding: RoundHalfEven, want: 2},
+		{name: "MulRatio_HalfEvenUp", amount: 35, num: 1, den: 10, rounding: RoundHalfEven, want: 4},
+		{name: "MulRatio_HalfEvenAboveHalf", amount: 26, num: 1, den: 10, rounding: RoundHalfEven, want: 3},
+		{name: "MulRatio_Down", amount: 29, num: 1, den: 10, rounding: RoundDown, want: 2},
+		{name: "MulRatio_DownNegative", amount: -29, num: 1, den: 10, rounding: RoundDown, want: -2},
+		{name: "MulRatio_NegativeDenominator", amount: 100, num: 1, den: -4, rounding: RoundHalfUp, want: -25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := New(tt.amount, "TRY").MulRatio(tt.num, tt.den, tt.rounding)
+			if got != New(tt.want, "TRY") {
+				t.Errorf("MulRatio() = %v, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSum(t *testing.T) {
+	got, err := Sum("TRY", New(100, "TRY"), New(200, "TRY"))
+	if err != nil || got != New(300, "TRY") {
+		t.Errorf("Sum() = %v, %v", got, err)
+	}
+	if _, err := Sum("TRY", New(100, "TRY"), New(200, "EUR")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Sum() error = %v, want %v", err, ErrCurrencyMismatch)
+	}
+}
+
+func TestMoney_String(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{money: New(129990, "TRY"), want: "1299.90 TRY"},
+		{money: New(-5, "USD"), want: "-0.05 USD"},
+		{money: New(500, "JPY"), want: "500 JPY"},
+	}
+	for _, tt := range tests {
+		if got := tt.money.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package pagination
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CursorVar specifies the query parameter name for the cursor, a request with it is paged by cursor
+// instead of page number
+var CursorVar = "cursor"
+
+// ErrInvalidCursor is returned for cursors that are malformed, not signed by this server or made for another sort
+var ErrInvalidCursor = errors.New("cursor is not valid")
+
+// cursorSecret signs the cursors, a random secret is used until SetCursorSecret is called so cursors
+// are only good until the server restarts
+var cursorSecret = randomSecret()
+
+// SetCursorSecret sets the key cursors are signed with, instances behind a load balancer must share it
+func SetCursorSecret(secret string) {
+	if secret != "" {
+		cursorSecret = []byte(secret)
+	}
+}
+
+func randomSecret() []byte {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(err)
+	}
+	return secret
+}
+
+// Cursor points at an item of a list sorted by a key and then by the item ID. Sort names the order the
+// cursor was made for so it can't be used with another one.
+type Cursor struct {
+	Sort string          `json:"s"`
+	Key  json.RawMessage `json:"k,omitempty"`
+	ID   json.RawMessage `json:"i"`
+	// Backward cursors page to the items before the item
+	Backward bool `json:"b,omitempty"`
+}
+
+// NewCursor creates a cursor for the item with the sort key and ID, key is nil when items are sorted by ID
+func NewCursor(sort string, key, id interface{}) (*Cursor, error) {
+	cursor := &Cursor{Sort: sort}
+	var err error
+	if key != nil {
+		if cursor.Key, err = json.Marshal(key); err != nil {
+			return nil, err
+		}
+	}
+	if cursor.ID, err = json.Marshal(id); err != nil {
+		return nil, err
+	}
+	return cursor, nil
+}
+
+// Encode returns the signed opaque form of the cursor
+func (c *Cursor) Encode() string {
+	payload, _ := json.Marshal(c)
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + sign(encoded)
+}
+
+// Values decodes the sort key and ID of the cursor into key and id, key is ignored when it is nil
+func (c *Cursor) Values(key, id interface{}) error {
+	if key != nil {
+		if err := json.Unmarshal(c.Key, key); err != nil {
+			return ErrInvalidCursor
+		}
+	}
+	if err := json.Unmarshal(c.ID, id); err != nil {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+// DecodeCursor verifies the signature of an encoded cursor and checks it was made for sort
+func DecodeCursor(token string, sort string) (*Cursor, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(sign(encoded))) {
+		return nil, ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	cursor := &Cursor{}
+	if err := json.Unmarshal(payload, cursor); err != nil || cursor.Sort != sort || len(cursor.ID) == 0 {
+		return nil, ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+func sign(encoded string) string {
+	mac := hmac.New(sha256.New, cursorSecret)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// GetCursorFromRequest reports whether the request is paged by cursor and returns its cursor, nil for the
+// first page. An empty cursor parameter asks for the first page.
+func GetCursorFromRequest(g *gin.Context, sort string) (cursor *Cursor, ok bool, err error) {
+	token, ok := g.GetQuery(CursorVar)
+	if !ok || token == "" {
+		return nil, ok, nil
+	}
+	cursor, err = DecodeCursor(token, sort)
+	return cursor, true, err
+}
+
+// GetPageSizeFromRequest returns the page size of the request within MaxPageSize
+func GetPageSizeFromRequest(g *gin.Context) int {
+	pageSize := parseInt(g.Query(PageSizeVar), DefaultPageSize)
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+	return pageSize
+}
+
+// NewCursorPages creates the Pages of a list paged by cursor. after is the cursor of the request, first and
+// last are the cursors of the first and last item on the page and more tells whether there are items past
+// the page in the direction it was read. The total is not counted.
+func NewCursorPages(pageSize int, after, first, last *Cursor, more bool) *Pages {
+	p := New(0, pageSize, -1)
+	p.cursor = true
+	if first == nil || last == nil {
+		return p
+	}
+
+	//A backward page was reached from the page after it
+	backward := after != nil && after.Backward
+	if more || backward {
+		p.Next = last.Encode()
+	}
+	if (more && backward) || (after != nil && !backward) {
+		prev := *first
+		prev.Backward = true
+		p.Prev = prev.Encode()
+	}
+	return p
+}
+
+// Keyset pages a query sorted by Column and then by IDColumn in the same direction, Column is empty when
+// the query is sorted by IDColumn only. Column names must come from the code, never from the request.
+type Keyset struct {
+	Column   string
+	IDColumn string
+	Desc     bool
+	// Key and ID are the values of the cursor the page starts after
+	Key      interface{}
+	ID       interface{}
+	Backward bool
+}
+
+// NewKeyset creates the Keyset of a sort, Values of the cursor are decoded into key and id when there is one.
+// key and id must be pointers, key is nil when the sort has no Column.
+func NewKeyset(column, idColumn string, desc bool, cursor *Cursor, key, id interface{}) (*Keyset, error) {
+	k := &Keyset{Column: column, IDColumn: idColumn, Desc: desc}
+	if cursor == nil {
+		return k, nil
+	}
+	if err := cursor.Values(key, id); err != nil {
+		return nil, err
+	}
+	k.ID, k.Backward = reflect.ValueOf(id).Elem().Interface(), cursor.Backward
+	if key != nil {
+		k.Key = reflect.ValueOf(key).Elem().Interface()
+	}
+	return k, nil
+}
+
+// Scope limits the query to the page after the cursor, or before it for a backward cursor, and reads one
+// item more than pageSize to tell whether another page follows. A backward page is read in reverse order.
+func (k *Keyset) Scope(pageSize int) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		desc := k.Desc != k.Backward
+		if k.ID != nil {
+			op := ">"
+			if desc {
+				op = "<"
+			}
+			if k.Column == "" {
+				db = db.Where(fmt.Sprintf("%s %s ?", k.IDColumn, op), k.ID)
+			} else {
+				db = db.Where(fmt.Sprintf("(%s, %s) %s (?, ?)", k.Column, k.IDColumn, op), k.Key, k.ID)
+			}
+		}
+
+		columns := []clause.OrderByColumn{{Column: clause.Column{Name: k.IDColumn}, Desc: desc}}
+		if k.Column != "" {
+			columns = append([]clause.OrderByColumn{{Column: clause.Column{Name: k.Column}, Desc: desc}}, columns...)
+		}
+		return db.Order(clause.OrderBy{Columns: columns}).Limit(pageSize + 1)
+	}
+}
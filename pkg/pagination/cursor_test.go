@@ -0,0 +1,77 @@
+package pagination
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCursor_EncodeDecode(t *testing.T) {
+	createdAt := time.Date(2022, 6, 1, 10, 30, 0, 123456000, time.UTC)
+	cursor, err := NewCursor("newest:desc", createdAt, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := DecodeCursor(cursor.Encode(), "newest:desc")
+	if err != nil {
+		t.Fatalf("DecodeCursor() error = %v", err)
+	}
+	var key time.Time
+	var id int
+	if err := decoded.Values(&key, &id); err != nil {
+		t.Fatalf("Values() error = %v", err)
+	}
+	if !key.Equal(createdAt) || id != 42 {
+		t.Errorf("Values() = %v %d, want %v 42", key, id, createdAt)
+	}
+}
+
+func TestDecodeCursor_Invalid(t *testing.T) {
+	cursor, _ := NewCursor("price:asc", int64(1000), 7)
+	token := cursor.Encode()
+	payload, signature, _ := strings.Cut(token, ".")
+	forged, _ := NewCursor("price:asc", int64(0), 7)
+	forgedPayload, _, _ := strings.Cut(forged.Encode(), ".")
+
+	tests := []struct {
+		name  string
+		token string
+		sort  string
+	}{
+		{name: "other sort", token: token, sort: "price:desc"},
+		{name: "forged payload", token: forgedPayload + "." + signature, sort: "price:asc"},
+		{name: "no signature", token: payload, sort: "price:asc"},
+		{name: "garbage", token: "not-a-cursor", sort: "price:asc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeCursor(tt.token, tt.sort); err != ErrInvalidCursor {
+				t.Errorf("DecodeCursor() error = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
+
+func TestNewCursorPages_Links(t *testing.T) {
+	first, _ := NewCursor("name", nil, "a")
+	last, _ := NewCursor("name", nil, "b")
+
+	p := NewCursorPages(2, first, first, last, true)
+	if p.Next == "" || p.Prev == "" || p.TotalCount != -1 {
+		t.Fatalf("NewCursorPages() = %+v, want next and prev cursors", p)
+	}
+	links := p.BuildLinks("/category/?q=x", DefaultPageSize)
+	if links[0] != "/category/?q=x&cursor=&pageSize=2" || links[1] == "" || links[2] == "" || links[3] != "" {
+		t.Errorf("BuildLinks() = %v", links)
+	}
+	header := p.BuildLinkHeader("/category/", DefaultPageSize)
+	if !strings.Contains(header, `rel="next"`) || !strings.Contains(header, `rel="prev"`) || strings.Contains(header, `rel="last"`) {
+		t.Errorf("BuildLinkHeader() = %s", header)
+	}
+
+	firstPage := NewCursorPages(2, nil, first, last, false)
+	if firstPage.BuildLinkHeader("/category/", DefaultPageSize) != "" {
+		t.Errorf("single cursor page has links %v", firstPage.BuildLinks("/category/", DefaultPageSize))
+	}
+}
@@ -21,12 +21,18 @@ var (
 )
 
 // Pages represents a paginated list of data items.
+// Lists paged by cursor have the encoded cursors of the next and previous pages instead of page numbers,
+// their Page is 0 and their total is unknown.
 type Pages struct {
 	Page       int         `json:"page"`
 	PageSize   int         `json:"pageSize"`
 	PageCount  int         `json:"pageCount"`
 	TotalCount int         `json:"totalCount"`
+	Next       string      `json:"next,omitempty"`
+	Prev       string      `json:"prev,omitempty"`
 	Items      interface{} `json:"items"`
+
+	cursor bool
 }
 
 // New creates a new Pages instance.
@@ -122,6 +128,10 @@ func (p *Pages) BuildLinkHeader(baseURL string, defaultPageSize int) string {
 // For example, if the pagination is at the first page, then both first and prev links
 // will be empty.
 func (p *Pages) BuildLinks(baseURL string, defaultPageSize int) [4]string {
+	if p.cursor {
+		return p.buildCursorLinks(baseURL, defaultPageSize)
+	}
+
 	var links [4]string
 	pageCount := p.PageCount
 	page := p.Page
@@ -153,3 +163,48 @@ func (p *Pages) BuildLinks(baseURL string, defaultPageSize int) [4]string {
 
 	return links
 }
+
+// buildCursorLinks returns the first, prev and next links of a list paged by cursor, there is no last link
+func (p *Pages) buildCursorLinks(baseURL string, defaultPageSize int) [4]string {
+	var links [4]string
+	if strings.Contains(baseURL, "?") {
+		baseURL += "&"
+	} else {
+		baseURL += "?"
+	}
+	if p.Prev != "" {
+		links[0] = fmt.Sprintf("%v%v=", baseURL, CursorVar)
+		links[1] = fmt.Sprintf("%v%v=%v", baseURL, CursorVar, p.Prev)
+	}
+	if p.Next != "" {
+		links[2] = fmt.Sprintf("%v%v=%v", baseURL, CursorVar, p.Next)
+	}
+	if pageSize := p.PageSize; pageSize != defaultPageSize {
+		for i := 0; i < 4; i++ {
+			if links[i] != "" {
+				links[i] += fmt.Sprintf("&%v=%v", PageSizeVar, pageSize)
+			}
+		}
+	}
+
+	return links
+}
+
+// BaseURL returns the URL of the request without its paging parameters, for BuildLinkHeader
+func BaseURL(g *gin.Context) string {
+	query := g.Request.URL.Query()
+	query.Del(PageVar)
+	query.Del(PageSizeVar)
+	query.Del(CursorVar)
+	if len(query) == 0 {
+		return g.Request.URL.Path
+	}
+	return g.Request.URL.Path + "?" + query.Encode()
+}
+
+// SetLinkHeader sets the Link header of the response to the links of the pages
+func SetLinkHeader(g *gin.Context, p *Pages) {
+	if header := p.BuildLinkHeader(BaseURL(g), DefaultPageSize); header != "" {
+		g.Header("Link", header)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package prompt
+
+import "os"
+
+// disableEcho is not supported here, the password is shown while typing
+func disableEcho(f *os.File) (restore func(), err error) {
+	return func() {}, nil
+}
@@ -0,0 +1,52 @@
+package prompt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+var ErrPasswordMismatch = errors.New("passwords don't match")
+
+// stdin is shared by prompts, piped input may be buffered past the current line
+var stdin = bufio.NewReader(os.Stdin)
+
+// NewPassword asks for a password twice on the terminal, the typed password is not shown where supported
+func NewPassword(label string) (string, error) {
+	password, err := Password(label + ": ")
+	if err != nil {
+		return "", err
+	}
+	again, err := Password("Repeat " + strings.ToLower(label[:1]) + label[1:] + ": ")
+	if err != nil {
+		return "", err
+	}
+	if password != again {
+		return "", ErrPasswordMismatch
+	}
+	return password, nil
+}
+
+// Password reads a line from stdin without echo
+func Password(label string) (string, error) {
+	fmt.Fprint(os.Stderr, label)
+	restore, err := disableEcho(os.Stdin)
+	if err != nil {
+		return "", err
+	}
+	password, err := readLine(stdin)
+	restore()
+	fmt.Fprintln(os.Stderr)
+	return password, err
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}